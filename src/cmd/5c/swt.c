@@ -393,7 +393,7 @@ outcode(void)
 	}
 
 	Bprint(&outbuf, "go object %s %s %s\n", getgoos(), thestring, getgoversion());
-	if(ndynimp > 0 || ndynexp > 0) {
+	if(ndynimp > 0 || ndynexp > 0 || nldflag > 0) {
 		int i;
 
 		Bprint(&outbuf, "\n");
@@ -402,6 +402,8 @@ outcode(void)
 		Bprint(&outbuf, "$$  // dynimport\n");
 		for(i=0; i<ndynimp; i++)
 			Bprint(&outbuf, "dynimport %s %s %s\n", dynimp[i].local, dynimp[i].remote, dynimp[i].path);
+		for(i=0; i<nldflag; i++)
+			Bprint(&outbuf, "cgo_ldflag %s\n", ldflag[i]);
 		Bprint(&outbuf, "\n$$  // dynexport\n");
 		for(i=0; i<ndynexp; i++)
 			Bprint(&outbuf, "dynexport %s %s\n", dynexp[i].local, dynexp[i].remote);
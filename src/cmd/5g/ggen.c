@@ -1029,3 +1029,10 @@ sliceslice:
 no:
 	return 0;
 }
+
+void
+ssagen(SsaFunc *f)
+{
+	USED(f);
+	fatal("ssagen: no SSA back end for 5g");
+}
@@ -247,7 +247,7 @@ outcode(void)
 	Binit(&b, f, OWRITE);
 
 	Bprint(&b, "go object %s %s %s\n", getgoos(), thestring, getgoversion());
-	if(ndynimp > 0 || ndynexp > 0) {
+	if(ndynimp > 0 || ndynexp > 0 || nldflag > 0) {
 		int i;
 
 		Bprint(&b, "\n");
@@ -256,6 +256,8 @@ outcode(void)
 		Bprint(&b, "$$  // dynimport\n");
 		for(i=0; i<ndynimp; i++)
 			Bprint(&b, "dynimport %s %s %s\n", dynimp[i].local, dynimp[i].remote, dynimp[i].path);
+		for(i=0; i<nldflag; i++)
+			Bprint(&b, "cgo_ldflag %s\n", ldflag[i]);
 		Bprint(&b, "\n$$  // dynexport\n");
 		for(i=0; i<ndynexp; i++)
 			Bprint(&b, "dynexport %s %s\n", dynexp[i].local, dynexp[i].remote);
@@ -0,0 +1,1165 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+ * register allocation and code generation
+ * for the SSA form built by ../gc/ssa.c.
+ *
+ * Values live in 64-bit registers.  4-byte values are computed
+ * with 32-bit instructions, so the register holds them zero- or
+ * sign-extended, and only the low half can be relied on.  Smaller
+ * values are kept sign- or zero-extended according to their type.
+ * Registers are assigned by a linear scan over single live
+ * intervals; a value that does not get a register lives in its own
+ * stack slot for its whole life.  A value may take the register of
+ * the operand it overwrites when that operand dies there.
+ * AX and DX are never allocated and serve as scratch registers.
+ * Constants are not allocated either; they are used as immediate
+ * operands or loaded into scratch registers where needed.
+ */
+
+#include <u.h>
+#include <libc.h>
+#include "gg.h"
+#include "../gc/ssa.h"
+
+static	int	regs[] =
+{
+	D_BX,
+	D_CX,
+	D_SI,
+	D_DI,
+	D_BP,
+	D_R8,
+	D_R9,
+	D_R10,
+	D_R11,
+	D_R12,
+	D_R13,
+	D_R14,
+	D_R15,
+};
+
+static	SsaFunc*	f;
+static	int	nword;
+
+static	int	fused(SsaValue*);
+
+static void
+setbit(uint32 *s, int i)
+{
+	s[i/32] |= 1<<(i%32);
+}
+
+static void
+clrbit(uint32 *s, int i)
+{
+	s[i/32] &= ~(1<<(i%32));
+}
+
+static int
+testbit(uint32 *s, int i)
+{
+	return (s[i/32]>>(i%32)) & 1;
+}
+
+static int
+predindex(SsaBlock *s, SsaBlock *b)
+{
+	int i;
+
+	for(i=0; i<s->npred; i++)
+		if(s->pred[i] == b)
+			return i;
+	fatal("ssagen: missing edge b%d -> b%d", b->id, s->id);
+	return -1;
+}
+
+// compute the values live on entry to and exit from each block.
+static void
+liveness(void)
+{
+	SsaBlock *b, *s;
+	SsaValue *v;
+	uint32 *in;
+	int i, j, k, n, change;
+
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		b->livein = mal(nword*sizeof(uint32));
+		b->liveout = mal(nword*sizeof(uint32));
+	}
+	in = mal(nword*sizeof(uint32));
+	do {
+		change = 0;
+		for(i=f->nblocks-1; i>=0; i--) {
+			b = f->blocks[i];
+			memset(b->liveout, 0, nword*sizeof(uint32));
+			for(j=0; j<b->nsucc; j++) {
+				s = b->succs[j];
+				for(k=0; k<nword; k++)
+					b->liveout[k] |= s->livein[k];
+				n = predindex(s, b);
+				for(k=0; k<s->nvalues; k++) {
+					v = s->values[k];
+					if(v->op == SOpPhi && ssahasreg(v->args[n]))
+						setbit(b->liveout, v->args[n]->id);
+				}
+			}
+			memmove(in, b->liveout, nword*sizeof(uint32));
+			if(b->control != nil && ssahasreg(b->control))
+				setbit(in, b->control->id);
+			for(j=b->nvalues-1; j>=0; j--) {
+				v = b->values[j];
+				clrbit(in, v->id);
+				if(v->op == SOpPhi)
+					continue;
+				for(k=0; k<v->nargs; k++)
+					if(ssahasreg(v->args[k]))
+						setbit(in, v->args[k]->id);
+			}
+			if(memcmp(in, b->livein, nword*sizeof(uint32)) != 0) {
+				memmove(b->livein, in, nword*sizeof(uint32));
+				change = 1;
+			}
+		}
+	} while(change);
+}
+
+static void
+extend(SsaValue *v, int pos)
+{
+	if(ssahasreg(v) && v->end < pos)
+		v->end = pos;
+}
+
+// number the values and compute their live intervals.
+static void
+intervals(void)
+{
+	SsaBlock *b, *s;
+	SsaValue *v;
+	int i, j, k, n, pos;
+
+	pos = 0;
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		b->start = pos;
+		pos += 2;
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			if(v->op == SOpPhi)
+				v->start = b->start;
+			else {
+				v->start = pos;
+				pos += 2;
+			}
+			v->end = v->start;
+		}
+		b->end = pos;
+		pos += 2;
+	}
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			if(v->op == SOpPhi)
+				continue;
+			for(k=0; k<v->nargs; k++)
+				extend(v->args[k], v->start);
+		}
+		if(b->control != nil)
+			extend(b->control, b->end);
+		for(j=0; j<b->nsucc; j++) {
+			s = b->succs[j];
+			n = predindex(s, b);
+			for(k=0; k<s->nvalues; k++)
+				if(s->values[k]->op == SOpPhi)
+					extend(s->values[k]->args[n], b->end);
+		}
+		for(j=0; j<f->nvalues; j++) {
+			if(testbit(b->livein, j))
+				extend(f->values[j], b->start);
+			if(testbit(b->liveout, j))
+				extend(f->values[j], b->end);
+		}
+	}
+}
+
+static void
+spill(SsaValue *v)
+{
+	Node n;
+
+	tempname(&n, types[TINT64]);
+	v->slot = mal(sizeof *v->slot);
+	*v->slot = n;
+	v->reg = 0;
+	if(debug['m'])
+		warnl(v->lineno, "spilled %s", ssaopname(v->op));
+}
+
+// whether v is computed in place, overwriting args[0],
+// or either argument if commutative is set.
+static int
+twoaddr(SsaValue *v, int *commutative)
+{
+	*commutative = 0;
+	switch(v->op) {
+	case SOpAdd:
+	case SOpMul:
+	case SOpAnd:
+	case SOpOr:
+	case SOpXor:
+		*commutative = 1;
+		return 1;
+	case SOpSub:
+	case SOpLsh:
+	case SOpRsh:
+	case SOpNeg:
+	case SOpCom:
+	case SOpNot:
+	case SOpConv:
+		return 1;
+	}
+	return 0;
+}
+
+// the operand of v that dies at v and whose register v can take.
+static SsaValue*
+dying(SsaValue *v, SsaValue **active, int nactive)
+{
+	SsaValue *x;
+	int i, k, c;
+
+	if(!twoaddr(v, &c))
+		return nil;
+	for(i=0; i<v->nargs && (i == 0 || c); i++) {
+		x = v->args[i];
+		if(x->reg == 0 || x->end != v->start)
+			continue;
+		for(k=0; k<nactive; k++)
+			if(active[k] == x)
+				return x;
+	}
+	return nil;
+}
+
+// linear scan register allocation.
+static void
+regalloc1(void)
+{
+	SsaBlock *b;
+	SsaValue *v, *w, *active[nelem(regs)];
+	int i, j, k, r, nactive, used[nelem(regs)];
+
+	nactive = 0;
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			v->reg = 0;
+			v->slot = N;
+			// a compare fused with its branch needs no register.
+			if(!ssahasreg(v) || fused(v))
+				continue;
+
+			// expire intervals that ended before v starts.
+			for(k=0; k<nactive; k++)
+				if(active[k]->end < v->start)
+					active[k--] = active[--nactive];
+
+			w = dying(v, active, nactive);
+			if(w != nil) {
+				v->reg = w->reg;
+				for(k=0; k<nactive; k++)
+					if(active[k] == w)
+						active[k] = v;
+				continue;
+			}
+
+			if(nactive < nelem(regs)) {
+				memset(used, 0, sizeof used);
+				for(k=0; k<nactive; k++)
+					for(r=0; r<nelem(regs); r++)
+						if(regs[r] == active[k]->reg)
+							used[r] = 1;
+				for(r=0; used[r]; r++)
+					;
+				v->reg = regs[r];
+				active[nactive++] = v;
+				continue;
+			}
+
+			// spill the interval that ends last.
+			w = v;
+			for(k=0; k<nactive; k++)
+				if(active[k]->end > w->end)
+					w = active[k];
+			if(w == v) {
+				spill(v);
+				continue;
+			}
+			v->reg = w->reg;
+			spill(w);
+			for(k=0; k<nactive; k++)
+				if(active[k] == w)
+					active[k] = v;
+		}
+	}
+}
+
+/*
+ * code generation
+ */
+
+static void
+regaddr(Addr *a, int r)
+{
+	memset(a, 0, sizeof *a);
+	a->type = r;
+	a->index = D_NONE;
+}
+
+static void
+conaddr(Addr *a, vlong c)
+{
+	memset(a, 0, sizeof *a);
+	a->type = D_CONST;
+	a->index = D_NONE;
+	a->offset = c;
+}
+
+static void
+memaddr(Addr *a, int r, vlong off, Type *t)
+{
+	memset(a, 0, sizeof *a);
+	a->type = r+D_INDIR;
+	a->index = D_NONE;
+	a->offset = off;
+	a->etype = simtype[t->etype];
+	a->width = t->width;
+}
+
+// address of word offset off of the stack variable n.
+static void
+nameaddr(Addr *a, Node *n, vlong off, Type *t)
+{
+	memset(a, 0, sizeof *a);
+	naddr(n, a, 1);
+	a->offset += off;
+	a->etype = simtype[t->etype];
+	a->width = t->width;
+}
+
+static Prog*
+ins(int as, Addr *from, Addr *to)
+{
+	Prog *p;
+
+	p = prog(as);
+	if(from != A)
+		p->from = *from;
+	if(to != A)
+		p->to = *to;
+	if(debug['g'])
+		print("%P\n", p);
+	return p;
+}
+
+static Prog*
+insreg(int as, Addr *from, int r)
+{
+	Addr a;
+
+	regaddr(&a, r);
+	return ins(as, from, &a);
+}
+
+static Prog*
+inscon(int as, vlong c, int r)
+{
+	Addr a;
+
+	conaddr(&a, c);
+	return insreg(as, &a, r);
+}
+
+static int
+isimm32(SsaValue *v)
+{
+	return v->op == SOpConst && v->auxint == (int32)v->auxint;
+}
+
+// operand for v: its register, its stack slot,
+// a 32-bit immediate, or the scratch register r.
+static void
+opnd(Addr *a, SsaValue *v, int r)
+{
+	if(v->op == SOpConst) {
+		conaddr(a, v->auxint);
+		if(isimm32(v))
+			return;
+		insreg(AMOVQ, a, r);
+		regaddr(a, r);
+		return;
+	}
+	if(v->reg != 0) {
+		regaddr(a, v->reg);
+		return;
+	}
+	if(v->slot == N)
+		fatal("ssagen: v%d has no location", v->id);
+	nameaddr(a, v->slot, 0, types[TINT64]);
+}
+
+// like opnd, but v is used by a 32-bit instruction,
+// so any constant fits in the immediate.
+static void
+opnd32(Addr *a, SsaValue *v, int r)
+{
+	if(v->op == SOpConst) {
+		conaddr(a, (int32)v->auxint);
+		return;
+	}
+	opnd(a, v, r);
+}
+
+// register holding v, using scratch register r if necessary.
+static int
+inreg(SsaValue *v, int r)
+{
+	Addr a;
+
+	if(v->reg != 0)
+		return v->reg;
+	opnd(&a, v, r);
+	if(a.type != r)
+		insreg(AMOVQ, &a, r);
+	return r;
+}
+
+// move v into register r.
+static void
+movereg(SsaValue *v, int r)
+{
+	Addr a;
+
+	opnd(&a, v, r);
+	if(a.type != r)
+		insreg(AMOVQ, &a, r);
+}
+
+// whether values of type t are computed with 32-bit instructions.
+static int
+is32(Type *t)
+{
+	return t != T && t->width == 4;
+}
+
+// the 32-bit form of the 64-bit instruction as, if t needs it.
+static int
+opsize(int as, Type *t)
+{
+	if(!is32(t))
+		return as;
+	switch(as) {
+	case AADDQ:	return AADDL;
+	case ASUBQ:	return ASUBL;
+	case AIMULQ:	return AIMULL;
+	case AANDQ:	return AANDL;
+	case AORQ:	return AORL;
+	case AXORQ:	return AXORL;
+	case ASHLQ:	return ASHLL;
+	case ASHRQ:	return ASHRL;
+	case ASARQ:	return ASARL;
+	case ANEGQ:	return ANEGL;
+	case ANOTQ:	return ANOTL;
+	case ACMPQ:	return ACMPL;
+	}
+	fatal("ssagen: no 32-bit form of %A", as);
+	return AXXX;
+}
+
+// sign- or zero-extend the low bits of r according to t.
+// 4-byte values are left alone: see the comment at the top.
+static void
+normalize(int r, Type *t)
+{
+	Addr a;
+	int s;
+
+	s = issigned[simtype[t->etype]];
+	regaddr(&a, r);
+	switch(t->width) {
+	case 1:
+		if(s) {
+			inscon(ASHLQ, 56, r);
+			inscon(ASARQ, 56, r);
+		} else
+			inscon(AANDQ, 0xff, r);
+		break;
+	case 2:
+		if(s) {
+			inscon(ASHLQ, 48, r);
+			inscon(ASARQ, 48, r);
+		} else
+			inscon(AANDQ, 0xffff, r);
+		break;
+	}
+}
+
+// sign- or zero-extend all of r according to t.
+static void
+extend64(int r, Type *t)
+{
+	Addr a;
+
+	if(!is32(t)) {
+		normalize(r, t);
+		return;
+	}
+	regaddr(&a, r);
+	insreg(issigned[simtype[t->etype]] ? AMOVLQSX : AMOVL, &a, r);
+}
+
+// load the t at a into register r.
+static void
+loadext(Addr *a, Type *t, int r)
+{
+	int s, as;
+
+	s = issigned[simtype[t->etype]];
+	switch(t->width) {
+	case 1:
+		as = s ? AMOVBQSX : AMOVBQZX;
+		break;
+	case 2:
+		as = s ? AMOVWQSX : AMOVWQZX;
+		break;
+	case 4:
+		as = s ? AMOVLQSX : AMOVL;
+		break;
+	default:
+		as = AMOVQ;
+		break;
+	}
+	insreg(as, a, r);
+}
+
+// store x, a value of type t, to the memory at a.
+static void
+storeto(Addr *a, SsaValue *x, Type *t)
+{
+	Addr b;
+	int as;
+
+	switch(t->width) {
+	case 1:
+		as = AMOVB;
+		break;
+	case 2:
+		as = AMOVW;
+		break;
+	case 4:
+		as = AMOVL;
+		break;
+	default:
+		as = AMOVQ;
+		break;
+	}
+	if(isimm32(x)) {
+		conaddr(&b, x->auxint);
+		ins(as, &b, a);
+		return;
+	}
+	regaddr(&b, inreg(x, D_DX));
+	if(as == AMOVB && b.type != D_AX && b.type != D_BX && b.type != D_CX && b.type != D_DX && b.type < D_R8) {
+		// no byte form of SI, DI and BP without a REX prefix.
+		insreg(AMOVQ, &b, D_DX);
+		b.type = D_DX;
+	}
+	ins(as, &b, a);
+}
+
+// set v's location from register r.
+static void
+setresult(SsaValue *v, int r)
+{
+	Addr a, b;
+
+	if(v->reg == r)
+		return;
+	regaddr(&a, r);
+	if(v->reg != 0)
+		regaddr(&b, v->reg);
+	else
+		nameaddr(&b, v->slot, 0, types[TINT64]);
+	ins(AMOVQ, &a, &b);
+}
+
+static int
+jumpop(SsaValue *c)
+{
+	switch(c->op) {
+	case SOpEq:
+		return AJEQ;
+	case SOpNe:
+		return AJNE;
+	case SOpLt:
+		return c->auxint ? AJCS : AJLT;
+	case SOpLe:
+		return c->auxint ? AJLS : AJLE;
+	case SOpGt:
+		return c->auxint ? AJHI : AJGT;
+	case SOpGe:
+		return c->auxint ? AJCC : AJGE;
+	}
+	fatal("ssagen: bad comparison %d", c->op);
+	return AXXX;
+}
+
+static int
+setop(int jump)
+{
+	switch(jump) {
+	case AJEQ:	return ASETEQ;
+	case AJNE:	return ASETNE;
+	case AJCS:	return ASETCS;
+	case AJLT:	return ASETLT;
+	case AJLS:	return ASETLS;
+	case AJLE:	return ASETLE;
+	case AJHI:	return ASETHI;
+	case AJGT:	return ASETGT;
+	case AJCC:	return ASETCC;
+	case AJGE:	return ASETGE;
+	}
+	fatal("ssagen: bad jump %A", jump);
+	return AXXX;
+}
+
+// compare x with y.  Values no wider than 4 bytes are compared
+// with a 32-bit instruction, anything else with a 64-bit one
+// after extending the 4-byte values among them.
+static void
+cmpvals(SsaValue *x, SsaValue *y)
+{
+	Addr a, b;
+	int r;
+
+	if(x->type->width <= 4 && y->type->width <= 4) {
+		regaddr(&a, inreg(x, D_AX));
+		opnd32(&b, y, D_DX);
+		ins(ACMPL, &a, &b);
+		return;
+	}
+	if(is32(x->type)) {
+		movereg(x, D_AX);
+		extend64(D_AX, x->type);
+		r = D_AX;
+	} else
+		r = inreg(x, D_AX);
+	regaddr(&a, r);
+	if(is32(y->type) && y->op != SOpConst) {
+		movereg(y, D_DX);
+		extend64(D_DX, y->type);
+		regaddr(&b, D_DX);
+	} else
+		opnd(&b, y, D_DX);
+	ins(ACMPQ, &a, &b);
+}
+
+// compare the arguments of c, returning 1 if they were
+// compared in reverse order.
+static int
+gencmp(SsaValue *c)
+{
+	SsaValue *x, *y;
+	int rev;
+
+	x = c->args[0];
+	y = c->args[1];
+	rev = 0;
+	if(x->op == SOpConst && y->op != SOpConst) {
+		x = c->args[1];
+		y = c->args[0];
+		rev = 1;
+	}
+	cmpvals(x, y);
+	return rev;
+}
+
+static int
+reversejump(int as)
+{
+	switch(as) {
+	case AJLT:	return AJGT;
+	case AJGT:	return AJLT;
+	case AJLE:	return AJGE;
+	case AJGE:	return AJLE;
+	case AJCS:	return AJHI;
+	case AJHI:	return AJCS;
+	case AJLS:	return AJCC;
+	case AJCC:	return AJLS;
+	}
+	return as;
+}
+
+static int
+negjump(int as)
+{
+	switch(as) {
+	case AJEQ:	return AJNE;
+	case AJNE:	return AJEQ;
+	case AJLT:	return AJGE;
+	case AJGE:	return AJLT;
+	case AJLE:	return AJGT;
+	case AJGT:	return AJLE;
+	case AJCS:	return AJCC;
+	case AJCC:	return AJCS;
+	case AJLS:	return AJHI;
+	case AJHI:	return AJLS;
+	}
+	fatal("ssagen: bad jump %A", as);
+	return AXXX;
+}
+
+static int
+iscompare(SsaValue *v)
+{
+	switch(v->op) {
+	case SOpEq:
+	case SOpNe:
+	case SOpLt:
+	case SOpLe:
+	case SOpGt:
+	case SOpGe:
+		return 1;
+	}
+	return 0;
+}
+
+// whether the comparison v is only used by the branch
+// at the end of its block and can be fused with it.
+static int
+fused(SsaValue *v)
+{
+	SsaBlock *b;
+
+	b = v->block;
+	return iscompare(v) && v->uses == 1 && b->control == v &&
+		b->values[b->nvalues-1] == v;
+}
+
+// whether the nil check at b->values[i] is done by
+// the hardware in the next instruction.
+static int
+implicitcheck(SsaBlock *b, int i)
+{
+	SsaValue *v, *w;
+
+	v = b->values[i];
+	for(i++; i<b->nvalues; i++) {
+		w = b->values[i];
+		if(w->op == SOpConst)
+			continue;
+		if(w->op != SOpLoad && w->op != SOpStore)
+			return 0;
+		return w->args[0] == v->args[0] && w->auxint >= 0 && w->auxint < unmappedzero;
+	}
+	return 0;
+}
+
+static int
+arithop(int op)
+{
+	switch(op) {
+	case SOpAdd:	return AADDQ;
+	case SOpSub:	return ASUBQ;
+	case SOpMul:	return AIMULQ;
+	case SOpAnd:	return AANDQ;
+	case SOpOr:	return AORQ;
+	case SOpXor:	return AXORQ;
+	}
+	return AXXX;
+}
+
+static void
+genvalue(SsaBlock *b, int i)
+{
+	SsaValue *v, *x, *y;
+	Addr a, c;
+	Prog *p;
+	int r, r0, r1, rev, j, as;
+
+	v = b->values[i];
+	lineno = v->lineno;
+	r = v->reg;
+	if(r == 0)
+		r = D_AX;
+
+	switch(v->op) {
+	default:
+		fatal("ssagen: unexpected op %d", v->op);
+
+	case SOpInitMem:
+	case SOpPhi:
+	case SOpConst:
+		break;
+
+	case SOpParam:
+		nameaddr(&a, v->aux, v->auxint, v->type);
+		loadext(&a, v->type, r);
+		setresult(v, r);
+		break;
+
+	case SOpAddr:
+		memset(&a, 0, sizeof a);
+		naddr(v->aux, &a, 1);
+		a.etype = tptr;
+		a.width = widthptr;
+		insreg(ALEAQ, &a, r);
+		setresult(v, r);
+		break;
+
+	case SOpAdd:
+	case SOpSub:
+	case SOpMul:
+	case SOpAnd:
+	case SOpOr:
+	case SOpXor:
+		x = v->args[0];
+		y = v->args[1];
+		// put the operand that is already in r, or else
+		// the one that is not a constant, first.
+		if(v->op != SOpSub && x->reg != r &&
+		   ((y->reg == r && y->reg != 0) || (x->op == SOpConst && y->op != SOpConst))) {
+			x = v->args[1];
+			y = v->args[0];
+		}
+		movereg(x, r);
+		if(is32(v->type))
+			opnd32(&a, y, D_DX);
+		else
+			opnd(&a, y, D_DX);
+		insreg(opsize(arithop(v->op), v->type), &a, r);
+		normalize(r, v->type);
+		setresult(v, r);
+		break;
+
+	case SOpLsh:
+	case SOpRsh:
+		movereg(v->args[0], r);
+		as = ASHLQ;
+		if(v->op == SOpRsh)
+			as = issigned[simtype[v->type->etype]] ? ASARQ : ASHRQ;
+		if(is32(v->type) && v->auxint >= 32) {
+			// the hardware only uses the low 5 bits of the count.
+			if(as == ASARQ)
+				inscon(ASARL, 31, r);
+			else
+				inscon(AMOVL, 0, r);
+		} else
+			inscon(opsize(as, v->type), v->auxint, r);
+		if(v->op == SOpLsh)
+			normalize(r, v->type);
+		setresult(v, r);
+		break;
+
+	case SOpNeg:
+	case SOpCom:
+		movereg(v->args[0], r);
+		regaddr(&a, r);
+		ins(opsize(v->op == SOpNeg ? ANEGQ : ANOTQ, v->type), A, &a);
+		normalize(r, v->type);
+		setresult(v, r);
+		break;
+
+	case SOpNot:
+		movereg(v->args[0], r);
+		inscon(AXORQ, 1, r);
+		setresult(v, r);
+		break;
+
+	case SOpConv:
+		x = v->args[0];
+		movereg(x, r);
+		if(v->type->width < 4)
+			normalize(r, v->type);
+		else if(v->type->width == 8 && is32(x->type))
+			extend64(r, x->type);
+		else if(is32(v->type) && x->type->width == 8) {
+			// drop the high half, as a 32-bit instruction would.
+			regaddr(&a, r);
+			insreg(AMOVL, &a, r);
+		}
+		setresult(v, r);
+		break;
+
+	case SOpEq:
+	case SOpNe:
+	case SOpLt:
+	case SOpLe:
+	case SOpGt:
+	case SOpGe:
+		if(fused(v))
+			break;
+		rev = gencmp(v);
+		j = jumpop(v);
+		if(rev)
+			j = reversejump(j);
+		regaddr(&a, D_AL);
+		ins(setop(j), A, &a);
+		insreg(AMOVBQZX, &a, r);
+		setresult(v, r);
+		break;
+
+	case SOpPtrIndex:
+		x = v->args[0];
+		y = v->args[1];
+		// y has passed its bounds check, so it is not negative
+		// and all of its register can be used even if it is 4 bytes.
+		switch(v->auxint) {
+		case 1:
+		case 2:
+		case 4:
+		case 8:
+			r0 = inreg(x, D_AX);
+			r1 = inreg(y, D_DX);
+			memset(&a, 0, sizeof a);
+			a.type = r0+D_INDIR;
+			a.index = r1;
+			a.scale = v->auxint;
+			insreg(ALEAQ, &a, r);
+			break;
+		default:
+			movereg(y, D_DX);
+			inscon(AIMULQ, v->auxint, D_DX);
+			movereg(x, r);
+			regaddr(&a, D_DX);
+			insreg(AADDQ, &a, r);
+			break;
+		}
+		setresult(v, r);
+		break;
+
+	case SOpLoad:
+		r0 = inreg(v->args[0], D_AX);
+		memaddr(&a, r0, v->auxint, v->type);
+		loadext(&a, v->type, r);
+		setresult(v, r);
+		break;
+
+	case SOpStore:
+		r0 = inreg(v->args[0], D_AX);
+		memaddr(&a, r0, v->auxint, v->type);
+		storeto(&a, v->args[1], v->type);
+		break;
+
+	case SOpStoreResult:
+		nameaddr(&a, v->aux, v->auxint, v->type);
+		storeto(&a, v->args[0], v->type);
+		break;
+
+	case SOpNilCheck:
+		if(implicitcheck(b, i))
+			break;
+		r0 = inreg(v->args[0], D_AX);
+		conaddr(&c, 0);
+		memaddr(&a, r0, 0, types[TUINT8]);
+		ins(ATESTB, &c, &a);
+		break;
+
+	case SOpBoundsCheck:
+		x = v->args[0];
+		y = v->args[1];
+		if(x->op == SOpConst) {
+			// len > i
+			cmpvals(y, x);
+			p = gbranch(AJHI, T);
+		} else {
+			cmpvals(x, y);
+			p = gbranch(AJCS, T);
+		}
+		ginscall(panicindex, 0);
+		patch(p, pc);
+		break;
+	}
+}
+
+/*
+ * phi moves, done as a parallel assignment.
+ */
+
+typedef	struct	Move	Move;
+struct	Move
+{
+	SsaValue*	src;	// source value, or nil if in DX
+	SsaValue*	dst;
+};
+
+static int
+sameloc(SsaValue *a, SsaValue *b)
+{
+	if(a->reg != 0 || b->reg != 0)
+		return a->reg == b->reg;
+	return a->slot == b->slot;
+}
+
+static void
+move1(SsaValue *src, SsaValue *dst)
+{
+	Addr a, b;
+
+	if(src == nil)
+		regaddr(&a, D_DX);
+	else
+		opnd(&a, src, D_AX);
+	if(dst->reg != 0)
+		regaddr(&b, dst->reg);
+	else {
+		nameaddr(&b, dst->slot, 0, types[TINT64]);
+		if(src != nil && src->op != SOpConst && src->reg == 0) {
+			// memory to memory
+			insreg(AMOVQ, &a, D_AX);
+			regaddr(&a, D_AX);
+		}
+	}
+	ins(AMOVQ, &a, &b);
+}
+
+static void
+phimoves(SsaBlock *b)
+{
+	SsaBlock *s;
+	SsaValue *v;
+	Move *m;
+	int i, j, n, k, nm;
+
+	if(b->nsucc != 1)
+		return;
+	s = b->succs[0];
+	k = predindex(s, b);
+	m = mal(s->nvalues*sizeof m[0]);
+	nm = 0;
+	for(i=0; i<s->nvalues; i++) {
+		v = s->values[i];
+		if(v->op != SOpPhi || !ssahasreg(v))
+			continue;
+		if(v->args[k]->op != SOpConst && sameloc(v->args[k], v))
+			continue;
+		m[nm].src = v->args[k];
+		m[nm].dst = v;
+		nm++;
+	}
+
+	// a move can be done once nothing else reads its destination.
+	while(nm > 0) {
+		for(i=0; i<nm; i++) {
+			for(j=0; j<nm; j++)
+				if(j != i && m[j].src != nil && m[j].src->op != SOpConst && sameloc(m[j].src, m[i].dst))
+					break;
+			if(j == nm)
+				break;
+		}
+		if(i < nm) {
+			move1(m[i].src, m[i].dst);
+			m[i] = m[--nm];
+			continue;
+		}
+
+		// only cycles remain; save one destination in DX.
+		v = m[0].dst;
+		n = 0;
+		for(j=0; j<nm; j++)
+			if(m[j].src != nil && m[j].src->op != SOpConst && sameloc(m[j].src, v)) {
+				if(n == 0)
+					movereg(m[j].src, D_DX);
+				m[j].src = nil;
+				n++;
+			}
+	}
+}
+
+static void
+genblock(SsaBlock *b, SsaBlock *next)
+{
+	SsaValue *c;
+	Addr a, k;
+	int i, j, rev;
+
+	b->pc = pc;
+	b->jmp[0] = P;
+	b->jmp[1] = P;
+	for(i=0; i<b->nvalues; i++)
+		genvalue(b, i);
+	if(b->nvalues > 0)
+		lineno = b->values[b->nvalues-1]->lineno;
+
+	switch(b->kind) {
+	case SBlockPlain:
+		phimoves(b);
+		if(b->succs[0] != next)
+			b->jmp[0] = gjmp(P);
+		break;
+
+	case SBlockIf:
+		c = b->control;
+		if(fused(c)) {
+			rev = gencmp(c);
+			j = jumpop(c);
+			if(rev)
+				j = reversejump(j);
+		} else {
+			regaddr(&a, inreg(c, D_AX));
+			conaddr(&k, 0);
+			ins(ACMPQ, &a, &k);
+			j = AJNE;
+		}
+		if(b->succs[0] == next)
+			b->jmp[1] = gbranch(negjump(j), T);
+		else {
+			b->jmp[0] = gbranch(j, T);
+			if(b->succs[1] != next)
+				b->jmp[1] = gjmp(P);
+		}
+		break;
+
+	case SBlockRet:
+		ins(ARET, A, A);
+		break;
+
+	case SBlockExit:
+		ginscall(throwreturn, 0);
+		break;
+	}
+}
+
+void
+ssagen(SsaFunc *fn)
+{
+	SsaBlock *b;
+	int i, j;
+	int32 lno;
+
+	f = fn;
+	lno = lineno;
+	nword = (f->nvalues+31)/32;
+	liveness();
+	intervals();
+	regalloc1();
+	if(f->dump)
+		ssadump(f, "regalloc");
+
+	for(i=0; i<f->nblocks; i++)
+		genblock(f->blocks[i], i+1 < f->nblocks ? f->blocks[i+1] : nil);
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		for(j=0; j<2; j++)
+			if(b->jmp[j] != P)
+				patch(b->jmp[j], b->succs[j]->pc);
+	}
+	lineno = lno;
+	f = nil;
+}
@@ -831,6 +831,7 @@ enum
 
 	D_SIZE = D_INDIR + D_INDIR,	/* 6l internal */
 	D_PCREL,
+	D_TLS,		/* 6l internal: offset of g or m from the thread pointer */
 
 	T_TYPE		= 1<<0,
 	T_INDEX		= 1<<1,
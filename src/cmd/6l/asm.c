@@ -301,9 +301,12 @@ adddynrel(Sym *s, Reloc *r)
 int
 archreloc(Reloc *r, Sym *s, vlong *val)
 {
-	USED(r);
 	USED(s);
-	USED(val);
+	switch(r->type) {
+	case D_TLS:
+		*val = tlsoffset + r->add;
+		return 0;
+	}
 	return -1;
 }
 
@@ -579,6 +582,7 @@ doelf(void)
 		elfstr[ElfStrNoteNetbsdIdent] = addstring(shstrtab, ".note.netbsd.ident");
 	addstring(shstrtab, ".elfdata");
 	addstring(shstrtab, ".rodata");
+	addstring(shstrtab, ".typelink");
 	addstring(shstrtab, ".gosymtab");
 	addstring(shstrtab, ".gopclntab");
 	if(!debug['s']) {
@@ -705,6 +709,368 @@ phsh(ElfPhdr *ph, ElfShdr *sh)
 	ph->align = sh->addralign;
 }
 
+/*
+ * Section indices in the ELF object written for the host linker.
+ */
+enum
+{
+	ElfObjNull,
+	ElfObjText,
+	ElfObjData,
+	ElfObjBss,
+	ElfObjTbss,
+	ElfObjStack,
+	ElfObjSymtab,
+	ElfObjStrtab,
+	ElfObjRelaText,
+	ElfObjRelaData,
+	ElfObjInitArray,	// C archives only, from here on
+	ElfObjRelaInitArray,
+	NElfObj,
+};
+
+static int	elfobjbind;
+
+/*
+ * the section of the object that holds s.  going by the type
+ * rather than the address tells apart symbols like edata and bss,
+ * one of which ends a section where the other begins.
+ */
+static int
+elfobjsymsect(Sym *s)
+{
+	switch(s->type&~SSUB) {
+	case SBSS:
+	case SNOPTRBSS:
+		return ElfObjBss;
+	}
+	if((s->type&~SSUB) >= SELFSECT)
+		return ElfObjData;
+	return ElfObjText;
+}
+
+static int
+elfobjsect(vlong addr)
+{
+	if(addr >= segdata.vaddr+segdata.filelen)
+		return ElfObjBss;
+	if(addr >= segdata.vaddr)
+		return ElfObjData;
+	return ElfObjText;
+}
+
+static vlong
+elfobjbase(int sect)
+{
+	switch(sect) {
+	case ElfObjText:
+		return segtext.sect->vaddr;
+	case ElfObjData:
+		return segdata.vaddr;
+	}
+	return segdata.vaddr+segdata.filelen;
+}
+
+static void
+putelfobjsym(Sym *x, char *s, int t, vlong addr, vlong size, int ver, Sym *go)
+{
+	int bind, type, shndx;
+
+	USED(go);
+	switch(t) {
+	default:
+		return;
+	case 'T':
+		type = STT_FUNC;
+		break;
+	case 'D':
+	case 'B':
+		type = STT_OBJECT;
+		break;
+	}
+	// the linker-defined symbols like etext and end
+	// would collide with the host linker's own.
+	bind = STB_GLOBAL;
+	if(ver != 0 || x->special)
+		bind = STB_LOCAL;
+	if(bind != elfobjbind)
+		return;
+	if((x->type&~SSUB) == SCONST)
+		shndx = SHN_ABS;
+	else {
+		shndx = elfobjsymsect(x);
+		addr -= elfobjbase(shndx);
+	}
+	putelfsyment(putelfstr(s), addr, size, (bind<<4)|type, shndx);
+}
+
+static vlong
+elfobjrela(int sect)
+{
+	int i, type, tsect;
+	vlong base, add, n;
+	uvlong sym;
+	Hostrel *h;
+	Reloc *r;
+
+	base = elfobjbase(sect);
+	n = 0;
+	for(i=0; i<nhostrel; i++) {
+		h = &hostrel[i];
+		if(elfobjsect(h->addr) != sect)
+			continue;
+		r = &h->r;
+		add = r->add;
+		if(r->type == D_TLS)
+			sym = ElfObjTbss;
+		else if(r->sym == S || (r->sym->type&~SSUB) == SCONST)
+			continue;
+		else if(r->sym->type == SHOSTOBJ)
+			sym = r->sym->elfsym;
+		else {
+			// a C archive: relocate against the section symbol.
+			// a pc-relative reference moves along with its target
+			// when both are in the same section.
+			tsect = elfobjsymsect(r->sym);
+			if(r->type == D_PCREL && tsect == sect)
+				continue;
+			sym = tsect;
+			add += symaddr(r->sym) - elfobjbase(tsect);
+		}
+		switch(r->type) {
+		default:
+			type = -1;
+			break;
+		case D_ADDR:
+			if(r->siz == 8)
+				type = R_X86_64_64;
+			else if(r->siz == 4 && sect == ElfObjText)
+				type = R_X86_64_32S;
+			else if(r->siz == 4)
+				type = R_X86_64_32;
+			else
+				type = -1;
+			break;
+		case D_PCREL:
+			type = -1;
+			if(r->siz == 4) {
+				type = R_X86_64_PC32;
+				add -= 4;	// ELF is relative to the start of the field, we to the end
+			}
+			break;
+		case D_TLS:
+			type = -1;
+			if(r->siz == 4)
+				type = R_X86_64_TPOFF32;
+			break;
+		}
+		if(type < 0) {
+			diag("unsupported relocation type %d size %d for %s", r->type, r->siz, r->sym ? r->sym->name : "thread-local storage");
+			continue;
+		}
+		VPUT(h->addr - base);
+		VPUT((sym<<32) | type);
+		VPUT(add);
+		n += ELF64RELASIZE;
+	}
+	return n;
+}
+
+/*
+ * write the Go part of an externally linked program as an
+ * ELF relocatable object, go.o.  The text and data keep the
+ * addresses we gave them (hostlink asks the host linker to
+ * place the sections there), so the only relocations left
+ * are those against symbols defined by host objects.
+ */
+static void
+asmbelfobj(void)
+{
+	int i, nlocal, nsect;
+	int32 name[NElfObj];
+	vlong off, symo, rtext, rdata, oinit, rinit;
+	ElfEhdr *eh;
+	ElfShdr *sh[NElfObj];
+	Section *sect;
+	Sym *s;
+
+	name[ElfObjNull] = putelfstr("");
+	name[ElfObjText] = putelfstr(".go.text");
+	name[ElfObjData] = putelfstr(".go.data");
+	name[ElfObjBss] = putelfstr(".go.bss");
+	name[ElfObjTbss] = putelfstr(".tbss");
+	name[ElfObjStack] = putelfstr(".note.GNU-stack");
+	name[ElfObjSymtab] = putelfstr(".symtab");
+	name[ElfObjStrtab] = putelfstr(".strtab");
+	name[ElfObjRelaText] = putelfstr(".rela.go.text");
+	name[ElfObjRelaData] = putelfstr(".rela.go.data");
+	name[ElfObjInitArray] = putelfstr(".init_array");
+	name[ElfObjRelaInitArray] = putelfstr(".rela.init_array");
+	nsect = ElfObjInitArray;
+	if(buildmode == BuildmodeCArchive)
+		nsect = NElfObj;
+
+	/* text segment: code, then read-only data */
+	off = rnd(ELF64HDRSIZE, 16);
+	sect = segtext.sect;
+	cseek(off);
+	codeblk(sect->vaddr, sect->len);
+	for(sect = sect->next; sect != nil; sect = sect->next) {
+		cseek(off + sect->vaddr - segtext.sect->vaddr);
+		datblk(sect->vaddr, sect->len);
+	}
+
+	/* data segment: initialized data; bss takes no space */
+	off = rnd(off + segtext.vaddr + segtext.len - segtext.sect->vaddr, 16);
+	cseek(off);
+	datblk(segdata.vaddr, segdata.filelen);
+
+	/* symbols: locals, then Go globals, then the host symbols we need */
+	symo = rnd(off + segdata.filelen, 8);
+	cseek(symo);
+	symsize = 0;
+	putelfsyment(0, 0, 0, (STB_LOCAL<<4)|STT_NOTYPE, 0);
+	// symbols 1 through 4 stand for the sections of the same numbers:
+	// relocations in C archives refer to them.
+	putelfsyment(0, 0, 0, (STB_LOCAL<<4)|STT_SECTION, ElfObjText);
+	putelfsyment(0, 0, 0, (STB_LOCAL<<4)|STT_SECTION, ElfObjData);
+	putelfsyment(0, 0, 0, (STB_LOCAL<<4)|STT_SECTION, ElfObjBss);
+	putelfsyment(putelfstr("runtime.tlsg"), 0, -tlsoffset, (STB_LOCAL<<4)|STT_TLS, ElfObjTbss);
+	elfobjbind = STB_LOCAL;
+	genasmsym(putelfobjsym);
+	nlocal = symsize/ELF64SYMSIZE;
+	elfobjbind = STB_GLOBAL;
+	genasmsym(putelfobjsym);
+	for(s = allsym; s != S; s = s->allsym) {
+		if(s->type != SHOSTOBJ || !s->reachable)
+			continue;
+		s->elfsym = symsize/ELF64SYMSIZE;
+		putelfsyment(putelfstr(s->name), 0, 0, (STB_GLOBAL<<4)|STT_NOTYPE, SHN_UNDEF);
+	}
+	cflush();
+	cwrite(elfstrdat, elfstrsize);
+
+	rtext = rnd(symo + symsize + elfstrsize, 8);
+	cseek(rtext);
+	rdata = rtext + elfobjrela(ElfObjText);
+	cseek(rdata);
+	off = rdata + elfobjrela(ElfObjData);
+
+	/* a C archive starts the runtime from .init_array */
+	oinit = rnd(off, 8);
+	rinit = oinit + 8;
+	if(buildmode == BuildmodeCArchive) {
+		cseek(oinit);
+		VPUT(0);
+		VPUT(0);
+		VPUT(((uvlong)ElfObjText<<32) | R_X86_64_64);
+		VPUT(lookup(INITENTRY, 0)->value - elfobjbase(ElfObjText));
+		off = rinit + ELF64RELASIZE;
+	}
+
+	/* section headers */
+	for(i=0; i<nsect; i++)
+		sh[i] = newElfShdr(name[i]);
+
+	sh[ElfObjText]->type = SHT_PROGBITS;
+	sh[ElfObjText]->flags = SHF_ALLOC+SHF_EXECINSTR;
+	sh[ElfObjText]->off = rnd(ELF64HDRSIZE, 16);
+	sh[ElfObjText]->size = segtext.vaddr + segtext.len - segtext.sect->vaddr;
+	sh[ElfObjText]->addralign = 16;
+
+	sh[ElfObjData]->type = SHT_PROGBITS;
+	sh[ElfObjData]->flags = SHF_ALLOC+SHF_WRITE;
+	sh[ElfObjData]->off = rnd(sh[ElfObjText]->off + sh[ElfObjText]->size, 16);
+	sh[ElfObjData]->size = segdata.filelen;
+	sh[ElfObjData]->addralign = 16;
+
+	sh[ElfObjBss]->type = SHT_NOBITS;
+	sh[ElfObjBss]->flags = SHF_ALLOC+SHF_WRITE;
+	sh[ElfObjBss]->off = sh[ElfObjData]->off + sh[ElfObjData]->size;
+	sh[ElfObjBss]->size = segdata.len - segdata.filelen;
+	sh[ElfObjBss]->addralign = 1;
+
+	// the runtime finds g and m at tlsoffset from the thread pointer.
+	sh[ElfObjTbss]->type = SHT_NOBITS;
+	sh[ElfObjTbss]->flags = SHF_ALLOC+SHF_WRITE+SHF_TLS;
+	sh[ElfObjTbss]->off = sh[ElfObjBss]->off;
+	sh[ElfObjTbss]->size = -tlsoffset;
+	sh[ElfObjTbss]->addralign = 8;
+
+	// no executable stack.
+	sh[ElfObjStack]->type = SHT_PROGBITS;
+	sh[ElfObjStack]->off = sh[ElfObjBss]->off;
+	sh[ElfObjStack]->addralign = 1;
+
+	sh[ElfObjSymtab]->type = SHT_SYMTAB;
+	sh[ElfObjSymtab]->off = symo;
+	sh[ElfObjSymtab]->size = symsize;
+	sh[ElfObjSymtab]->addralign = 8;
+	sh[ElfObjSymtab]->entsize = ELF64SYMSIZE;
+	sh[ElfObjSymtab]->link = ElfObjStrtab;
+	sh[ElfObjSymtab]->info = nlocal;
+
+	sh[ElfObjStrtab]->type = SHT_STRTAB;
+	sh[ElfObjStrtab]->off = symo + symsize;
+	sh[ElfObjStrtab]->size = elfstrsize;
+	sh[ElfObjStrtab]->addralign = 1;
+
+	sh[ElfObjRelaText]->type = SHT_RELA;
+	sh[ElfObjRelaText]->off = rtext;
+	sh[ElfObjRelaText]->size = rdata - rtext;
+	sh[ElfObjRelaText]->addralign = 8;
+	sh[ElfObjRelaText]->entsize = ELF64RELASIZE;
+	sh[ElfObjRelaText]->link = ElfObjSymtab;
+	sh[ElfObjRelaText]->info = ElfObjText;
+
+	sh[ElfObjRelaData]->type = SHT_RELA;
+	sh[ElfObjRelaData]->off = rdata;
+	sh[ElfObjRelaData]->size = off - rdata;
+	sh[ElfObjRelaData]->addralign = 8;
+	sh[ElfObjRelaData]->entsize = ELF64RELASIZE;
+	sh[ElfObjRelaData]->link = ElfObjSymtab;
+	sh[ElfObjRelaData]->info = ElfObjData;
+
+	if(buildmode == BuildmodeCArchive) {
+		sh[ElfObjInitArray]->type = SHT_INIT_ARRAY;
+		sh[ElfObjInitArray]->flags = SHF_ALLOC+SHF_WRITE;
+		sh[ElfObjInitArray]->off = oinit;
+		sh[ElfObjInitArray]->size = 8;
+		sh[ElfObjInitArray]->addralign = 8;
+		sh[ElfObjInitArray]->entsize = 8;
+
+		sh[ElfObjRelaInitArray]->type = SHT_RELA;
+		sh[ElfObjRelaInitArray]->off = rinit;
+		sh[ElfObjRelaInitArray]->size = ELF64RELASIZE;
+		sh[ElfObjRelaInitArray]->addralign = 8;
+		sh[ElfObjRelaInitArray]->entsize = ELF64RELASIZE;
+		sh[ElfObjRelaInitArray]->link = ElfObjSymtab;
+		sh[ElfObjRelaInitArray]->info = ElfObjInitArray;
+	}
+
+	eh = getElfEhdr();
+	eh->ident[EI_MAG0] = '\177';
+	eh->ident[EI_MAG1] = 'E';
+	eh->ident[EI_MAG2] = 'L';
+	eh->ident[EI_MAG3] = 'F';
+	eh->ident[EI_CLASS] = ELFCLASS64;
+	eh->ident[EI_DATA] = ELFDATA2LSB;
+	eh->ident[EI_VERSION] = EV_CURRENT;
+	eh->type = ET_REL;
+	eh->machine = EM_X86_64;
+	eh->version = EV_CURRENT;
+	eh->phoff = 0;
+	eh->phentsize = 0;
+	eh->shoff = rnd(off, 8);
+	eh->shstrndx = ElfObjStrtab;
+
+	cseek(eh->shoff);
+	elfwriteshdrs();
+	cseek(0);
+	elfwritehdr();
+	cflush();
+}
+
 void
 asmb(void)
 {
@@ -721,6 +1087,11 @@ asmb(void)
 		Bprint(&bso, "%5.2f asmb\n", cputime());
 	Bflush(&bso);
 
+	if(linkmode == LinkExternal) {
+		asmbelfobj();
+		return;
+	}
+
 	elftextsh = 0;
 	
 	if(debug['v'])
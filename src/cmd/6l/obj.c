@@ -74,14 +74,14 @@ Header headers[] = {
 void
 usage(void)
 {
-	fprint(2, "usage: 6l [-options] [-E entry] [-H head] [-I interpreter] [-L dir] [-T text] [-R rnd] [-r path] [-o out] main.6\n");
+	fprint(2, "usage: 6l [-options] [-E entry] [-H head] [-I interpreter] [-L dir] [-T text] [-R rnd] [-r path] [-o out] [-buildmode mode] [-linkmode mode] [-extld ld] [-extldflags flags] [-tmpdir dir] main.6\n");
 	exits("usage");
 }
 
 void
 main(int argc, char *argv[])
 {
-	int c;
+	int c, deftext;
 	char *name, *val;
 
 	Binit(&bso, 1, OWRITE);
@@ -96,6 +96,7 @@ main(int argc, char *argv[])
 	INITENTRY = 0;
 	nuxiinit();
 
+	argc = linkflags(argc, argv);
 	ARGBEGIN {
 	default:
 		c = ARGC();
@@ -136,6 +137,10 @@ main(int argc, char *argv[])
 		errorexit();
 	case 'X':
 		name = EARGF(usage());
+		if(strchr(name, '=') != nil) {
+			addstrdata1(name);
+			break;
+		}
 		val = EARGF(usage());
 		addstrdata(name, val);
 		break;
@@ -149,6 +154,27 @@ main(int argc, char *argv[])
 	if(HEADTYPE == -1)
 		HEADTYPE = headtype(goos);
 
+	if(buildmode == BuildmodeCShared) {
+		diag("-buildmode c-shared is not supported: 6l does not generate position-independent code");
+		errorexit();
+	}
+	if(buildmode == BuildmodeCArchive) {
+		if(HEADTYPE != Hlinux) {
+			diag("-buildmode c-archive is not supported for -H %s", headstring);
+			errorexit();
+		}
+		if(linkmode == LinkInternal) {
+			diag("-buildmode c-archive requires external linking");
+			errorexit();
+		}
+		linkmode = LinkExternal;
+	}
+
+	if(linkmode == LinkExternal && HEADTYPE != Hlinux) {
+		diag("-linkmode external is not supported for -H %s", headstring);
+		errorexit();
+	}
+
 	if(outfile == nil) {
 		if(HEADTYPE == Hwindows)
 			outfile = "6.out.exe";
@@ -158,6 +184,7 @@ main(int argc, char *argv[])
 
 	libinit();
 
+	deftext = INITTEXT == -1;
 	switch(HEADTYPE) {
 	default:
 		diag("unknown -H option");
@@ -212,7 +239,9 @@ main(int argc, char *argv[])
 		 * ELF uses TLS offset negative from FS.
 		 * Translate 0(FS) and 8(FS) into -16(FS) and -8(FS).
 		 * Also known to ../../pkg/runtime/sys_linux_amd64.s
-		 * and ../../pkg/runtime/cgo/gcc_linux_amd64.c.
+		 * and ../../pkg/runtime/cgo/gcc_freebsd_amd64.c.
+		 * A library (-buildmode=c-archive) leaves the offset
+		 * to the host linker; see asmandsz.
 		 */
 		tlsoffset = -16;
 		elfinit();
@@ -264,6 +293,11 @@ main(int argc, char *argv[])
 
 	addlibpath("command line", "command line", argv[0], "main");
 	loadlib();
+	if(linkmode == LinkExternal && deftext) {
+		// keep clear of where the host linker puts
+		// the C code, which is at 0x400000.
+		INITTEXT = (1<<28)+HEADR;
+	}
 	deadcode();
 	patch();
 	follow();
@@ -281,7 +315,8 @@ main(int argc, char *argv[])
 	span();
 	if(HEADTYPE == Hwindows)
 		dope();
-	addexport();
+	if(linkmode != LinkExternal)
+		addexport();
 	textaddress();
 	pclntab();
 	symtab();
@@ -291,6 +326,7 @@ main(int argc, char *argv[])
 	reloc();
 	asmb();
 	undef();
+	hostlink();
 	if(debug['v']) {
 		Bprint(&bso, "%5.2f cpu time\n", cputime());
 		Bprint(&bso, "%d symbols\n", nsymbol);
@@ -784,6 +784,15 @@ asmandsz(Adr *a, int r, int rex, int m64)
 		/* temporary */
 		*andptr++ = (0 <<  6) | (4 << 0) | (r << 3);	/* sib present */
 		*andptr++ = (0 << 6) | (4 << 3) | (5 << 0);	/* DS:d32 */
+		if((t == D_FS || t == D_GS) && buildmode == BuildmodeCArchive) {
+			// g and m live wherever the host linker puts
+			// our thread-local storage in the C program.
+			memset(&rel, 0, sizeof rel);
+			rel.type = D_TLS;
+			rel.siz = 4;
+			rel.add = v - tlsoffset;
+			v = 0;
+		}
 		goto putrelv;
 	}
 	if(t == D_SP || t == D_R12) {
@@ -1165,3 +1165,10 @@ sliceslice:
 no:
 	return 0;
 }
+
+void
+ssagen(SsaFunc *f)
+{
+	USED(f);
+	fatal("ssagen: no SSA back end for 8g");
+}
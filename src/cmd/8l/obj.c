@@ -141,6 +141,10 @@ main(int argc, char *argv[])
 		errorexit();
 	case 'X':
 		name = EARGF(usage());
+		if(strchr(name, '=') != nil) {
+			addstrdata1(name);
+			break;
+		}
 		val = EARGF(usage());
 		addstrdata(name, val);
 		break;
@@ -22,6 +22,7 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -35,8 +36,10 @@ import (
 
 // Flags
 var (
-	checkFile = flag.String("c", "", "optional filename to check API against")
-	verbose   = flag.Bool("v", false, "Verbose debugging")
+	checkFile  = flag.String("c", "", "optional filename to check API against")
+	nextFile   = flag.String("next", "", "optional filename of features added since the -c file")
+	exceptFile = flag.String("except", "", "optional filename of features allowed to disappear from the -c file")
+	verbose    = flag.Bool("v", false, "Verbose debugging")
 )
 
 var contexts = []*build.Context{
@@ -127,33 +130,10 @@ func main() {
 	defer bw.Flush()
 
 	if *checkFile != "" {
-		bs, err := ioutil.ReadFile(*checkFile)
-		if err != nil {
-			log.Fatalf("Error reading file %s: %v", *checkFile, err)
-		}
-		v1 := strings.Split(strings.TrimSpace(string(bs)), "\n")
-		sort.Strings(v1)
-		v2 := features
-		take := func(sl *[]string) string {
-			s := (*sl)[0]
-			*sl = (*sl)[1:]
-			return s
-		}
-		changes := false
-		for len(v1) > 0 || len(v2) > 0 {
-			switch {
-			case len(v2) == 0 || v1[0] < v2[0]:
-				fmt.Fprintf(bw, "-%s\n", take(&v1))
-				changes = true
-			case len(v1) == 0 || v1[0] > v2[0]:
-				fmt.Fprintf(bw, "+%s\n", take(&v2))
-				changes = true
-			default:
-				take(&v1)
-				take(&v2)
-			}
-		}
-		if changes {
+		required := fileFeatures(*checkFile)
+		optional := fileFeatures(*nextFile)
+		exception := fileFeatures(*exceptFile)
+		if !compareAPI(bw, features, required, optional, exception) {
 			bw.Flush()
 			os.Exit(1)
 		}
@@ -164,6 +144,68 @@ func main() {
 	}
 }
 
+// compareAPI writes to w the differences between the features of the
+// current tree and the required ones: "-" for a required feature that
+// is gone and "+" for a new feature.  New features listed in optional
+// and missing ones listed in exception are not reported.  It reports
+// whether there were no differences.
+func compareAPI(w io.Writer, features, required, optional, exception []string) bool {
+	optionalSet := make(map[string]bool)
+	for _, f := range optional {
+		optionalSet[f] = true
+	}
+	exceptionSet := make(map[string]bool)
+	for _, f := range exception {
+		exceptionSet[f] = true
+	}
+
+	sort.Strings(features)
+	sort.Strings(required)
+	take := func(sl *[]string) string {
+		s := (*sl)[0]
+		*sl = (*sl)[1:]
+		return s
+	}
+	ok := true
+	for len(required) > 0 || len(features) > 0 {
+		switch {
+		case len(features) == 0 || (len(required) > 0 && required[0] < features[0]):
+			f := take(&required)
+			if !exceptionSet[f] {
+				fmt.Fprintf(w, "-%s\n", f)
+				ok = false
+			}
+		case len(required) == 0 || required[0] > features[0]:
+			f := take(&features)
+			if !optionalSet[f] {
+				fmt.Fprintf(w, "+%s\n", f)
+				ok = false
+			}
+		default:
+			take(&required)
+			take(&features)
+		}
+	}
+	return ok
+}
+
+// fileFeatures returns the features listed one per line in the named
+// file, or nil if filename is empty.
+func fileFeatures(filename string) []string {
+	if filename == "" {
+		return nil
+	}
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Error reading file %s: %v", filename, err)
+	}
+	s := strings.TrimSpace(string(bs))
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
 // pkgSymbol represents a symbol in a package
 type pkgSymbol struct {
 	pkg    string // "net/http"
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -73,3 +74,53 @@ func TestGolden(t *testing.T) {
 		}
 	}
 }
+
+func TestCompareAPI(t *testing.T) {
+	tests := []struct {
+		name                                    string
+		features, required, optional, exception []string
+		ok                                      bool
+		out                                     string
+	}{
+		{
+			name:     "unchanged",
+			features: []string{"A", "B"},
+			required: []string{"B", "A"},
+			ok:       true,
+		},
+		{
+			name:     "feature added",
+			features: []string{"A", "B", "C"},
+			required: []string{"A", "C"},
+			out:      "+B\n",
+		},
+		{
+			name:     "feature removed",
+			features: []string{"A", "C"},
+			required: []string{"A", "B", "C"},
+			out:      "-B\n",
+		},
+		{
+			name:     "feature added in next",
+			features: []string{"A", "B", "C"},
+			required: []string{"A", "C"},
+			optional: []string{"B", "D"},
+			ok:       true,
+		},
+		{
+			name:      "feature removed by exception",
+			features:  []string{"A", "B2"},
+			required:  []string{"A", "B"},
+			optional:  []string{"B2"},
+			exception: []string{"B"},
+			ok:        true,
+		},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		ok := compareAPI(buf, tt.features, tt.required, tt.optional, tt.exception)
+		if ok != tt.ok || buf.String() != tt.out {
+			t.Errorf("%s: compareAPI = %v, %q; want %v, %q", tt.name, ok, buf.String(), tt.ok, tt.out)
+		}
+	}
+}
@@ -452,6 +452,9 @@ struct	Dynimp
 EXTERN	Dynimp	*dynimp;
 EXTERN	int	ndynimp;
 
+EXTERN	char**	ldflag;
+EXTERN	int	nldflag;
+
 struct	Dynexp
 {
 	char*	local;
@@ -773,6 +776,7 @@ void	pragtextflag(void);
 void	pragincomplete(void);
 void	pragdynimport(void);
 void	pragdynexport(void);
+void	pragcgoldflag(void);
 
 /*
  * calls to machine depend part
@@ -725,3 +725,23 @@ out:
 	while(getnsc() != '\n')
 		;
 }
+
+void
+pragcgoldflag(void)
+{
+	char *p;
+
+	p = getquoted();
+	if(p == nil) {
+		yyerror("usage: #pragma cgo_ldflag \"flag\"");
+		goto out;
+	}
+
+	if(nldflag%32 == 0)
+		ldflag = realloc(ldflag, (nldflag+32)*sizeof ldflag[0]);
+	ldflag[nldflag++] = p;
+
+out:
+	while(getnsc() != '\n')
+		;
+}
@@ -377,11 +377,14 @@ lookup(void)
 		symb[1] = '"';
 	}
 
-	// turn · into .
+	// turn · into . and ∕ into /
 	for(r=w=symb; *r; r++) {
 		if((uchar)*r == 0xc2 && (uchar)*(r+1) == 0xb7) {
 			*w++ = '.';
 			r++;
+		}else if((uchar)*r == 0xe2 && (uchar)*(r+1) == 0x88 && (uchar)*(r+2) == 0x95) {
+			*w++ = '/';
+			r += 2;
 		}else
 			*w++ = *r;
 	}
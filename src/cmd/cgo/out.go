@@ -68,6 +68,13 @@ func (p *Package) writeDefs() {
 		fmt.Fprintf(fc, cPrologGccgo)
 	} else {
 		fmt.Fprintf(fc, cProlog)
+		// Record the #cgo LDFLAGS and any -ldflags in the object so
+		// that the linker can pass them on when it links externally.
+		args := strings.Fields(p.CgoFlags["LDFLAGS"])
+		args = append(args, strings.Fields(*ldflags)...)
+		for _, arg := range args {
+			fmt.Fprintf(fc, "#pragma cgo_ldflag \"%s\"\n", arg)
+		}
 	}
 
 	cVars := make(map[string]bool)
@@ -583,6 +590,10 @@ func (p *Package) writeExports(fgo2, fc, fm *os.File) {
 		}
 		fmt.Fprintf(fc, "#pragma dynexport %s %s\n", goname, goname)
 		fmt.Fprintf(fc, "extern void ·%s();\n\n", goname)
+		// The C wrapper above is all that calls _cgoexp; when it
+		// is linked by the host linker, only the dynexport keeps
+		// the Go linker from discarding _cgoexp as unreachable.
+		fmt.Fprintf(fc, "#pragma dynexport _cgoexp%s_%s _cgoexp%s_%s\n", cPrefix, exp.ExpName, cPrefix, exp.ExpName)
 		fmt.Fprintf(fc, "#pragma textflag 7\n") // no split stack, so no use of m or g
 		fmt.Fprintf(fc, "void\n")
 		fmt.Fprintf(fc, "_cgoexp%s_%s(void *a, int32 n)\n", cPrefix, exp.ExpName)
@@ -1107,15 +1107,24 @@ static char *buildorder[] = {
 	"pkg/sort",
 	"pkg/container/heap",
 	"pkg/encoding/base64",
+	"pkg/hash",
+	"pkg/hash/adler32",
+	"pkg/crypto",
+	"pkg/crypto/sha256",
 	"pkg/syscall",
 	"pkg/time",
 	"pkg/os",
 	"pkg/reflect",
 	"pkg/fmt",
+	"pkg/encoding/binary",
+	"pkg/compress/flate",
+	"pkg/compress/zlib",
+	"pkg/path",
+	"pkg/debug/dwarf",
+	"pkg/debug/elf",
 	"pkg/encoding/json",
 	"pkg/flag",
 	"pkg/path/filepath",
-	"pkg/path",
 	"pkg/io/ioutil",
 	"pkg/log",
 	"pkg/regexp/syntax",
@@ -1163,8 +1172,15 @@ static char *cleantab[] = {
 	"libmach",
 	"pkg/bufio",
 	"pkg/bytes",
+	"pkg/compress/flate",
+	"pkg/compress/zlib",
 	"pkg/container/heap",
+	"pkg/crypto",
+	"pkg/crypto/sha256",
+	"pkg/debug/dwarf",
+	"pkg/debug/elf",
 	"pkg/encoding/base64",
+	"pkg/encoding/binary",
 	"pkg/encoding/json",
 	"pkg/errors",
 	"pkg/flag",
@@ -1175,6 +1191,8 @@ static char *cleantab[] = {
 	"pkg/go/parser",
 	"pkg/go/scanner",
 	"pkg/go/token",
+	"pkg/hash",
+	"pkg/hash/adler32",
 	"pkg/io",
 	"pkg/io/ioutil",
 	"pkg/log",
@@ -185,12 +185,19 @@ makeclosure(Node *func, NodeList **init, int nowrap)
 	return xfunc;
 }
 
+// Bytes of code the runtime writes in front of a closure's
+// variables, rounded up.  Must cover all of closure_*.c.
+enum
+{
+	ClosureCode = 64,
+};
+
 Node*
 walkclosure(Node *func, NodeList **init)
 {
-	int narg;
-	Node *xtype, *xfunc, *call, *clos;
-	NodeList *l, *in;
+	int narg, bufsiz;
+	Node *xtype, *xfunc, *call, *clos, *buf;
+	NodeList *l, *in, *args;
 
 	// no closure vars, don't bother wrapping
 	if(func->cvars == nil)
@@ -205,17 +212,33 @@ walkclosure(Node *func, NodeList **init)
 	xfunc = makeclosure(func, init, 0);
 	xtype = xfunc->nname->ntype;
 
+	narg = 0;
+	for(l=func->cvars; l; l=l->next)
+		if(l->n->op != 0)
+			narg++;
+
 	// prepare call of sys.closure that turns external func into func literal value.
-	clos = syslook("closure", 1);
+	// if the func literal does not escape, sys.closurebuf
+	// builds it in a buffer in this frame instead of on the heap.
+	in = nil;
+	args = nil;
+	if(func->esc == EscNone) {
+		bufsiz = narg*widthptr + ClosureCode;
+		buf = temp(aindex(nodintconst(bufsiz/widthptr), types[TUINTPTR]));
+		clos = syslook("closurebuf", 1);
+		in = list(in, nod(ODCLFIELD, N, typenod(ptrto(types[TUINTPTR]))));	// buf
+		in = list(in, nod(ODCLFIELD, N, typenod(types[TINT])));	// bufsiz
+		args = list(args, nod(OADDR, nod(OINDEX, buf, nodintconst(0)), N));
+		args = list(args, nodintconst(bufsiz));
+	} else
+		clos = syslook("closure", 1);
 	clos->type = T;
 	clos->ntype = nod(OTFUNC, N, N);
-	in = list1(nod(ODCLFIELD, N, typenod(types[TINT])));	// siz
+	in = list(in, nod(ODCLFIELD, N, typenod(types[TINT])));	// siz
 	in = list(in, nod(ODCLFIELD, N, xtype));
-	narg = 0;
 	for(l=func->cvars; l; l=l->next) {
 		if(l->n->op == 0)
 			continue;
-		narg++;
 		in = list(in, nod(ODCLFIELD, N, l->n->heapaddr->ntype));
 	}
 	clos->ntype->list = in;
@@ -225,10 +248,10 @@ walkclosure(Node *func, NodeList **init)
 	call = nod(OCALL, clos, N);
 	if(narg*widthptr > 100)
 		yyerror("closure needs too many variables; runtime will reject it");
-	in = list1(nodintconst(narg*widthptr));
-	in = list(in, xfunc->nname);
-	in = concat(in, func->enter);
-	call->list = in;
+	args = list(args, nodintconst(narg*widthptr));
+	args = list(args, xfunc->nname);
+	args = concat(args, func->enter);
+	call->list = args;
 
 	typecheck(&call, Erv);
 	walkexpr(&call, init);
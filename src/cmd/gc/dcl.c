@@ -170,6 +170,9 @@ declare(Node *n, int ctxt)
 	int gen;
 	static int typegen, vargen;
 
+	if(ctxt == PDISCARD)
+		return;
+
 	if(isblank(n))
 		return;
 
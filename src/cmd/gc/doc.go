@@ -40,6 +40,9 @@ Flags:
 		and diagnose any attempt to import a package that depends on it.
 	-D path
 		treat a relative import as relative to path
+	-F path=actual
+		compile imports of path using the package with import path actual;
+		the go command uses this for packages in vendor directories
 	-L
 		show entire file path when printing line numbers in errors
 	-I dir1 -I dir2
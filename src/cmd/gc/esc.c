@@ -17,10 +17,23 @@
 // variables of it can reach an & node as escaping and all function
 // parameters it can reach as leaking.
 //
+// Functions are analyzed in groups: escapes finds the strongly
+// connected components of the static call graph and analyzes them
+// bottom up, so that a call to a function outside the current group
+// can use the callee's parameter tags instead of assuming that
+// every argument escapes.  Calls within a group of mutually
+// recursive functions, and calls of function literals in place,
+// are incorporated into the flow graph directly.
+//
+// Each edge remembers why it was added, so that with -m -m every
+// escaping value is followed by the path that carried it out.
+//
 // If a value's address is taken but the address does not escape,
 // then the value can stay on the stack.  If the value new(T) does
 // not escape, then new(T) can be rewritten into a stack allocation.
-// The same is true of slice literals.
+// The same is true of slice literals, of make([]T, n) with a
+// constant n and of function literals, whose code and captured
+// variables can be laid out in the enclosing frame.
 //
 // If escape analysis is disabled (-s), this code is not used.
 // Instead, the compiler assumes that any value whose address
@@ -32,17 +45,54 @@
 #include <libc.h>
 #include "go.h"
 
+typedef	struct	EscStep	EscStep;
+
 static void escfunc(Node *func);
 static void esclist(NodeList *l);
 static void esc(Node *n);
 static void escloopdepthlist(NodeList *l);
 static void escloopdepth(Node *n);
-static void escassign(Node *dst, Node *src);
+static void escassign(Node *dst, Node *src, char *why);
+static void escsrc(Node *dst, Node *src, Node *where, char *why);
 static void esccall(Node*);
-static void escflows(Node *dst, Node *src);
+static void escflows(Node *dst, Node *src, Node *where, char *why);
 static void escflood(Node *dst);
-static void escwalk(int level, Node *dst, Node *src);
+static void escwalk(int level, Node *dst, Node *src, EscStep *step);
+static char*	escexplain(EscStep *step);
 static void esctag(Node *func);
+static uint32	visit(Node *fn);
+static uint32	visitcode(Node *n, uint32 min);
+static uint32	visitcodelist(NodeList *l, uint32 min);
+static void analyze(NodeList *fns);
+
+// An EscFlow is the edge flow(dst, src), kept on the list
+// dst->escflowsrc.  where is the destination as it was written,
+// before escassign replaced it by theSink, and why says how
+// the edge came about.
+struct	EscFlow
+{
+	Node*	src;
+	Node*	where;
+	char*	why;
+	int32	lineno;
+	EscFlow*	next;
+};
+
+// An EscStep links the edges escwalk followed from the root
+// of a flood, so that -m -m can say how a value got out.
+struct	EscStep
+{
+	EscFlow*	flow;
+	EscStep*	parent;
+};
+
+// State of the analysis of an ODCLFUNC, kept in its esc field.
+enum
+{
+	EscFuncUnknown = 0,
+	EscFuncStarted,
+	EscFuncTagged,
+};
 
 // Fake node that all
 //   - return values and output variables
@@ -57,6 +107,8 @@ static int	pdepth;		// for debug printing in recursions.
 static Strlit*	safetag;	// gets slapped on safe parameters' field types for export
 static int	dstcount, edgecount;	// diagnostic
 static NodeList*	noesc;	// list of possible non-escaping nodes, for printing
+static uint32	visitgen;	// for numbering functions in visit
+static NodeList*	stack;		// functions visited but not yet analyzed
 
 void
 escapes(NodeList *all)
@@ -70,24 +122,144 @@ escapes(NodeList *all)
 	theSink.escloopdepth = -1;
 
 	safetag = strlit("noescape");
+
+	// analyze functions bottom up in the call graph,
+	// one group of mutually recursive functions at a time.
+	// walkgen numbers the functions; inlining used it too.
+	for(l=all; l; l=l->next)
+		if(l->n->op == ODCLFUNC)
+			l->n->walkgen = 0;
+	visitgen = 0;
+	for(l=all; l; l=l->next)
+		if(l->n->op == ODCLFUNC)
+			visit(l->n);
+	for(l=all; l; l=l->next)
+		if(l->n->op == ODCLFUNC)
+			l->n->walkgen = 0;
+
+	// function literals in package-level
+	// initializers stand on their own.
+	for(l=all; l; l=l->next)
+		if(l->n->op == OCLOSURE)
+			analyze(list1(l->n));
+}
+
+// visit numbers fn and the functions it calls, using Tarjan's
+// algorithm to find the strongly connected components of the call
+// graph.  When fn turns out to be the root of a component, the
+// component is popped off the stack and analyzed, so callees are
+// always analyzed before their callers.  visit returns the lowest
+// number reachable from fn.
+static uint32
+visit(Node *fn)
+{
+	uint32 min;
+	NodeList *l, *block;
+
+	if(fn->esc == EscFuncTagged)
+		return ~0U;	// analyzed by an earlier call of escapes
+	if(fn->walkgen > 0)
+		return fn->walkgen;
+
+	fn->walkgen = ++visitgen;
+	l = mal(sizeof *l);
+	l->n = fn;
+	l->next = stack;
+	stack = l;
+
+	min = visitcodelist(fn->nbody, fn->walkgen);
+	if(min == fn->walkgen) {
+		// Mark the component with a large number so
+		// that later visits do not lower their caller's min.
+		block = stack;
+		for(l=stack; l->n != fn; l=l->next)
+			l->n->walkgen = ~0U;
+		fn->walkgen = ~0U;
+		stack = l->next;
+		l->next = nil;
+		analyze(block);
+	}
+	return min;
+}
+
+static uint32
+visitcodelist(NodeList *l, uint32 min)
+{
+	for(; l; l=l->next)
+		min = visitcode(l->n, min);
+	return min;
+}
+
+static uint32
+visitcode(Node *n, uint32 min)
+{
+	Node *fn;
+	uint32 m;
+
+	if(n == N)
+		return min;
+
+	min = visitcodelist(n->ninit, min);
+	min = visitcode(n->left, min);
+	min = visitcode(n->right, min);
+	min = visitcodelist(n->list, min);
+	min = visitcode(n->ntest, min);
+	min = visitcode(n->nincr, min);
+	min = visitcodelist(n->nbody, min);
+	min = visitcodelist(n->nelse, min);
+	min = visitcodelist(n->rlist, min);
+
+	if(n->op == OCALLFUNC || n->op == OCALLMETH) {
+		fn = n->left;
+		if(n->op == OCALLMETH)
+			fn = n->left->right->sym->def;
+		if(fn && fn->op == ONAME && fn->class == PFUNC && fn->defn && fn->defn->nbody && fn->ntype) {
+			m = visit(fn->defn);
+			if(m < min)
+				min = m;
+		}
+	}
+	return min;
+}
+
+// analyze runs escape analysis on a group of functions
+// that can only call each other or functions already tagged.
+static void
+analyze(NodeList *fns)
+{
+	NodeList *l;
+
+	dsts = nil;
 	noesc = nil;
 
+	for(l=fns; l; l=l->next)
+		if(l->n->op == ODCLFUNC)
+			l->n->esc = EscFuncStarted;
+
 	// flow-analyze functions
-	for(l=all; l; l=l->next)
-		if(l->n->op == ODCLFUNC || l->n->op == OCLOSURE)
-			escfunc(l->n);
+	for(l=fns; l; l=l->next)
+		escfunc(l->n);
 
 	// print("escapes: %d dsts, %d edges\n", dstcount, edgecount);
 
 	// visit the updstream of each dst, mark address nodes with
 	// addrescapes, mark parameters unsafe
-	for(l = dsts; l; l=l->next)
+	for(l=dsts; l; l=l->next)
 		escflood(l->n);
 
 	// for all top level functions, tag the typenodes corresponding to the param nodes
-	for(l=all; l; l=l->next)
-		if(l->n->op == ODCLFUNC)
+	for(l=fns; l; l=l->next) {
+		if(l->n->op == ODCLFUNC) {
 			esctag(l->n);
+			l->n->esc = EscFuncTagged;
+		}
+	}
+
+	// The graph is not needed once the group is tagged.
+	// Callers see only the tags, and a function literal
+	// compiled later is analyzed afresh.
+	for(l=dsts; l; l=l->next)
+		l->n->escflowsrc = nil;
 
 	if(debug['m']) {
 		for(l=noesc; l; l=l->next)
@@ -98,7 +270,6 @@ escapes(NodeList *all)
 	}
 }
 
-
 static void
 escfunc(Node *func)
 {
@@ -106,8 +277,12 @@ escfunc(Node *func)
 	NodeList *ll;
 	int saveld;
 
+	// A function literal is analyzed in the scope of the loop
+	// enclosing it, so that its parameters compare correctly
+	// with the arguments of a call in place.
 	saveld = loopdepth;
-	loopdepth = 1;
+	if(func->op != OCLOSURE || loopdepth == 0)
+		loopdepth = 1;
 	savefn = curfn;
 	curfn = func;
 
@@ -117,7 +292,7 @@ escfunc(Node *func)
 		switch (ll->n->class) {
 		case PPARAMOUT:
 			// output parameters flow to the sink
-			escflows(&theSink, ll->n);
+			escflows(&theSink, ll->n, &theSink, "returned");
 			ll->n->escloopdepth = loopdepth;
 			break;
 		case PPARAM:
@@ -139,7 +314,7 @@ escfunc(Node *func)
 		n = nod(OADDR, ll->n->closure, N);
 		n->lineno = ll->n->lineno;
 		typecheck(&n, Erv);
-		escassign(curfn, n);
+		escassign(curfn, n, "captured by closure");
 	}
 
 	escloopdepthlist(curfn->nbody);
@@ -212,6 +387,7 @@ esc(Node *n)
 {
 	int lno;
 	NodeList *ll, *lr;
+	char *why;
 
 	if(n == N)
 		return;
@@ -226,7 +402,8 @@ esc(Node *n)
 	esc(n->ntest);
 	esc(n->nincr);
 	esclist(n->ninit);
-	esclist(n->nbody);
+	if(n->op != OCLOSURE)	// analyzed by escfunc below
+		esclist(n->nbody);
 	esclist(n->nelse);
 	esclist(n->list);
 	esclist(n->rlist);
@@ -234,7 +411,7 @@ esc(Node *n)
 	if(n->op == OFOR || n->op == ORANGE)
 		loopdepth--;
 
-	if(debug['m'] > 1)
+	if(debug['m'] > 2)
 		print("%L:[%d] %S esc: %N\n", lineno, loopdepth,
 		      (curfn && curfn->nname) ? curfn->nname->sym : S, n);
 
@@ -247,10 +424,10 @@ esc(Node *n)
 
 	case OLABEL:
 		if(n->left->sym->label == &nonlooping) {
-			if(debug['m'] > 1)
+			if(debug['m'] > 2)
 				print("%L:%N non-looping label\n", lineno, n);
 		} else if(n->left->sym->label == &looping) {
-			if(debug['m'] > 1)
+			if(debug['m'] > 2)
 				print("%L: %N looping label\n", lineno, n);
 			loopdepth++;
 		}
@@ -263,7 +440,7 @@ esc(Node *n)
 	case ORANGE:
 		// Everything but fixed array is a dereference.
 		if(isfixedarray(n->type) && n->list->next)
-			escassign(n->list->next->n, n->right);
+			escassign(n->list->next->n, n->right, "range");
 		break;
 
 	case OSWITCH:
@@ -271,30 +448,30 @@ esc(Node *n)
 			for(ll=n->list; ll; ll=ll->next) {  // cases
 				// ntest->right is the argument of the .(type),
 				// ll->n->nname is the variable per case
-				escassign(ll->n->nname, n->ntest->right);
+				escassign(ll->n->nname, n->ntest->right, "type switch");
 			}
 		}
 		break;
 
 	case OAS:
 	case OASOP:
-		escassign(n->left, n->right);
+		escassign(n->left, n->right, "assigned");
 		break;
 
 	case OAS2:	// x,y = a,b
 		if(count(n->list) == count(n->rlist))
 			for(ll=n->list, lr=n->rlist; ll; ll=ll->next, lr=lr->next)
-				escassign(ll->n, lr->n);
+				escassign(ll->n, lr->n, "assigned");
 		break;
 
 	case OAS2RECV:		// v, ok = <-ch
 	case OAS2MAPR:		// v, ok = m[k]
 	case OAS2DOTTYPE:	// v, ok = x.(type)
-		escassign(n->list->n, n->rlist->n);
+		escassign(n->list->n, n->rlist->n, "assigned");
 		break;
 
 	case OSEND:		// ch <- x
-		escassign(&theSink, n->right);
+		escassign(&theSink, n->right, "sent on channel");
 		break;
 
 	case ODEFER:
@@ -305,26 +482,27 @@ esc(Node *n)
 		// fallthrough
 	case OPROC:
 		// go f(x) - f and x escape
-		escassign(&theSink, n->left->left);
-		escassign(&theSink, n->left->right);  // ODDDARG for call
+		why = n->op == OPROC ? "go statement" : "defer in loop";
+		escassign(&theSink, n->left->left, why);
+		escassign(&theSink, n->left->right, why);  // ODDDARG for call
 		for(ll=n->left->list; ll; ll=ll->next)
-			escassign(&theSink, ll->n);
+			escassign(&theSink, ll->n, why);
 		break;
 
 	case ORETURN:
 		for(ll=n->list; ll; ll=ll->next)
-			escassign(&theSink, ll->n);
+			escassign(&theSink, ll->n, "returned");
 		break;
 
 	case OPANIC:
 		// Argument could leak through recover.
-		escassign(&theSink, n->left);
+		escassign(&theSink, n->left, "panic");
 		break;
 
 	case OAPPEND:
 		if(!n->isddd)
 			for(ll=n->list->next; ll; ll=ll->next)
-				escassign(&theSink, ll->n);  // lose track of assign to dereference
+				escassign(&theSink, ll->n, "appended to slice");  // lose track of assign to dereference
 		break;
 
 	case OCALLMETH:
@@ -336,7 +514,7 @@ esc(Node *n)
 	case OCONV:
 	case OCONVNOP:
 	case OCONVIFACE:
-		escassign(n, n->left);
+		escassign(n, n->left, "converted");
 		break;
 
 	case OARRAYLIT:
@@ -346,18 +524,18 @@ esc(Node *n)
 			n->escloopdepth = loopdepth;
 			// Values make it to memory, lose track.
 			for(ll=n->list; ll; ll=ll->next)
-				escassign(&theSink, ll->n->right);
+				escassign(&theSink, ll->n->right, "slice literal element");
 		} else {
 			// Link values to array.
 			for(ll=n->list; ll; ll=ll->next)
-				escassign(n, ll->n->right);
+				escassign(n, ll->n->right, "array literal element");
 		}
 		break;
 
 	case OSTRUCTLIT:
 		// Link values to struct.
 		for(ll=n->list; ll; ll=ll->next)
-			escassign(n, ll->n->right);
+			escassign(n, ll->n->right, "struct literal field");
 		break;
 	
 	case OPTRLIT:
@@ -365,7 +543,7 @@ esc(Node *n)
 		noesc = list(noesc, n);
 		n->escloopdepth = loopdepth;
 		// Contents make it to memory, lose track.
-		escassign(&theSink, n->left);
+		escassign(&theSink, n->left, "pointed to by &T{} literal");
 		break;
 
 	case OMAPLIT:
@@ -374,13 +552,19 @@ esc(Node *n)
 		n->escloopdepth = loopdepth;
 		// Keys and values make it to memory, lose track.
 		for(ll=n->list; ll; ll=ll->next) {
-			escassign(&theSink, ll->n->left);
-			escassign(&theSink, ll->n->right);
+			escassign(&theSink, ll->n->left, "map literal key");
+			escassign(&theSink, ll->n->right, "map literal value");
 		}
 		break;
 	
-	case OADDR:
 	case OCLOSURE:
+		n->escloopdepth = loopdepth;
+		n->esc = EscNone;  // until proven otherwise
+		noesc = list(noesc, n);
+		escfunc(n);
+		break;
+
+	case OADDR:
 	case OMAKECHAN:
 	case OMAKEMAP:
 	case OMAKESLICE:
@@ -398,19 +582,21 @@ esc(Node *n)
 // dst==nil, any name node expr still must be marked as being
 // evaluated in curfn.	For expr==nil, dst must still be examined for
 // evaluations inside it (e.g *f(x) = y)
+// why says what kind of assignment it is, for -m -m.
 static void
-escassign(Node *dst, Node *src)
+escassign(Node *dst, Node *src, char *why)
 {
-	int lno;
+	Node *where;
 
 	if(isblank(dst) || dst == N || src == N || src->op == ONONAME || src->op == OXXX)
 		return;
 
-	if(debug['m'] > 1)
+	if(debug['m'] > 2)
 		print("%L:[%d] %S escassign: %hN = %hN\n", lineno, loopdepth,
 		      (curfn && curfn->nname) ? curfn->nname->sym : S, dst, src);
 
 	setlineno(dst);
+	where = dst;
 	
 	// Analyze lhs of assignment.
 	// Replace dst with theSink if we can't track it.
@@ -433,11 +619,11 @@ escassign(Node *dst, Node *src)
 			dst = &theSink;
 		break;
 	case ODOT:	      // treat "dst.x  = src" as "dst = src"
-		escassign(dst->left, src);
+		escassign(dst->left, src, why);
 		return;
 	case OINDEX:
 		if(isfixedarray(dst->left->type)) {
-			escassign(dst->left, src);
+			escassign(dst->left, src, why);
 			return;
 		}
 		dst = &theSink;  // lose track of dereference
@@ -448,11 +634,25 @@ escassign(Node *dst, Node *src)
 		break;
 	case OINDEXMAP:
 		// lose track of key and value
-		escassign(&theSink, dst->right);
+		escassign(&theSink, dst->right, "map key");
 		dst = &theSink;
 		break;
 	}
 
+	escsrc(dst, src, where, why);
+}
+
+// escsrc records that src, or the pointers in it, flow to dst.
+// It is the second half of escassign, split off so that
+// the recursion keeps the destination as written.
+static void
+escsrc(Node *dst, Node *src, Node *where, char *why)
+{
+	int lno;
+
+	if(src == N || src->op == ONONAME || src->op == OXXX)
+		return;
+
 	lno = setlineno(src);
 	pdepth++;
 
@@ -468,7 +668,7 @@ escassign(Node *dst, Node *src)
 	case OMAPLIT:
 	case OSTRUCTLIT:
 		// loopdepth was set in the defining statement or function header
-		escflows(dst, src);
+		escflows(dst, src, where, why);
 		break;
 
 	case ODOT:
@@ -486,30 +686,26 @@ escassign(Node *dst, Node *src)
 	case OSLICE:
 	case OSLICEARR:
 		// Conversions, field access, slice all preserve the input value.
-		escassign(dst, src->left);
+		escsrc(dst, src->left, where, why);
 		break;
 
 	case OAPPEND:
 		// Append returns first argument.
-		escassign(dst, src->list->n);
+		escsrc(dst, src->list->n, where, why);
 		break;
 	
 	case OINDEX:
 		// Index of array preserves input value.
 		if(isfixedarray(src->left->type))
-			escassign(dst, src->left);
+			escsrc(dst, src->left, where, why);
 		break;
 
 	case OMAKECHAN:
 	case OMAKEMAP:
 	case OMAKESLICE:
 	case ONEW:
-		escflows(dst, src);
-		break;
-
 	case OCLOSURE:
-		escflows(dst, src);
-		escfunc(src);
+		escflows(dst, src, where, why);
 		break;
 
 	case OADD:
@@ -529,8 +725,8 @@ escassign(Node *dst, Node *src)
 		// Might be pointer arithmetic, in which case
 		// the operands flow into the result.
 		// TODO(rsc): Decide what the story is here.  This is unsettling.
-		escassign(dst, src->left);
-		escassign(dst, src->right);
+		escsrc(dst, src->left, where, why);
+		escsrc(dst, src->right, where, why);
 		break;
 
 	}
@@ -586,15 +782,25 @@ esccall(Node *n)
 			ll = nil;
 		}
 	}
-			
-	if(fn && fn->op == ONAME && fn->class == PFUNC && fn->defn && fn->defn->nbody && fn->ntype) {
-		// Local function.  Incorporate into flow graph.
+
+	if(fn && fn->op == OCLOSURE) {
+		// Function literal called in place.  Its body was
+		// analyzed in this scope, see case OCLOSURE in esc.
+		lr = fn->list;
+		goto local;
+	}
+
+	if(fn && fn->op == ONAME && fn->class == PFUNC && fn->defn && fn->defn->nbody && fn->ntype
+	&& fn->defn->esc == EscFuncStarted) {
+		// Function in the group being analyzed.  Incorporate into flow graph.
 
 		// Receiver.
 		if(n->op != OCALLFUNC)
-			escassign(fn->ntype->left->left, n->left->left);
+			escassign(fn->ntype->left->left, n->left->left, "receiver");
+		lr = fn->ntype->list;
 
-		for(lr=fn->ntype->list; ll && lr; ll=ll->next, lr=lr->next) {
+	local:
+		for(; ll && lr; ll=ll->next, lr=lr->next) {
 			src = ll->n;
 			if(lr->n->isddd && !n->isddd) {
 				// Introduce ODDDARG node to represent ... allocation.
@@ -606,21 +812,21 @@ esccall(Node *n)
 				n->right = src;
 			}
 			if(lr->n->left != N)
-				escassign(lr->n->left, src);
+				escassign(lr->n->left, src, "passed to call");
 			if(src != ll->n)
 				break;
 		}
 		// "..." arguments are untracked
 		for(; ll; ll=ll->next)
-			escassign(&theSink, ll->n);
+			escassign(&theSink, ll->n, "variadic argument");
 		return;
 	}
 
-	// Imported function.  Use the escape tags.
+	// Imported or already analyzed function.  Use the escape tags.
 	if(n->op != OCALLFUNC) {
 		t = getthisx(fntype)->type;
 		if(!t->note || strcmp(t->note->s, safetag->s) != 0)
-			escassign(&theSink, n->left->left);
+			escassign(&theSink, n->left->left, "receiver leaked by callee");
 	}
 	for(t=getinargx(fntype)->type; ll; ll=ll->next) {
 		src = ll->n;
@@ -634,20 +840,22 @@ esccall(Node *n)
 			n->right = src;
 		}
 		if(!t->note || strcmp(t->note->s, safetag->s) != 0)
-			escassign(&theSink, src);
+			escassign(&theSink, src, "argument leaked by callee");
 		if(src != ll->n)
 			break;
 		t = t->down;
 	}
 	// "..." arguments are untracked
 	for(; ll; ll=ll->next)
-		escassign(&theSink, ll->n);
+		escassign(&theSink, ll->n, "variadic argument");
 }
 
 // Store the link src->dst in dst, throwing out some quick wins.
 static void
-escflows(Node *dst, Node *src)
+escflows(Node *dst, Node *src, Node *where, char *why)
 {
+	EscFlow *f;
+
 	if(dst == nil || src == nil || dst == src)
 		return;
 
@@ -655,7 +863,7 @@ escflows(Node *dst, Node *src)
 	if(src->type && !haspointers(src->type))
 		return;
 
-	if(debug['m']>2)
+	if(debug['m']>3)
 		print("%L::flows:: %hN <- %hN\n", lineno, dst, src);
 
 	if(dst->escflowsrc == nil) {
@@ -664,7 +872,13 @@ escflows(Node *dst, Node *src)
 	}
 	edgecount++;
 
-	dst->escflowsrc = list(dst->escflowsrc, src);
+	f = mal(sizeof *f);
+	f->src = src;
+	f->where = where;
+	f->why = why;
+	f->lineno = lineno;
+	f->next = dst->escflowsrc;
+	dst->escflowsrc = f;
 }
 
 // Whenever we hit a reference node, the level goes up by one, and whenever
@@ -679,7 +893,8 @@ escflows(Node *dst, Node *src)
 static void
 escflood(Node *dst)
 {
-	NodeList *l;
+	EscFlow *f;
+	EscStep step;
 
 	switch(dst->op) {
 	case ONAME:
@@ -689,28 +904,32 @@ escflood(Node *dst)
 		return;
 	}
 
-	if(debug['m']>1)
+	if(debug['m']>2)
 		print("\nescflood:%d: dst %hN scope:%S[%d]\n", walkgen, dst,
 		      (dst->curfn && dst->curfn->nname) ? dst->curfn->nname->sym : S,
 		      dst->escloopdepth);
 
-	for(l = dst->escflowsrc; l; l=l->next) {
+	for(f = dst->escflowsrc; f; f=f->next) {
 		walkgen++;
-		escwalk(0, dst, l->n);
+		step.flow = f;
+		step.parent = nil;
+		escwalk(0, dst, f->src, &step);
 	}
 }
 
+// step is the edge by which escwalk reached src.
 static void
-escwalk(int level, Node *dst, Node *src)
+escwalk(int level, Node *dst, Node *src, EscStep *step)
 {
-	NodeList *ll;
+	EscFlow *f;
+	EscStep next;
 	int leaks;
 
 	if(src->walkgen == walkgen)
 		return;
 	src->walkgen = walkgen;
 
-	if(debug['m']>1)
+	if(debug['m']>2)
 		print("escwalk: level:%d depth:%d %.*s %hN scope:%S[%d]\n",
 		      level, pdepth, pdepth, "\t\t\t\t\t\t\t\t\t\t", src,
 		      (src->curfn && src->curfn->nname) ? src->curfn->nname->sym : S, src->escloopdepth);
@@ -724,19 +943,19 @@ escwalk(int level, Node *dst, Node *src)
 		if(src->class == PPARAM && leaks && src->esc == EscNone) {
 			src->esc = EscScope;
 			if(debug['m'])
-				warnl(src->lineno, "leaking param: %hN", src);
+				warnl(src->lineno, "leaking param: %hN%s", src, escexplain(step));
 		}
 		break;
 
 	case OPTRLIT:
 	case OADDR:
-		if(leaks) {
+		if(leaks && src->esc != EscHeap) {
 			src->esc = EscHeap;
 			addrescapes(src->left);
 			if(debug['m'])
-				warnl(src->lineno, "%hN escapes to heap", src);
+				warnl(src->lineno, "%hN escapes to heap%s", src, escexplain(step));
 		}
-		escwalk(level-1, dst, src->left);
+		escwalk(level-1, dst, src->left, step);
 		break;
 
 	case OARRAYLIT:
@@ -750,10 +969,10 @@ escwalk(int level, Node *dst, Node *src)
 	case OMAPLIT:
 	case ONEW:
 	case OCLOSURE:
-		if(leaks) {
+		if(leaks && src->esc != EscHeap) {
 			src->esc = EscHeap;
 			if(debug['m'])
-				warnl(src->lineno, "%hN escapes to heap", src);
+				warnl(src->lineno, "%hN escapes to heap%s", src, escexplain(step));
 		}
 		break;
 
@@ -765,15 +984,41 @@ escwalk(int level, Node *dst, Node *src)
 	case ODOTPTR:
 	case OINDEXMAP:
 	case OIND:
-		escwalk(level+1, dst, src->left);
+		escwalk(level+1, dst, src->left, step);
 	}
 
-	for(ll=src->escflowsrc; ll; ll=ll->next)
-		escwalk(level, dst, ll->n);
+	next.parent = step;
+	for(f=src->escflowsrc; f; f=f->next) {
+		next.flow = f;
+		escwalk(level, dst, f->src, &next);
+	}
 
 	pdepth--;
 }
 
+// escexplain returns, for -m -m, the path that
+// step and its parents took from a value to the root
+// of the flood, one line per edge.
+static char*
+escexplain(EscStep *step)
+{
+	Fmt fmt;
+	EscFlow *f;
+
+	if(debug['m'] < 2)
+		return "";
+
+	fmtstrinit(&fmt);
+	for(; step; step=step->parent) {
+		f = step->flow;
+		if(f->where == &theSink)
+			fmtprint(&fmt, "\n\tflows to heap (%s) at %L", f->why, f->lineno);
+		else
+			fmtprint(&fmt, "\n\tflows to %hN (%s) at %L", f->where, f->why, f->lineno);
+	}
+	return fmtstrflush(&fmt);
+}
+
 static void
 esctag(Node *func)
 {
@@ -262,6 +262,7 @@ static const char* classnames[] = {
 	"PPARAMOUT",
 	"PPARAMREF",
 	"PFUNC",
+	"PDISCARD",
 };
 
 // Fmt "%J": Node details.
@@ -805,6 +806,12 @@ stmtfmt(Fmt *f, Node *n)
 		break;
 
 	case OAS:
+		// Don't export "v = <N>" initializing statements, hope they're always
+		// preceded by the DCL which will be re-parsed and typecheck to reproduce
+		// the "v = <N>" again.
+		if(fmtmode == FExp && n->right == N)
+			break;
+
 		if(n->colas && !complexinit)
 			fmtprint(f, "%N := %N", n->left, n->right);
 		else
@@ -133,6 +133,7 @@ typedef	struct	Node	Node;
 typedef	struct	NodeList	NodeList;
 typedef	struct	Type	Type;
 typedef	struct	Label	Label;
+typedef	struct	EscFlow	EscFlow;
 
 struct	Type
 {
@@ -267,6 +268,7 @@ struct	Node
 	NodeList*	cvars;	// closure params
 	NodeList*	dcl;	// autodcl for this func/closure
 	NodeList*	inl;	// copy of the body for use in inlining
+	NodeList*	inldcl;	// copy of dcl for use in inlining
 
 	// OLITERAL/OREGISTER
 	Val	val;
@@ -297,7 +299,7 @@ struct	Node
 	InitPlan*	initplan;
 
 	// Escape analysis.
-	NodeList* escflowsrc;	// flow(this, src)
+	EscFlow*	escflowsrc;	// flow(this, src)
 	int	escloopdepth;	// -1: global, 0: not set, function top level:1, increased inside function for every loop or label to mark scopes
 
 	Sym*	sym;		// various
@@ -569,6 +571,7 @@ enum
 	PPARAMOUT,
 	PPARAMREF,	// param passed by reference
 	PFUNC,
+	PDISCARD,	// discard during parse of duplicate import
 
 	PHEAP = 1<<7,
 };
@@ -663,6 +666,14 @@ struct Idir
 	char*	dir;
 };
 
+typedef	struct	Importmap	Importmap;
+struct Importmap
+{
+	Importmap*	link;
+	char*	path;	// import path as written in the source
+	char*	actual;	// import path of the package to use instead
+};
+
 /*
  * argument passing to/from
  * smagic and umagic
@@ -765,6 +776,7 @@ EXTERN	Pkg*	runtimepkg;	// package runtime
 EXTERN	Pkg*	stringpkg;	// fake package for C strings
 EXTERN	Pkg*	typepkg;	// fake package for runtime type info
 EXTERN	Pkg*	weaktypepkg;	// weak references to runtime type info
+EXTERN	Pkg*	typelinkpkg;	// fake package for runtime type info (data)
 EXTERN	Pkg*	unsafepkg;	// package unsafe
 EXTERN	Pkg*	phash[128];
 EXTERN	int	tptr;		// either TPTR32 or TPTR64
@@ -773,6 +785,7 @@ extern	char*	unsafeimport;
 EXTERN	char*	myimportpath;
 EXTERN	Idir*	idirs;
 EXTERN	char*	localimport;
+EXTERN	Importmap*	importmaps;
 
 EXTERN	Type*	types[NTYPE];
 EXTERN	Type*	idealstring;
@@ -1004,6 +1017,7 @@ Sym*	renameinit(void);
  *	inl.c
  */
 void	caninl(Node *fn);
+void	caninllist(NodeList *l);
 void	inlcalls(Node *fn);
 void	typecheckinl(Node *fn);
 
@@ -1113,6 +1127,7 @@ void	dumptypestructs(void);
 Type*	methodfunc(Type *f, Type*);
 Node*	typename(Type *t);
 Sym*	typesym(Type *t);
+Sym*	typelinksym(Type *t);
 Sym*	typesymprefix(char *prefix, Type *t);
 int	haspointers(Type *t);
 
@@ -1131,6 +1146,12 @@ NodeList*	initfix(NodeList *l);
 int	oaslit(Node *n, NodeList **init);
 int	stataddr(Node *nam, Node *n);
 
+/*
+ *	ssa.c
+ */
+typedef	struct	SsaFunc	SsaFunc;
+SsaFunc*	ssabuild(Node *fn);
+
 /*
  *	subr.c
  */
@@ -1333,6 +1354,7 @@ Plist*	newplist(void);
 Node*	nodarg(Type*, int);
 void	nopout(Prog*);
 void	patch(Prog*, Prog*);
+void	ssagen(SsaFunc*);
 Prog*	unpatch(Prog*);
 void	zfile(Biobuf *b, char *p, int n);
 void	zhist(Biobuf *b, int line, vlong offset);
@@ -11,19 +11,33 @@
 // making 1 the default and -l disable.  -ll and more is useful to flush out bugs.
 // These additional levels (beyond -l) may be buggy and are not supported.
 //      0: disabled
-//      1: 40-nodes leaf functions, oneliners, lazy typechecking (default)
+//      1: 80-node functions whose calls are all to inlinable functions,
+//         lazy typechecking (default)
 //      2: early typechecking of all imported bodies 
 //      3: 
-//      4: allow non-leaf functions , (breaks runtime.Caller)
-//      5: transitive inlining
+//      4: allow calls to any function (breaks runtime.Caller)
+//
+// A function that calls other inlinable functions is itself inlinable if
+// their bodies together fit the budget.  Calls in an inlined body are
+// expanded in turn, so no call is left behind whose frame runtime.Caller
+// could miss.  caninllist visits callees before callers to make this work
+// within a package; the bodies of imported functions come from export data.
+//
+// A call to a function that cannot be inlined still makes its caller
+// non-inlinable, whatever the cost: the line tables have no record of
+// inlined frames, so runtime.Caller, panics and profiles in the callee
+// would show the caller's caller in place of the inlined function.
+// Lifting that needs inlining records in the tables and the runtime's
+// traceback, which do not exist yet.
 //
 //  At some point this may get another default and become switch-offable with -N.
 //
 //  The debug['m'] flag enables diagnostic output.  a single -m is useful for verifying
-//  which calls get inlined or not, more is for debugging, and may go away at any point.
+//  which calls get inlined or not; -m -m also reports the cost of each inlinable
+//  function and why the others cannot be inlined.  More is for debugging, and
+//  may go away at any point.
 //
 // TODO:
-//   - inline functions with ... args
 //   - handle T.meth(f()) with func f() (t T, arg, arg, )
 
 #include <u.h>
@@ -35,6 +49,10 @@ static Node*	inlcopy(Node *n);
 static NodeList* inlcopylist(NodeList *ll);
 static int	ishairy(Node *n, int *budget);
 static int	ishairylist(NodeList *ll, int *budget); 
+static int	isambiguousdcl(Node *fn);
+static Node*	inlcallee(Node *n);
+static void	inlvisit(Node *fn);
+static void	inlvisitcalls(NodeList *l);
 
 // Used by inlcalls
 static void	inlnodelist(NodeList *l);
@@ -53,6 +71,12 @@ static Node *inlfn;		// function currently being inlined
 static Node *inlretlabel;	// target of the goto substituted in place of a return
 static NodeList *inlretvars;	// temp out variables
 
+// Used by caninl to explain -m -m diagnostics
+enum {
+	InlBudget = 80,	// allowed hairyness
+};
+static char inlwhy[256];	// why the function being checked cannot be inlined
+
 // Get the function's package.  For ordinary functions it's on the ->sym, but for imported methods
 // the ->sym can be re-used in the local package, so peel it off the receiver's type.
 static Pkg*
@@ -74,7 +98,10 @@ fnpkg(Node *fn)
 }
 
 // Lazy typechecking of imported bodies.  For local functions, caninl will set ->typecheck
-// because they're a copy of an already checked body. 
+// because they're a copy of an already checked body.  fn->typecheck can't tell
+// whether an imported body has been checked, since it is also set once fn is
+// used in an expression; checking the body again is cheap, as typecheck skips
+// nodes it has seen.
 void
 typecheckinl(Node *fn)
 {
@@ -82,7 +109,12 @@ typecheckinl(Node *fn)
 	Pkg *pkg;
 	int save_safemode, lno;
 
-	if(fn->typecheck)
+	// typecheckinl is only for imported functions;
+	// their bodies may refer to unsafe as long as the package
+	// was marked safe during import (which was checked then).
+	// the ->inl of a local function has been typechecked before caninl copied it.
+	pkg = fnpkg(fn);
+	if (pkg == localpkg || pkg == nil)
 		return;
 
 	lno = setlineno(fn);
@@ -90,13 +122,6 @@ typecheckinl(Node *fn)
 	if (debug['m']>2)
 		print("typecheck import [%S] %lN { %#H }\n", fn->sym, fn, fn->inl);
 
-	// typecheckinl is only used for imported functions;
-	// their bodies may refer to unsafe as long as the package
-	// was marked safe during import (which was checked then).
-	pkg = fnpkg(fn);
-	if (pkg == localpkg || pkg == nil)
-		fatal("typecheckinl on local function %lN", fn);
-
 	save_safemode = safemode;
 	safemode = 0;
 
@@ -111,15 +136,99 @@ typecheckinl(Node *fn)
 	lineno = lno;
 }
 
+// Caninllist runs caninl on each function in l, visiting the functions
+// called by a function before the function itself, so that calls to
+// inlinable functions do not stop their callers from being inlined.
+void
+caninllist(NodeList *l)
+{
+	walkgen++;
+	for(; l; l=l->next)
+		if(l->n->op == ODCLFUNC)
+			inlvisit(l->n);
+}
+
+static void
+inlvisit(Node *fn)
+{
+	if(fn->walkgen == walkgen)
+		return;
+	fn->walkgen = walkgen;
+	inlvisitcalls(fn->nbody);
+	caninl(fn);
+}
+
+// Inlvisitcalls visits the local functions called in l.
+static void
+inlvisitcalls(NodeList *l)
+{
+	Node *n, *fn;
+
+	for(; l; l=l->next) {
+		n = l->n;
+		if(n == N)
+			continue;
+		switch(n->op) {
+		case OCALLFUNC:
+		case OCALLMETH:
+			fn = inlcallee(n);
+			if(fn != N && fn->defn != N && fn->defn->op == ODCLFUNC)
+				inlvisit(fn->defn);
+			break;
+		case OCLOSURE:
+			// Closures are never inlined, nor is anything containing one.
+			continue;
+		}
+		if(n->left)
+			inlvisitcalls(list1(n->left));
+		if(n->right)
+			inlvisitcalls(list1(n->right));
+		if(n->ntest)
+			inlvisitcalls(list1(n->ntest));
+		if(n->nincr)
+			inlvisitcalls(list1(n->nincr));
+		inlvisitcalls(n->list);
+		inlvisitcalls(n->rlist);
+		inlvisitcalls(n->ninit);
+		inlvisitcalls(n->nbody);
+		inlvisitcalls(n->nelse);
+	}
+}
+
+// Inlcallee returns the ONAME of the function called by n, or N if
+// the call is not to a function known at compile time.
+static Node*
+inlcallee(Node *n)
+{
+	Node *fn;
+
+	switch(n->op) {
+	case OCALLFUNC:
+		fn = n->left;
+		if(fn->op != ONAME)
+			return N;
+		if(fn->left && fn->left->op == OTYPE && fn->right && fn->right->op == ONAME)  // methods called as functions
+			return fn->sym->def;
+		if(fn->class == PFUNC)
+			return fn;
+		break;
+	case OCALLMETH:
+		if(n->left->type != T)
+			return n->left->type->nname;
+		break;
+	}
+	return N;
+}
+
 // Caninl determines whether fn is inlineable. Currently that means:
-// fn is exactly 1 statement, either a return or an assignment, and
-// some temporary constraints marked TODO.  If fn is inlineable, saves
-// fn->nbody in fn->inl and substitutes it with a copy.
+// fn is small enough to fit the budget, calls only functions that are
+// themselves inlinable, and avoids the constructs ishairy rejects.
+// If fn is inlineable, saves fn->nbody in fn->inl and substitutes it
+// with a copy.
 void
 caninl(Node *fn)
 {
 	Node *savefn;
-	Type *t;
 	int budget;
 
 	if(fn->op != ODCLFUNC)
@@ -128,23 +237,31 @@ caninl(Node *fn)
 		fatal("caninl no nname %+N", fn);
 
 	// If fn has no body (is defined outside of Go), cannot inline it.
-	if(fn->nbody == nil)
+	if(fn->nbody == nil) {
+		if(debug['m'] > 1)
+			print("%L: cannot inline %N: no function body\n", fn->lineno, fn->nname);
 		return;
+	}
 
-	// can't handle ... args yet
-	for(t=fn->type->type->down->down->type; t; t=t->down)
-		if(t->isddd)
-			return;
-
-	budget = 40;  // allowed hairyness
-	if(ishairylist(fn->nbody, &budget))
+	budget = InlBudget;
+	inlwhy[0] = '\0';
+	if(isambiguousdcl(fn) || ishairylist(fn->nbody, &budget)) {
+		if(debug['m'] > 1) {
+			if(budget < 0)
+				snprint(inlwhy, sizeof inlwhy, "function too complex: cost exceeds budget %d", InlBudget);
+			print("%L: cannot inline %N: %s\n", fn->lineno, fn->nname, inlwhy);
+		}
 		return;
+	}
 
 	savefn = curfn;
 	curfn = fn;
 
 	fn->nname->inl = fn->nbody;
 	fn->nbody = inlcopylist(fn->nname->inl);
+	// compiling fn drops unused autos from its dcl, and closures
+	// are inlined into after that, so keep the list as it is now.
+	fn->nname->inldcl = inlcopylist(fn->nname->defn->dcl);
 	// nbody will have been typechecked, so we can set this:
 	fn->typecheck = 1;
 
@@ -153,13 +270,42 @@ caninl(Node *fn)
 	fn->type->nname = fn->nname;
 
 	if(debug['m'] > 1)
-		print("%L: can inline %#N as: %#T { %#H }\n", fn->lineno, fn->nname, fn->type, fn->nname->inl);
+		print("%L: can inline %#N with cost %d as: %#T { %#H }\n", fn->lineno, fn->nname, InlBudget-budget, fn->type, fn->nname->inl);
 	else if(debug['m'])
 		print("%L: can inline %N\n", fn->lineno, fn->nname);
 
 	curfn = savefn;
 }
 
+// The export data prints a body without its inner blocks, so a local
+// that reuses the name of another declaration would capture the other
+// one's uses once the body is imported.  Punt on such functions.
+static int
+isambiguousdcl(Node *fn)
+{
+	NodeList *l, *l2;
+	Sym *s;
+
+	for(l=fn->dcl; l; l=l->next) {
+		if(l->n->op != ONAME || l->n->class != PAUTO)
+			continue;
+		s = l->n->sym;
+		if(s == S || isblanksym(s))
+			continue;
+		if(s->def != N && s->def->sym != S && s->def->sym->pkg != builtinpkg) {
+			snprint(inlwhy, sizeof inlwhy, "local %S shadows a package-level name", s);
+			return 1;
+		}
+		for(l2=fn->dcl; l2!=l; l2=l2->next) {
+			if(l2->n->sym == s) {
+				snprint(inlwhy, sizeof inlwhy, "local %S declared twice", s);
+				return 1;
+			}
+		}
+	}
+	return 0;
+}
+
 // Look for anything we want to punt on.
 static int
 ishairylist(NodeList *ll, int* budget)
@@ -173,19 +319,42 @@ ishairylist(NodeList *ll, int* budget)
 static int
 ishairy(Node *n, int *budget)
 {
+	Node *fn;
+
 	if(!n)
 		return 0;
 
 	// Things that are too hairy, irrespective of the budget
 	switch(n->op) {
-	case OCALL:
 	case OCALLFUNC:
-	case OCALLINTER:
 	case OCALLMETH:
+		if(debug['l'] >= 4)
+			break;
+		// A call is fine if the callee can be inlined too: mkinlcall1
+		// expands it along with the body containing it.  The callee's
+		// body counts toward the budget.
+		fn = inlcallee(n);
+		if(fn == N || fn->inl == nil) {
+			if(fn == N)
+				snprint(inlwhy, sizeof inlwhy, "call to function value");
+			else
+				snprint(inlwhy, sizeof inlwhy, "call to non-inlinable function %N", fn);
+			return 1;
+		}
+		if(debug['l'] < 2)
+			typecheckinl(fn);
+		if(ishairylist(fn->inl, budget))
+			return 1;
+		break;
+
+	case OCALL:
+	case OCALLINTER:
 	case OPANIC:
 	case ORECOVER:
-		if(debug['l'] < 4)
+		if(debug['l'] < 4) {
+			snprint(inlwhy, sizeof inlwhy, "unhandled op %#O", n->op);
 			return 1;
+		}
 		break;
 
 	case OCLOSURE:
@@ -195,19 +364,10 @@ ishairy(Node *n, int *budget)
 	case OSWITCH:
 	case OPROC:
 	case ODEFER:
-	case ODCL:	// declares locals as globals b/c of @"". qualification
 	case ODCLTYPE:  // can't print yet
 	case ODCLCONST:  // can't print yet
+		snprint(inlwhy, sizeof inlwhy, "unhandled op %#O", n->op);
 		return 1;
-
-		break;
-	case OAS:
-		// x = <N> zero initializing assignments aren't representible in export yet.
-		// alternatively we may just skip them in printing and hope their DCL printed
-		// as a var will regenerate it
-		if(n->right == N)
-			return 1;
-		break;
 	}
 
 	(*budget)--;
@@ -513,10 +673,10 @@ mkinlcall(Node **np, Node *fn)
 static void
 mkinlcall1(Node **np, Node *fn)
 {
-	int i;
-	Node *n, *call, *saveinlfn, *as, *m;
-	NodeList *dcl, *ll, *ninit, *body;
-	Type *t;
+	int i, nargs;
+	Node *n, *call, *saveinlfn, *as, *m, *vararg;
+	NodeList *dcl, *ll, *ninit, *body, *args;
+	Type *t, *vartype;
 
 	if (fn->inl == nil)
 		return;
@@ -524,11 +684,24 @@ mkinlcall1(Node **np, Node *fn)
 	if (fn == curfn || fn->defn == curfn)
 		return;
 
+	n = *np;
+
+	// The trailing arguments of a call to a variadic function
+	// are packed into a slice below, unless passed with ...
+	vartype = T;
+	if(!n->isddd) {
+		for(t = getinargx(fn->type)->type; t; t=t->down)
+			if(t->isddd)
+				vartype = t->type;
+		// f(g()) with multiple results from g can't be unpacked here.
+		if(vartype != T && n->list && !n->list->next &&
+		   n->list->n->type != T && n->list->n->type->etype == TSTRUCT && n->list->n->type->funarg)
+			return;
+	}
+
 	if(debug['l']<2)
 		typecheckinl(fn);
 
-	n = *np;
-
 	// Bingo, we have a function node, and it has an inlineable body
 	if(debug['m']>1)
 		print("%L: inlining call to %S %#T { %#H }\n", n->lineno, fn->sym, fn->type, fn->inl);
@@ -544,7 +717,7 @@ mkinlcall1(Node **np, Node *fn)
 	ninit = n->ninit;
 
 	if (fn->defn) // local function
-		dcl = fn->defn->dcl;
+		dcl = fn->inldcl;
 	else // imported function
 		dcl = fn->dcl;
 
@@ -596,8 +769,27 @@ mkinlcall1(Node **np, Node *fn)
 		}
 	}
 
+	args = n->list;
+	if(vartype != T) {
+		// keep the leading arguments, then pack the rest into a slice
+		nargs = fn->type->intuple - 1;
+		if(fn->type->thistuple && n->left->op != ODOTMETH)
+			nargs++;
+		args = nil;
+		for(ll = n->list; ll && nargs > 0; ll=ll->next, nargs--)
+			args = list(args, ll->n);
+		if(ll == nil) {
+			vararg = nodnil();
+			vararg->type = vartype;
+		} else {
+			vararg = nod(OCOMPLIT, N, typenod(vartype));
+			vararg->list = ll;
+		}
+		args = list(args, vararg);
+	}
+
 	as = nod(OAS2, N, N);
-	if(fn->type->intuple > 1 && n->list && !n->list->next) {
+	if(vartype == T && fn->type->intuple > 1 && n->list && !n->list->next) {
 		// TODO check that n->list->n is a call?
 		// TODO: non-method call to T.meth(f()) where f returns t, args...
 		as->rlist = n->list;
@@ -611,7 +803,7 @@ mkinlcall1(Node **np, Node *fn)
 			}
 		}		
 	} else {
-		ll = n->list;
+		ll = args;
 		if(fn->type->thistuple && n->left->op != ODOTMETH) // non method call to method
 			ll=ll->next;  // was handled above in if(thistuple)
 
@@ -661,19 +853,18 @@ mkinlcall1(Node **np, Node *fn)
 
 	inlfn =	saveinlfn;
 
-	// transitive inlining
+	// transitive inlining: the body may call other inlinable functions,
+	// which caninl has already counted against fn's budget.
 	// TODO do this pre-expansion on fn->inl directly.  requires
 	// either supporting exporting statemetns with complex ninits
 	// or saving inl and making inlinl
-	if(debug['l'] >= 5) {
-		body = fn->inl;
-		fn->inl = nil;	// prevent infinite recursion
-		inlnodelist(call->nbody);
-		for(ll=call->nbody; ll; ll=ll->next)
-			if(ll->n->op == OINLCALL)
-				inlconv2stmt(ll->n);
-		fn->inl = body;
-	}
+	body = fn->inl;
+	fn->inl = nil;	// prevent infinite recursion
+	inlnodelist(call->nbody);
+	for(ll=call->nbody; ll; ll=ll->next)
+		if(ll->n->op == OINLCALL)
+			inlconv2stmt(ll->n);
+	fn->inl = body;
 
 	if(debug['m']>2)
 		print("%L: After inlining %+N\n\n", n->lineno, *np);
@@ -27,6 +27,7 @@ static void	ungetc(int);
 static int32	getr(void);
 static int	escchar(int, int*, vlong*);
 static void	addidir(char*);
+static void	addimportmap(char*);
 static int	getlinepragma(void);
 static char *goos, *goarch, *goroot;
 
@@ -149,9 +150,11 @@ usage(void)
 	// -% print non-static initializers
 	// -+ indicate that the runtime is being compiled
 	print("  -D PATH interpret local imports relative to this import path\n");
+	print("  -F PATH=ACTUAL satisfy imports of PATH with the package ACTUAL\n");
 	print("  -I DIR search for packages in DIR\n");
 	print("  -L show full path in file:line prints\n");
 	print("  -N disable optimizations\n");
+	print("  -O compile simple functions with the SSA back end (6g only)\n");
 	print("  -S print the assembly language\n");
 	print("  -V print the compiler version\n");
 	print("  -W print the parse tree after typing\n");
@@ -214,6 +217,10 @@ main(int argc, char *argv[])
 	weaktypepkg->name = "weak.type";
 	weaktypepkg->prefix = "weak.type";  // not weak%2etype
 
+	typelinkpkg = mkpkg(strlit("go.typelink"));
+	typelinkpkg->name = "go.typelink";
+	typelinkpkg->prefix = "go.typelink"; // not go%2etypelink
+
 	unsafepkg = mkpkg(strlit("unsafe"));
 	unsafepkg->name = "unsafe";
 
@@ -247,6 +254,10 @@ main(int argc, char *argv[])
 		localimport = EARGF(usage());
 		break;
 
+	case 'F':
+		addimportmap(EARGF(usage()));
+		break;
+
 	case 'I':
 		addidir(EARGF(usage()));
 		break;
@@ -383,9 +394,7 @@ main(int argc, char *argv[])
 
 	if (debug['l']) {
 		// Find functions that can be inlined and clone them before walk expands them.
-		for(l=xtop; l; l=l->next)
-			if(l->n->op == ODCLFUNC)
-				caninl(l->n);
+		caninllist(xtop);
 		
 		// Expand inlineable calls in all functions
 		for(l=xtop; l; l=l->next)
@@ -510,6 +519,27 @@ addidir(char* dir)
 	(*pp)->dir = dir;
 }
 
+// addimportmap records a -F PATH=ACTUAL argument,
+// used by the go command to compile imports of vendored packages.
+static void
+addimportmap(char *arg)
+{
+	char *p;
+	Importmap *m;
+
+	p = strchr(arg, '=');
+	if(p == nil || p == arg || p[1] == '\0') {
+		print("-F argument must be of the form PATH=ACTUAL\n");
+		usage();
+	}
+	m = mal(sizeof(Importmap));
+	m->path = mal(p - arg + 1);
+	memmove(m->path, arg, p - arg);
+	m->actual = p+1;
+	m->link = importmaps;
+	importmaps = m;
+}
+
 // is this path a local name?  begins with ./ or ../ or /
 static int
 islocalname(Strlit *name)
@@ -598,6 +628,7 @@ importfile(Val *f, int line)
 	int len;
 	Strlit *path;
 	char *cleanbuf, *prefix;
+	Importmap *m;
 
 	USED(line);
 
@@ -660,6 +691,15 @@ importfile(Val *f, int line)
 		strcat(cleanbuf, path->s);
 		cleanname(cleanbuf);
 		path = strlit(cleanbuf);
+	} else {
+		// Use the package the go command chose for this path,
+		// such as a copy in a vendor directory.
+		for(m = importmaps; m != nil; m = m->link) {
+			if(strcmp(m->path, path->s) == 0) {
+				path = strlit(m->actual);
+				break;
+			}
+		}
 	}
 
 	if(!findpkg(path)) {
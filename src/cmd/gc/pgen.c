@@ -19,6 +19,7 @@ compile(Node *fn)
 	Type *t;
 	Iter save;
 	vlong oldstksize;
+	SsaFunc *ssaf;
 
 	if(newproc == N) {
 		newproc = sysfunc("newproc");
@@ -64,6 +65,12 @@ compile(Node *fn)
 	if(nerrors != 0)
 		goto ret;
 
+	// the SSA back end, enabled by -O, handles only simple
+	// functions; ssabuild returns nil for the rest.
+	ssaf = nil;
+	if(thechar == '6' && debug['O'] && !debug['N'])
+		ssaf = ssabuild(curfn);
+
 	continpc = P;
 	breakpc = P;
 
@@ -79,6 +86,15 @@ compile(Node *fn)
 	afunclit(&ptxt->from);
 
 	ginit();
+	if(ssaf != nil) {
+		ssagen(ssaf);
+		gclean();
+		if(curfn->endlineno)
+			lineno = curfn->endlineno;
+		pc->as = ARET;	// overwrite AEND
+		pc->lineno = lineno;
+		goto alloc;
+	}
 	genlist(curfn->enter);
 
 	retpc = nil;
@@ -118,6 +134,7 @@ compile(Node *fn)
 		regopt(ptxt);
 	}
 
+alloc:
 	oldstksize = stksize;
 	allocauto(ptxt);
 	if(0)
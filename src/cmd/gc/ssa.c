@@ -0,0 +1,2114 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+ * static single assignment form.
+ *
+ * ssabuild translates a walked function into a graph of
+ * basic blocks holding SSA values, using the on-the-fly
+ * construction of Braun et al, "Simple and Efficient
+ * Construction of Static Single Assignment Form" (CC 2013).
+ * Local variables of integer, boolean, pointer and slice type
+ * whose address is not taken become SSA values; slices are
+ * split into their three words.  Memory is threaded through
+ * loads and stores as one more variable.
+ *
+ * The graph is then cleaned up by copy propagation, dead code
+ * elimination, common subexpression elimination and the removal
+ * of nil and bounds checks that are implied by dominating
+ * branches, before the arch-specific ssagen allocates registers
+ * and emits code.
+ *
+ * Only a subset of the language is handled.  If the function
+ * uses anything else (calls, division, floating point, strings,
+ * defer, user labels, ...), ssabuild returns nil and the function
+ * is compiled by the usual gen/cgen path.  ssabuild does not modify
+ * the function, so falling back is always possible.  Because so
+ * little is handled, the back end is off unless -O is given.
+ *
+ * With -m, the passes report what they remove, and -m -m also
+ * reports the functions that could not be converted.
+ */
+
+#include	<u.h>
+#include	<libc.h>
+#include	"go.h"
+#include	"ssa.h"
+
+typedef	struct	SsaVar	SsaVar;
+typedef	struct	Target	Target;
+typedef	struct	Lab	Lab;
+typedef	struct	Incomplete	Incomplete;
+typedef	struct	Slice	Slice;
+typedef	struct	Fact	Fact;
+typedef	struct	CseEnt	CseEnt;
+
+// An SsaVar is a variable tracked by the SSA construction:
+// a scalar variable, one word of a slice variable, or memory.
+struct	SsaVar
+{
+	Node*	n;
+	int	word;
+	Type*	type;
+};
+
+// A Target is an enclosing for or switch statement.
+struct	Target
+{
+	SsaBlock*	brk;
+	SsaBlock*	cont;
+	Target*	link;
+};
+
+struct	Lab
+{
+	Sym*	sym;
+	SsaBlock*	b;
+	Lab*	link;
+};
+
+struct	Incomplete
+{
+	int	var;
+	SsaValue*	phi;
+	Incomplete*	link;
+};
+
+struct	Slice
+{
+	SsaValue*	ptr;
+	SsaValue*	len;
+	SsaValue*	cap;
+};
+
+static	SsaFunc*	f;
+static	SsaBlock*	cur;
+static	Node*	bad;
+static	SsaVar*	vars;
+static	int	nvar;
+static	int	mvar;
+static	Target*	targets;
+static	Lab*	ssalabs;
+
+static	SsaValue*	expr(Node*);
+static	void	sliceexpr(Node*, Slice*);
+static	SsaValue*	addr(Node*, vlong*);
+static	void	stmt(Node*);
+static	void	stmtlist(NodeList*);
+static	void	cond(Node*, SsaBlock*, SsaBlock*);
+
+static char*	opnames[] =
+{
+	[SOpInvalid]	"Invalid",
+	[SOpInitMem]	"InitMem",
+	[SOpPhi]	"Phi",
+	[SOpCopy]	"Copy",
+	[SOpConst]	"Const",
+	[SOpParam]	"Param",
+	[SOpAddr]	"Addr",
+	[SOpAdd]	"Add",
+	[SOpSub]	"Sub",
+	[SOpMul]	"Mul",
+	[SOpAnd]	"And",
+	[SOpOr]		"Or",
+	[SOpXor]	"Xor",
+	[SOpLsh]	"Lsh",
+	[SOpRsh]	"Rsh",
+	[SOpNeg]	"Neg",
+	[SOpCom]	"Com",
+	[SOpNot]	"Not",
+	[SOpConv]	"Conv",
+	[SOpEq]		"Eq",
+	[SOpNe]		"Ne",
+	[SOpLt]		"Lt",
+	[SOpLe]		"Le",
+	[SOpGt]		"Gt",
+	[SOpGe]		"Ge",
+	[SOpPtrIndex]	"PtrIndex",
+	[SOpLoad]	"Load",
+	[SOpStore]	"Store",
+	[SOpStoreResult]	"StoreResult",
+	[SOpNilCheck]	"NilCheck",
+	[SOpBoundsCheck]	"BoundsCheck",
+};
+
+char*
+ssaopname(int op)
+{
+	return opnames[op];
+}
+
+// iscomputation reports whether op computes a value from its
+// arguments, as opposed to plumbing such as phis, copies and
+// constants.  -m reports the removal of computations only.
+static int
+iscomputation(int op)
+{
+	return op >= SOpAdd && op <= SOpLoad;
+}
+
+// checkremoved reports the removal of a nil or bounds check for -m.
+static void
+checkremoved(SsaValue *v)
+{
+	if(debug['m'])
+		warnl(v->lineno, "removed %s", v->op == SOpNilCheck ? "nil check" : "bounds check");
+}
+
+int
+ssaismem(SsaValue *v)
+{
+	switch(v->op) {
+	case SOpInitMem:
+	case SOpStore:
+	case SOpStoreResult:
+		return 1;
+	case SOpPhi:
+	case SOpCopy:
+		return v->type == T;
+	}
+	return 0;
+}
+
+// ssahasreg reports whether v is a value that
+// must be kept in a register or spill slot.
+// Constants are rematerialized at each use.
+int
+ssahasreg(SsaValue *v)
+{
+	switch(v->op) {
+	case SOpInvalid:
+	case SOpConst:
+	case SOpNilCheck:
+	case SOpBoundsCheck:
+		return 0;
+	}
+	return !ssaismem(v);
+}
+
+/*
+ * graph construction
+ */
+
+static int
+ssascalar(Type *t)
+{
+	if(t == T)
+		return 0;
+	switch(simtype[t->etype]) {
+	case TINT8:
+	case TUINT8:
+	case TINT16:
+	case TUINT16:
+	case TINT32:
+	case TUINT32:
+	case TINT64:
+	case TUINT64:
+	case TPTR32:
+	case TPTR64:
+	case TBOOL:
+		return t->width > 0;
+	}
+	return 0;
+}
+
+static SsaBlock*
+newblock(void)
+{
+	SsaBlock *b;
+
+	b = mal(sizeof *b);
+	b->kind = SBlockPlain;
+	b->id = f->nblocks;
+	if(f->nblocks >= f->mblocks) {
+		f->blocks = remal(f->blocks, f->mblocks*sizeof f->blocks[0], (f->mblocks+32)*sizeof f->blocks[0]);
+		f->mblocks += 32;
+	}
+	f->blocks[f->nblocks++] = b;
+	return b;
+}
+
+static void
+addedge(SsaBlock *b, SsaBlock *s)
+{
+	if(b->nsucc >= 2)
+		fatal("ssa: too many successors");
+	b->succs[b->nsucc++] = s;
+	if(s->npred >= s->mpred) {
+		s->pred = remal(s->pred, s->mpred*sizeof s->pred[0], (s->mpred+4)*sizeof s->pred[0]);
+		s->mpred += 4;
+	}
+	s->pred[s->npred++] = b;
+}
+
+static void
+addvalue(SsaBlock *b, SsaValue *v, int front)
+{
+	if(b->nvalues >= b->mvalues) {
+		b->values = remal(b->values, b->mvalues*sizeof b->values[0], (b->mvalues+16)*sizeof b->values[0]);
+		b->mvalues += 16;
+	}
+	if(front) {
+		memmove(b->values+1, b->values, b->nvalues*sizeof b->values[0]);
+		b->values[0] = v;
+	} else
+		b->values[b->nvalues] = v;
+	b->nvalues++;
+	v->block = b;
+}
+
+static SsaValue*
+newvalue(SsaBlock *b, int op, Type *t, int front)
+{
+	SsaValue *v;
+
+	v = mal(sizeof *v);
+	v->op = op;
+	v->type = t;
+	v->id = f->nvalues;
+	v->lineno = lineno;
+	if(f->nvalues >= f->mvalues) {
+		f->values = remal(f->values, f->mvalues*sizeof f->values[0], (f->mvalues+64)*sizeof f->values[0]);
+		f->mvalues += 64;
+	}
+	f->values[f->nvalues++] = v;
+	addvalue(b, v, front);
+	return v;
+}
+
+static void
+addarg(SsaValue *v, SsaValue *a)
+{
+	if(v->nargs >= v->margs) {
+		v->args = remal(v->args, v->margs*sizeof v->args[0], (v->margs+4)*sizeof v->args[0]);
+		v->margs += 4;
+	}
+	v->args[v->nargs++] = a;
+}
+
+static SsaValue*
+value1(int op, Type *t, SsaValue *a)
+{
+	SsaValue *v;
+
+	v = newvalue(cur, op, t, 0);
+	addarg(v, a);
+	return v;
+}
+
+static SsaValue*
+value2(int op, Type *t, SsaValue *a, SsaValue *b)
+{
+	SsaValue *v;
+
+	v = value1(op, t, a);
+	addarg(v, b);
+	return v;
+}
+
+static SsaValue*
+constval(Type *t, vlong c)
+{
+	SsaValue *v;
+
+	v = newvalue(cur, SOpConst, t, 0);
+	v->auxint = c;
+	return v;
+}
+
+static SsaValue*
+unsupported(Node *n)
+{
+	if(bad == N)
+		bad = n;
+	return constval(types[TINT64], 0);
+}
+
+static int
+indcl(Node *n)
+{
+	NodeList *l;
+
+	for(l=curfn->dcl; l; l=l->next)
+		if(l->n == n)
+			return 1;
+	// tempname declares the original and returns a copy.
+	if(n->sym != S && n->sym->def != N && n->sym->def != n && strncmp(n->sym->name, "autotmp_", 8) == 0)
+		return indcl(n->sym->def);
+	return 0;
+}
+
+static Type*
+wordtype(Type *t, int word)
+{
+	if(!isslice(t))
+		return t;
+	if(word == 0)
+		return ptrto(t->type);
+	return types[TINT];
+}
+
+static int
+wordoffset(Type *t, int word)
+{
+	if(!isslice(t))
+		return 0;
+	switch(word) {
+	case 0:
+		return Array_array;
+	case 1:
+		return Array_nel;
+	}
+	return Array_cap;
+}
+
+static int
+newvar(Node *n, int word, Type *t)
+{
+	if(nvar >= mvar) {
+		vars = remal(vars, mvar*sizeof vars[0], (mvar+32)*sizeof vars[0]);
+		mvar += 32;
+	}
+	vars[nvar].n = n;
+	vars[nvar].word = word;
+	vars[nvar].type = t;
+	return nvar++;
+}
+
+// varof returns the variable number for word of the
+// local variable n, or -1 if n cannot be an SSA variable.
+static int
+varof(Node *n, int word)
+{
+	int i;
+
+	for(i=1; i<nvar; i++)
+		if(vars[i].n == n && vars[i].word == word)
+			return i;
+	if(n->op != ONAME || n->addrtaken || (n->class & PHEAP))
+		return -1;
+	if(n->class != PAUTO && n->class != PPARAM && n->class != PPARAMOUT)
+		return -1;
+	if(!ssascalar(n->type) && !isslice(n->type))
+		return -1;
+	if(!indcl(n))
+		return -1;
+	i = newvar(n, 0, wordtype(n->type, 0));
+	if(isslice(n->type)) {
+		newvar(n, 1, wordtype(n->type, 1));
+		newvar(n, 2, wordtype(n->type, 2));
+	}
+	return i + word;
+}
+
+static void
+writevar(int var, SsaBlock *b, SsaValue *v)
+{
+	if(var >= b->ndefs) {
+		b->defs = remal(b->defs, b->ndefs*sizeof b->defs[0], (var+16)*sizeof b->defs[0]);
+		b->ndefs = var+16;
+	}
+	b->defs[var] = v;
+}
+
+// initial value of a variable on entry to the function.
+static SsaValue*
+initvalue(int var)
+{
+	SsaVar *vr;
+	SsaValue *v;
+
+	if(var == 0)
+		return f->initmem;
+	vr = &vars[var];
+	if(vr->n->class == PPARAM) {
+		v = newvalue(f->entry, SOpParam, vr->type, 1);
+		v->aux = vr->n;
+		v->auxint = wordoffset(vr->n->type, vr->word);
+		return v;
+	}
+	v = newvalue(f->entry, SOpConst, vr->type, 1);
+	return v;
+}
+
+static SsaValue* readvar(int, SsaBlock*);
+
+static void
+addphiargs(int var, SsaValue *phi)
+{
+	int i;
+	SsaBlock *b;
+
+	b = phi->block;
+	for(i=0; i<b->npred; i++)
+		addarg(phi, readvar(var, b->pred[i]));
+}
+
+static SsaValue*
+readvar(int var, SsaBlock *b)
+{
+	SsaValue *v;
+	Incomplete *inc;
+
+	if(var < b->ndefs && b->defs[var] != nil)
+		return b->defs[var];
+	if(b == f->entry) {
+		v = initvalue(var);
+	} else if(!b->sealed) {
+		v = newvalue(b, SOpPhi, vars[var].type, 1);
+		inc = mal(sizeof *inc);
+		inc->var = var;
+		inc->phi = v;
+		inc->link = b->incomplete;
+		b->incomplete = inc;
+	} else if(b->npred == 0) {
+		// unreachable code
+		if(var == 0)
+			v = f->initmem;
+		else
+			v = newvalue(b, SOpConst, vars[var].type, 1);
+	} else if(b->npred == 1) {
+		v = readvar(var, b->pred[0]);
+	} else {
+		v = newvalue(b, SOpPhi, vars[var].type, 1);
+		writevar(var, b, v);
+		addphiargs(var, v);
+	}
+	writevar(var, b, v);
+	return v;
+}
+
+static void
+seal(SsaBlock *b)
+{
+	Incomplete *inc;
+
+	if(b->sealed)
+		return;
+	for(inc=b->incomplete; inc; inc=inc->link)
+		addphiargs(inc->var, inc->phi);
+	b->incomplete = nil;
+	b->sealed = 1;
+}
+
+static SsaValue*
+mem(void)
+{
+	return readvar(0, cur);
+}
+
+// end the current block with a jump to b.
+static void
+jump(SsaBlock *b)
+{
+	cur->kind = SBlockPlain;
+	addedge(cur, b);
+}
+
+static SsaBlock*
+deadblock(void)
+{
+	SsaBlock *b;
+
+	b = newblock();
+	b->sealed = 1;
+	return b;
+}
+
+static SsaValue*
+load(SsaValue *p, vlong off, Type *t)
+{
+	SsaValue *v;
+
+	v = value2(SOpLoad, t, p, mem());
+	v->auxint = off;
+	return v;
+}
+
+static void
+store(SsaValue *p, vlong off, Type *t, SsaValue *x)
+{
+	SsaValue *v;
+
+	v = value2(SOpStore, t, p, x);
+	addarg(v, mem());
+	v->auxint = off;
+	writevar(0, cur, v);
+}
+
+static void
+nilcheck(SsaValue *p)
+{
+	if(p->op != SOpAddr)
+		value1(SOpNilCheck, T, p);
+}
+
+static SsaValue*
+shift(int op, Type *t, SsaValue *a, vlong s)
+{
+	SsaValue *v;
+
+	if(s >= 64) {
+		if(op == OLSH || !issigned[simtype[t->etype]])
+			return constval(t, 0);
+		s = 63;
+	}
+	if(s == 0)
+		return a;
+	v = value1(op == OLSH ? SOpLsh : SOpRsh, t, a);
+	v->auxint = s;
+	return v;
+}
+
+static SsaValue*
+binop(int op, Type *t, SsaValue *a, Node *r)
+{
+	int sop;
+
+	switch(op) {
+	case OLSH:
+	case ORSH:
+		if(r->op != OLITERAL)
+			return unsupported(r);
+		return shift(op, t, a, mpgetfix(r->val.u.xval));
+	case OADD:
+		sop = SOpAdd;
+		break;
+	case OSUB:
+		sop = SOpSub;
+		break;
+	case OMUL:
+		sop = SOpMul;
+		break;
+	case OAND:
+		sop = SOpAnd;
+		break;
+	case OOR:
+		sop = SOpOr;
+		break;
+	case OXOR:
+		sop = SOpXor;
+		break;
+	default:
+		return unsupported(r);
+	}
+	return value2(sop, t, a, expr(r));
+}
+
+static SsaValue*
+compare(Node *n)
+{
+	Type *t;
+	SsaValue *a, *b, *v;
+	Slice s;
+	int op;
+
+	t = n->left->type;
+	if(isslice(t)) {
+		// comparison with nil
+		if(n->left->op == OLITERAL)
+			sliceexpr(n->right, &s);
+		else
+			sliceexpr(n->left, &s);
+		a = s.ptr;
+		b = constval(a->type, 0);
+	} else {
+		if(!ssascalar(t))
+			return unsupported(n);
+		a = expr(n->left);
+		b = expr(n->right);
+	}
+	switch(n->op) {
+	case OEQ:
+		op = SOpEq;
+		break;
+	case ONE:
+		op = SOpNe;
+		break;
+	case OLT:
+		op = SOpLt;
+		break;
+	case OLE:
+		op = SOpLe;
+		break;
+	case OGT:
+		op = SOpGt;
+		break;
+	default:
+		op = SOpGe;
+		break;
+	}
+	v = value2(op, types[TBOOL], a, b);
+	v->auxint = !issigned[simtype[a->type->etype]];
+	return v;
+}
+
+// value of a boolean && or || expression.
+static SsaValue*
+andor(Node *n)
+{
+	int var;
+	SsaBlock *yes, *no, *join;
+
+	var = newvar(n, 0, types[TBOOL]);
+	yes = newblock();
+	no = newblock();
+	join = newblock();
+	cond(n, yes, no);
+	seal(yes);
+	seal(no);
+	cur = yes;
+	writevar(var, cur, constval(types[TBOOL], 1));
+	jump(join);
+	cur = no;
+	writevar(var, cur, constval(types[TBOOL], 0));
+	jump(join);
+	seal(join);
+	cur = join;
+	return readvar(var, cur);
+}
+
+static SsaValue*
+expr(Node *n)
+{
+	SsaValue *a, *v;
+	Slice s;
+	vlong off;
+	int var;
+
+	stmtlist(n->ninit);
+	switch(n->op) {
+	case OLITERAL:
+		if(!ssascalar(n->type))
+			break;
+		switch(n->val.ctype) {
+		case CTINT:
+		case CTRUNE:
+			return constval(n->type, mpgetfix(n->val.u.xval));
+		case CTBOOL:
+			return constval(n->type, n->val.u.bval);
+		case CTNIL:
+			return constval(n->type, 0);
+		}
+		break;
+
+	case ONAME:
+		if(!ssascalar(n->type))
+			break;
+		if(n->class == PEXTERN)
+			return load(addr(n, &off), 0, n->type);
+		var = varof(n, 0);
+		if(var < 0)
+			break;
+		return readvar(var, cur);
+
+	case OADD:
+	case OSUB:
+	case OMUL:
+	case OAND:
+	case OOR:
+	case OXOR:
+	case OLSH:
+	case ORSH:
+		if(!ssascalar(n->type))
+			break;
+		return binop(n->op, n->type, expr(n->left), n->right);
+
+	case OMINUS:
+	case OCOM:
+	case ONOT:
+		if(!ssascalar(n->type))
+			break;
+		a = expr(n->left);
+		if(n->op == OMINUS)
+			return value1(SOpNeg, n->type, a);
+		if(n->op == OCOM)
+			return value1(SOpCom, n->type, a);
+		return value1(SOpNot, n->type, a);
+
+	case OPLUS:
+		return expr(n->left);
+
+	case OCONV:
+	case OCONVNOP:
+		if(!ssascalar(n->type) || !ssascalar(n->left->type))
+			break;
+		a = expr(n->left);
+		if(simtype[a->type->etype] == simtype[n->type->etype])
+			return a;
+		return value1(SOpConv, n->type, a);
+
+	case OEQ:
+	case ONE:
+	case OLT:
+	case OLE:
+	case OGT:
+	case OGE:
+		return compare(n);
+
+	case OANDAND:
+	case OOROR:
+		return andor(n);
+
+	case OLEN:
+	case OCAP:
+		if(!isslice(n->left->type))
+			break;
+		sliceexpr(n->left, &s);
+		if(n->op == OLEN)
+			return s.len;
+		return s.cap;
+
+	case OINDEX:
+	case OIND:
+	case ODOT:
+	case ODOTPTR:
+		if(!ssascalar(n->type))
+			break;
+		a = addr(n, &off);
+		return load(a, off, n->type);
+
+	case OADDR:
+		a = addr(n->left, &off);
+		if(off == 0)
+			return a;
+		v = value2(SOpAdd, n->type, a, constval(types[TUINTPTR], off));
+		return v;
+	}
+	return unsupported(n);
+}
+
+static void
+sliceexpr(Node *n, Slice *s)
+{
+	SsaValue *p;
+	vlong off;
+	int var;
+
+	stmtlist(n->ninit);
+	switch(n->op) {
+	case ONAME:
+		if(n->class == PEXTERN)
+			goto mem;
+		var = varof(n, 0);
+		if(var < 0)
+			break;
+		s->ptr = readvar(var, cur);
+		s->len = readvar(var+1, cur);
+		s->cap = readvar(var+2, cur);
+		return;
+
+	case OLITERAL:
+		if(n->val.ctype != CTNIL)
+			break;
+		s->ptr = constval(wordtype(n->type, 0), 0);
+		s->len = constval(types[TINT], 0);
+		s->cap = constval(types[TINT], 0);
+		return;
+
+	case OCONVNOP:
+		sliceexpr(n->left, s);
+		return;
+
+	case OINDEX:
+	case OIND:
+	case ODOT:
+	case ODOTPTR:
+	mem:
+		p = addr(n, &off);
+		s->ptr = load(p, off+Array_array, wordtype(n->type, 0));
+		s->len = load(p, off+Array_nel, types[TINT]);
+		s->cap = load(p, off+Array_cap, types[TINT]);
+		return;
+	}
+	s->ptr = unsupported(n);
+	s->len = s->ptr;
+	s->cap = s->ptr;
+}
+
+// addr returns a pointer p such that
+// n is the memory at p+*off.
+static SsaValue*
+addr(Node *n, vlong *off)
+{
+	SsaValue *p, *i, *bound, *v;
+	Slice s;
+	Type *t;
+	vlong o;
+
+	*off = 0;
+	switch(n->op) {
+	case ONAME:
+		if(n->class != PEXTERN)
+			break;
+		v = newvalue(cur, SOpAddr, ptrto(n->type), 0);
+		v->aux = n;
+		return v;
+
+	case OIND:
+		p = expr(n->left);
+		nilcheck(p);
+		return p;
+
+	case ODOTPTR:
+		p = expr(n->left);
+		nilcheck(p);
+		*off = n->xoffset;
+		return p;
+
+	case ODOT:
+		p = addr(n->left, off);
+		*off += n->xoffset;
+		return p;
+
+	case OINDEX:
+		t = n->left->type;
+		o = 0;
+		if(isslice(t)) {
+			sliceexpr(n->left, &s);
+			p = s.ptr;
+			bound = s.len;
+		} else if(isfixedarray(t)) {
+			p = addr(n->left, &o);
+			bound = constval(types[TINT], t->bound);
+		} else
+			break;
+		if(!ssascalar(n->right->type))
+			break;
+		i = expr(n->right);
+		if(!debug['B'] && !n->etype && !(isfixedarray(t) && i->op == SOpConst))
+			value2(SOpBoundsCheck, T, i, bound);
+		if(i->op == SOpConst) {
+			*off = o + i->auxint*n->type->width;
+			return p;
+		}
+		v = value2(SOpPtrIndex, ptrto(n->type), p, i);
+		v->auxint = n->type->width;
+		*off = o;
+		return v;
+	}
+	return unsupported(n);
+}
+
+static void
+assign(Node *l, Node *r)
+{
+	SsaValue *v, *p;
+	Slice s;
+	vlong off;
+	int var, i;
+
+	if(isblank(l)) {
+		if(r == N)
+			return;
+		if(isslice(r->type))
+			sliceexpr(r, &s);
+		else
+			expr(r);
+		return;
+	}
+	if(isslice(l->type)) {
+		if(r == N) {
+			s.ptr = constval(wordtype(l->type, 0), 0);
+			s.len = constval(types[TINT], 0);
+			s.cap = constval(types[TINT], 0);
+		} else
+			sliceexpr(r, &s);
+		if(l->op == ONAME && l->class != PEXTERN) {
+			var = varof(l, 0);
+			if(var < 0) {
+				unsupported(l);
+				return;
+			}
+			writevar(var, cur, s.ptr);
+			writevar(var+1, cur, s.len);
+			writevar(var+2, cur, s.cap);
+			return;
+		}
+		p = addr(l, &off);
+		for(i=0; i<3; i++)
+			store(p, off+wordoffset(l->type, i), wordtype(l->type, i), i == 0 ? s.ptr : i == 1 ? s.len : s.cap);
+		return;
+	}
+	if(!ssascalar(l->type)) {
+		unsupported(l);
+		return;
+	}
+	if(r == N)
+		v = constval(l->type, 0);
+	else
+		v = expr(r);
+	if(l->op == ONAME && l->class != PEXTERN) {
+		var = varof(l, 0);
+		if(var < 0) {
+			unsupported(l);
+			return;
+		}
+		writevar(var, cur, v);
+		return;
+	}
+	p = addr(l, &off);
+	store(p, off, l->type, v);
+}
+
+static void
+asop(Node *n)
+{
+	Node *l;
+	SsaValue *p, *v;
+	vlong off;
+	int var;
+
+	l = n->left;
+	if(!ssascalar(l->type)) {
+		unsupported(n);
+		return;
+	}
+	if(l->op == ONAME && l->class != PEXTERN) {
+		var = varof(l, 0);
+		if(var < 0) {
+			unsupported(l);
+			return;
+		}
+		v = binop(n->etype, l->type, readvar(var, cur), n->right);
+		writevar(var, cur, v);
+		return;
+	}
+	p = addr(l, &off);
+	v = binop(n->etype, l->type, load(p, off, l->type), n->right);
+	store(p, off, l->type, v);
+}
+
+// store the word values of a result.
+static void
+storeresult(Node *res, SsaValue **w, int nw)
+{
+	SsaValue *v;
+	int i;
+
+	for(i=0; i<nw; i++) {
+		v = value2(SOpStoreResult, wordtype(res->type, i), w[i], mem());
+		v->aux = res;
+		v->auxint = wordoffset(res->type, i);
+		writevar(0, cur, v);
+	}
+}
+
+static void
+ret(Node *n)
+{
+	NodeList *l;
+	Node *r;
+	SsaValue *w[3];
+	Slice s;
+	int slot, var, i;
+
+	slot = 0;
+	for(l=n->list; l; l=l->next) {
+		r = l->n;
+		if(r->op == OAS && r->left->op == ONAME && r->left->class == PPARAM && !indcl(r->left)) {
+			// assignment to result slot, made by ascompatte
+			slot = 1;
+			if(isslice(r->left->type)) {
+				sliceexpr(r->right, &s);
+				w[0] = s.ptr;
+				w[1] = s.len;
+				w[2] = s.cap;
+				storeresult(r->left, w, 3);
+			} else if(ssascalar(r->left->type)) {
+				w[0] = expr(r->right);
+				storeresult(r->left, w, 1);
+			} else
+				unsupported(r);
+			continue;
+		}
+		stmt(r);
+	}
+	if(!slot) {
+		for(l=curfn->dcl; l; l=l->next) {
+			r = l->n;
+			if(r->class != PPARAMOUT)
+				continue;
+			var = varof(r, 0);
+			if(var < 0) {
+				unsupported(r);
+				return;
+			}
+			for(i=0; i<3 && (i == 0 || isslice(r->type)); i++)
+				w[i] = readvar(var+i, cur);
+			storeresult(r, w, i);
+		}
+	}
+	cur->kind = SBlockRet;
+	cur->control = mem();
+}
+
+static SsaBlock*
+label(Sym *s)
+{
+	Lab *l;
+
+	for(l=ssalabs; l; l=l->link)
+		if(l->sym == s)
+			return l->b;
+	l = mal(sizeof *l);
+	l->sym = s;
+	l->b = newblock();
+	l->link = ssalabs;
+	ssalabs = l;
+	return l->b;
+}
+
+static void
+cond(Node *n, SsaBlock *yes, SsaBlock *no)
+{
+	SsaBlock *mid;
+	SsaValue *v;
+
+	stmtlist(n->ninit);
+	switch(n->op) {
+	case OANDAND:
+		mid = newblock();
+		cond(n->left, mid, no);
+		seal(mid);
+		cur = mid;
+		cond(n->right, yes, no);
+		return;
+	case OOROR:
+		mid = newblock();
+		cond(n->left, yes, mid);
+		seal(mid);
+		cur = mid;
+		cond(n->right, yes, no);
+		return;
+	case ONOT:
+		cond(n->left, no, yes);
+		return;
+	}
+	v = expr(n);
+	cur->kind = SBlockIf;
+	cur->control = v;
+	addedge(cur, yes);
+	addedge(cur, no);
+}
+
+static void
+stmt(Node *n)
+{
+	SsaBlock *b, *body, *incr, *exit, *els;
+	Target *t, tg;
+
+	if(n == N || bad != N)
+		return;
+	setlineno(n);
+	stmtlist(n->ninit);
+	switch(n->op) {
+	default:
+		unsupported(n);
+		break;
+
+	case OEMPTY:
+	case ODCLCONST:
+	case ODCLTYPE:
+	case ODCLFUNC:
+	case OCASE:
+	case OXCASE:
+	case OFALL:
+	case OXFALL:
+		break;
+
+	case ODCL:
+		if(n->left->class & PHEAP)
+			unsupported(n);
+		break;
+
+	case OBLOCK:
+		stmtlist(n->list);
+		break;
+
+	case OAS:
+		assign(n->left, n->right);
+		break;
+
+	case OASOP:
+		asop(n);
+		break;
+
+	case OLABEL:
+		// only the labels made by walkswitch;
+		// gen diagnoses misuse of user labels.
+		if(!isdigit((uchar)n->left->sym->name[0])) {
+			unsupported(n);
+			break;
+		}
+		b = label(n->left->sym);
+		jump(b);
+		cur = b;
+		break;
+
+	case OGOTO:
+		if(!isdigit((uchar)n->left->sym->name[0])) {
+			unsupported(n);
+			break;
+		}
+		jump(label(n->left->sym));
+		cur = deadblock();
+		break;
+
+	case OBREAK:
+	case OCONTINUE:
+		if(n->left != N) {
+			unsupported(n);
+			break;
+		}
+		for(t=targets; t; t=t->link)
+			if(n->op == OBREAK || t->cont != nil)
+				break;
+		if(t == nil) {
+			unsupported(n);
+			break;
+		}
+		jump(n->op == OBREAK ? t->brk : t->cont);
+		cur = deadblock();
+		break;
+
+	case OFOR:
+		b = newblock();
+		body = newblock();
+		incr = newblock();
+		exit = newblock();
+		jump(b);
+		cur = b;
+		if(n->ntest != N)
+			cond(n->ntest, body, exit);
+		else
+			jump(body);
+		seal(body);
+
+		tg.brk = exit;
+		tg.cont = incr;
+		tg.link = targets;
+		targets = &tg;
+		cur = body;
+		stmtlist(n->nbody);
+		jump(incr);
+		seal(incr);
+		cur = incr;
+		stmt(n->nincr);
+		jump(b);
+		seal(b);
+		targets = tg.link;
+
+		seal(exit);
+		cur = exit;
+		break;
+
+	case OIF:
+		body = newblock();
+		els = newblock();
+		exit = newblock();
+		cond(n->ntest, body, els);
+		seal(body);
+		seal(els);
+		cur = body;
+		stmtlist(n->nbody);
+		jump(exit);
+		cur = els;
+		stmtlist(n->nelse);
+		jump(exit);
+		seal(exit);
+		cur = exit;
+		break;
+
+	case OSWITCH:
+		exit = newblock();
+		tg.brk = exit;
+		tg.cont = nil;
+		tg.link = targets;
+		targets = &tg;
+		stmtlist(n->nbody);
+		jump(exit);
+		targets = tg.link;
+		seal(exit);
+		cur = exit;
+		break;
+
+	case ORETURN:
+		ret(n);
+		cur = deadblock();
+		break;
+	}
+}
+
+static void
+stmtlist(NodeList *l)
+{
+	for(; l; l=l->next)
+		stmt(l->n);
+}
+
+/*
+ * optimization passes
+ */
+
+static SsaValue*
+copysource(SsaValue *v)
+{
+	while(v->op == SOpCopy)
+		v = v->args[0];
+	return v;
+}
+
+static void
+tocopy(SsaValue *v, SsaValue *w)
+{
+	v->op = SOpCopy;
+	v->nargs = 0;
+	addarg(v, w);
+}
+
+// replace phis that merge a single value with copies,
+// then make every use refer to the copied value.
+static void
+copyelim(void)
+{
+	SsaBlock *b;
+	SsaValue *v, *w, *a;
+	int i, j, k, change;
+
+	do {
+		change = 0;
+		for(i=0; i<f->nblocks; i++) {
+			b = f->blocks[i];
+			for(j=0; j<b->nvalues; j++) {
+				v = b->values[j];
+				if(v->op != SOpPhi)
+					continue;
+				w = nil;
+				for(k=0; k<v->nargs; k++) {
+					a = copysource(v->args[k]);
+					if(a == v || a == w)
+						continue;
+					if(w != nil)
+						break;
+					w = a;
+				}
+				if(k == v->nargs && w != nil) {
+					tocopy(v, w);
+					change = 1;
+				}
+			}
+		}
+	} while(change);
+
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			for(k=0; k<v->nargs; k++)
+				v->args[k] = copysource(v->args[k]);
+		}
+		if(b->control != nil)
+			b->control = copysource(b->control);
+	}
+}
+
+// remove the edge b->s, which must be s's i'th predecessor.
+static void
+removepred(SsaBlock *s, int i)
+{
+	SsaValue *v;
+	int j;
+
+	s->npred--;
+	memmove(s->pred+i, s->pred+i+1, (s->npred-i)*sizeof s->pred[0]);
+	for(j=0; j<s->nvalues; j++) {
+		v = s->values[j];
+		if(v->op != SOpPhi)
+			continue;
+		v->nargs--;
+		memmove(v->args+i, v->args+i+1, (v->nargs-i)*sizeof v->args[0]);
+	}
+}
+
+static void
+removeedge(SsaBlock *b, SsaBlock *s)
+{
+	int i;
+
+	for(i=0; i<s->npred; i++)
+		if(s->pred[i] == b) {
+			removepred(s, i);
+			return;
+		}
+	fatal("ssa: missing edge b%d -> b%d", b->id, s->id);
+}
+
+static void
+markreachable(SsaBlock *b)
+{
+	int i;
+
+	if(b->mark)
+		return;
+	b->mark = 1;
+	for(i=0; i<b->nsucc; i++)
+		markreachable(b->succs[i]);
+}
+
+// turn branches on constants into jumps
+// and remove unreachable blocks.
+static void
+deadblocks(void)
+{
+	SsaBlock *b, *s;
+	int i, j, n;
+
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		if(b->kind != SBlockIf || b->control->op != SOpConst)
+			continue;
+		if(b->control->auxint)
+			s = b->succs[1];
+		else {
+			s = b->succs[0];
+			b->succs[0] = b->succs[1];
+		}
+		removeedge(b, s);
+		b->kind = SBlockPlain;
+		b->control = nil;
+		b->nsucc = 1;
+	}
+
+	for(i=0; i<f->nblocks; i++)
+		f->blocks[i]->mark = 0;
+	markreachable(f->entry);
+	n = 0;
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		if(b->mark) {
+			f->blocks[n++] = b;
+			continue;
+		}
+		for(j=0; j<b->nsucc; j++)
+			if(b->succs[j]->mark)
+				removeedge(b, b->succs[j]);
+		if(debug['m'])
+			for(j=0; j<b->nvalues; j++)
+				if(iscomputation(b->values[j]->op))
+					warnl(b->values[j]->lineno, "removed dead %s", opnames[b->values[j]->op]);
+	}
+	f->nblocks = n;
+}
+
+static void
+markvalue(SsaValue *v)
+{
+	int i;
+
+	while(v->mark == 0) {
+		v->mark = 1;
+		if(v->nargs == 0)
+			return;
+		for(i=1; i<v->nargs; i++)
+			markvalue(v->args[i]);
+		v = v->args[0];
+	}
+}
+
+// remove values that are not needed, and count uses of the others.
+static void
+deadvalues(void)
+{
+	SsaBlock *b;
+	SsaValue *v;
+	int i, j, k, n;
+
+	for(i=0; i<f->nvalues; i++) {
+		f->values[i]->mark = 0;
+		f->values[i]->uses = 0;
+	}
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		if(b->control != nil)
+			markvalue(b->control);
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			switch(v->op) {
+			case SOpStore:
+			case SOpStoreResult:
+			case SOpNilCheck:
+			case SOpBoundsCheck:
+				markvalue(v);
+			}
+		}
+	}
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		n = 0;
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			if(v->mark) {
+				b->values[n++] = v;
+				for(k=0; k<v->nargs; k++)
+					v->args[k]->uses++;
+			} else {
+				if(debug['m'] && iscomputation(v->op))
+					warnl(v->lineno, "removed dead %s", opnames[v->op]);
+				v->op = SOpInvalid;
+			}
+		}
+		b->nvalues = n;
+		if(b->control != nil)
+			b->control->uses++;
+	}
+}
+
+/*
+ * dominators, by Cooper, Harvey and Kennedy,
+ * "A Simple, Fast Dominance Algorithm".
+ */
+
+static int	nrpo;
+
+static void
+postorder(SsaBlock *b)
+{
+	int i;
+
+	b->mark = 1;
+	for(i=b->nsucc-1; i>=0; i--)
+		if(!b->succs[i]->mark)
+			postorder(b->succs[i]);
+	b->rpo = nrpo++;
+}
+
+static SsaBlock*
+intersect(SsaBlock *a, SsaBlock *b)
+{
+	while(a != b) {
+		while(a->rpo > b->rpo)
+			a = a->idom;
+		while(b->rpo > a->rpo)
+			b = b->idom;
+	}
+	return a;
+}
+
+// lay out the blocks in reverse postorder
+// and build the dominator tree.
+static void
+dominators(void)
+{
+	SsaBlock *b, *d, **order;
+	int i, j, change;
+
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		b->mark = 0;
+		b->idom = nil;
+		b->dchild = nil;
+		b->dsibling = nil;
+	}
+	nrpo = 0;
+	postorder(f->entry);
+	order = mal(f->nblocks*sizeof order[0]);
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		b->rpo = nrpo-1 - b->rpo;
+		order[b->rpo] = b;
+	}
+	memmove(f->blocks, order, f->nblocks*sizeof order[0]);
+
+	f->entry->idom = f->entry;
+	do {
+		change = 0;
+		for(i=1; i<f->nblocks; i++) {
+			b = f->blocks[i];
+			d = nil;
+			for(j=0; j<b->npred; j++) {
+				if(b->pred[j]->idom == nil)
+					continue;
+				if(d == nil)
+					d = b->pred[j];
+				else
+					d = intersect(b->pred[j], d);
+			}
+			if(d != b->idom) {
+				b->idom = d;
+				change = 1;
+			}
+		}
+	} while(change);
+
+	for(i=f->nblocks-1; i>=1; i--) {
+		b = f->blocks[i];
+		b->dsibling = b->idom->dchild;
+		b->idom->dchild = b;
+	}
+}
+
+/*
+ * common subexpression elimination.
+ * walks the dominator tree with a scoped hash table
+ * of the values available in the current block.
+ */
+
+struct	CseEnt
+{
+	SsaValue*	v;
+	CseEnt*	link;
+};
+
+static	CseEnt**	csetab;
+static	uint32	csemask;
+
+static int
+typekey(Type *t)
+{
+	if(t == T)
+		return -1;
+	return simtype[t->etype];
+}
+
+static uint32
+csehash(SsaValue *v)
+{
+	uint32 h;
+	int i;
+
+	h = v->op*31 + typekey(v->type);
+	h = h*31 + (uint32)v->auxint;
+	h = h*31 + (uint32)(uintptr)v->aux;
+	for(i=0; i<v->nargs; i++)
+		h = h*31 + v->args[i]->id;
+	return h & csemask;
+}
+
+static int
+cseequal(SsaValue *v, SsaValue *w)
+{
+	int i;
+
+	if(v->op != w->op || typekey(v->type) != typekey(w->type))
+		return 0;
+	if(v->auxint != w->auxint || v->aux != w->aux || v->nargs != w->nargs)
+		return 0;
+	for(i=0; i<v->nargs; i++)
+		if(v->args[i] != w->args[i])
+			return 0;
+	return 1;
+}
+
+static void
+cseblock(SsaBlock *b)
+{
+	SsaValue *v;
+	SsaBlock *c;
+	CseEnt *e;
+	uint32 h;
+	int i, j;
+
+	for(i=0; i<b->nvalues; i++) {
+		v = b->values[i];
+		switch(v->op) {
+		case SOpInvalid:
+		case SOpPhi:
+		case SOpCopy:
+		case SOpInitMem:
+		case SOpStore:
+		case SOpStoreResult:
+			continue;
+		}
+		for(j=0; j<v->nargs; j++)
+			v->args[j] = copysource(v->args[j]);
+		h = csehash(v);
+		for(e=csetab[h]; e; e=e->link)
+			if(cseequal(v, e->v))
+				break;
+		if(e != nil) {
+			if(v->op == SOpNilCheck || v->op == SOpBoundsCheck) {
+				checkremoved(v);
+				v->op = SOpInvalid;
+			} else {
+				if(debug['m'] && iscomputation(v->op))
+					warnl(v->lineno, "common subexpression %s", opnames[v->op]);
+				tocopy(v, e->v);
+			}
+			continue;
+		}
+		e = mal(sizeof *e);
+		e->v = v;
+		e->link = csetab[h];
+		csetab[h] = e;
+		v->mark = h;
+	}
+	if(b->control != nil)
+		b->control = copysource(b->control);
+
+	for(c=b->dchild; c; c=c->dsibling)
+		cseblock(c);
+
+	// remove this block's entries, most recent first.
+	for(i=b->nvalues-1; i>=0; i--) {
+		v = b->values[i];
+		e = csetab[v->mark];
+		if(e != nil && e->v == v)
+			csetab[v->mark] = e->link;
+	}
+}
+
+static void
+cse(void)
+{
+	uint32 n;
+	int i, j;
+	SsaBlock *b;
+
+	n = 64;
+	while(n < f->nvalues)
+		n <<= 1;
+	csemask = n-1;
+	csetab = mal(n*sizeof csetab[0]);
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		for(j=0; j<b->nvalues; j++)
+			b->values[j]->mark = 0;
+	}
+	cseblock(f->entry);
+	csetab = nil;
+}
+
+/*
+ * removal of nil and bounds checks.
+ * walks the dominator tree collecting the outcomes
+ * of the branches that must have been taken to reach
+ * each block, and deletes the checks they imply.
+ */
+
+struct	Fact
+{
+	SsaValue*	c;
+	int	t;
+};
+
+static	Fact*	facts;
+static	int	nfact;
+
+static int
+negate(int op)
+{
+	switch(op) {
+	case SOpEq:	return SOpNe;
+	case SOpNe:	return SOpEq;
+	case SOpLt:	return SOpGe;
+	case SOpGe:	return SOpLt;
+	case SOpLe:	return SOpGt;
+	case SOpGt:	return SOpLe;
+	}
+	return SOpInvalid;
+}
+
+static int
+reverse(int op)
+{
+	switch(op) {
+	case SOpLt:	return SOpGt;
+	case SOpGt:	return SOpLt;
+	case SOpLe:	return SOpGe;
+	case SOpGe:	return SOpLe;
+	}
+	return op;
+}
+
+// unconv strips conversions that keep the bits of their argument,
+// such as uint(len(a)).
+static SsaValue*
+unconv(SsaValue *v)
+{
+	while(v->op == SOpConv && v->type->width == v->args[0]->type->width)
+		v = v->args[0];
+	return v;
+}
+
+static int
+same(SsaValue *a, SsaValue *b)
+{
+	a = unconv(a);
+	b = unconv(b);
+	if(a == b)
+		return 1;
+	return a->op == SOpConst && b->op == SOpConst && a->auxint == b->auxint;
+}
+
+// known reports whether a op b is implied by the facts,
+// where b == nil matches anything.
+static int
+known(int op, int uns, SsaValue *a, SsaValue *b)
+{
+	Fact *fa;
+	SsaValue *c;
+	int fop;
+
+	for(fa=facts; fa<facts+nfact; fa++) {
+		c = fa->c;
+		if(c->nargs != 2 || c->auxint != uns)
+			continue;
+		fop = c->op;
+		if(!fa->t)
+			fop = negate(fop);
+		if(fop == op && same(c->args[0], a) && (b == nil || same(c->args[1], b)))
+			return 1;
+		if(reverse(fop) == op && same(c->args[1], a) && (b == nil || same(c->args[0], b)))
+			return 1;
+	}
+	return 0;
+}
+
+// knownnonneg reports whether v >= 0 is implied
+// by a comparison of v with a constant.
+static int
+knownnonneg(SsaValue *v)
+{
+	Fact *fa;
+	SsaValue *c, *x, *k;
+	int fop;
+
+	for(fa=facts; fa<facts+nfact; fa++) {
+		c = fa->c;
+		if(c->nargs != 2 || c->auxint != 0)
+			continue;
+		fop = c->op;
+		if(!fa->t)
+			fop = negate(fop);
+		x = c->args[0];
+		k = c->args[1];
+		if(k == v) {
+			fop = reverse(fop);
+			k = x;
+			x = v;
+		}
+		if(x != v || k->op != SOpConst)
+			continue;
+		if((fop == SOpGe && k->auxint >= 0) || (fop == SOpGt && k->auxint >= -1))
+			return 1;
+	}
+	return 0;
+}
+
+static int
+nonneg(SsaValue *v)
+{
+	if(!issigned[simtype[v->type->etype]])
+		return 1;
+	switch(v->op) {
+	case SOpConst:
+		return v->auxint >= 0;
+	case SOpPhi:
+		// induction variable, see inductionvars
+		if(v->mark)
+			return 1;
+		break;
+	}
+	return knownnonneg(v);
+}
+
+static	SsaValue	zero = { SOpConst };
+
+static void
+proveblock(SsaBlock *b, int pass)
+{
+	SsaBlock *p, *c;
+	SsaValue *v, *i, *n;
+	int j, pushed;
+
+	pushed = 0;
+	if(b->npred == 1) {
+		p = b->pred[0];
+		if(p->kind == SBlockIf && p->succs[0] != p->succs[1]) {
+			facts[nfact].c = p->control;
+			facts[nfact].t = b == p->succs[0];
+			nfact++;
+			pushed = 1;
+		}
+	}
+	for(j=0; j<b->nvalues; j++) {
+		v = b->values[j];
+		switch(v->op) {
+		case SOpAdd:
+			// phi+1 cannot overflow if phi < x.
+			if(pass != 0)
+				break;
+			i = v->args[0];
+			n = v->args[1];
+			if(i->op == SOpConst) {
+				i = v->args[1];
+				n = v->args[0];
+			}
+			if(i->op == SOpPhi && n->op == SOpConst && n->auxint == 1 && known(SOpLt, 0, i, nil))
+				v->mark = 1;
+			break;
+
+		case SOpBoundsCheck:
+			if(pass != 1)
+				break;
+			i = v->args[0];
+			n = v->args[1];
+			if((i->op == SOpConst && n->op == SOpConst && i->auxint >= 0 && i->auxint < n->auxint)
+			|| known(SOpLt, 1, i, n)
+			|| (nonneg(i) && known(SOpLt, 0, i, n))) {
+				checkremoved(v);
+				v->op = SOpInvalid;
+			}
+			break;
+
+		case SOpNilCheck:
+			if(pass != 1)
+				break;
+			if(known(SOpNe, 1, v->args[0], &zero)) {
+				checkremoved(v);
+				v->op = SOpInvalid;
+			}
+			break;
+		}
+	}
+	for(c=b->dchild; c; c=c->dsibling)
+		proveblock(c, pass);
+	if(pushed)
+		nfact--;
+}
+
+// mark the phis that start at a non-negative
+// constant and are only incremented while less than
+// some other value.
+static void
+inductionvars(void)
+{
+	SsaBlock *b;
+	SsaValue *v, *a;
+	int i, j, k;
+
+	for(i=0; i<f->nblocks; i++) {
+		b = f->blocks[i];
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			if(v->op != SOpPhi || v->type == T || !isint[v->type->etype])
+				continue;
+			for(k=0; k<v->nargs; k++) {
+				a = v->args[k];
+				if(a->op == SOpConst && a->auxint >= 0)
+					continue;
+				if(a->op == SOpAdd && a->mark && (a->args[0] == v || a->args[1] == v))
+					continue;
+				break;
+			}
+			if(k == v->nargs)
+				v->mark = 2;
+		}
+	}
+	for(i=0; i<f->nvalues; i++) {
+		v = f->values[i];
+		if(v->op == SOpPhi)
+			v->mark = v->mark == 2;
+	}
+}
+
+static void
+prove(void)
+{
+	int i;
+
+	for(i=0; i<f->nvalues; i++)
+		f->values[i]->mark = 0;
+	facts = mal(f->nblocks*sizeof facts[0]);
+	nfact = 0;
+	proveblock(f->entry, 0);
+	inductionvars();
+	proveblock(f->entry, 1);
+	facts = nil;
+}
+
+static int
+hasphi(SsaBlock *b)
+{
+	int i;
+
+	for(i=0; i<b->nvalues; i++)
+		if(b->values[i]->op == SOpPhi && ssahasreg(b->values[i]))
+			return 1;
+	return 0;
+}
+
+// split the edges from blocks with two successors
+// to blocks with phis and several predecessors,
+// so that ssagen has a place for the phi moves.
+static void
+splitcritical(void)
+{
+	SsaBlock *b, *s, *nb, **order;
+	int i, j, k, n, nblocks;
+
+	nblocks = f->nblocks;
+	order = mal(3*nblocks*sizeof order[0]);
+	n = 0;
+	for(i=0; i<nblocks; i++) {
+		b = f->blocks[i];
+		order[n++] = b;
+		if(b->nsucc != 2)
+			continue;
+		for(j=0; j<2; j++) {
+			s = b->succs[j];
+			if(s->npred < 2 || !hasphi(s))
+				continue;
+			nb = newblock();
+			nb->succs[0] = s;
+			nb->nsucc = 1;
+			nb->pred = mal(sizeof nb->pred[0]);
+			nb->pred[0] = b;
+			nb->npred = 1;
+			nb->mpred = 1;
+			nb->idom = b;
+			b->succs[j] = nb;
+			for(k=0; k<s->npred; k++)
+				if(s->pred[k] == b) {
+					s->pred[k] = nb;
+					break;
+				}
+			order[n++] = nb;
+		}
+	}
+	memmove(f->blocks, order, n*sizeof order[0]);
+	f->nblocks = n;
+}
+
+void
+ssadump(SsaFunc *fn, char *pass)
+{
+	SsaBlock *b;
+	SsaValue *v;
+	int i, j, k;
+
+	print("--- %S %s\n", fn->fn->nname->sym, pass);
+	for(i=0; i<fn->nblocks; i++) {
+		b = fn->blocks[i];
+		print("b%d:", b->id);
+		if(b->npred > 0) {
+			print(" <-");
+			for(j=0; j<b->npred; j++)
+				print(" b%d", b->pred[j]->id);
+		}
+		print("\n");
+		for(j=0; j<b->nvalues; j++) {
+			v = b->values[j];
+			print("\tv%d = %s", v->id, opnames[v->op]);
+			if(v->type != T)
+				print(" <%T>", v->type);
+			if(v->auxint != 0 || v->op == SOpConst)
+				print(" [%lld]", v->auxint);
+			if(v->aux != N)
+				print(" {%S}", v->aux->sym);
+			for(k=0; k<v->nargs; k++)
+				print(" v%d", v->args[k]->id);
+			if(v->reg != 0)
+				print(" : %R", v->reg);
+			else if(v->slot != N)
+				print(" : %S", v->slot->sym);
+			print("\n");
+		}
+		switch(b->kind) {
+		case SBlockPlain:
+			print("\tplain -> b%d\n", b->succs[0]->id);
+			break;
+		case SBlockIf:
+			print("\tif v%d -> b%d b%d\n", b->control->id, b->succs[0]->id, b->succs[1]->id);
+			break;
+		case SBlockRet:
+			print("\tret v%d\n", b->control->id);
+			break;
+		case SBlockExit:
+			print("\texit\n");
+			break;
+		}
+	}
+}
+
+static int
+dumpfunc(Node *fn)
+{
+	static char *name;
+
+	if(name == nil) {
+		name = getenv("GOSSAFUNC");
+		if(name == nil)
+			name = "";
+	}
+	return fn->nname != N && strcmp(fn->nname->sym->name, name) == 0;
+}
+
+SsaFunc*
+ssabuild(Node *fn)
+{
+	int32 lno;
+	int i, dump;
+
+	if(fn->enter != nil || fn->exit != nil || hasdefer)
+		return nil;
+
+	lno = lineno;
+	f = mal(sizeof *f);
+	f->fn = fn;
+	nvar = 0;
+	newvar(N, 0, T);
+	targets = nil;
+	ssalabs = nil;
+	bad = N;
+
+	f->entry = newblock();
+	f->entry->sealed = 1;
+	cur = f->entry;
+	f->initmem = newvalue(cur, SOpInitMem, T, 0);
+	writevar(0, cur, f->initmem);
+	stmtlist(fn->nbody);
+	if(fn->endlineno)
+		lineno = fn->endlineno;
+	if(fn->type->outtuple != 0)
+		cur->kind = SBlockExit;
+	else {
+		cur->kind = SBlockRet;
+		cur->control = mem();
+	}
+	for(i=0; i<f->nblocks; i++)
+		seal(f->blocks[i]);
+	lineno = lno;
+
+	dump = dumpfunc(fn);
+	f->dump = dump;
+	if(bad != N) {
+		if(dump)
+			print("--- %S not converted: %O at %L\n", fn->nname->sym, bad->op, bad->lineno);
+		if(debug['m'] > 1)
+			warnl(bad->lineno, "cannot compile %S with SSA: unhandled op %O", fn->nname->sym, bad->op);
+		return nil;
+	}
+	if(debug['m'])
+		warnl(fn->lineno, "compiling %S with SSA", fn->nname->sym);
+	if(dump)
+		ssadump(f, "build");
+
+	copyelim();
+	deadblocks();
+	copyelim();
+	deadvalues();
+	dominators();
+	cse();
+	copyelim();
+	deadvalues();
+	prove();
+	deadvalues();
+	splitcritical();
+
+	if(dump)
+		ssadump(f, "opt");
+	return f;
+}
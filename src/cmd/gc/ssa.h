@@ -0,0 +1,136 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+ * static single assignment form.
+ * built by ssa.c, register allocated
+ * and compiled by the arch-specific ssagen.c.
+ */
+
+typedef	struct	SsaBlock	SsaBlock;
+typedef	struct	SsaValue	SsaValue;
+
+enum
+{
+	SOpInvalid,
+
+	SOpInitMem,	// memory at function entry
+	SOpPhi,
+	SOpCopy,	// args[0]
+	SOpConst,	// auxint
+	SOpParam,	// word at offset auxint of incoming argument aux
+	SOpAddr,	// address of global aux
+
+	SOpAdd,
+	SOpSub,
+	SOpMul,
+	SOpAnd,
+	SOpOr,
+	SOpXor,
+	SOpLsh,		// args[0] << auxint
+	SOpRsh,		// args[0] >> auxint, arithmetic if type is signed
+	SOpNeg,
+	SOpCom,
+	SOpNot,
+	SOpConv,	// args[0] converted to type
+
+	SOpEq,		// comparisons of args[0] and args[1].
+	SOpNe,		// auxint is 1 if the comparison is unsigned.
+	SOpLt,
+	SOpLe,
+	SOpGt,
+	SOpGe,
+
+	SOpPtrIndex,	// args[0] + args[1]*auxint
+	SOpLoad,	// *(args[0]+auxint) in memory args[1]
+	SOpStore,	// *(args[0]+auxint) = args[1] in memory args[2]; type is stored type
+	SOpStoreResult,	// result aux word auxint = args[0] in memory args[1]
+	SOpNilCheck,	// panic if args[0] is nil
+	SOpBoundsCheck,	// panic unless 0 <= args[0] < args[1]
+
+	SOpLast
+};
+
+enum
+{
+	SBlockPlain,	// goto succs[0]
+	SBlockIf,	// if control goto succs[0] else succs[1]
+	SBlockRet,	// return; control is final memory
+	SBlockExit,	// fell off the end of a function with results
+};
+
+struct	SsaValue
+{
+	int	op;
+	int	id;
+	Type*	type;		// T for memory and checks
+	vlong	auxint;
+	Node*	aux;
+	SsaValue**	args;
+	int	nargs;
+	int	margs;
+	SsaBlock*	block;
+	int32	lineno;
+	int	uses;
+
+	// for ssagen.c
+	int	reg;		// register, or 0
+	Node*	slot;		// spill slot, or nil
+	int	start;		// live range
+	int	end;
+	int	mark;
+};
+
+struct	SsaBlock
+{
+	int	kind;
+	int	id;
+	SsaValue*	control;
+	SsaValue**	values;
+	int	nvalues;
+	int	mvalues;
+	SsaBlock**	pred;
+	int	npred;
+	int	mpred;
+	SsaBlock*	succs[2];
+	int	nsucc;
+
+	int	sealed;
+	SsaValue**	defs;
+	int	ndefs;
+	void*	incomplete;
+
+	SsaBlock*	idom;
+	SsaBlock*	dchild;	// dominator tree
+	SsaBlock*	dsibling;
+	int	rpo;
+	int	mark;
+
+	// for ssagen.c
+	int	start;
+	int	end;
+	uint32*	livein;
+	uint32*	liveout;
+	Prog*	pc;
+	Prog*	jmp[2];
+};
+
+struct	SsaFunc
+{
+	Node*	fn;
+	SsaBlock*	entry;
+	SsaBlock**	blocks;		// in layout order
+	int	nblocks;
+	int	mblocks;
+	SsaValue**	values;		// by id
+	int	nvalues;
+	int	mvalues;
+	SsaValue*	initmem;
+	int	dump;		// print the function at each stage
+};
+
+char*	ssaopname(int op);
+int	ssaismem(SsaValue *v);
+int	ssahasreg(SsaValue *v);
+void	ssadump(SsaFunc *f, char *pass);
@@ -1133,6 +1133,22 @@ walkexpr(Node **np, NodeList **init)
 		if(r == nil)
 			l = r = safeexpr(l, init);
 		t = n->type;
+		if(n->esc == EscNone && smallintconst(l) && smallintconst(r) &&
+		   mpgetfix(l->val.u.xval) >= 0 && mpcmpfixfix(l->val.u.xval, r->val.u.xval) <= 0 &&
+		   (t->type->width == 0 || mpgetfix(r->val.u.xval) < (1<<16) / t->type->width)) {
+			// var arr [r]T
+			// n = arr[:l]
+			var = temp(aindex(r, t->type));
+			a = nod(OAS, var, N);  // zero temp
+			typecheck(&a, Etop);
+			*init = list(*init, a);
+			r = nod(OSLICE, var, nod(OKEY, N, l));
+			typecheck(&r, Erv);
+			r = conv(r, n->type);  // in case n->type is named
+			walkexpr(&r, init);
+			n = r;
+			goto ret;
+		}
 		fn = syslook("makeslice", 1);
 		argtype(fn, t->type);			// any-1
 		n = mkcall1(fn, n->type, init,
@@ -4098,8 +4098,10 @@ yyreduce:
 
 		importsym(s, ONAME);
 		if(s->def != N && s->def->op == ONAME) {
-			if(eqtype(t, s->def->type))
+			if(eqtype(t, s->def->type)) {
+				dclcontext = PDISCARD;  // since we skip funchdr below
 				break;
+			}
 			yyerror("inconsistent definition for func %S during import\n\t%T\n\t%T", s, s->def->type, t);
 		}
 
@@ -4114,7 +4116,7 @@ yyreduce:
   case 201:
 
 /* Line 1806 of yacc.c  */
-#line 1302 "go.y"
+#line 1304 "go.y"
     {
 		(yyval.node) = methodname1(newname((yyvsp[(4) - (8)].sym)), (yyvsp[(2) - (8)].list)->n->right); 
 		(yyval.node)->type = functype((yyvsp[(2) - (8)].list)->n, (yyvsp[(6) - (8)].list), (yyvsp[(8) - (8)].list));
@@ -4134,7 +4136,7 @@ yyreduce:
   case 202:
 
 /* Line 1806 of yacc.c  */
-#line 1319 "go.y"
+#line 1321 "go.y"
     {
 		(yyvsp[(3) - (5)].list) = checkarglist((yyvsp[(3) - (5)].list), 1);
 		(yyval.node) = nod(OTFUNC, N, N);
@@ -4146,7 +4148,7 @@ yyreduce:
   case 203:
 
 /* Line 1806 of yacc.c  */
-#line 1327 "go.y"
+#line 1329 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4155,7 +4157,7 @@ yyreduce:
   case 204:
 
 /* Line 1806 of yacc.c  */
-#line 1331 "go.y"
+#line 1333 "go.y"
     {
 		(yyval.list) = (yyvsp[(2) - (3)].list);
 		if((yyval.list) == nil)
@@ -4166,7 +4168,7 @@ yyreduce:
   case 205:
 
 /* Line 1806 of yacc.c  */
-#line 1339 "go.y"
+#line 1341 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4175,7 +4177,7 @@ yyreduce:
   case 206:
 
 /* Line 1806 of yacc.c  */
-#line 1343 "go.y"
+#line 1345 "go.y"
     {
 		(yyval.list) = list1(nod(ODCLFIELD, N, (yyvsp[(1) - (1)].node)));
 	}
@@ -4184,7 +4186,7 @@ yyreduce:
   case 207:
 
 /* Line 1806 of yacc.c  */
-#line 1347 "go.y"
+#line 1349 "go.y"
     {
 		(yyvsp[(2) - (3)].list) = checkarglist((yyvsp[(2) - (3)].list), 0);
 		(yyval.list) = (yyvsp[(2) - (3)].list);
@@ -4194,7 +4196,7 @@ yyreduce:
   case 208:
 
 /* Line 1806 of yacc.c  */
-#line 1354 "go.y"
+#line 1356 "go.y"
     {
 		closurehdr((yyvsp[(1) - (1)].node));
 	}
@@ -4203,7 +4205,7 @@ yyreduce:
   case 209:
 
 /* Line 1806 of yacc.c  */
-#line 1360 "go.y"
+#line 1362 "go.y"
     {
 		(yyval.node) = closurebody((yyvsp[(3) - (4)].list));
 		fixlbrace((yyvsp[(2) - (4)].i));
@@ -4213,7 +4215,7 @@ yyreduce:
   case 210:
 
 /* Line 1806 of yacc.c  */
-#line 1365 "go.y"
+#line 1367 "go.y"
     {
 		(yyval.node) = closurebody(nil);
 	}
@@ -4222,7 +4224,7 @@ yyreduce:
   case 211:
 
 /* Line 1806 of yacc.c  */
-#line 1376 "go.y"
+#line 1378 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4231,7 +4233,7 @@ yyreduce:
   case 212:
 
 /* Line 1806 of yacc.c  */
-#line 1380 "go.y"
+#line 1382 "go.y"
     {
 		(yyval.list) = concat((yyvsp[(1) - (3)].list), (yyvsp[(2) - (3)].list));
 		if(nsyntaxerrors == 0)
@@ -4242,7 +4244,7 @@ yyreduce:
   case 214:
 
 /* Line 1806 of yacc.c  */
-#line 1389 "go.y"
+#line 1391 "go.y"
     {
 		(yyval.list) = concat((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].list));
 	}
@@ -4251,7 +4253,7 @@ yyreduce:
   case 216:
 
 /* Line 1806 of yacc.c  */
-#line 1396 "go.y"
+#line 1398 "go.y"
     {
 		(yyval.list) = concat((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].list));
 	}
@@ -4260,7 +4262,7 @@ yyreduce:
   case 217:
 
 /* Line 1806 of yacc.c  */
-#line 1402 "go.y"
+#line 1404 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4269,7 +4271,7 @@ yyreduce:
   case 218:
 
 /* Line 1806 of yacc.c  */
-#line 1406 "go.y"
+#line 1408 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4278,7 +4280,7 @@ yyreduce:
   case 220:
 
 /* Line 1806 of yacc.c  */
-#line 1413 "go.y"
+#line 1415 "go.y"
     {
 		(yyval.list) = concat((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].list));
 	}
@@ -4287,7 +4289,7 @@ yyreduce:
   case 221:
 
 /* Line 1806 of yacc.c  */
-#line 1419 "go.y"
+#line 1421 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4296,7 +4298,7 @@ yyreduce:
   case 222:
 
 /* Line 1806 of yacc.c  */
-#line 1423 "go.y"
+#line 1425 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4305,7 +4307,7 @@ yyreduce:
   case 223:
 
 /* Line 1806 of yacc.c  */
-#line 1429 "go.y"
+#line 1431 "go.y"
     {
 		NodeList *l;
 
@@ -4333,7 +4335,7 @@ yyreduce:
   case 224:
 
 /* Line 1806 of yacc.c  */
-#line 1452 "go.y"
+#line 1454 "go.y"
     {
 		(yyvsp[(1) - (2)].node)->val = (yyvsp[(2) - (2)].val);
 		(yyval.list) = list1((yyvsp[(1) - (2)].node));
@@ -4343,7 +4345,7 @@ yyreduce:
   case 225:
 
 /* Line 1806 of yacc.c  */
-#line 1457 "go.y"
+#line 1459 "go.y"
     {
 		(yyvsp[(2) - (4)].node)->val = (yyvsp[(4) - (4)].val);
 		(yyval.list) = list1((yyvsp[(2) - (4)].node));
@@ -4354,7 +4356,7 @@ yyreduce:
   case 226:
 
 /* Line 1806 of yacc.c  */
-#line 1463 "go.y"
+#line 1465 "go.y"
     {
 		(yyvsp[(2) - (3)].node)->right = nod(OIND, (yyvsp[(2) - (3)].node)->right, N);
 		(yyvsp[(2) - (3)].node)->val = (yyvsp[(3) - (3)].val);
@@ -4365,7 +4367,7 @@ yyreduce:
   case 227:
 
 /* Line 1806 of yacc.c  */
-#line 1469 "go.y"
+#line 1471 "go.y"
     {
 		(yyvsp[(3) - (5)].node)->right = nod(OIND, (yyvsp[(3) - (5)].node)->right, N);
 		(yyvsp[(3) - (5)].node)->val = (yyvsp[(5) - (5)].val);
@@ -4377,7 +4379,7 @@ yyreduce:
   case 228:
 
 /* Line 1806 of yacc.c  */
-#line 1476 "go.y"
+#line 1478 "go.y"
     {
 		(yyvsp[(3) - (5)].node)->right = nod(OIND, (yyvsp[(3) - (5)].node)->right, N);
 		(yyvsp[(3) - (5)].node)->val = (yyvsp[(5) - (5)].val);
@@ -4389,7 +4391,7 @@ yyreduce:
   case 229:
 
 /* Line 1806 of yacc.c  */
-#line 1485 "go.y"
+#line 1487 "go.y"
     {
 		Node *n;
 
@@ -4403,7 +4405,7 @@ yyreduce:
   case 230:
 
 /* Line 1806 of yacc.c  */
-#line 1494 "go.y"
+#line 1496 "go.y"
     {
 		Pkg *pkg;
 
@@ -4421,7 +4423,7 @@ yyreduce:
   case 231:
 
 /* Line 1806 of yacc.c  */
-#line 1509 "go.y"
+#line 1511 "go.y"
     {
 		(yyval.node) = embedded((yyvsp[(1) - (1)].sym));
 	}
@@ -4430,7 +4432,7 @@ yyreduce:
   case 232:
 
 /* Line 1806 of yacc.c  */
-#line 1515 "go.y"
+#line 1517 "go.y"
     {
 		(yyval.node) = nod(ODCLFIELD, (yyvsp[(1) - (2)].node), (yyvsp[(2) - (2)].node));
 		ifacedcl((yyval.node));
@@ -4440,7 +4442,7 @@ yyreduce:
   case 233:
 
 /* Line 1806 of yacc.c  */
-#line 1520 "go.y"
+#line 1522 "go.y"
     {
 		(yyval.node) = nod(ODCLFIELD, N, oldname((yyvsp[(1) - (1)].sym)));
 	}
@@ -4449,7 +4451,7 @@ yyreduce:
   case 234:
 
 /* Line 1806 of yacc.c  */
-#line 1524 "go.y"
+#line 1526 "go.y"
     {
 		(yyval.node) = nod(ODCLFIELD, N, oldname((yyvsp[(2) - (3)].sym)));
 		yyerror("cannot parenthesize embedded type");
@@ -4459,7 +4461,7 @@ yyreduce:
   case 235:
 
 /* Line 1806 of yacc.c  */
-#line 1531 "go.y"
+#line 1533 "go.y"
     {
 		// without func keyword
 		(yyvsp[(2) - (4)].list) = checkarglist((yyvsp[(2) - (4)].list), 1);
@@ -4472,7 +4474,7 @@ yyreduce:
   case 237:
 
 /* Line 1806 of yacc.c  */
-#line 1545 "go.y"
+#line 1547 "go.y"
     {
 		(yyval.node) = nod(ONONAME, N, N);
 		(yyval.node)->sym = (yyvsp[(1) - (2)].sym);
@@ -4483,7 +4485,7 @@ yyreduce:
   case 238:
 
 /* Line 1806 of yacc.c  */
-#line 1551 "go.y"
+#line 1553 "go.y"
     {
 		(yyval.node) = nod(ONONAME, N, N);
 		(yyval.node)->sym = (yyvsp[(1) - (2)].sym);
@@ -4494,7 +4496,7 @@ yyreduce:
   case 240:
 
 /* Line 1806 of yacc.c  */
-#line 1560 "go.y"
+#line 1562 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4503,7 +4505,7 @@ yyreduce:
   case 241:
 
 /* Line 1806 of yacc.c  */
-#line 1564 "go.y"
+#line 1566 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4512,7 +4514,7 @@ yyreduce:
   case 242:
 
 /* Line 1806 of yacc.c  */
-#line 1569 "go.y"
+#line 1571 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4521,7 +4523,7 @@ yyreduce:
   case 243:
 
 /* Line 1806 of yacc.c  */
-#line 1573 "go.y"
+#line 1575 "go.y"
     {
 		(yyval.list) = (yyvsp[(1) - (2)].list);
 	}
@@ -4530,7 +4532,7 @@ yyreduce:
   case 244:
 
 /* Line 1806 of yacc.c  */
-#line 1581 "go.y"
+#line 1583 "go.y"
     {
 		(yyval.node) = N;
 	}
@@ -4539,7 +4541,7 @@ yyreduce:
   case 246:
 
 /* Line 1806 of yacc.c  */
-#line 1586 "go.y"
+#line 1588 "go.y"
     {
 		(yyval.node) = liststmt((yyvsp[(1) - (1)].list));
 	}
@@ -4548,7 +4550,7 @@ yyreduce:
   case 248:
 
 /* Line 1806 of yacc.c  */
-#line 1591 "go.y"
+#line 1593 "go.y"
     {
 		(yyval.node) = N;
 	}
@@ -4557,7 +4559,7 @@ yyreduce:
   case 254:
 
 /* Line 1806 of yacc.c  */
-#line 1602 "go.y"
+#line 1604 "go.y"
     {
 		(yyvsp[(1) - (2)].node) = nod(OLABEL, (yyvsp[(1) - (2)].node), N);
 		(yyvsp[(1) - (2)].node)->sym = dclstack;  // context, for goto restrictions
@@ -4567,7 +4569,7 @@ yyreduce:
   case 255:
 
 /* Line 1806 of yacc.c  */
-#line 1607 "go.y"
+#line 1609 "go.y"
     {
 		NodeList *l;
 
@@ -4582,7 +4584,7 @@ yyreduce:
   case 256:
 
 /* Line 1806 of yacc.c  */
-#line 1617 "go.y"
+#line 1619 "go.y"
     {
 		// will be converted to OFALL
 		(yyval.node) = nod(OXFALL, N, N);
@@ -4592,7 +4594,7 @@ yyreduce:
   case 257:
 
 /* Line 1806 of yacc.c  */
-#line 1622 "go.y"
+#line 1624 "go.y"
     {
 		(yyval.node) = nod(OBREAK, (yyvsp[(2) - (2)].node), N);
 	}
@@ -4601,7 +4603,7 @@ yyreduce:
   case 258:
 
 /* Line 1806 of yacc.c  */
-#line 1626 "go.y"
+#line 1628 "go.y"
     {
 		(yyval.node) = nod(OCONTINUE, (yyvsp[(2) - (2)].node), N);
 	}
@@ -4610,7 +4612,7 @@ yyreduce:
   case 259:
 
 /* Line 1806 of yacc.c  */
-#line 1630 "go.y"
+#line 1632 "go.y"
     {
 		(yyval.node) = nod(OPROC, (yyvsp[(2) - (2)].node), N);
 	}
@@ -4619,7 +4621,7 @@ yyreduce:
   case 260:
 
 /* Line 1806 of yacc.c  */
-#line 1634 "go.y"
+#line 1636 "go.y"
     {
 		(yyval.node) = nod(ODEFER, (yyvsp[(2) - (2)].node), N);
 	}
@@ -4628,7 +4630,7 @@ yyreduce:
   case 261:
 
 /* Line 1806 of yacc.c  */
-#line 1638 "go.y"
+#line 1640 "go.y"
     {
 		(yyval.node) = nod(OGOTO, (yyvsp[(2) - (2)].node), N);
 		(yyval.node)->sym = dclstack;  // context, for goto restrictions
@@ -4638,7 +4640,7 @@ yyreduce:
   case 262:
 
 /* Line 1806 of yacc.c  */
-#line 1643 "go.y"
+#line 1645 "go.y"
     {
 		(yyval.node) = nod(ORETURN, N, N);
 		(yyval.node)->list = (yyvsp[(2) - (2)].list);
@@ -4660,7 +4662,7 @@ yyreduce:
   case 263:
 
 /* Line 1806 of yacc.c  */
-#line 1662 "go.y"
+#line 1664 "go.y"
     {
 		(yyval.list) = nil;
 		if((yyvsp[(1) - (1)].node) != N)
@@ -4671,7 +4673,7 @@ yyreduce:
   case 264:
 
 /* Line 1806 of yacc.c  */
-#line 1668 "go.y"
+#line 1670 "go.y"
     {
 		(yyval.list) = (yyvsp[(1) - (3)].list);
 		if((yyvsp[(3) - (3)].node) != N)
@@ -4682,7 +4684,7 @@ yyreduce:
   case 265:
 
 /* Line 1806 of yacc.c  */
-#line 1676 "go.y"
+#line 1678 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4691,7 +4693,7 @@ yyreduce:
   case 266:
 
 /* Line 1806 of yacc.c  */
-#line 1680 "go.y"
+#line 1682 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4700,7 +4702,7 @@ yyreduce:
   case 267:
 
 /* Line 1806 of yacc.c  */
-#line 1686 "go.y"
+#line 1688 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4709,7 +4711,7 @@ yyreduce:
   case 268:
 
 /* Line 1806 of yacc.c  */
-#line 1690 "go.y"
+#line 1692 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4718,7 +4720,7 @@ yyreduce:
   case 269:
 
 /* Line 1806 of yacc.c  */
-#line 1696 "go.y"
+#line 1698 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4727,7 +4729,7 @@ yyreduce:
   case 270:
 
 /* Line 1806 of yacc.c  */
-#line 1700 "go.y"
+#line 1702 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4736,7 +4738,7 @@ yyreduce:
   case 271:
 
 /* Line 1806 of yacc.c  */
-#line 1706 "go.y"
+#line 1708 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4745,7 +4747,7 @@ yyreduce:
   case 272:
 
 /* Line 1806 of yacc.c  */
-#line 1710 "go.y"
+#line 1712 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4754,7 +4756,7 @@ yyreduce:
   case 273:
 
 /* Line 1806 of yacc.c  */
-#line 1719 "go.y"
+#line 1721 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4763,7 +4765,7 @@ yyreduce:
   case 274:
 
 /* Line 1806 of yacc.c  */
-#line 1723 "go.y"
+#line 1725 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -4772,7 +4774,7 @@ yyreduce:
   case 275:
 
 /* Line 1806 of yacc.c  */
-#line 1727 "go.y"
+#line 1729 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4781,7 +4783,7 @@ yyreduce:
   case 276:
 
 /* Line 1806 of yacc.c  */
-#line 1731 "go.y"
+#line 1733 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -4790,7 +4792,7 @@ yyreduce:
   case 277:
 
 /* Line 1806 of yacc.c  */
-#line 1736 "go.y"
+#line 1738 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4799,7 +4801,7 @@ yyreduce:
   case 278:
 
 /* Line 1806 of yacc.c  */
-#line 1740 "go.y"
+#line 1742 "go.y"
     {
 		(yyval.list) = (yyvsp[(1) - (2)].list);
 	}
@@ -4808,7 +4810,7 @@ yyreduce:
   case 283:
 
 /* Line 1806 of yacc.c  */
-#line 1754 "go.y"
+#line 1756 "go.y"
     {
 		(yyval.node) = N;
 	}
@@ -4817,7 +4819,7 @@ yyreduce:
   case 285:
 
 /* Line 1806 of yacc.c  */
-#line 1760 "go.y"
+#line 1762 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4826,7 +4828,7 @@ yyreduce:
   case 287:
 
 /* Line 1806 of yacc.c  */
-#line 1766 "go.y"
+#line 1768 "go.y"
     {
 		(yyval.node) = N;
 	}
@@ -4835,7 +4837,7 @@ yyreduce:
   case 289:
 
 /* Line 1806 of yacc.c  */
-#line 1772 "go.y"
+#line 1774 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4844,7 +4846,7 @@ yyreduce:
   case 291:
 
 /* Line 1806 of yacc.c  */
-#line 1778 "go.y"
+#line 1780 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4853,7 +4855,7 @@ yyreduce:
   case 293:
 
 /* Line 1806 of yacc.c  */
-#line 1784 "go.y"
+#line 1786 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -4862,7 +4864,7 @@ yyreduce:
   case 295:
 
 /* Line 1806 of yacc.c  */
-#line 1790 "go.y"
+#line 1792 "go.y"
     {
 		(yyval.val).ctype = CTxxx;
 	}
@@ -4871,7 +4873,7 @@ yyreduce:
   case 297:
 
 /* Line 1806 of yacc.c  */
-#line 1800 "go.y"
+#line 1802 "go.y"
     {
 		importimport((yyvsp[(2) - (4)].sym), (yyvsp[(3) - (4)].val).u.sval);
 	}
@@ -4880,7 +4882,7 @@ yyreduce:
   case 298:
 
 /* Line 1806 of yacc.c  */
-#line 1804 "go.y"
+#line 1806 "go.y"
     {
 		importvar((yyvsp[(2) - (4)].sym), (yyvsp[(3) - (4)].type));
 	}
@@ -4889,7 +4891,7 @@ yyreduce:
   case 299:
 
 /* Line 1806 of yacc.c  */
-#line 1808 "go.y"
+#line 1810 "go.y"
     {
 		importconst((yyvsp[(2) - (5)].sym), types[TIDEAL], (yyvsp[(4) - (5)].node));
 	}
@@ -4898,7 +4900,7 @@ yyreduce:
   case 300:
 
 /* Line 1806 of yacc.c  */
-#line 1812 "go.y"
+#line 1814 "go.y"
     {
 		importconst((yyvsp[(2) - (6)].sym), (yyvsp[(3) - (6)].type), (yyvsp[(5) - (6)].node));
 	}
@@ -4907,7 +4909,7 @@ yyreduce:
   case 301:
 
 /* Line 1806 of yacc.c  */
-#line 1816 "go.y"
+#line 1818 "go.y"
     {
 		importtype((yyvsp[(2) - (4)].type), (yyvsp[(3) - (4)].type));
 	}
@@ -4916,10 +4918,12 @@ yyreduce:
   case 302:
 
 /* Line 1806 of yacc.c  */
-#line 1820 "go.y"
+#line 1822 "go.y"
     {
-		if((yyvsp[(2) - (4)].node) == N)
+		if((yyvsp[(2) - (4)].node) == N) {
+			dclcontext = PEXTERN;  // since we skip the funcbody below
 			break;
+		}
 
 		(yyvsp[(2) - (4)].node)->inl = (yyvsp[(3) - (4)].list);
 
@@ -4937,7 +4941,7 @@ yyreduce:
   case 303:
 
 /* Line 1806 of yacc.c  */
-#line 1838 "go.y"
+#line 1842 "go.y"
     {
 		(yyval.sym) = (yyvsp[(1) - (1)].sym);
 		structpkg = (yyval.sym)->pkg;
@@ -4947,7 +4951,7 @@ yyreduce:
   case 304:
 
 /* Line 1806 of yacc.c  */
-#line 1845 "go.y"
+#line 1849 "go.y"
     {
 		(yyval.type) = pkgtype((yyvsp[(1) - (1)].sym));
 		importsym((yyvsp[(1) - (1)].sym), OTYPE);
@@ -4957,7 +4961,7 @@ yyreduce:
   case 310:
 
 /* Line 1806 of yacc.c  */
-#line 1865 "go.y"
+#line 1869 "go.y"
     {
 		(yyval.type) = pkgtype((yyvsp[(1) - (1)].sym));
 	}
@@ -4966,7 +4970,7 @@ yyreduce:
   case 311:
 
 /* Line 1806 of yacc.c  */
-#line 1869 "go.y"
+#line 1873 "go.y"
     {
 		// predefined name like uint8
 		(yyvsp[(1) - (1)].sym) = pkglookup((yyvsp[(1) - (1)].sym)->name, builtinpkg);
@@ -4981,7 +4985,7 @@ yyreduce:
   case 312:
 
 /* Line 1806 of yacc.c  */
-#line 1879 "go.y"
+#line 1883 "go.y"
     {
 		(yyval.type) = aindex(N, (yyvsp[(3) - (3)].type));
 	}
@@ -4990,7 +4994,7 @@ yyreduce:
   case 313:
 
 /* Line 1806 of yacc.c  */
-#line 1883 "go.y"
+#line 1887 "go.y"
     {
 		(yyval.type) = aindex(nodlit((yyvsp[(2) - (4)].val)), (yyvsp[(4) - (4)].type));
 	}
@@ -4999,7 +5003,7 @@ yyreduce:
   case 314:
 
 /* Line 1806 of yacc.c  */
-#line 1887 "go.y"
+#line 1891 "go.y"
     {
 		(yyval.type) = maptype((yyvsp[(3) - (5)].type), (yyvsp[(5) - (5)].type));
 	}
@@ -5008,7 +5012,7 @@ yyreduce:
   case 315:
 
 /* Line 1806 of yacc.c  */
-#line 1891 "go.y"
+#line 1895 "go.y"
     {
 		(yyval.type) = tostruct((yyvsp[(3) - (4)].list));
 	}
@@ -5017,7 +5021,7 @@ yyreduce:
   case 316:
 
 /* Line 1806 of yacc.c  */
-#line 1895 "go.y"
+#line 1899 "go.y"
     {
 		(yyval.type) = tointerface((yyvsp[(3) - (4)].list));
 	}
@@ -5026,7 +5030,7 @@ yyreduce:
   case 317:
 
 /* Line 1806 of yacc.c  */
-#line 1899 "go.y"
+#line 1903 "go.y"
     {
 		(yyval.type) = ptrto((yyvsp[(2) - (2)].type));
 	}
@@ -5035,7 +5039,7 @@ yyreduce:
   case 318:
 
 /* Line 1806 of yacc.c  */
-#line 1903 "go.y"
+#line 1907 "go.y"
     {
 		(yyval.type) = typ(TCHAN);
 		(yyval.type)->type = (yyvsp[(2) - (2)].type);
@@ -5046,7 +5050,7 @@ yyreduce:
   case 319:
 
 /* Line 1806 of yacc.c  */
-#line 1909 "go.y"
+#line 1913 "go.y"
     {
 		(yyval.type) = typ(TCHAN);
 		(yyval.type)->type = (yyvsp[(3) - (4)].type);
@@ -5057,7 +5061,7 @@ yyreduce:
   case 320:
 
 /* Line 1806 of yacc.c  */
-#line 1915 "go.y"
+#line 1919 "go.y"
     {
 		(yyval.type) = typ(TCHAN);
 		(yyval.type)->type = (yyvsp[(3) - (3)].type);
@@ -5068,7 +5072,7 @@ yyreduce:
   case 321:
 
 /* Line 1806 of yacc.c  */
-#line 1923 "go.y"
+#line 1927 "go.y"
     {
 		(yyval.type) = typ(TCHAN);
 		(yyval.type)->type = (yyvsp[(3) - (3)].type);
@@ -5079,7 +5083,7 @@ yyreduce:
   case 322:
 
 /* Line 1806 of yacc.c  */
-#line 1931 "go.y"
+#line 1935 "go.y"
     {
 		(yyval.type) = functype(nil, (yyvsp[(3) - (5)].list), (yyvsp[(5) - (5)].list));
 	}
@@ -5088,7 +5092,7 @@ yyreduce:
   case 323:
 
 /* Line 1806 of yacc.c  */
-#line 1937 "go.y"
+#line 1941 "go.y"
     {
 		(yyval.node) = nod(ODCLFIELD, N, typenod((yyvsp[(2) - (3)].type)));
 		if((yyvsp[(1) - (3)].sym))
@@ -5100,7 +5104,7 @@ yyreduce:
   case 324:
 
 /* Line 1806 of yacc.c  */
-#line 1944 "go.y"
+#line 1948 "go.y"
     {
 		Type *t;
 	
@@ -5119,7 +5123,7 @@ yyreduce:
   case 325:
 
 /* Line 1806 of yacc.c  */
-#line 1960 "go.y"
+#line 1964 "go.y"
     {
 		Sym *s;
 
@@ -5140,7 +5144,7 @@ yyreduce:
   case 326:
 
 /* Line 1806 of yacc.c  */
-#line 1978 "go.y"
+#line 1982 "go.y"
     {
 		(yyval.node) = nod(ODCLFIELD, newname((yyvsp[(1) - (5)].sym)), typenod(functype(fakethis(), (yyvsp[(3) - (5)].list), (yyvsp[(5) - (5)].list))));
 	}
@@ -5149,7 +5153,7 @@ yyreduce:
   case 327:
 
 /* Line 1806 of yacc.c  */
-#line 1982 "go.y"
+#line 1986 "go.y"
     {
 		(yyval.node) = nod(ODCLFIELD, N, typenod((yyvsp[(1) - (1)].type)));
 	}
@@ -5158,7 +5162,7 @@ yyreduce:
   case 328:
 
 /* Line 1806 of yacc.c  */
-#line 1987 "go.y"
+#line 1991 "go.y"
     {
 		(yyval.list) = nil;
 	}
@@ -5167,7 +5171,7 @@ yyreduce:
   case 330:
 
 /* Line 1806 of yacc.c  */
-#line 1994 "go.y"
+#line 1998 "go.y"
     {
 		(yyval.list) = (yyvsp[(2) - (3)].list);
 	}
@@ -5176,7 +5180,7 @@ yyreduce:
   case 331:
 
 /* Line 1806 of yacc.c  */
-#line 1998 "go.y"
+#line 2002 "go.y"
     {
 		(yyval.list) = list1(nod(ODCLFIELD, N, typenod((yyvsp[(1) - (1)].type))));
 	}
@@ -5185,7 +5189,7 @@ yyreduce:
   case 332:
 
 /* Line 1806 of yacc.c  */
-#line 2008 "go.y"
+#line 2012 "go.y"
     {
 		(yyval.node) = nodlit((yyvsp[(1) - (1)].val));
 	}
@@ -5194,7 +5198,7 @@ yyreduce:
   case 333:
 
 /* Line 1806 of yacc.c  */
-#line 2012 "go.y"
+#line 2016 "go.y"
     {
 		(yyval.node) = nodlit((yyvsp[(2) - (2)].val));
 		switch((yyval.node)->val.ctype){
@@ -5214,7 +5218,7 @@ yyreduce:
   case 334:
 
 /* Line 1806 of yacc.c  */
-#line 2027 "go.y"
+#line 2031 "go.y"
     {
 		(yyval.node) = oldname(pkglookup((yyvsp[(1) - (1)].sym)->name, builtinpkg));
 		if((yyval.node)->op != OLITERAL)
@@ -5225,7 +5229,7 @@ yyreduce:
   case 336:
 
 /* Line 1806 of yacc.c  */
-#line 2036 "go.y"
+#line 2040 "go.y"
     {
 		if((yyvsp[(2) - (5)].node)->val.ctype == CTRUNE && (yyvsp[(4) - (5)].node)->val.ctype == CTINT) {
 			(yyval.node) = (yyvsp[(2) - (5)].node);
@@ -5239,7 +5243,7 @@ yyreduce:
   case 339:
 
 /* Line 1806 of yacc.c  */
-#line 2050 "go.y"
+#line 2054 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -5248,7 +5252,7 @@ yyreduce:
   case 340:
 
 /* Line 1806 of yacc.c  */
-#line 2054 "go.y"
+#line 2058 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -5257,7 +5261,7 @@ yyreduce:
   case 341:
 
 /* Line 1806 of yacc.c  */
-#line 2060 "go.y"
+#line 2064 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -5266,7 +5270,7 @@ yyreduce:
   case 342:
 
 /* Line 1806 of yacc.c  */
-#line 2064 "go.y"
+#line 2068 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -5275,7 +5279,7 @@ yyreduce:
   case 343:
 
 /* Line 1806 of yacc.c  */
-#line 2070 "go.y"
+#line 2074 "go.y"
     {
 		(yyval.list) = list1((yyvsp[(1) - (1)].node));
 	}
@@ -5284,7 +5288,7 @@ yyreduce:
   case 344:
 
 /* Line 1806 of yacc.c  */
-#line 2074 "go.y"
+#line 2078 "go.y"
     {
 		(yyval.list) = list((yyvsp[(1) - (3)].list), (yyvsp[(3) - (3)].node));
 	}
@@ -5293,7 +5297,7 @@ yyreduce:
 
 
 /* Line 1806 of yacc.c  */
-#line 5298 "y.tab.c"
+#line 5302 "y.tab.c"
       default: break;
     }
   /* User semantic actions sometimes alter yychar, and that requires
@@ -5524,7 +5528,7 @@ yyreturn:
 
 
 /* Line 2067 of yacc.c  */
-#line 2078 "go.y"
+#line 2082 "go.y"
 
 
 static void
@@ -38,6 +38,8 @@ When the command line specifies a single main package,
 build writes the resulting executable to output.
 Otherwise build compiles the packages but discards the results,
 serving only as a check that the packages can be built.
+Either way, the compiled packages are kept in the build cache,
+so later builds need not compile them again; see 'go help cache'.
 
 The -o flag specifies the output file name.  If not specified, the
 name is packagename.a (for a non-main package) or the base
@@ -46,7 +48,8 @@ name of the first source file (for a main package).
 The build flags are shared by the build, install, run, and test commands:
 
 	-a
-		force rebuilding of packages that are already up-to-date.
+		force rebuilding of packages that are already up-to-date,
+		without using the build cache.
 	-n
 		print the commands but do not run them.
 	-p n
@@ -60,6 +63,8 @@ The build flags are shared by the build, install, run, and test commands:
 	-x
 		print the commands.
 
+	-asmflags 'arg list'
+		arguments to pass on each 5a, 6a, or 8a assembler invocation
 	-compiler name
 		name of compiler to use, as in runtime.Compiler (gccgo or gc)
 	-gccgoflags 'arg list'
@@ -67,7 +72,21 @@ The build flags are shared by the build, install, run, and test commands:
 	-gcflags 'arg list'
 		arguments to pass on each 5g, 6g, or 8g compiler invocation
 	-ldflags 'flag list'
-		arguments to pass on each 5l, 6l, or 8l linker invocation
+		arguments to pass on each 5l, 6l, or 8l linker invocation.
+		For example, -ldflags "-X main.version=1.2" sets the string
+		variable version in package main; see 'go tool 6l' for details
+	-buildmode mode
+		what to build from a main package: exe (the default) or
+		c-archive.  A C archive, supported only for linux/amd64,
+		is written to pkg.a, with the C declarations of the functions
+		the package marks with //export in pkg.h; a C program links
+		against it with -lpthread, and with -no-pie if its compiler
+		builds position-independent executables by default.  See
+		'go tool 6l' for details.
+	-linkmode mode
+		how to link programs: internal, external or auto (the default).
+		External linking runs the host linker on the Go code and the C
+		code of cgo packages; see 'go tool 6l' for details.
 	-tags 'tag list'
 		a list of build tags to consider satisfied during the build.
 		See the documentation for the go/build package for
@@ -98,8 +117,11 @@ var buildV bool               // -v flag
 var buildX bool               // -x flag
 var buildO = cmdBuild.Flag.String("o", "", "output file")
 var buildWork bool           // -work flag
+var buildAsmflags []string   // -asmflags flag
 var buildGcflags []string    // -gcflags flag
 var buildLdflags []string    // -ldflags flag
+var buildLinkmode string     // -linkmode flag
+var buildBuildmode string    // -buildmode flag
 var buildGccgoflags []string // -gccgoflags flag
 
 var buildContext = build.Default
@@ -145,8 +167,11 @@ func addBuildFlags(cmd *Command) {
 	cmd.Flag.BoolVar(&buildV, "v", false, "")
 	cmd.Flag.BoolVar(&buildX, "x", false, "")
 	cmd.Flag.BoolVar(&buildWork, "work", false, "")
+	cmd.Flag.Var((*stringsFlag)(&buildAsmflags), "asmflags", "")
 	cmd.Flag.Var((*stringsFlag)(&buildGcflags), "gcflags", "")
 	cmd.Flag.Var((*stringsFlag)(&buildLdflags), "ldflags", "")
+	cmd.Flag.StringVar(&buildLinkmode, "linkmode", "", "")
+	cmd.Flag.StringVar(&buildBuildmode, "buildmode", "", "")
 	cmd.Flag.Var((*stringsFlag)(&buildGccgoflags), "gccgoflags", "")
 	cmd.Flag.Var((*stringsFlag)(&buildContext.BuildTags), "tags", "")
 	cmd.Flag.Var(buildCompiler{}, "compiler", "")
@@ -167,11 +192,25 @@ func runBuild(cmd *Command, args []string) {
 	var b builder
 	b.init()
 
+	switch buildBuildmode {
+	case "", "exe", "c-archive":
+	case "c-shared":
+		fatalf("go build: -buildmode=c-shared is not supported: 6l does not generate position-independent code")
+	default:
+		fatalf("go build: unknown build mode -buildmode=%s", buildBuildmode)
+	}
+
 	pkgs := packagesForBuild(args)
 
+	if buildBuildmode == "c-archive" {
+		if len(pkgs) != 1 || pkgs[0].Name != "main" {
+			fatalf("go build: -buildmode=%s requires exactly one main package", buildBuildmode)
+		}
+	}
+
 	if len(pkgs) == 1 && pkgs[0].Name == "main" && *buildO == "" {
 		_, *buildO = path.Split(pkgs[0].ImportPath)
-		*buildO += exeSuffix
+		*buildO += targetSuffix()
 	}
 
 	if *buildO != "" {
@@ -193,6 +232,16 @@ func runBuild(cmd *Command, args []string) {
 	b.do(a)
 }
 
+// targetSuffix returns the suffix of the file go build writes
+// for a main package in the build mode given by -buildmode.
+func targetSuffix() string {
+	switch buildBuildmode {
+	case "c-archive":
+		return ".a"
+	}
+	return exeSuffix
+}
+
 var cmdInstall = &Command{
 	UsageLine: "install [build flags] [packages]",
 	Short:     "compile and install packages and dependencies",
@@ -208,6 +257,9 @@ See also: go build, go get, go clean.
 }
 
 func runInstall(cmd *Command, args []string) {
+	if buildBuildmode != "" && buildBuildmode != "exe" {
+		fatalf("go install: -buildmode=%s is only for go build", buildBuildmode)
+	}
 	pkgs := packagesForBuild(args)
 
 	for _, p := range pkgs {
@@ -287,6 +339,10 @@ type action struct {
 	pending  int  // number of deps yet to complete
 	priority int  // relative execution priority
 	failed   bool // whether the action failed
+
+	// Build cache state; see cache.go.
+	actionID actionID // hash of the inputs that determine target
+	cacheOK  bool     // whether actionID is valid
 }
 
 // cacheKey is the key for the action cache.
@@ -390,7 +446,7 @@ func goFilesPackage(gofiles []string) *Package {
 		_, elem := filepath.Split(gofiles[0])
 		exe := elem[:len(elem)-len(".go")] + exeSuffix
 		if *buildO == "" {
-			*buildO = exe
+			*buildO = elem[:len(elem)-len(".go")] + targetSuffix()
 		}
 		if gobin != "" {
 			pkg.target = filepath.Join(gobin, exe)
@@ -632,6 +688,12 @@ func (b *builder) build(a *action) (err error) {
 		return err
 	}
 
+	// Reuse an earlier build of the same inputs.
+	b.setActionID(a)
+	if b.buildFromCache(a) {
+		return nil
+	}
+
 	var gofiles, cfiles, sfiles, objects, cgoObjects []string
 	gofiles = append(gofiles, a.p.GoFiles...)
 	cfiles = append(cfiles, a.p.CFiles...)
@@ -755,8 +817,16 @@ func (b *builder) build(a *action) (err error) {
 		if err := buildToolchain.ld(b, a.p, a.target, all, a.objpkg, objects); err != nil {
 			return err
 		}
+		// go build of a local package links straight to the -o file,
+		// with no install action to write the header next to it.
+		if buildBuildmode == "c-archive" && !strings.HasPrefix(a.target, a.objdir) {
+			if err := b.copyExportHeader(a, a); err != nil {
+				return err
+			}
+		}
 	}
 
+	b.saveBuild(a)
 	return nil
 }
 
@@ -768,8 +838,9 @@ func (b *builder) install(a *action) (err error) {
 		}
 	}()
 	a1 := a.deps[0]
+	a.actionID, a.cacheOK = a1.actionID, a1.cacheOK
 	perm := os.FileMode(0666)
-	if a1.link {
+	if a1.link && buildBuildmode != "c-archive" {
 		perm = 0777
 	}
 
@@ -790,7 +861,36 @@ func (b *builder) install(a *action) (err error) {
 		defer os.Remove(a1.target)
 	}
 
-	return b.copyFile(a, a.target, a1.target, perm)
+	if err := b.copyFile(a, a.target, a1.target, perm); err != nil {
+		return err
+	}
+	if a1.link && buildBuildmode == "c-archive" {
+		return b.copyExportHeader(a, a1)
+	}
+	return nil
+}
+
+// copyExportHeader writes the C declarations of the functions that
+// the package of the C archive a1 exports next to the archive,
+// replacing its .a suffix with .h.  A package without cgo files
+// exports nothing, and gets no header.
+func (b *builder) copyExportHeader(a, a1 *action) error {
+	if len(a1.p.CgoFiles) == 0 {
+		return nil
+	}
+	src := a1.objdir + "_cgo_export.h"
+	dst := a.target[:len(a.target)-len(filepath.Ext(a.target))] + ".h"
+	if buildN || buildX {
+		b.showcmd("", "cp %s %s", src, dst)
+		if buildN {
+			return nil
+		}
+	}
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0666)
 }
 
 // includeArgs returns the -I or -L directory list for access
@@ -1238,6 +1338,13 @@ func (gcToolchain) gc(b *builder, p *Package, obj string, importArgs []string, g
 		// additional reflect type data.
 		gcargs = append(gcargs, "-+")
 	}
+	// Tell the compiler which packages in vendor directories
+	// satisfy the imports written in the source.
+	for _, path := range p.Imports {
+		if vpath := vendorlessImportPath(path); vpath != path {
+			gcargs = append(gcargs, "-F", vpath+"="+path)
+		}
+	}
 
 	args := stringList(tool(archChar+"g"), "-o", ofile, buildGcflags, gcargs, "-D", p.localPrefix, importArgs)
 	for _, f := range gofiles {
@@ -1248,7 +1355,7 @@ func (gcToolchain) gc(b *builder, p *Package, obj string, importArgs []string, g
 
 func (gcToolchain) asm(b *builder, p *Package, obj, ofile, sfile string) error {
 	sfile = mkAbs(p.Dir, sfile)
-	return b.run(p.Dir, p.ImportPath, tool(archChar+"a"), "-I", obj, "-o", ofile, "-DGOOS_"+goos, "-DGOARCH_"+goarch, sfile)
+	return b.run(p.Dir, p.ImportPath, tool(archChar+"a"), "-I", obj, "-o", ofile, "-DGOOS_"+goos, "-DGOARCH_"+goarch, buildAsmflags, sfile)
 }
 
 func (gcToolchain) pkgpath(basedir string, p *Package) string {
@@ -1266,7 +1373,19 @@ func (gcToolchain) pack(b *builder, p *Package, objDir, afile string, ofiles []s
 
 func (gcToolchain) ld(b *builder, p *Package, out string, allactions []*action, mainpkg string, ofiles []string) error {
 	importArgs := b.includeArgs("-L", allactions)
-	return b.run(".", p.ImportPath, tool(archChar+"l"), "-o", out, importArgs, buildLdflags, mainpkg)
+	var infoArgs []string
+	if !p.fake {
+		// Record how the command was built; see buildinfo.go.
+		infoArgs = []string{"-X", buildInfoSym + "=" + buildInfo(p)}
+	}
+	var linkArgs []string
+	if buildLinkmode != "" {
+		linkArgs = []string{"-linkmode", buildLinkmode}
+	}
+	if buildBuildmode != "" {
+		linkArgs = append(linkArgs, "-buildmode", buildBuildmode)
+	}
+	return b.run(".", p.ImportPath, tool(archChar+"l"), "-o", out, importArgs, infoArgs, linkArgs, buildLdflags, mainpkg)
 }
 
 func (gcToolchain) cc(b *builder, p *Package, objdir, ofile, cfile string) error {
@@ -1325,6 +1444,9 @@ func (gccgcToolchain) pack(b *builder, p *Package, objDir, afile string, ofiles
 }
 
 func (tools gccgcToolchain) ld(b *builder, p *Package, out string, allactions []*action, mainpkg string, ofiles []string) error {
+	if buildBuildmode != "" && buildBuildmode != "exe" {
+		return fmt.Errorf("gccgo does not support -buildmode=%s", buildBuildmode)
+	}
 	// gccgo needs explicit linking with all package dependencies,
 	// and all LDFLAGS from cgo dependencies.
 	afiles := make(map[*Package]string)
@@ -1429,6 +1551,9 @@ func (b *builder) cgo(p *Package, cgoExe, obj string, gccfiles []string) (outGo,
 
 	cgoCFLAGS := stringList(envList("CGO_CFLAGS"), p.CgoCFLAGS)
 	cgoLDFLAGS := stringList(envList("CGO_LDFLAGS"), p.CgoLDFLAGS)
+	// The flags the cgo directives don't record in the package,
+	// for when the program is linked by the host linker.
+	extLDFLAGS := envList("CGO_LDFLAGS")
 
 	if pkgs := p.CgoPkgConfig; len(pkgs) > 0 {
 		out, err := b.runOut(p.Dir, p.ImportPath, "pkg-config", "--cflags", pkgs)
@@ -1448,6 +1573,7 @@ func (b *builder) cgo(p *Package, cgoExe, obj string, gccfiles []string) (outGo,
 		}
 		if len(out) > 0 {
 			cgoLDFLAGS = append(cgoLDFLAGS, strings.Fields(string(out))...)
+			extLDFLAGS = append(extLDFLAGS, strings.Fields(string(out))...)
 		}
 	}
 
@@ -1479,6 +1605,8 @@ func (b *builder) cgo(p *Package, cgoExe, obj string, gccfiles []string) (outGo,
 			cgoflags = append(cgoflags, "-gccgoprefix="+gccgoPrefix(p))
 		}
 		objExt = "o"
+	} else if len(extLDFLAGS) > 0 {
+		cgoflags = append(cgoflags, "-ldflags", strings.Join(extLDFLAGS, " "))
 	}
 	if err := b.run(p.Dir, p.ImportPath, cgoExe, "-objdir", obj, cgoflags, "--", cgoCFLAGS, p.CgoFiles); err != nil {
 		return nil, nil, err
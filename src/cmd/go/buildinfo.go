@@ -0,0 +1,127 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// Commands built by the go command record how they were built in
+// the variable runtime/debug.buildInfo, which the linker sets with -X.
+// See runtime/debug.ReadBuildInfo for the format.
+const buildInfoSym = "runtime/debug.buildInfo"
+
+// buildInfo returns the build information to record in the command p.
+func buildInfo(p *Package) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "go\t%s\n", runtime.Version())
+	fmt.Fprintf(&buf, "path\t%s\n", p.ImportPath)
+	seen := map[string]bool{}
+	add := func(kind string, p *Package) {
+		root, rev := repoRevision(p)
+		if root == "" || seen[root] {
+			return
+		}
+		seen[root] = true
+		fmt.Fprintf(&buf, "%s\t%s\t%s\n", kind, root, rev)
+	}
+	add("main", p)
+	for _, p1 := range p.deps {
+		if !p1.Goroot {
+			add("dep", p1)
+		}
+	}
+	return buf.String()
+}
+
+// repoRevCache maps a repository directory to its checked-out revision.
+var repoRevCache = map[string]string{}
+
+// repoRevision returns the import path of the root of the repository
+// holding p, and the revision of it in use.  It returns an empty root if
+// p is not in a repository, and an empty revision if it cannot be found.
+func repoRevision(p *Package) (root, rev string) {
+	if pin := projectPins().lookup(p.ImportPath); pin != nil {
+		return pin.root, pin.rev
+	}
+	if p.build == nil || p.build.SrcRoot == "" {
+		return "", ""
+	}
+	vcs, root, err := vcsForDir(p)
+	if err != nil {
+		return "", ""
+	}
+	dir := filepath.Join(p.build.SrcRoot, filepath.FromSlash(root))
+	rev, ok := repoRevCache[dir]
+	if !ok {
+		rev, _ = vcs.revision(dir)
+		repoRevCache[dir] = rev
+	}
+	return root, rev
+}
+
+// readBuildInfo returns the build information recorded in the ELF executable file.
+func readBuildInfo(file string) (string, error) {
+	f, err := elf.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	syms, err := f.Symbols()
+	if err != nil {
+		return "", err
+	}
+	var addr uint64
+	for _, s := range syms {
+		if s.Name == buildInfoSym {
+			addr = s.Value
+			break
+		}
+	}
+	if addr == 0 {
+		return "", errors.New("no build information")
+	}
+
+	// The variable is a string header: a data pointer and a length.
+	ptrSize := uint64(4)
+	if f.Class == elf.ELFCLASS64 {
+		ptrSize = 8
+	}
+	hdr, err := readELFData(f, addr, 2*ptrSize)
+	if err != nil {
+		return "", err
+	}
+	var data, n uint64
+	if ptrSize == 8 {
+		data, n = f.ByteOrder.Uint64(hdr), f.ByteOrder.Uint64(hdr[8:])
+	} else {
+		data, n = uint64(f.ByteOrder.Uint32(hdr)), uint64(f.ByteOrder.Uint32(hdr[4:]))
+	}
+	info, err := readELFData(f, data, n)
+	if err != nil {
+		return "", err
+	}
+	return string(info), nil
+}
+
+// readELFData reads n bytes at virtual address addr in f.
+func readELFData(f *elf.File, addr, n uint64) ([]byte, error) {
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Vaddr <= addr && addr+n <= prog.Vaddr+prog.Filesz {
+			buf := make([]byte, n)
+			if _, err := prog.ReadAt(buf, int64(addr-prog.Vaddr)); err != nil {
+				return nil, err
+			}
+			return buf, nil
+		}
+	}
+	return nil, fmt.Errorf("address %#x not in file", addr)
+}
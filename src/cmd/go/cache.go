@@ -0,0 +1,536 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The build cache.  See 'go help cache' for the user's view.
+//
+// Every cached result is stored under an action ID, a SHA-256 hash
+// of everything that can affect the result: the contents of the
+// source files (not their modification times), the flags, the
+// toolchain binaries and the action IDs of the dependencies.
+// Because the hash depends only on content, the same result is
+// found again no matter where or when the sources were checked out.
+
+// cacheVersion is mixed into every action ID.  Changing it
+// invalidates all existing cache entries.
+const cacheVersion = "go-build 1"
+
+// An actionID identifies the inputs of an action.
+type actionID [sha256.Size]byte
+
+// Kinds of cache entries.
+const (
+	cacheArchive = "a" // compiled package archive
+	cacheExe     = "x" // linked executable
+	cacheTest    = "t" // output of a passing test
+	cacheVet     = "v" // vet found no problems
+)
+
+// A buildCache is a directory of cached results.
+type buildCache struct {
+	dir string
+}
+
+var (
+	theCacheOnce sync.Once
+	theCache     *buildCache
+)
+
+// openCache returns the build cache, or nil if caching is disabled.
+func openCache() *buildCache {
+	theCacheOnce.Do(func() {
+		dir := cacheDir()
+		if dir == "" {
+			return
+		}
+		if !filepath.IsAbs(dir) {
+			fatalf("go: GOCACHE is not an absolute path")
+		}
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			// Building still works without a cache, so this
+			// is not an error.
+			fmt.Fprintf(os.Stderr, "warning: disabling build cache: %v\n", err)
+			return
+		}
+		theCache = &buildCache{dir: dir}
+	})
+	return theCache
+}
+
+// cacheDir returns the directory holding the build cache,
+// or "" if the cache is disabled.
+func cacheDir() string {
+	dir := os.Getenv("GOCACHE")
+	if dir == "off" {
+		return ""
+	}
+	if dir != "" {
+		return dir
+	}
+	var base string
+	switch runtime.GOOS {
+	case "windows":
+		base = os.Getenv("LocalAppData")
+	case "darwin":
+		if home := os.Getenv("HOME"); home != "" {
+			base = filepath.Join(home, "Library", "Caches")
+		}
+	case "plan9":
+		if home := os.Getenv("home"); home != "" {
+			base = filepath.Join(home, "lib", "cache")
+		}
+	default:
+		base = os.Getenv("XDG_CACHE_HOME")
+		if base == "" {
+			if home := os.Getenv("HOME"); home != "" {
+				base = filepath.Join(home, ".cache")
+			}
+		}
+	}
+	if base == "" {
+		return ""
+	}
+	return filepath.Join(base, "go-build")
+}
+
+// file returns the name of the cache entry of the given kind for id.
+// Entries are spread over 256 subdirectories named by the first
+// byte of the ID.
+func (c *buildCache) file(id actionID, kind string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%02x", id[0]), fmt.Sprintf("%x-%s", id, kind))
+}
+
+// get returns the name of the cache entry of the given kind for id,
+// if there is one.
+func (c *buildCache) get(id actionID, kind string) (string, bool) {
+	file := c.file(id, kind)
+	if _, err := os.Stat(file); err != nil {
+		return "", false
+	}
+	return file, true
+}
+
+// getBytes returns the contents of the cache entry of the given kind for id.
+func (c *buildCache) getBytes(id actionID, kind string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(c.file(id, kind))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// put stores the data read from r as the cache entry of the given kind for id.
+// The entry is written under a temporary name and then renamed,
+// so that concurrent go commands never see a partial entry.
+func (c *buildCache) put(id actionID, kind string, r io.Reader) error {
+	file := c.file(id, kind)
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(file), "tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// putFile stores a copy of file as the cache entry of the given kind for id.
+func (c *buildCache) putFile(id actionID, kind, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.put(id, kind, f)
+}
+
+// putBytes stores data as the cache entry of the given kind for id.
+func (c *buildCache) putBytes(id actionID, kind string, data []byte) error {
+	return c.put(id, kind, bytes.NewReader(data))
+}
+
+// newActionHash returns a hash for computing an action ID
+// for an action of the given kind.
+func newActionHash(kind string) hash.Hash {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s %s\n", cacheVersion, runtime.Version(), kind)
+	return h
+}
+
+// sumID returns the action ID accumulated in h.
+func sumID(h hash.Hash) actionID {
+	var id actionID
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// fileHashCache maps a file name to the hash of its contents.
+// Tool binaries and installed archives are hashed by many actions,
+// so the hashes are computed once per run.
+var fileHashCache struct {
+	sync.Mutex
+	m map[string]actionID
+}
+
+// fileHash returns the SHA-256 hash of the contents of file.
+func fileHash(file string) (actionID, error) {
+	fileHashCache.Lock()
+	id, ok := fileHashCache.m[file]
+	fileHashCache.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return id, err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, bufio.NewReader(f))
+	f.Close()
+	if err != nil {
+		return id, err
+	}
+	id = sumID(h)
+
+	fileHashCache.Lock()
+	if fileHashCache.m == nil {
+		fileHashCache.m = make(map[string]actionID)
+	}
+	fileHashCache.m[file] = id
+	fileHashCache.Unlock()
+	return id, nil
+}
+
+// hashFiles writes the names and content hashes of the named files
+// in dir to h.
+func hashFiles(h hash.Hash, dir string, names []string) error {
+	for _, name := range names {
+		id, err := fileHash(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file %s %x\n", name, id)
+	}
+	return nil
+}
+
+// setActionID computes the action ID of the build action a
+// and records whether the result of a may be cached.
+// The dependencies of a must have completed.
+func (b *builder) setActionID(a *action) {
+	a.cacheOK = false
+	if buildN || openCache() == nil {
+		return
+	}
+	if _, ok := buildToolchain.(gcToolchain); !ok {
+		return
+	}
+
+	p := a.p
+	h := newActionHash("compile")
+	fmt.Fprintf(h, "import %s\n", p.ImportPath)
+	fmt.Fprintf(h, "goos %s goarch %s\n", goos, goarch)
+	if !strings.HasPrefix(p.Dir, b.work) {
+		// The directory is recorded in the object files.
+		// Generated packages like testmain live in $WORK,
+		// whose name changes from run to run; leave it out.
+		fmt.Fprintf(h, "dir %s\n", p.Dir)
+		fmt.Fprintf(h, "localprefix %s\n", p.localPrefix)
+	}
+	fmt.Fprintf(h, "gcflags %q\n", buildGcflags)
+	fmt.Fprintf(h, "asmflags %q\n", buildAsmflags)
+	tools := []string{buildToolchain.compiler(), tool(archChar + "a"), tool(archChar + "c"), tool("pack")}
+	for _, t := range tools {
+		id, err := fileHash(t)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(h, "tool %s %x\n", filepath.Base(t), id)
+	}
+	if err := hashFiles(h, p.Dir, stringList(p.GoFiles, p.CgoFiles, p.CFiles, p.HFiles, p.SFiles, p.SysoFiles)); err != nil {
+		return
+	}
+	if len(p.CFiles) > 0 {
+		// C files may include the runtime headers installed in $GOROOT/pkg.
+		inc := filepath.Join(goroot, "pkg", goos+"_"+goarch)
+		names, _ := filepath.Glob(filepath.Join(inc, "*.h"))
+		for i := range names {
+			names[i] = filepath.Base(names[i])
+		}
+		if err := hashFiles(h, inc, names); err != nil {
+			return
+		}
+	}
+	if len(p.CgoFiles) > 0 {
+		fmt.Fprintf(h, "CC=%q CGO_CFLAGS=%q CGO_LDFLAGS=%q\n", os.Getenv("CC"), os.Getenv("CGO_CFLAGS"), os.Getenv("CGO_LDFLAGS"))
+		fmt.Fprintf(h, "cgo %q %q %q\n", p.CgoCFLAGS, p.CgoLDFLAGS, p.CgoPkgConfig)
+	}
+
+	// The compiler reads the export data of the direct dependencies,
+	// whose IDs cover their own dependencies in turn.
+	var deps []string
+	for _, a1 := range a.deps {
+		id, ok := depID(a1)
+		if !ok {
+			return
+		}
+		deps = append(deps, fmt.Sprintf("dep %s %x\n", a1.p.ImportPath, id))
+	}
+	sort.Strings(deps)
+	for _, d := range deps {
+		io.WriteString(h, d)
+	}
+	a.actionID = sumID(h)
+
+	if a.link {
+		if buildBuildmode != "" && buildBuildmode != "exe" {
+			// The cache holds only executables, not C archives
+			// and the headers that go with them.
+			return
+		}
+		// The ID of an executable also covers the linker inputs.
+		// The linker reads every package in the program,
+		// not just the direct dependencies.
+		h := newActionHash("link")
+		fmt.Fprintf(h, "compile %x\n", a.actionID)
+		fmt.Fprintf(h, "ldflags %q\n", buildLdflags)
+		fmt.Fprintf(h, "linkmode %q\n", buildLinkmode)
+		id, err := fileHash(buildToolchain.linker())
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(h, "tool %s %x\n", filepath.Base(buildToolchain.linker()), id)
+		deps = nil
+		for _, a1 := range actionList(a) {
+			if a1 == a {
+				continue
+			}
+			id, ok := depID(a1)
+			if !ok {
+				return
+			}
+			deps = append(deps, fmt.Sprintf("pkg %s %x\n", a1.p.ImportPath, id))
+		}
+		sort.Strings(deps)
+		for _, d := range deps {
+			io.WriteString(h, d)
+		}
+		if !p.fake {
+			io.WriteString(h, buildInfo(p))
+		}
+		a.actionID = sumID(h)
+	}
+	a.cacheOK = true
+}
+
+// depID returns the action ID of the completed dependency a1.
+func depID(a1 *action) (actionID, bool) {
+	if a1.f == nil {
+		// Nothing was built: the package is already installed,
+		// or it is a fake package like unsafe with no archive at all.
+		if a1.target == "" {
+			return actionID{}, true
+		}
+		id, err := fileHash(a1.target)
+		return id, err == nil
+	}
+	return a1.actionID, a1.cacheOK
+}
+
+// buildFromCache copies the result of the build action a from the
+// cache, if it is there.  It reports whether it did.
+func (b *builder) buildFromCache(a *action) bool {
+	c := openCache()
+	if !a.cacheOK || c == nil || buildA {
+		return false
+	}
+	kind, dst, perm := cacheArchive, a.objpkg, os.FileMode(0666)
+	if a.link {
+		kind, dst, perm = cacheExe, a.target, 0777
+	}
+	file, ok := c.get(a.actionID, kind)
+	if !ok {
+		return false
+	}
+	if err := b.copyFile(a, dst, file, perm); err != nil {
+		return false
+	}
+	return true
+}
+
+// saveBuild stores the result of the build action a in the cache.
+// The cache is only an optimization; failures are ignored.
+func (b *builder) saveBuild(a *action) {
+	c := openCache()
+	if !a.cacheOK || c == nil {
+		return
+	}
+	if a.link {
+		c.putFile(a.actionID, cacheExe, a.target)
+	} else {
+		c.putFile(a.actionID, cacheArchive, a.objpkg)
+	}
+}
+
+// testCacheFlags lists the test binary flags that do not stop
+// a test result from being cached.  Other flags, like -bench and
+// -cpuprofile, ask for output that a cached result cannot provide.
+var testCacheFlags = map[string]bool{
+	"cpu":      true,
+	"parallel": true,
+	"run":      true,
+	"short":    true,
+	"timeout":  true,
+	"v":        true,
+}
+
+// testCacheable reports whether the results of running tests
+// with the given test binary arguments may be cached.
+func testCacheable(args []string) bool {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-test.") {
+			return false
+		}
+		name := arg[len("-test."):]
+		if i := strings.Index(name, "="); i >= 0 {
+			name = name[:i]
+		}
+		if !testCacheFlags[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// testActionID returns the action ID for running the test of p with
+// the test binary built by the action pmain.  Besides the binary and
+// its arguments, it covers the files in the package directory and
+// its testdata subdirectory, which tests commonly read.
+func testActionID(pmain *action, p *Package, args []string) (actionID, bool) {
+	h := newActionHash("test")
+	fmt.Fprintf(h, "binary %x\n", pmain.actionID)
+	fmt.Fprintf(h, "dir %s\n", p.Dir)
+	fmt.Fprintf(h, "args %q\n", args)
+	dirs, err := ioutil.ReadDir(p.Dir)
+	if err != nil {
+		return actionID{}, false
+	}
+	var names []string
+	for _, fi := range dirs {
+		if fi.Mode()&os.ModeType == 0 {
+			names = append(names, fi.Name())
+		}
+	}
+	if err := hashFiles(h, p.Dir, names); err != nil {
+		return actionID{}, false
+	}
+	testdata := filepath.Join(p.Dir, "testdata")
+	if _, err := os.Stat(testdata); err == nil {
+		sum, err := hashDir(testdata)
+		if err != nil {
+			return actionID{}, false
+		}
+		fmt.Fprintf(h, "testdata %s\n", sum)
+	}
+	return sumID(h), true
+}
+
+// vetActionID returns the action ID for vetting the files of p.
+// Besides the files, it covers the installed archives of the packages
+// they import, from which vet's type checker reads export data.
+func vetActionID(p *Package) (actionID, bool) {
+	h := newActionHash("vet")
+	id, err := fileHash(tool("vet"))
+	if err != nil {
+		return actionID{}, false
+	}
+	fmt.Fprintf(h, "tool vet %x\n", id)
+	fmt.Fprintf(h, "dir %s\n", p.Dir)
+	if err := hashFiles(h, p.Dir, stringList(p.GoFiles, p.CgoFiles, p.TestGoFiles, p.XTestGoFiles)); err != nil {
+		return actionID{}, false
+	}
+	imports := stringList(p.Imports, p.TestImports, p.XTestImports)
+	sort.Strings(imports)
+	var stk importStack
+	for i, path := range imports {
+		if path == "C" || path == "unsafe" || i > 0 && path == imports[i-1] {
+			continue
+		}
+		p1 := loadImport(path, p.Dir, &stk, nil)
+		if p1.target == "" {
+			// Nothing to read; vet checks without the types.
+			fmt.Fprintf(h, "import %s none\n", path)
+			continue
+		}
+		id, err := fileHash(p1.target)
+		if err != nil {
+			fmt.Fprintf(h, "import %s none\n", path)
+			continue
+		}
+		fmt.Fprintf(h, "import %s %x\n", path, id)
+	}
+	return sumID(h), true
+}
+
+// removeCache removes the entries of the given kind from the cache,
+// or all entries if kind is "".
+func removeCache(kind string) {
+	c := openCache()
+	if c == nil {
+		return
+	}
+	var b builder
+	b.print = fmt.Print
+	if kind == "" {
+		if cleanN || cleanX {
+			b.showcmd("", "rm -r %s", c.dir)
+		}
+		if !cleanN {
+			if err := os.RemoveAll(c.dir); err != nil {
+				errorf("go clean -cache: %v", err)
+			}
+		}
+		return
+	}
+	if cleanN || cleanX {
+		b.showcmd("", "rm -f %s", filepath.Join(c.dir, "*", "*-"+kind))
+	}
+	if cleanN {
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(c.dir, "*", "*-"+kind))
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			errorf("go clean: %v", err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testCacheableTests = []struct {
+	args []string
+	ok   bool
+}{
+	{nil, true},
+	{[]string{"-test.v=true", "-test.run=Foo", "-test.short=true"}, true},
+	{[]string{"-test.cpu=1,2", "-test.parallel=4", "-test.timeout=1m"}, true},
+	{[]string{"-test.bench=."}, false},
+	{[]string{"-test.cpuprofile=cpu.out"}, false},
+	{[]string{"-custom"}, false},
+	{[]string{"-test.v=true", "arg"}, false},
+}
+
+func TestTestCacheable(t *testing.T) {
+	for _, tt := range testCacheableTests {
+		if ok := testCacheable(tt.args); ok != tt.ok {
+			t.Errorf("testCacheable(%q) = %v, want %v", tt.args, ok, tt.ok)
+		}
+	}
+}
+
+func TestBuildCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &buildCache{dir: dir}
+	h := newActionHash("test")
+	h.Write([]byte("input"))
+	id := sumID(h)
+
+	if _, ok := c.getBytes(id, cacheTest); ok {
+		t.Fatalf("getBytes found entry in empty cache")
+	}
+	if err := c.putBytes(id, cacheTest, []byte("output")); err != nil {
+		t.Fatal(err)
+	}
+	data, ok := c.getBytes(id, cacheTest)
+	if !ok || string(data) != "output" {
+		t.Fatalf("getBytes = %q, %v, want %q, true", data, ok, "output")
+	}
+	if _, ok := c.get(id, cacheArchive); ok {
+		t.Fatalf("get found entry of the wrong kind")
+	}
+	file, ok := c.get(id, cacheTest)
+	if !ok || filepath.Dir(filepath.Dir(file)) != dir {
+		t.Fatalf("get = %q, %v, want file in %s", file, ok, dir)
+	}
+}
@@ -13,7 +13,7 @@ import (
 )
 
 var cmdClean = &Command{
-	UsageLine: "clean [-i] [-r] [-n] [-x] [packages]",
+	UsageLine: "clean [-i] [-r] [-n] [-x] [-cache] [-testcache] [packages]",
 	Short:     "remove object files",
 	Long: `
 Clean removes object files from package source directories.
@@ -51,6 +51,12 @@ dependencies of the packages named by the import paths.
 
 The -x flag causes clean to print remove commands as it executes them.
 
+The -cache flag causes clean to remove the entire build cache.
+The -testcache flag causes clean to remove only the cached test results,
+so that the next go test runs every test again.  When either flag is
+given and no packages are named, clean does nothing else.
+See 'go help cache'.
+
 For more about specifying packages, see 'go help packages'.
 	`,
 }
@@ -60,6 +66,9 @@ var cleanN bool // clean -n flag
 var cleanR bool // clean -r flag
 var cleanX bool // clean -x flag
 
+var cleanCache bool     // clean -cache flag
+var cleanTestcache bool // clean -testcache flag
+
 func init() {
 	// break init cycle
 	cmdClean.Run = runClean
@@ -68,11 +77,20 @@ func init() {
 	cmdClean.Flag.BoolVar(&cleanN, "n", false, "")
 	cmdClean.Flag.BoolVar(&cleanR, "r", false, "")
 	cmdClean.Flag.BoolVar(&cleanX, "x", false, "")
+	cmdClean.Flag.BoolVar(&cleanCache, "cache", false, "")
+	cmdClean.Flag.BoolVar(&cleanTestcache, "testcache", false, "")
 }
 
 func runClean(cmd *Command, args []string) {
-	for _, pkg := range packagesAndErrors(args) {
-		clean(pkg)
+	if len(args) > 0 || !cleanCache && !cleanTestcache {
+		for _, pkg := range packagesAndErrors(args) {
+			clean(pkg)
+		}
+	}
+	if cleanCache {
+		removeCache("")
+	} else if cleanTestcache {
+		removeCache(cacheTest)
 	}
 }
 
@@ -17,6 +17,7 @@ The commands are:
     env         print Go environment information
     fix         run go tool fix on packages
     fmt         run gofmt on package sources
+    generate    generate Go files by processing source
     get         download and install packages and dependencies
     install     compile and install packages and dependencies
     list        list packages
@@ -30,6 +31,8 @@ Use "go help [command]" for more information about a command.
 
 Additional help topics:
 
+    cache       build and test caching
+    deps        pinned dependency versions
     gopath      GOPATH environment variable
     packages    description of package lists
     remote      remote import path syntax
@@ -55,6 +58,8 @@ When the command line specifies a single main package,
 build writes the resulting executable to output.
 Otherwise build compiles the packages but discards the results,
 serving only as a check that the packages can be built.
+Either way, the compiled packages are kept in the build cache,
+so later builds need not compile them again; see 'go help cache'.
 
 The -o flag specifies the output file name.  If not specified, the
 name is packagename.a (for a non-main package) or the base
@@ -63,7 +68,8 @@ name of the first source file (for a main package).
 The build flags are shared by the build, install, run, and test commands:
 
 	-a
-		force rebuilding of packages that are already up-to-date.
+		force rebuilding of packages that are already up-to-date,
+		without using the build cache.
 	-n
 		print the commands but do not run them.
 	-p n
@@ -77,6 +83,8 @@ The build flags are shared by the build, install, run, and test commands:
 	-x
 		print the commands.
 
+	-asmflags 'arg list'
+		arguments to pass on each 5a, 6a, or 8a assembler invocation
 	-compiler name
 		name of compiler to use, as in runtime.Compiler (gccgo or gc)
 	-gccgoflags 'arg list'
@@ -84,7 +92,21 @@ The build flags are shared by the build, install, run, and test commands:
 	-gcflags 'arg list'
 		arguments to pass on each 5g, 6g, or 8g compiler invocation
 	-ldflags 'flag list'
-		arguments to pass on each 5l, 6l, or 8l linker invocation
+		arguments to pass on each 5l, 6l, or 8l linker invocation.
+		For example, -ldflags "-X main.version=1.2" sets the string
+		variable version in package main; see 'go tool 6l' for details
+	-buildmode mode
+		what to build from a main package: exe (the default) or
+		c-archive.  A C archive, supported only for linux/amd64,
+		is written to pkg.a, with the C declarations of the functions
+		the package marks with //export in pkg.h; a C program links
+		against it with -lpthread, and with -no-pie if its compiler
+		builds position-independent executables by default.  See
+		'go tool 6l' for details.
+	-linkmode mode
+		how to link programs: internal, external or auto (the default).
+		External linking runs the host linker on the Go code and the C
+		code of cgo packages; see 'go tool 6l' for details.
 	-tags 'tag list'
 		a list of build tags to consider satisfied during the build.
 		See the documentation for the go/build package for
@@ -101,7 +123,7 @@ Remove object files
 
 Usage:
 
-	go clean [-i] [-r] [-n] [-x] [packages]
+	go clean [-i] [-r] [-n] [-x] [-cache] [-testcache] [packages]
 
 Clean removes object files from package source directories.
 The go command builds most objects in a temporary directory,
@@ -138,6 +160,12 @@ dependencies of the packages named by the import paths.
 
 The -x flag causes clean to print remove commands as it executes them.
 
+The -cache flag causes clean to remove the entire build cache.
+The -testcache flag causes clean to remove only the cached test results,
+so that the next go test runs every test again.  When either flag is
+given and no packages are named, clean does nothing else.
+See 'go help cache'.
+
 For more about specifying packages, see 'go help packages'.
 
 
@@ -205,6 +233,90 @@ To run gofmt with specific options, run gofmt itself.
 See also: go doc, go fix, go vet.
 
 
+Generate Go files by processing source
+
+Usage:
+
+	go generate [-run regexp] [-n] [-v] [-x] [file.go... | packages]
+
+Generate runs commands described by directives within existing
+files.  Those commands can run any process but the intent is to
+create or update Go source files, for instance by running yacc.
+
+Go generate is never run automatically by go build, go get, go test,
+and so on.  It must be run explicitly.
+
+Go generate scans the file for directives, which are lines of
+the form,
+
+	//go:generate command argument...
+
+(note: no leading spaces and no space in "//go") where command
+is the generator to be run, corresponding to an executable file
+that can be run locally.  It must either be in the shell path
+(gofmt), a fully qualified path (/usr/you/bin/mytool), or a
+command alias, described below.
+
+The arguments to the directive are space-separated tokens or
+double-quoted strings passed to the generator as individual
+arguments when it is run.
+
+Quoted strings use Go syntax and are evaluated before execution; a
+quoted string appears as a single argument to the generator.
+
+Go generate sets several variables when it runs the generator:
+
+	$GOARCH
+		The execution architecture (arm, amd64, etc.)
+	$GOOS
+		The execution operating system (linux, windows, etc.)
+	$GOFILE
+		The base name of the file.
+	$GOPACKAGE
+		The name of the package of the file containing the directive.
+	$DOLLAR
+		A dollar sign.
+
+Other than variable substitution and quoted-string evaluation, no
+special processing such as "globbing" is performed on the command
+line.  The variables are also set in the environment of the
+generator, which runs in the directory holding the file.
+
+Directives are processed one file at a time, in the order the files
+appear in the package and the directives appear in each file.  If any
+generator returns an error exit status, "go generate" skips all
+further processing for that package.
+
+The directive
+
+	//go:generate -command xxx args...
+
+specifies, for the remainder of this source file only, that the
+string xxx represents the command identified by the arguments.
+This can be used to create aliases or to handle multiword generators.
+For example,
+
+	//go:generate -command yacc go tool yacc
+
+specifies that the command "yacc" represents the generator
+"go tool yacc".
+
+Generate processes packages in the order given on the command line,
+one at a time.  If the command line lists .go files, they are treated
+as a single package.  Only the files that would be compiled, after
+applying build constraints, are scanned; test files are included.
+
+The -run flag takes a regular expression and causes generate to run
+only the directives whose full original source text (excluding any
+trailing spaces and final newline) matches the expression.
+
+The -n flag prints the commands that would be run but does not run them.
+The -v flag prints the names of packages and files as they are processed.
+The -x flag prints the commands as they are run.
+
+For more about specifying packages, see 'go help packages'.
+
+
 Download and install packages and dependencies
 
 Usage:
@@ -227,6 +339,10 @@ The -u flag instructs get to use the network to update the named packages
 and their dependencies.  By default, get uses the network to check out
 missing packages but does not use it to look for updates to existing packages.
 
+In a project with a go.deps file, get checks out the dependencies listed
+there at their pinned revisions instead, and never updates them.
+See 'go help deps'.
+
 When checking out or updating a package, get looks for a branch or
 tag that matches the locally installed version of Go. If the local
 version "is release.rNN", it searches for "go.rNN". (For an
@@ -264,7 +380,7 @@ List packages
 
 Usage:
 
-	go list [-e] [-f format] [-json] [packages]
+	go list [-e] [-f format] [-json] [-tags 'tag list'] [packages]
 
 List lists the packages named by the import paths, one per line.
 
@@ -293,6 +409,7 @@ being passed to the template is:
         // Source files
         GoFiles  []string  // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
         CgoFiles []string  // .go sources files that import "C"
+        IgnoredGoFiles []string // .go sources ignored due to build constraints
         CFiles   []string  // .c source files
         HFiles   []string  // .h source files
         SFiles   []string  // .s source files
@@ -331,6 +448,9 @@ printing.  Erroneous packages will have a non-empty ImportPath and
 a non-nil Error field; other information may or may not be missing
 (zeroed).
 
+The -tags flag lists build tags to consider satisfied when deciding
+which files make up each package, as in 'go build'.
+
 For more about specifying packages, see 'go help packages'.
 
 
@@ -373,6 +493,16 @@ with source in the current directory, including tests, and runs the tests.
 The package is built in a temporary directory so it does not interfere with the
 non-test installation.
 
+When packages are named on the command line, go test remembers the
+results of passing tests in the build cache and does not run a test
+again while the test binary, its flags, and the files in the package
+directory and its testdata subdirectory are unchanged.  It prints
+"(cached)" in place of the elapsed time for such tests.  Only the
+-cpu, -parallel, -run, -short, -timeout, and -v test flags allow caching.
+A test that depends on anything else, such as environment variables or
+files elsewhere, should be run with 'go clean -testcache' first.
+See 'go help cache'.
+
 In addition to the build flags, the flags handled by 'go test' itself are:
 
 	-c  Compile the test binary to pkg.test but do not run it.
@@ -381,6 +511,14 @@ In addition to the build flags, the flags handled by 'go test' itself are:
 	    Install packages that are dependencies of the test.
 	    Do not run the test.
 
+	-json
+	    Convert the test output to a stream of JSON events, one per line,
+	    suitable for automated processing.  The test binary is run with
+	    -test.v, and its output is converted as it runs.  The events are
+	    printed as they happen when testing a single package, and a
+	    package at a time otherwise, as the output of -v is.
+	    See 'godoc cmd/test2json' for the encoding.
+
 The test binary also accepts flags that control execution of the test; these
 flags are also accessible by 'go test'.  See 'go help testflag' for details.
 
@@ -409,10 +547,18 @@ Print Go version
 
 Usage:
 
-	go version
+	go version [-m] [file...]
 
 Version prints the Go version, as reported by runtime.Version.
 
+If files are named on the command line, version instead prints the
+Go version that built each executable file, as recorded by the go
+command.  The -m flag causes version to print all the build information
+recorded in each file: the import path of the main package and the
+repositories it was built from, with their revisions.  The information
+is also available to the program itself; see runtime/debug.ReadBuildInfo.
+Only ELF executables are supported.
+
 
 Run go tool vet on packages
 
@@ -422,6 +568,9 @@ Usage:
 
 Vet runs the Go vet command on the packages named by the import paths.
 
+Packages whose files vet has already found no problems in are not
+checked again; see 'go help cache'.
+
 For more about vet, see 'godoc vet'.
 For more about specifying packages, see 'go help packages'.
 
@@ -430,6 +579,70 @@ To run the vet tool with specific options, run 'go tool vet'.
 See also: go fmt, go fix.
 
 
+Build and test caching
+
+The go command caches build outputs for reuse in future builds.
+A package is compiled again only when something that could change
+the result has changed: the contents of its source files, the
+compiler and assembler flags, the build tags, the target operating
+system and architecture, the compiled form of its dependencies, or
+the toolchain binaries themselves.  File modification times play no
+part, so a fresh checkout of unchanged sources builds from the cache.
+Linked commands and test binaries are cached the same way, as are
+passing test results (see 'go help test') and packages in which
+'go vet' found nothing to report.
+
+The cache is stored in the directory named by the GOCACHE environment
+variable.  If GOCACHE is not set, the default is go-build in the
+user's cache directory: $XDG_CACHE_HOME or $HOME/.cache on Unix
+systems, $HOME/Library/Caches on OS X, and %LocalAppData% on Windows.
+Setting GOCACHE=off disables the cache.  Because entries are named by
+the hash of their inputs, several go commands, and several checkouts,
+can safely share one cache directory, and a continuous build can
+save and restore it between runs.
+
+The cache grows without bound.  'go clean -cache' removes it entirely,
+and 'go clean -testcache' removes just the test results.  The -a build
+flag bypasses the cache.  Packages compiled with gccgo are not cached.
+
+
+Pinned dependency versions
+
+By default, the go command builds against whatever revision of a
+remote repository happens to be checked out in GOPATH, and 'go get'
+downloads the current revision.  A project can instead pin the
+revisions of the repositories it depends on, so that every build of
+the project uses the same code.
+
+The pins are listed in a file named go.deps in the project's top
+directory.  The go command uses the go.deps file in the current
+directory or the nearest directory above it.  Each line names the
+import path of a repository root and the revision to use, in any
+form the repository's version control system accepts; a commit
+identifier is best, because it cannot be moved.  A # starts a comment.
+
+    # go.deps
+    code.google.com/p/go.net      4b1a9ab7b0da
+    github.com/user/project       8f41a61b8e2d6e5d4d2b9c8e3c11a5b7a6ab4e9f
+
+'go get' checks each pinned repository out at its revision into a
+read-only tree in the first GOPATH directory, named
+DIR/pkg/dep/ROOT@REVISION, and records a checksum of the files in
+a file named go.sum next to go.deps.  Commit both files to the
+project's repository.  If go.sum already has a checksum for the
+revision, 'go get' refuses a checkout that does not match it.
+
+When building, testing or listing packages, an import of a package in
+a pinned repository is resolved only in that repository's read-only
+tree, never in the usual GOPATH directories, and the tree is checked
+against go.sum first.  Packages built from it are installed in the
+pkg/ directory of the tree.  Imports of packages in repositories that
+are not pinned are resolved as usual.
+
+To move to a new revision, edit go.deps and run 'go get'.
+Subversion repositories cannot be pinned.
+
+
 GOPATH environment variable
 
 The Go path is used to resolve import statements.
@@ -489,6 +702,18 @@ Go searches each directory listed in GOPATH to find source code,
 but new packages are always downloaded into the first directory 
 in the list.
 
+Code below a directory named "vendor" can be imported only by code
+in the directory tree rooted at the parent of "vendor", and only
+using the import path below "vendor".  In the layout above, if
+DIR/src/foo/vendor/example.com/dep holds Go code, then an import of
+"example.com/dep" in foo/quux/y.go uses that copy, even if
+DIR/src/example.com/dep exists.  The go command searches the vendor
+directory of the importing package's directory first, then those of
+its parents, stopping at the top of the tree.  Commands such as
+'go list' report a vendored package by its full import path, here
+"foo/vendor/example.com/dep", and 'go get' does not download or
+update it separately from the repository holding it.
+
 
 Description of package lists
 
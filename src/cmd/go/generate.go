@@ -0,0 +1,389 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var cmdGenerate = &Command{
+	UsageLine: "generate [-run regexp] [-n] [-v] [-x] [file.go... | packages]",
+	Short:     "generate Go files by processing source",
+	Long: `
+Generate runs commands described by directives within existing
+files.  Those commands can run any process but the intent is to
+create or update Go source files, for instance by running yacc.
+
+Go generate is never run automatically by go build, go get, go test,
+and so on.  It must be run explicitly.
+
+Go generate scans the file for directives, which are lines of
+the form,
+
+	//go:generate command argument...
+
+(note: no leading spaces and no space in "//go") where command
+is the generator to be run, corresponding to an executable file
+that can be run locally.  It must either be in the shell path
+(gofmt), a fully qualified path (/usr/you/bin/mytool), or a
+command alias, described below.
+
+The arguments to the directive are space-separated tokens or
+double-quoted strings passed to the generator as individual
+arguments when it is run.
+
+Quoted strings use Go syntax and are evaluated before execution; a
+quoted string appears as a single argument to the generator.
+
+Go generate sets several variables when it runs the generator:
+
+	$GOARCH
+		The execution architecture (arm, amd64, etc.)
+	$GOOS
+		The execution operating system (linux, windows, etc.)
+	$GOFILE
+		The base name of the file.
+	$GOPACKAGE
+		The name of the package of the file containing the directive.
+	$DOLLAR
+		A dollar sign.
+
+Other than variable substitution and quoted-string evaluation, no
+special processing such as "globbing" is performed on the command
+line.  The variables are also set in the environment of the
+generator, which runs in the directory holding the file.
+
+Directives are processed one file at a time, in the order the files
+appear in the package and the directives appear in each file.  If any
+generator returns an error exit status, "go generate" skips all
+further processing for that package.
+
+The directive
+
+	//go:generate -command xxx args...
+
+specifies, for the remainder of this source file only, that the
+string xxx represents the command identified by the arguments.
+This can be used to create aliases or to handle multiword generators.
+For example,
+
+	//go:generate -command yacc go tool yacc
+
+specifies that the command "yacc" represents the generator
+"go tool yacc".
+
+Generate processes packages in the order given on the command line,
+one at a time.  If the command line lists .go files, they are treated
+as a single package.  Only the files that would be compiled, after
+applying build constraints, are scanned; test files are included.
+
+The -run flag takes a regular expression and causes generate to run
+only the directives whose full original source text (excluding any
+trailing spaces and final newline) matches the expression.
+
+The -n flag prints the commands that would be run but does not run them.
+The -v flag prints the names of packages and files as they are processed.
+The -x flag prints the commands as they are run.
+
+For more about specifying packages, see 'go help packages'.
+	`,
+}
+
+var (
+	generateRun   string         // -run flag
+	generateRunRE *regexp.Regexp // compiled -run flag
+)
+
+func init() {
+	// break init cycle
+	cmdGenerate.Run = runGenerate
+
+	cmdGenerate.Flag.StringVar(&generateRun, "run", "", "")
+	cmdGenerate.Flag.BoolVar(&buildN, "n", false, "")
+	cmdGenerate.Flag.BoolVar(&buildV, "v", false, "")
+	cmdGenerate.Flag.BoolVar(&buildX, "x", false, "")
+}
+
+func runGenerate(cmd *Command, args []string) {
+	if generateRun != "" {
+		var err error
+		generateRunRE, err = regexp.Compile(generateRun)
+		if err != nil {
+			fatalf("go generate: -run: %v", err)
+		}
+	}
+	// Even if the arguments are .go files, this loop suffices.
+	for _, pkg := range packages(args) {
+		if buildV {
+			fmt.Fprintf(os.Stderr, "%s\n", pkg.ImportPath)
+		}
+		for _, file := range pkg.gofiles {
+			if !generate(pkg.Name, file) {
+				break
+			}
+		}
+	}
+	exitIfErrors()
+}
+
+// generate runs the generation directives for a single file.
+// It reports whether processing of the package may continue.
+func generate(pkg, absFile string) bool {
+	f, err := os.Open(absFile)
+	if err != nil {
+		errorf("go generate: %s", err)
+		return false
+	}
+	defer f.Close()
+	g := &generator{
+		r:        bufio.NewReader(f),
+		path:     absFile,
+		pkg:      pkg,
+		commands: make(map[string][]string),
+	}
+	return g.run()
+}
+
+// A generator holds the state for the processing of a single file.
+type generator struct {
+	r        *bufio.Reader
+	path     string // full path of the file
+	dir      string // directory holding the file
+	file     string // base name of the file
+	pkg      string // package name
+	lineNum  int
+	commands map[string][]string // aliases set by -command
+}
+
+// generateError is the panic value used to abandon a file.
+type generateError struct{}
+
+// run runs the directives in the file.  It reports whether
+// processing of the package may continue.
+func (g *generator) run() (ok bool) {
+	// Processing below here calls g.errorf on failure, which panics.
+	defer func() {
+		e := recover()
+		if e != nil {
+			if _, isGenErr := e.(generateError); !isGenErr {
+				panic(e)
+			}
+			ok = false
+		}
+	}()
+	g.dir, g.file = filepath.Split(g.path)
+	g.dir = filepath.Clean(g.dir) // No final separator please.
+	if buildV {
+		fmt.Fprintf(os.Stderr, "%s\n", shortPath(g.path))
+	}
+
+	// Scan for lines that start "//go:generate".
+	for {
+		g.lineNum++
+		buf, err := g.r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Line too long - consume and ignore.
+			if isGoGenerate(buf) {
+				g.errorf("directive too long")
+			}
+			for err == bufio.ErrBufferFull {
+				_, err = g.r.ReadSlice('\n')
+			}
+			if err != nil {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			// Check for marker at EOF without final \n.
+			if err == io.EOF && isGoGenerate(buf) {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != io.EOF {
+				g.errorf("error reading %s: %s", shortPath(g.path), err)
+			}
+			break
+		}
+
+		if !isGoGenerate(buf) {
+			continue
+		}
+		if generateRunRE != nil && !generateRunRE.Match(bytes.TrimRight(buf, " \t\r\n")) {
+			continue
+		}
+
+		words := g.split(string(buf))
+		if len(words) == 0 {
+			g.errorf("no arguments to directive")
+		}
+		if words[0] == "-command" {
+			g.setShorthand(words)
+			continue
+		}
+		// Run the command line.
+		if buildN || buildX {
+			fmt.Fprintf(os.Stderr, "%s\n", strings.Join(words, " "))
+		}
+		if buildN {
+			continue
+		}
+		g.exec(words)
+	}
+	return true
+}
+
+// isGoGenerate reports whether buf holds a //go:generate directive.
+func isGoGenerate(buf []byte) bool {
+	return bytes.HasPrefix(buf, []byte("//go:generate ")) || bytes.HasPrefix(buf, []byte("//go:generate\t"))
+}
+
+// split breaks the line into words, evaluating quoted
+// strings and evaluating environment variables.
+// The initial //go:generate element is present in line.
+func (g *generator) split(line string) []string {
+	// Parse line, obeying quoted strings.
+	var words []string
+	line = line[len("//go:generate ") : len(line)-1] // Drop preamble and final newline.
+	// There may still be a carriage return.
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	// One (possibly quoted) word per iteration.
+Words:
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if len(line) == 0 {
+			break
+		}
+		if line[0] == '"' {
+			for i := 1; i < len(line); i++ {
+				c := line[i] // Only looking for ASCII so this is OK.
+				switch c {
+				case '\\':
+					if i+1 == len(line) {
+						g.errorf("bad backslash")
+					}
+					i++ // Absorb next byte (If it's a multibyte we'll get an error in Unquote).
+				case '"':
+					word, err := strconv.Unquote(line[0 : i+1])
+					if err != nil {
+						g.errorf("bad quoted string")
+					}
+					words = append(words, word)
+					line = line[i+1:]
+					// Check the next character is space or end of line.
+					if len(line) > 0 && line[0] != ' ' && line[0] != '\t' {
+						g.errorf("expect space after quoted argument")
+					}
+					continue Words
+				}
+			}
+			g.errorf("mismatched quoted string")
+		}
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			i = len(line)
+		}
+		words = append(words, line[0:i])
+		line = line[i:]
+	}
+	// Substitute command if required.
+	if len(words) > 0 && g.commands[words[0]] != nil {
+		// Replace 0th word by command substitution.
+		words = append(append([]string{}, g.commands[words[0]]...), words[1:]...)
+	}
+	// Substitute environment variables.
+	expand := func(word string) string { return g.expandVar(word) }
+	for i, word := range words {
+		words[i] = os.Expand(word, expand)
+	}
+	return words
+}
+
+// errorf logs an error message prefixed with the file and line number.
+// It then exits the program (with exit status 1) because generation stops
+// at the first error.
+func (g *generator) errorf(format string, args ...interface{}) {
+	errorf("%s:%d: %s", shortPath(g.path), g.lineNum, fmt.Sprintf(format, args...))
+	panic(generateError{})
+}
+
+// expandVar expands the $XXX invocation in word.  It is called
+// by os.Expand.
+func (g *generator) expandVar(word string) string {
+	switch word {
+	case "GOARCH":
+		return buildContext.GOARCH
+	case "GOOS":
+		return buildContext.GOOS
+	case "GOFILE":
+		return g.file
+	case "GOPACKAGE":
+		return g.pkg
+	case "DOLLAR":
+		return "$"
+	}
+	return os.Getenv(word)
+}
+
+// setShorthand installs a new shorthand as defined by a -command directive.
+func (g *generator) setShorthand(words []string) {
+	// Create command shorthand.
+	if len(words) == 1 {
+		g.errorf("no command specified for -command")
+	}
+	command := words[1]
+	if g.commands[command] != nil {
+		g.errorf("command %q defined more than once", command)
+	}
+	g.commands[command] = append([]string{}, words[2:]...)
+}
+
+// exec runs the command specified by the argument. The first word is
+// the command name itself.
+func (g *generator) exec(words []string) {
+	cmd := exec.Command(words[0], words[1:]...)
+	// Standard in and out of generator should be the usual.
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Run the command in the package directory.
+	cmd.Dir = g.dir
+	cmd.Env = mergeEnvLists([]string{
+		"GOARCH=" + buildContext.GOARCH,
+		"GOOS=" + buildContext.GOOS,
+		"GOFILE=" + g.file,
+		"GOPACKAGE=" + g.pkg,
+	}, os.Environ())
+	err := cmd.Run()
+	if err != nil {
+		g.errorf("running %q: %s", words[0], err)
+	}
+}
+
+// mergeEnvLists merges the two environment lists such that
+// variables with the same name in in replace those in out.
+func mergeEnvLists(in, out []string) []string {
+NextVar:
+	for _, inkv := range in {
+		k := strings.SplitAfterN(inkv, "=", 2)[0]
+		for i, outkv := range out {
+			if strings.HasPrefix(outkv, k) {
+				out[i] = inkv
+				continue NextVar
+			}
+		}
+		out = append(out, inkv)
+	}
+	return out
+}
@@ -37,6 +37,10 @@ The -u flag instructs get to use the network to update the named packages
 and their dependencies.  By default, get uses the network to check out
 missing packages but does not use it to look for updates to existing packages.
 
+In a project with a go.deps file, get checks out the dependencies listed
+there at their pinned revisions instead, and never updates them.
+See 'go help deps'.
+
 When checking out or updating a package, get looks for a branch or
 tag that matches the locally installed version of Go. If the local
 version "is release.rNN", it searches for "go.rNN". (For an
@@ -142,6 +146,19 @@ var downloadRootCache = map[string]bool{}
 // download runs the download half of the get command
 // for the package named by the argument.
 func download(arg string, stk *importStack) {
+	// A pinned dependency is checked out at its pinned revision
+	// before loading, and never updated.
+	pin := projectPins().lookup(arg)
+	if pin != nil && !downloadCache[arg] {
+		if err := projectPins().download(pin); err != nil {
+			stk.push(arg)
+			errorf("%s", &PackageError{ImportStack: stk.copy(), Err: err.Error()})
+			stk.pop()
+			downloadCache[arg] = true
+			return
+		}
+	}
+
 	p := loadPackage(arg, stk)
 
 	// There's nothing to do if this is a package in the standard library.
@@ -159,7 +176,9 @@ func download(arg string, stk *importStack) {
 	wildcardOkay := len(*stk) == 0
 
 	// Download if the package is missing, or update if we're using -u.
-	if p.Dir == "" || *getU {
+	// A package in a vendor directory is part of the repository
+	// holding that directory and is never downloaded by itself.
+	if pin == nil && vendorlessImportPath(p.ImportPath) == p.ImportPath && (p.Dir == "" || *getU) {
 		// The actual download.
 		stk.push(p.ImportPath)
 		err := downloadPackage(p)
@@ -234,5 +234,87 @@ Here's an example directory layout:
 Go searches each directory listed in GOPATH to find source code,
 but new packages are always downloaded into the first directory 
 in the list.
+
+Code below a directory named "vendor" can be imported only by code
+in the directory tree rooted at the parent of "vendor", and only
+using the import path below "vendor".  In the layout above, if
+DIR/src/foo/vendor/example.com/dep holds Go code, then an import of
+"example.com/dep" in foo/quux/y.go uses that copy, even if
+DIR/src/example.com/dep exists.  The go command searches the vendor
+directory of the importing package's directory first, then those of
+its parents, stopping at the top of the tree.  Commands such as
+'go list' report a vendored package by its full import path, here
+"foo/vendor/example.com/dep", and 'go get' does not download or
+update it separately from the repository holding it.
+	`,
+}
+
+var helpDeps = &Command{
+	UsageLine: "deps",
+	Short:     "pinned dependency versions",
+	Long: `
+By default, the go command builds against whatever revision of a
+remote repository happens to be checked out in GOPATH, and 'go get'
+downloads the current revision.  A project can instead pin the
+revisions of the repositories it depends on, so that every build of
+the project uses the same code.
+
+The pins are listed in a file named go.deps in the project's top
+directory.  The go command uses the go.deps file in the current
+directory or the nearest directory above it.  Each line names the
+import path of a repository root and the revision to use, in any
+form the repository's version control system accepts; a commit
+identifier is best, because it cannot be moved.  A # starts a comment.
+
+    # go.deps
+    code.google.com/p/go.net      4b1a9ab7b0da
+    github.com/user/project       8f41a61b8e2d6e5d4d2b9c8e3c11a5b7a6ab4e9f
+
+'go get' checks each pinned repository out at its revision into a
+read-only tree in the first GOPATH directory, named
+DIR/pkg/dep/ROOT@REVISION, and records a checksum of the files in
+a file named go.sum next to go.deps.  Commit both files to the
+project's repository.  If go.sum already has a checksum for the
+revision, 'go get' refuses a checkout that does not match it.
+
+When building, testing or listing packages, an import of a package in
+a pinned repository is resolved only in that repository's read-only
+tree, never in the usual GOPATH directories, and the tree is checked
+against go.sum first.  Packages built from it are installed in the
+pkg/ directory of the tree.  Imports of packages in repositories that
+are not pinned are resolved as usual.
+
+To move to a new revision, edit go.deps and run 'go get'.
+Subversion repositories cannot be pinned.
+	`,
+}
+
+var helpCache = &Command{
+	UsageLine: "cache",
+	Short:     "build and test caching",
+	Long: `
+The go command caches build outputs for reuse in future builds.
+A package is compiled again only when something that could change
+the result has changed: the contents of its source files, the
+compiler and assembler flags, the build tags, the target operating
+system and architecture, the compiled form of its dependencies, or
+the toolchain binaries themselves.  File modification times play no
+part, so a fresh checkout of unchanged sources builds from the cache.
+Linked commands and test binaries are cached the same way, as are
+passing test results (see 'go help test') and packages in which
+'go vet' found nothing to report.
+
+The cache is stored in the directory named by the GOCACHE environment
+variable.  If GOCACHE is not set, the default is go-build in the
+user's cache directory: $XDG_CACHE_HOME or $HOME/.cache on Unix
+systems, $HOME/Library/Caches on OS X, and %LocalAppData% on Windows.
+Setting GOCACHE=off disables the cache.  Because entries are named by
+the hash of their inputs, several go commands, and several checkouts,
+can safely share one cache directory, and a continuous build can
+save and restore it between runs.
+
+The cache grows without bound.  'go clean -cache' removes it entirely,
+and 'go clean -testcache' removes just the test results.  The -a build
+flag bypasses the cache.  Packages compiled with gccgo are not cached.
 	`,
 }
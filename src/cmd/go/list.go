@@ -13,7 +13,7 @@ import (
 )
 
 var cmdList = &Command{
-	UsageLine: "list [-e] [-f format] [-json] [packages]",
+	UsageLine: "list [-e] [-f format] [-json] [-tags 'tag list'] [packages]",
 	Short:     "list packages",
 	Long: `
 List lists the packages named by the import paths, one per line.
@@ -43,6 +43,7 @@ being passed to the template is:
         // Source files
         GoFiles  []string  // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
         CgoFiles []string  // .go sources files that import "C"
+        IgnoredGoFiles []string // .go sources ignored due to build constraints
         CFiles   []string  // .c source files
         HFiles   []string  // .h source files
         SFiles   []string  // .s source files
@@ -81,6 +82,9 @@ printing.  Erroneous packages will have a non-empty ImportPath and
 a non-nil Error field; other information may or may not be missing
 (zeroed).
 
+The -tags flag lists build tags to consider satisfied when deciding
+which files make up each package, as in 'go build'.
+
 For more about specifying packages, see 'go help packages'.
 	`,
 }
@@ -88,6 +92,7 @@ For more about specifying packages, see 'go help packages'.
 func init() {
 	cmdList.Run = runList // break init cycle
 	cmdList.Flag.Var(buildCompiler{}, "compiler", "")
+	cmdList.Flag.Var((*stringsFlag)(&buildContext.BuildTags), "tags", "")
 }
 
 var listE = cmdList.Flag.Bool("e", false, "")
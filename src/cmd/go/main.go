@@ -80,6 +80,7 @@ var commands = []*Command{
 	cmdEnv,
 	cmdFix,
 	cmdFmt,
+	cmdGenerate,
 	cmdGet,
 	cmdInstall,
 	cmdList,
@@ -89,6 +90,8 @@ var commands = []*Command{
 	cmdVersion,
 	cmdVet,
 
+	helpCache,
+	helpDeps,
 	helpGopath,
 	helpPackages,
 	helpRemote,
@@ -0,0 +1,411 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Pinned dependencies.  See 'go help deps' for the file formats.
+
+const (
+	depsFile = "go.deps"
+	sumFile  = "go.sum"
+)
+
+// A pin fixes the revision of a repository named in a go.deps file.
+type pin struct {
+	root string // import path of the repository root
+	rev  string // revision to use
+
+	verified bool  // checksum has been checked
+	err      error // result of the check
+}
+
+// A pinSet is the set of pins that applies to the current project.
+type pinSet struct {
+	dir  string            // directory holding go.deps and go.sum
+	pins []*pin            // pins, in go.deps order
+	sums map[string]string // checksum by "root rev"
+}
+
+var (
+	projectPinsLoaded bool
+	projectPinsCache  *pinSet
+)
+
+// projectPins returns the pins of the project containing the
+// current directory, or nil if there is no go.deps file in the
+// current directory or any parent.
+func projectPins() *pinSet {
+	if projectPinsLoaded {
+		return projectPinsCache
+	}
+	projectPinsLoaded = true
+	for dir := cwd; ; {
+		file := filepath.Join(dir, depsFile)
+		if _, err := os.Stat(file); err == nil {
+			ps, err := readPins(dir)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			projectPinsCache = ps
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return projectPinsCache
+}
+
+// readPins reads the go.deps and go.sum files in dir.
+func readPins(dir string) (*pinSet, error) {
+	ps := &pinSet{dir: dir, sums: map[string]string{}}
+	data, err := ioutil.ReadFile(filepath.Join(dir, depsFile))
+	if err != nil {
+		return nil, err
+	}
+	ps.pins, err = parseDeps(filepath.Join(dir, depsFile), data)
+	if err != nil {
+		return nil, err
+	}
+	data, err = ioutil.ReadFile(filepath.Join(dir, sumFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ps.sums, err = parseSums(filepath.Join(dir, sumFile), data)
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// parseDeps parses the contents of a go.deps file.
+func parseDeps(file string, data []byte) ([]*pin, error) {
+	var pins []*pin
+	seen := map[string]bool{}
+	for i, line := range strings.Split(string(data), "\n") {
+		if j := strings.Index(line, "#"); j >= 0 {
+			line = line[:j]
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected import path and revision", file, i+1)
+		}
+		root := f[0]
+		if build.IsLocalImport(root) || !strings.Contains(root, ".") {
+			return nil, fmt.Errorf("%s:%d: %s is not a remote import path", file, i+1, root)
+		}
+		if seen[root] {
+			return nil, fmt.Errorf("%s:%d: %s listed twice", file, i+1, root)
+		}
+		seen[root] = true
+		pins = append(pins, &pin{root: root, rev: f[1]})
+	}
+	return pins, nil
+}
+
+// parseSums parses the contents of a go.sum file.
+func parseSums(file string, data []byte) (map[string]string, error) {
+	sums := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected import path, revision and checksum", file, i+1)
+		}
+		sums[f[0]+" "+f[1]] = f[2]
+	}
+	return sums, nil
+}
+
+// lookup returns the pin for the repository containing
+// the package with the given import path, or nil if none.
+func (ps *pinSet) lookup(path string) *pin {
+	if ps == nil {
+		return nil
+	}
+	for _, p := range ps.pins {
+		if path == p.root || strings.HasPrefix(path, p.root+"/") {
+			return p
+		}
+	}
+	return nil
+}
+
+// roots returns the roots of the pinned checkouts.
+func (ps *pinSet) roots() []string {
+	if ps == nil {
+		return nil
+	}
+	var list []string
+	for _, p := range ps.pins {
+		if root, err := p.pinRoot(); err == nil {
+			list = append(list, root)
+		}
+	}
+	return list
+}
+
+// writeSums rewrites the go.sum file.
+func (ps *pinSet) writeSums() error {
+	var lines []string
+	for key, sum := range ps.sums {
+		lines = append(lines, key+" "+sum+"\n")
+	}
+	sort.Strings(lines)
+	return ioutil.WriteFile(filepath.Join(ps.dir, sumFile), []byte(strings.Join(lines, "")), 0666)
+}
+
+// pinRoot returns the root of the read-only tree holding
+// the pinned revision of p's repository.  The tree is laid out
+// like a GOPATH entry: the sources are in src/ under the root,
+// and packages built from them are installed in pkg/.
+func (p *pin) pinRoot() (string, error) {
+	list := filepath.SplitList(buildContext.GOPATH)
+	if len(list) == 0 || list[0] == goroot {
+		return "", fmt.Errorf("pinned dependency %s: GOPATH not set", p.root)
+	}
+	return filepath.Join(list[0], "pkg", "dep", filepath.FromSlash(p.root)+"@"+p.rev), nil
+}
+
+// srcDir returns the directory holding the pinned checkout.
+func (p *pin) srcDir() (string, error) {
+	root, err := p.pinRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "src", filepath.FromSlash(p.root)), nil
+}
+
+// verify checks the pinned checkout against the checksum in go.sum.
+func (ps *pinSet) verify(p *pin) error {
+	if p.verified {
+		return p.err
+	}
+	p.verified = true
+	p.err = ps.verify1(p)
+	return p.err
+}
+
+func (ps *pinSet) verify1(p *pin) error {
+	dir, err := p.srcDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("pinned dependency %s@%s not downloaded; run 'go get'", p.root, p.rev)
+	}
+	want := ps.sums[p.root+" "+p.rev]
+	if want == "" {
+		return fmt.Errorf("pinned dependency %s@%s has no checksum in %s; run 'go get'", p.root, p.rev, filepath.Join(ps.dir, sumFile))
+	}
+	sum, err := hashDir(dir)
+	if err != nil {
+		return err
+	}
+	if sum != want {
+		return fmt.Errorf("pinned dependency %s@%s: checksum mismatch\n\t%s: %s\n\t%s: %s", p.root, p.rev, dir, sum, sumFile, want)
+	}
+	return nil
+}
+
+// importPinned is like buildContext.Import for a package in
+// a pinned repository.  It looks only in the pinned checkout.
+func (ps *pinSet) importPinned(p *pin, path, srcDir string) (*build.Package, error) {
+	if err := ps.verify(p); err != nil {
+		return &build.Package{ImportPath: path}, err
+	}
+	root, _ := p.pinRoot()
+	ctxt := buildContext
+	ctxt.GOPATH = root
+	return ctxt.Import(path, srcDir, 0)
+}
+
+// download creates the read-only checkout of p's repository at
+// the pinned revision, if it does not exist yet, and records or
+// checks its checksum in go.sum.
+func (ps *pinSet) download(p *pin) error {
+	dir, err := p.srcDir()
+	if err != nil {
+		return err
+	}
+	exists := false
+	if _, err := os.Stat(dir); err == nil {
+		if ps.sums[p.root+" "+p.rev] != "" {
+			return ps.verify(p)
+		}
+		// Checked out by another project, but with no checksum
+		// here.  The checkout may have been altered since, so
+		// download the revision again and record the checksum
+		// only if the two agree.
+		exists = true
+	}
+
+	rr, err := repoRootForImportPath(p.root)
+	if err != nil {
+		return err
+	}
+	if rr.root != p.root {
+		return fmt.Errorf("pinned dependency %s: not a repository root (root is %s)", p.root, rr.root)
+	}
+	if rr.vcs.tagSyncCmd == "" {
+		return fmt.Errorf("pinned dependency %s: cannot select revisions in %s", p.root, rr.vcs)
+	}
+	if buildV {
+		fmt.Fprintf(os.Stderr, "%s@%s (download)\n", p.root, p.rev)
+	}
+	if buildN {
+		fmt.Fprintf(os.Stderr, "# %s %s %s; %s sync %s\n", rr.vcs.cmd, rr.repo, dir, rr.vcs.cmd, p.rev)
+		return nil
+	}
+
+	// Check out into a temporary directory next to the final one,
+	// so that an interrupted download leaves nothing behind that
+	// looks like a complete checkout.
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(tmp), 0777); err != nil {
+		return err
+	}
+	if err := rr.vcs.create(tmp, rr.repo); err != nil {
+		return err
+	}
+	if err := rr.vcs.revSync(tmp, p.rev); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	// The checkout is not a working copy; drop the metadata.
+	if err := os.RemoveAll(filepath.Join(tmp, "."+rr.vcs.cmd)); err != nil {
+		return err
+	}
+	if exists {
+		defer os.RemoveAll(tmp)
+		fresh, err := hashDir(tmp)
+		if err != nil {
+			return err
+		}
+		have, err := hashDir(dir)
+		if err != nil {
+			return err
+		}
+		if have != fresh {
+			return fmt.Errorf("pinned dependency %s@%s: checkout differs from repository\n\t%s: %s\n\tdownloaded: %s\n\tremove %s and run 'go get' again", p.root, p.rev, dir, have, fresh, dir)
+		}
+		return ps.record(p, dir)
+	}
+	if err := ps.record(p, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return err
+	}
+	return makeReadOnly(dir)
+}
+
+// record checks the checkout in dir against go.sum,
+// adding its checksum to go.sum if it is not there.
+func (ps *pinSet) record(p *pin, dir string) error {
+	sum, err := hashDir(dir)
+	if err != nil {
+		return err
+	}
+	key := p.root + " " + p.rev
+	if want := ps.sums[key]; want != "" {
+		if sum != want {
+			return fmt.Errorf("pinned dependency %s@%s: checksum mismatch\n\tdownloaded: %s\n\t%s: %s", p.root, p.rev, sum, sumFile, want)
+		}
+	} else {
+		ps.sums[key] = sum
+		if err := ps.writeSums(); err != nil {
+			return err
+		}
+	}
+	p.verified = true
+	return nil
+}
+
+// hashDir returns a checksum of the files in the tree rooted at dir.
+// The checksum covers the names and contents of the files but not
+// their modes or times, so it is the same for every copy of the tree.
+func hashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeType == 0 {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		fh := sha256.New()
+		_, err = io.Copy(fh, bufio.NewReader(f))
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%x  %s\n", fh.Sum(nil), file)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+// makeReadOnly removes write permission from the tree rooted at dir.
+func makeReadOnly(dir string) error {
+	// Walk visits a directory before its contents, so collect
+	// the directories and change them after the files.
+	var dirs []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+		return os.Chmod(path, fi.Mode()&^0222)
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i], 0555); err != nil {
+			return err
+		}
+	}
+	return nil
+}
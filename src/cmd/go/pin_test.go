@@ -0,0 +1,99 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var parseDepsTests = []struct {
+	in   string
+	pins []string
+	err  bool
+}{
+	{"", nil, false},
+	{"# nothing\n\n", nil, false},
+	{"code.google.com/p/x 1234abcd\ngithub.com/u/p v1 # tag\n", []string{"code.google.com/p/x@1234abcd", "github.com/u/p@v1"}, false},
+	{"code.google.com/p/x\n", nil, true},
+	{"./local 1\n", nil, true},
+	{"fmt 1\n", nil, true},
+	{"a.com/x 1\na.com/x 2\n", nil, true},
+}
+
+func TestParseDeps(t *testing.T) {
+	for _, tt := range parseDepsTests {
+		pins, err := parseDeps("go.deps", []byte(tt.in))
+		if (err != nil) != tt.err {
+			t.Errorf("parseDeps(%q): error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		var got []string
+		for _, p := range pins {
+			got = append(got, p.root+"@"+p.rev)
+		}
+		if len(got) != len(tt.pins) {
+			t.Errorf("parseDeps(%q) = %v, want %v", tt.in, got, tt.pins)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.pins[i] {
+				t.Errorf("parseDeps(%q) = %v, want %v", tt.in, got, tt.pins)
+				break
+			}
+		}
+	}
+}
+
+func TestPinLookup(t *testing.T) {
+	pins, _ := parseDeps("go.deps", []byte("code.google.com/p/x 1\n"))
+	ps := &pinSet{pins: pins}
+	for path, want := range map[string]bool{
+		"code.google.com/p/x":     true,
+		"code.google.com/p/x/y/z": true,
+		"code.google.com/p/xy":    false,
+		"code.google.com/p":       false,
+	} {
+		if got := ps.lookup(path) != nil; got != want {
+			t.Errorf("lookup(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) {
+		name = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(name), 0777)
+		if err := ioutil.WriteFile(name, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n")
+	write("sub/b.go", "package b\n")
+	h1, err := hashDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "a.go"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := hashDir(dir)
+	if h1 != h2 {
+		t.Errorf("checksum depends on file mode: %s, %s", h1, h2)
+	}
+	write("sub/b.go", "package c\n")
+	h3, _ := hashDir(dir)
+	if h1 == h3 {
+		t.Errorf("checksum did not change with file contents")
+	}
+}
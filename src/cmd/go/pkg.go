@@ -36,12 +36,13 @@ type Package struct {
 	Root       string `json:",omitempty"` // Go root or Go path dir containing this package
 
 	// Source files
-	GoFiles   []string `json:",omitempty"` // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
-	CgoFiles  []string `json:",omitempty"` // .go sources files that import "C"
-	CFiles    []string `json:",omitempty"` // .c source files
-	HFiles    []string `json:",omitempty"` // .h source files
-	SFiles    []string `json:",omitempty"` // .s source files
-	SysoFiles []string `json:",omitempty"` // .syso system object files added to package
+	GoFiles        []string `json:",omitempty"` // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
+	CgoFiles       []string `json:",omitempty"` // .go sources files that import "C"
+	IgnoredGoFiles []string `json:",omitempty"` // .go sources ignored due to build constraints
+	CFiles         []string `json:",omitempty"` // .c source files
+	HFiles         []string `json:",omitempty"` // .h source files
+	SFiles         []string `json:",omitempty"` // .s source files
+	SysoFiles      []string `json:",omitempty"` // .syso system object files added to package
 
 	// Cgo directives
 	CgoCFLAGS    []string `json:",omitempty"` // cgo: flags for C compiler
@@ -90,6 +91,7 @@ func (p *Package) copyBuild(pp *build.Package) {
 	p.Standard = p.Goroot && p.ImportPath != "" && !strings.Contains(p.ImportPath, ".")
 	p.GoFiles = pp.GoFiles
 	p.CgoFiles = pp.CgoFiles
+	p.IgnoredGoFiles = pp.IgnoredGoFiles
 	p.CFiles = pp.CFiles
 	p.HFiles = pp.HFiles
 	p.SFiles = pp.SFiles
@@ -200,10 +202,14 @@ func loadImport(path string, srcDir string, stk *importStack, importPos []token.
 	// For a local import the identifier is the pseudo-import path
 	// we create from the full directory to the package.
 	// Otherwise it is the usual import path.
+	// A path that refers to a copy in a vendor directory is
+	// identified by the full import path of that copy.
 	importPath := path
 	isLocal := build.IsLocalImport(path)
 	if isLocal {
 		importPath = dirToImportPath(filepath.Join(srcDir, path))
+	} else if srcDir != "" {
+		importPath = vendoredImportPath(path, srcDir)
 	}
 	if p := packageCache[importPath]; p != nil {
 		return reusePackage(p, stk)
@@ -220,7 +226,17 @@ func loadImport(path string, srcDir string, stk *importStack, importPos []token.
 	//
 	// TODO: After Go 1, decide when to pass build.AllowBinary here.
 	// See issue 3268 for mistakes to avoid.
-	bp, err := buildContext.Import(path, srcDir, 0)
+	var bp *build.Package
+	var err error
+	if importPath != path && !isLocal {
+		ctxt := vendorContext()
+		bp, err = ctxt.Import(path, srcDir, 0)
+	} else if pin := projectPins().lookup(path); pin != nil && !isLocal {
+		// A pinned dependency is found only in its pinned checkout.
+		bp, err = projectPins().importPinned(pin, path, srcDir)
+	} else {
+		bp, err = buildContext.Import(path, srcDir, 0)
+	}
 	bp.ImportPath = importPath
 	if gobin != "" {
 		bp.BinDir = gobin
@@ -235,6 +251,45 @@ func loadImport(path string, srcDir string, stk *importStack, importPos []token.
 	return p
 }
 
+// vendorContext returns the build context used to resolve imports
+// from vendor directories.  It is buildContext with the pinned
+// checkouts added to the Go path, so that the vendor directories
+// inside pinned repositories are searched too.
+func vendorContext() build.Context {
+	ctxt := buildContext
+	list := filepath.SplitList(ctxt.GOPATH)
+	list = append(list, projectPins().roots()...)
+	ctxt.GOPATH = strings.Join(list, string(filepath.ListSeparator))
+	return ctxt
+}
+
+// vendoredImportPath returns the import path of the package
+// that an import of path in a file in srcDir refers to:
+// the full path of a copy in a vendor directory if there is one,
+// or else path itself.
+func vendoredImportPath(path, srcDir string) string {
+	ctxt := vendorContext()
+	bp, err := ctxt.Import(path, srcDir, build.FindOnly)
+	if err != nil {
+		return path
+	}
+	return bp.ImportPath
+}
+
+// vendorlessImportPath returns the import path that code uses to
+// import the package with the given import path: for a package in
+// a vendor directory, the path below the last vendor element.
+// Other paths are returned unchanged.
+func vendorlessImportPath(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+		return path[i+len("/vendor/"):]
+	}
+	if strings.HasPrefix(path, "vendor/") {
+		return path[len("vendor/"):]
+	}
+	return path
+}
+
 // reusePackage reuses package p to satisfy the import at the top
 // of the import stack stk.  If this use causes an import loop,
 // reusePackage updates p's error information to record the loop.
@@ -260,13 +315,14 @@ func reusePackage(p *Package, stk *importStack) *Package {
 // isGoTool is the list of directories for Go programs that are installed in
 // $GOROOT/pkg/tool.
 var isGoTool = map[string]bool{
-	"cmd/api":      true,
-	"cmd/cgo":      true,
-	"cmd/fix":      true,
-	"cmd/vet":      true,
-	"cmd/yacc":     true,
-	"exp/gotype":   true,
-	"exp/ebnflint": true,
+	"cmd/api":       true,
+	"cmd/cgo":       true,
+	"cmd/fix":       true,
+	"cmd/test2json": true,
+	"cmd/vet":       true,
+	"cmd/yacc":      true,
+	"exp/gotype":    true,
+	"exp/ebnflint":  true,
 }
 
 // expandScanner expands a scanner.List error into all the errors in the list.
@@ -331,7 +387,12 @@ func (p *Package) load(stk *importStack, bp *build.Package, err error) *Package
 		p.target = p.build.PkgObj
 	}
 
-	importPaths := p.Imports
+	// Resolve test imports now, so that they are reported
+	// as the packages the test would use.
+	p.TestImports = vendoredImportPaths(p.TestImports, p.Dir)
+	p.XTestImports = vendoredImportPaths(p.XTestImports, p.Dir)
+
+	importPaths := append([]string{}, p.Imports...)
 	// Packages that use cgo import runtime/cgo implicitly,
 	// except runtime/cgo itself.
 	if len(p.CgoFiles) > 0 && (!p.Standard || p.ImportPath != "runtime/cgo") {
@@ -371,6 +432,19 @@ func (p *Package) load(stk *importStack, bp *build.Package, err error) *Package
 			}
 			path = p1.ImportPath
 			importPaths[i] = path
+		} else if vendorlessImportPath(path) != path && p.Error == nil {
+			// Code in a vendor directory is imported by the path below it.
+			p.Error = &PackageError{
+				ImportStack: stk.copy(),
+				Err:         fmt.Sprintf("must be imported as %s", vendorlessImportPath(path)),
+			}
+			pos := p.build.ImportPos[path]
+			if len(pos) > 0 {
+				p.Error.Pos = pos[0].String()
+			}
+		} else if p1.ImportPath != path {
+			path = p1.ImportPath
+			importPaths[i] = path
 		}
 		deps[path] = true
 		imports = append(imports, p1)
@@ -382,6 +456,7 @@ func (p *Package) load(stk *importStack, bp *build.Package, err error) *Package
 		}
 	}
 	p.imports = imports
+	p.Imports = importPaths[:len(p.Imports)]
 
 	p.Deps = make([]string, 0, len(deps))
 	for dep := range deps {
@@ -408,6 +483,19 @@ func (p *Package) load(stk *importStack, bp *build.Package, err error) *Package
 	return p
 }
 
+// vendoredImportPaths returns the result of vendoredImportPath
+// for each of the paths imported from srcDir.
+func vendoredImportPaths(paths []string, srcDir string) []string {
+	var out []string
+	for _, path := range paths {
+		if path != "C" && !build.IsLocalImport(path) {
+			path = vendoredImportPath(path, srcDir)
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
 // packageList returns the list of packages in the dag rooted at roots
 // as visited in a depth-first post-order traversal.
 func packageList(roots []*Package) []*Package {
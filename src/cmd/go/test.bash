@@ -119,6 +119,119 @@ elif ! test -x testdata/bin1/helloworld; then
 	ok=false
 fi
 
+# Test that go build records build information,
+# and that the linker accepts -X importpath.name=value.
+if ! GOPATH=$(pwd)/testdata ./testgo build -o hello -ldflags '-X main.greeting=hi' go-cmd-test; then
+	echo "go build go-cmd-test failed"
+	ok=false
+elif ! ./testgo version -m hello | grep -q '^	path	go-cmd-test$'; then
+	echo "go version -m did not report the path of go-cmd-test"
+	./testgo version -m hello
+	ok=false
+fi
+rm -f hello
+
+# Test that imports are satisfied from vendor directories.
+export GOPATH=$(pwd)/testdata/vendor
+if ! ./testgo list -f '{{.Imports}}' vend/hello | grep -q vend/vendor/dep; then
+	echo "go list vend/hello did not report vend/vendor/dep"
+	ok=false
+fi
+if ! ./testgo run testdata/vendor/src/vend/hello/hello.go | grep -q '^vendor$'; then
+	echo "testdata/vendor/src/vend/hello did not use vend/vendor/dep"
+	ok=false
+fi
+if ! ./testgo test vend/hello; then
+	echo "go test vend/hello failed"
+	ok=false
+fi
+unset GOPATH
+
+# Test go generate.
+if ! ./testgo generate ./testdata/generate/test1.go > testdata/std.out; then
+	echo "go generate ./testdata/generate/test1.go failed"
+	ok=false
+elif ! grep -q 'hello world' testdata/std.out; then
+	echo "go generate ./testdata/generate/test1.go generated wrong output"
+	ok=false
+fi
+if ! ./testgo generate ./testdata/generate/test2.go > testdata/std.out; then
+	echo "go generate ./testdata/generate/test2.go failed"
+	ok=false
+elif ! grep -q 'Now is the time for all good men test2.go p a b' testdata/std.out; then
+	echo "go generate ./testdata/generate/test2.go generated wrong output"
+	cat testdata/std.out
+	ok=false
+fi
+if ! ./testgo generate -run 'hello' ./testdata/generate/test2.go > testdata/std.out; then
+	echo "go generate -run ./testdata/generate/test2.go failed"
+	ok=false
+elif [ -s testdata/std.out ]; then
+	echo "go generate -run did not filter directives"
+	ok=false
+fi
+rm -f testdata/std.out
+
+# Test that passing test results are cached.
+export GOPATH=$(pwd)/testdata/vendor
+export GOCACHE=$(pwd)/testdata/cache
+if ! ./testgo test vend/hello >/dev/null; then
+	echo "go test vend/hello failed"
+	ok=false
+elif ! ./testgo test vend/hello | grep -q '(cached)'; then
+	echo "second go test vend/hello did not use the cached result"
+	ok=false
+elif ./testgo test -cpuprofile=/dev/null vend/hello | grep -q '(cached)'; then
+	echo "go test -cpuprofile used a cached result"
+	ok=false
+fi
+if ! ./testgo test -json vend/hello | grep -q '"Action":"pass","Package":"vend/hello"'; then
+	echo "go test -json vend/hello did not report a passing package"
+	ok=false
+fi
+if ./testgo test -json ./testdata/testpanic > testdata/std.out; then
+	echo "go test -json ./testdata/testpanic succeeded"
+	ok=false
+elif ! grep -q '"Action":"fail","Package":"[^"]*testpanic","Test":"TestPanic"' testdata/std.out; then
+	echo "go test -json ./testdata/testpanic did not report TestPanic as failed"
+	cat testdata/std.out
+	ok=false
+fi
+rm -f testdata/std.out
+./testgo clean -testcache
+if ./testgo test vend/hello | grep -q '(cached)'; then
+	echo "go test used a cached result after go clean -testcache"
+	ok=false
+fi
+rm -rf testdata/cache
+unset GOPATH GOCACHE
+
+# Test that -linkmode external hands the final link to the host
+# linker and that the program it writes runs.  The wrapper records
+# that it was called.  This needs gcc and an installed runtime/cgo.
+goos=$(./testgo env GOOS)
+goarch=$(./testgo env GOARCH)
+if [ "$goos/$goarch" != linux/amd64 ] || ! which gcc >/dev/null 2>&1 ||
+	[ ! -f "$(./testgo env GOROOT)/pkg/${goos}_$goarch/runtime/cgo.a" ]; then
+	echo "skipping external linking test: needs linux/amd64, gcc and runtime/cgo"
+else
+	d=$(mktemp -d -t testgoXXX)
+	printf '#!/bin/sh\ntouch %s/called\nexec gcc "$@"\n' "$d" >$d/extld
+	chmod +x $d/extld
+	if ! ./testgo build -linkmode external -ldflags "-extld $d/extld" -o $d/extlink ./testdata/extlink >$d/err 2>&1; then
+		echo "go build -linkmode external failed"
+		cat $d/err
+		ok=false
+	elif [ ! -f $d/called ]; then
+		echo "go build -linkmode external did not run the host linker"
+		ok=false
+	elif [ "$($d/extlink)" != "extlink ok" ]; then
+		echo "externally linked program did not print extlink ok"
+		ok=false
+	fi
+	rm -rf $d
+fi
+
 if $ok; then
 	echo PASS
 else
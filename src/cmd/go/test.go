@@ -12,6 +12,7 @@ import (
 	"go/doc"
 	"go/parser"
 	"go/token"
+	"io"
 	"os"
 	"os/exec"
 	"path"
@@ -55,6 +56,16 @@ with source in the current directory, including tests, and runs the tests.
 The package is built in a temporary directory so it does not interfere with the
 non-test installation.
 
+When packages are named on the command line, go test remembers the
+results of passing tests in the build cache and does not run a test
+again while the test binary, its flags, and the files in the package
+directory and its testdata subdirectory are unchanged.  It prints
+"(cached)" in place of the elapsed time for such tests.  Only the
+-cpu, -parallel, -run, -short, -timeout, and -v test flags allow caching.
+A test that depends on anything else, such as environment variables or
+files elsewhere, should be run with 'go clean -testcache' first.
+See 'go help cache'.
+
 In addition to the build flags, the flags handled by 'go test' itself are:
 
 	-c  Compile the test binary to pkg.test but do not run it.
@@ -63,6 +74,14 @@ In addition to the build flags, the flags handled by 'go test' itself are:
 	    Install packages that are dependencies of the test.
 	    Do not run the test.
 
+	-json
+	    Convert the test output to a stream of JSON events, one per line,
+	    suitable for automated processing.  The test binary is run with
+	    -test.v, and its output is converted as it runs.  The events are
+	    printed as they happen when testing a single package, and a
+	    package at a time otherwise, as the output of -v is.
+	    See 'godoc cmd/test2json' for the encoding.
+
 The test binary also accepts flags that control execution of the test; these
 flags are also accessible by 'go test'.  See 'go help testflag' for details.
 
@@ -192,6 +211,7 @@ See the documentation of the testing package for more information.
 var (
 	testC            bool     // -c flag
 	testI            bool     // -i flag
+	testJSON         bool     // -json flag
 	testV            bool     // -v flag
 	testFiles        []string // -file flag(s)  TODO: not respected
 	testTimeout      string   // -timeout flag
@@ -199,6 +219,7 @@ var (
 	testBench        bool
 	testStreamOutput bool // show output as it is generated
 	testShowPass     bool // show passing output
+	testCacheOK      bool // results of passing tests may be cached
 
 	testKillTimeout = 10 * time.Minute
 )
@@ -239,6 +260,20 @@ func runTest(cmd *Command, args []string) {
 	testStreamOutput = len(pkgArgs) == 0 || testBench ||
 		(len(pkgs) <= 1 && testShowPass)
 
+	// The JSON converter needs the test binary to announce each test.
+	// Its events are streamed under the same conditions as the output
+	// of -v would be.
+	if testJSON {
+		testArgs = append(testArgs, "-test.v=true")
+		testShowPass = true
+		testStreamOutput = len(pkgArgs) == 0 || testBench || len(pkgs) <= 1
+	}
+
+	// Test results are cached only when packages are named
+	// explicitly and the test flags cannot change the outcome
+	// in ways the cache does not track.
+	testCacheOK = len(pkgArgs) > 0 && testCacheable(testArgs)
+
 	var b builder
 	b.init()
 
@@ -247,8 +282,8 @@ func runTest(cmd *Command, args []string) {
 
 		deps := map[string]bool{
 			// Dependencies for testmain.
-			"testing": true,
-			"regexp":  true,
+			"testing":                   true,
+			"testing/internal/testdeps": true,
 		}
 		for _, p := range pkgs {
 			// Dependencies for each test.
@@ -498,17 +533,17 @@ func (b *builder) test(p *Package) (buildAction, runAction, printAction *action,
 		pmain.imports = append(pmain.imports, pxtest)
 	}
 
-	// The generated main also imports testing and regexp.
+	// The generated main also imports testing and testing/internal/testdeps.
 	stk.push("testmain")
 	ptesting := loadImport("testing", "", &stk, nil)
 	if ptesting.Error != nil {
 		return nil, nil, nil, ptesting.Error
 	}
-	pregexp := loadImport("regexp", "", &stk, nil)
-	if pregexp.Error != nil {
-		return nil, nil, nil, pregexp.Error
+	ptestdeps := loadImport("testing/internal/testdeps", "", &stk, nil)
+	if ptestdeps.Error != nil {
+		return nil, nil, nil, ptestdeps.Error
 	}
-	pmain.imports = append(pmain.imports, ptesting, pregexp)
+	pmain.imports = append(pmain.imports, ptesting, ptestdeps)
 	computeStale(pmain)
 
 	if ptest != p {
@@ -565,7 +600,7 @@ func (b *builder) test(p *Package) (buildAction, runAction, printAction *action,
 }
 
 // runTest is the action for running a test binary.
-func (b *builder) runTest(a *action) error {
+func (b *builder) runTest(a *action) (err error) {
 	args := stringList(a.deps[0].target, testArgs)
 	a.testOutput = new(bytes.Buffer)
 
@@ -576,27 +611,73 @@ func (b *builder) runTest(a *action) error {
 		}
 	}
 
+	// w receives the result lines for the test, and the test
+	// binary's output when that is not streamed.  With -json,
+	// everything goes through the converter as the test runs.
+	var w io.Writer = a.testOutput
+	if testJSON {
+		var events io.Writer = a.testOutput
+		if testStreamOutput {
+			events = os.Stdout
+		}
+		conv, cerr := startTest2JSON(a.p, events)
+		if cerr != nil {
+			return cerr
+		}
+		defer func() {
+			if cerr := conv.close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = conv
+	}
+
 	if a.failed {
 		// We were unable to build the binary.
 		a.failed = false
-		fmt.Fprintf(a.testOutput, "FAIL\t%s [build failed]\n", a.p.ImportPath)
+		fmt.Fprintf(w, "FAIL\t%s [build failed]\n", a.p.ImportPath)
 		setExitStatus(1)
 		return nil
 	}
 
+	// Replay the result of an earlier run of the same test.
+	c := openCache()
+	var testID actionID
+	cacheOK := testCacheOK && c != nil && a.deps[0].cacheOK
+	if cacheOK {
+		testID, cacheOK = testActionID(a.deps[0], a.p, testArgs)
+	}
+	if cacheOK {
+		if out, ok := c.getBytes(testID, cacheTest); ok {
+			if testShowPass {
+				w.Write(out)
+			}
+			fmt.Fprintf(w, "ok  \t%s\t(cached)\n", a.p.ImportPath)
+			return nil
+		}
+	}
+
 	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Dir = a.p.Dir
 	var buf bytes.Buffer
-	if testStreamOutput {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
+	streamed := testStreamOutput || testJSON
+	switch {
+	case testJSON:
+		// Keep a copy of the output for the cache.  A single
+		// writer keeps standard output and error in order.
+		cmd.Stdout = io.MultiWriter(w, &buf)
+		cmd.Stderr = cmd.Stdout
+	case testStreamOutput:
+		// Keep a copy of the output for the cache.
+		cmd.Stdout = io.MultiWriter(os.Stdout, &buf)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &buf)
+	default:
 		cmd.Stdout = &buf
 		cmd.Stderr = &buf
 	}
 
 	t0 := time.Now()
-	err := cmd.Start()
+	err = cmd.Start()
 
 	// This is a last-ditch deadline to detect and
 	// stop wedged test binaries, to keep the builders
@@ -613,7 +694,7 @@ func (b *builder) runTest(a *action) error {
 		case <-tick.C:
 			cmd.Process.Kill()
 			err = <-done
-			fmt.Fprintf(&buf, "*** Test killed: ran too long.\n")
+			fmt.Fprintf(cmd.Stdout, "*** Test killed: ran too long.\n")
 		}
 		tick.Stop()
 	}
@@ -621,25 +702,61 @@ func (b *builder) runTest(a *action) error {
 	t1 := time.Now()
 	t := fmt.Sprintf("%.3fs", t1.Sub(t0).Seconds())
 	if err == nil {
-		if testShowPass {
-			a.testOutput.Write(out)
+		if cacheOK {
+			c.putBytes(testID, cacheTest, out)
+		}
+		if testShowPass && !streamed {
+			w.Write(out)
 		}
-		fmt.Fprintf(a.testOutput, "ok  \t%s\t%s\n", a.p.ImportPath, t)
+		fmt.Fprintf(w, "ok  \t%s\t%s\n", a.p.ImportPath, t)
 		return nil
 	}
 
 	setExitStatus(1)
+	if streamed {
+		// The output has been shown already.
+		out = nil
+	}
 	if len(out) > 0 {
-		a.testOutput.Write(out)
+		w.Write(out)
 		// assume printing the test binary's exit status is superfluous
 	} else {
-		fmt.Fprintf(a.testOutput, "%s\n", err)
+		fmt.Fprintf(w, "%s\n", err)
 	}
-	fmt.Fprintf(a.testOutput, "FAIL\t%s\t%s\n", a.p.ImportPath, t)
+	fmt.Fprintf(w, "FAIL\t%s\t%s\n", a.p.ImportPath, t)
 
 	return nil
 }
 
+// A test2json is a running go tool test2json, converting
+// what is written to it into JSON events.
+type test2json struct {
+	io.WriteCloser // standard input of the converter
+	cmd            *exec.Cmd
+}
+
+// startTest2JSON starts go tool test2json for the test of p,
+// writing the events to out.
+func startTest2JSON(p *Package, out io.Writer) (*test2json, error) {
+	cmd := exec.Command(tool("test2json"), "-p", p.ImportPath)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &test2json{stdin, cmd}, nil
+}
+
+// close ends the input of the converter and waits for it to exit.
+func (t *test2json) close() error {
+	t.WriteCloser.Close()
+	return t.cmd.Wait()
+}
+
 // cleanTest is the action for cleaning up after a test.
 func (b *builder) cleanTest(a *action) error {
 	if buildWork {
@@ -662,8 +779,17 @@ func (b *builder) printTest(a *action) error {
 
 // notest is the action for testing a package with no test files.
 func (b *builder) notest(a *action) error {
-	fmt.Printf("?   \t%s\t[no test files]\n", a.p.ImportPath)
-	return nil
+	line := fmt.Sprintf("?   \t%s\t[no test files]\n", a.p.ImportPath)
+	if !testJSON || buildN {
+		fmt.Print(line)
+		return nil
+	}
+	conv, err := startTest2JSON(a.p, os.Stdout)
+	if err != nil {
+		return err
+	}
+	io.WriteString(conv, line)
+	return conv.close()
 }
 
 // isTest tells whether name looks like a test (or benchmark, according to prefix).
@@ -765,8 +891,8 @@ var testmainTmpl = template.Must(template.New("main").Parse(`
 package main
 
 import (
-	"regexp"
 	"testing"
+	"testing/internal/testdeps"
 
 {{if .NeedTest}}
 	_test {{.Package.ImportPath | printf "%q"}}
@@ -794,22 +920,8 @@ var examples = []testing.InternalExample{
 {{end}}
 }
 
-var matchPat string
-var matchRe *regexp.Regexp
-
-func matchString(pat, str string) (result bool, err error) {
-	if matchRe == nil || matchPat != pat {
-		matchPat = pat
-		matchRe, err = regexp.Compile(matchPat)
-		if err != nil {
-			return
-		}
-	}
-	return matchRe.MatchString(str), nil
-}
-
 func main() {
-	testing.Main(matchString, tests, benchmarks, examples)
+	testing.MainStart(testdeps.TestDeps{}, tests, benchmarks, examples)
 }
 
 `))
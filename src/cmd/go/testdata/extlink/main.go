@@ -0,0 +1,13 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "fmt"
+
+func main() {
+	c := make(chan string)
+	go func() { c <- "extlink" }()
+	fmt.Println(<-c, "ok")
+}
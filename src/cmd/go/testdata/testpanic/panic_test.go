@@ -0,0 +1,10 @@
+package p
+
+import "testing"
+
+func TestPass(t *testing.T) {
+}
+
+func TestPanic(t *testing.T) {
+	panic("boom")
+}
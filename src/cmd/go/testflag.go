@@ -59,6 +59,7 @@ var testFlagDefn = []*testFlagSpec{
 	{name: "c", boolVar: &testC},
 	{name: "file", multiOK: true},
 	{name: "i", boolVar: &testI},
+	{name: "json", boolVar: &testJSON},
 
 	// build flags.
 	{name: "a", boolVar: &buildA},
@@ -66,8 +67,10 @@ var testFlagDefn = []*testFlagSpec{
 	{name: "p"},
 	{name: "x", boolVar: &buildX},
 	{name: "work", boolVar: &buildWork},
+	{name: "asmflags"},
 	{name: "gcflags"},
 	{name: "ldflags"},
+	{name: "linkmode"},
 	{name: "gccgoflags"},
 	{name: "tags"},
 	{name: "compiler"},
@@ -129,14 +132,18 @@ func testFlags(args []string) (packageNames, passToTest []string) {
 		}
 		switch f.name {
 		// bool flags.
-		case "a", "c", "i", "n", "x", "v", "work":
+		case "a", "c", "i", "json", "n", "x", "v", "work":
 			setBoolFlag(f.boolVar, value)
 		case "p":
 			setIntFlag(&buildP, value)
+		case "asmflags":
+			buildAsmflags = strings.Fields(value)
 		case "gcflags":
 			buildGcflags = strings.Fields(value)
 		case "ldflags":
 			buildLdflags = strings.Fields(value)
+		case "linkmode":
+			buildLinkmode = value
 		case "gccgoflags":
 			buildGccgoflags = strings.Fields(value)
 		case "tags":
@@ -31,6 +31,8 @@ type vcsCmd struct {
 	tagSyncCmd     string   // command to sync to specific tag
 	tagSyncDefault string   // command to sync to default tag
 
+	revCmd tagCmd // command to print the checked-out revision
+
 	scheme  []string
 	pingCmd string
 }
@@ -81,6 +83,8 @@ var vcsHg = &vcsCmd{
 	tagSyncCmd:     "update -r {tag}",
 	tagSyncDefault: "update default",
 
+	revCmd: tagCmd{"identify -i", `^([0-9a-f]+\+?)`},
+
 	scheme:  []string{"https", "http"},
 	pingCmd: "identify {scheme}://{repo}",
 }
@@ -104,6 +108,8 @@ var vcsGit = &vcsCmd{
 	tagSyncCmd:     "checkout {tag}",
 	tagSyncDefault: "checkout origin/master",
 
+	revCmd: tagCmd{"rev-parse HEAD", `^([0-9a-f]+)`},
+
 	scheme:  []string{"git", "https", "http", "git+ssh"},
 	pingCmd: "ls-remote {scheme}://{repo}",
 }
@@ -123,6 +129,8 @@ var vcsBzr = &vcsCmd{
 	tagSyncCmd:     "update -r {tag}",
 	tagSyncDefault: "update -r revno:-1",
 
+	revCmd: tagCmd{"revno", `^(\d+)`},
+
 	scheme:  []string{"https", "http", "bzr", "bzr+ssh"},
 	pingCmd: "info {scheme}://{repo}",
 }
@@ -138,6 +146,8 @@ var vcsSvn = &vcsCmd{
 	// There is no tag command in subversion.
 	// The branch information is all in the path names.
 
+	revCmd: tagCmd{"info", `^Revision: (\d+)`},
+
 	scheme:  []string{"https", "http", "svn", "svn+ssh"},
 	pingCmd: "info {scheme}://{repo}",
 }
@@ -259,6 +269,26 @@ func (v *vcsCmd) tagSync(dir, tag string) error {
 	return v.run(dir, v.tagSyncCmd, "tag", tag)
 }
 
+// revSync syncs the repo in dir to the given revision,
+// which may be any revision identifier the tool accepts.
+func (v *vcsCmd) revSync(dir, rev string) error {
+	return v.run(dir, v.tagSyncCmd, "tag", rev)
+}
+
+// revision returns the revision checked out in the repo in dir.
+func (v *vcsCmd) revision(dir string) (string, error) {
+	out, err := v.run1(dir, v.revCmd.cmd, nil, false)
+	if err != nil {
+		return "", err
+	}
+	re := regexp.MustCompile(`(?m-s)` + v.revCmd.pattern)
+	m := re.FindSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("cannot find %s revision in %s", v.name, dir)
+	}
+	return string(m[1]), nil
+}
+
 // A vcsPath describes how to convert an import path into a
 // version control system and repository name.
 type vcsPath struct {
@@ -7,19 +7,57 @@ package main
 import (
 	"fmt"
 	"runtime"
+	"strings"
 )
 
 var cmdVersion = &Command{
-	Run:       runVersion,
-	UsageLine: "version",
+	UsageLine: "version [-m] [file...]",
 	Short:     "print Go version",
-	Long:      `Version prints the Go version, as reported by runtime.Version.`,
+	Long: `
+Version prints the Go version, as reported by runtime.Version.
+
+If files are named on the command line, version instead prints the
+Go version that built each executable file, as recorded by the go
+command.  The -m flag causes version to print all the build information
+recorded in each file: the import path of the main package and the
+repositories it was built from, with their revisions.  The information
+is also available to the program itself; see runtime/debug.ReadBuildInfo.
+Only ELF executables are supported.
+	`,
+}
+
+var versionM = cmdVersion.Flag.Bool("m", false, "")
+
+func init() {
+	cmdVersion.Run = runVersion // break init cycle
 }
 
 func runVersion(cmd *Command, args []string) {
-	if len(args) != 0 {
-		cmd.Usage()
+	if len(args) == 0 {
+		if *versionM {
+			cmd.Usage()
+		}
+		fmt.Printf("go version %s\n", runtime.Version())
+		return
 	}
 
-	fmt.Printf("go version %s\n", runtime.Version())
+	for _, file := range args {
+		info, err := readBuildInfo(file)
+		if err != nil {
+			errorf("go version %s: %v", file, err)
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(info, "\n"), "\n")
+		for _, line := range lines {
+			if strings.HasPrefix(line, "go\t") {
+				fmt.Printf("%s: %s\n", file, line[len("go\t"):])
+			}
+		}
+		if *versionM {
+			for _, line := range lines {
+				fmt.Printf("\t%s\n", line)
+			}
+		}
+	}
+	exitIfErrors()
 }
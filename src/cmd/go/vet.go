@@ -4,6 +4,12 @@
 
 package main
 
+import (
+	"bytes"
+	"os"
+	"os/exec"
+)
+
 var cmdVet = &Command{
 	Run:       runVet,
 	UsageLine: "vet [packages]",
@@ -11,6 +17,9 @@ var cmdVet = &Command{
 	Long: `
 Vet runs the Go vet command on the packages named by the import paths.
 
+Packages whose files vet has already found no problems in are not
+checked again; see 'go help cache'.
+
 For more about vet, see 'godoc vet'.
 For more about specifying packages, see 'go help packages'.
 
@@ -21,10 +30,34 @@ See also: go fmt, go fix.
 }
 
 func runVet(cmd *Command, args []string) {
+	c := openCache()
 	for _, pkg := range packages(args) {
+		var id actionID
+		cacheOK := c != nil
+		if cacheOK {
+			id, cacheOK = vetActionID(pkg)
+		}
+		if cacheOK {
+			if _, ok := c.get(id, cacheVet); ok {
+				continue
+			}
+		}
 		// Use pkg.gofiles instead of pkg.Dir so that
 		// the command only applies to this package,
 		// not to packages in subdirectories.
-		run(tool("vet"), relPaths(pkg.gofiles))
+		cmdline := stringList(tool("vet"), relPaths(pkg.gofiles))
+		cmd := exec.Command(cmdline[0], cmdline[1:]...)
+		var buf bytes.Buffer
+		cmd.Stdout = &buf
+		cmd.Stderr = &buf
+		err := cmd.Run()
+		os.Stderr.Write(buf.Bytes())
+		if err != nil {
+			errorf("%v", err)
+		} else if buf.Len() == 0 && cacheOK {
+			// Remember only silent runs: vet prints some
+			// warnings without failing.
+			c.putBytes(id, cacheVet, nil)
+		}
 	}
 }
@@ -0,0 +1,511 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the static analysis mode of godoc (-analysis).
+//
+// In analysis mode, all packages below /src/pkg are type-checked from
+// source with go/types, in a single universe so that types and objects
+// are shared across packages. The results are:
+//
+//	- for each source file, links from every identifier to the
+//	  declaration of the object it denotes (shown in source views),
+//	- for each named type, the interfaces it implements, the types
+//	  implementing it (for interfaces), and its method set,
+//	- for each function and method, its static callers and callees.
+//
+// The call graph is computed from the syntax and type information alone:
+// calls of interface methods and of function values are attributed to
+// the interface method or not at all, respectively.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	pathpkg "path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// analysisResult holds the current *Analysis, if any.
+var analysisResult RWValue
+
+// An Analysis holds the results of the static analysis of all packages.
+type Analysis struct {
+	files map[string]*fileAnalysis    // source links, by file path
+	pkgs  map[string]*PackageAnalysis // package information, by directory path
+}
+
+// fileAnalysis holds the source links of a single file.
+type fileAnalysis struct {
+	size  int    // file size at the time of the analysis
+	links []Link // sorted by Start
+}
+
+// A PackageAnalysis holds the analysis results shown
+// on the documentation page of a package.
+type PackageAnalysis struct {
+	Name  string                   // package name
+	types map[string]*TypeAnalysis // by type name
+	funcs map[string]*FuncAnalysis // by function name or "T.M" for methods
+}
+
+// Type returns the analysis results for the named type, or nil.
+func (p *PackageAnalysis) Type(name string) *TypeAnalysis {
+	return p.types[name]
+}
+
+// Func returns the analysis results for the named function, or nil.
+func (p *PackageAnalysis) Func(name string) *FuncAnalysis {
+	return p.funcs[name]
+}
+
+// Method returns the analysis results for the named method
+// of the type recv, or nil.
+func (p *PackageAnalysis) Method(recv, name string) *FuncAnalysis {
+	return p.funcs[recv+"."+name]
+}
+
+// A TypeAnalysis describes the relations of a named type.
+type TypeAnalysis struct {
+	Implements    []AnalysisRef // interfaces implemented by the type (or a pointer to it)
+	ImplementedBy []AnalysisRef // for interfaces: types implementing it
+	Methods       []string      // method set of the type, or of *T for non-interface types T
+}
+
+// A FuncAnalysis describes the static call relations of a function.
+type FuncAnalysis struct {
+	Callers []AnalysisRef // functions calling the function; linked to the call site
+	Callees []AnalysisRef // functions called by the function; linked to their declaration
+}
+
+// An AnalysisRef is a named link to a source position.
+type AnalysisRef struct {
+	Name string
+	Href string
+}
+
+type refList []AnalysisRef
+
+func (l refList) Len() int           { return len(l) }
+func (l refList) Less(i, j int) bool { return l[i].Name < l[j].Name }
+func (l refList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+type linkList []Link
+
+func (l linkList) Len() int           { return len(l) }
+func (l linkList) Less(i, j int) bool { return l[i].Start < l[j].Start }
+func (l linkList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+// analysisLinks returns the source links for the file with the given
+// path and size, or nil if there are none or they are out of date.
+func analysisLinks(path string, size int) []Link {
+	a, _ := analysisResult.get()
+	if a == nil {
+		return nil
+	}
+	if f := a.(*Analysis).files[path]; f != nil && f.size == size {
+		return f.links
+	}
+	return nil
+}
+
+// analysisPackage returns the analysis results for the package
+// documented by pdoc in directory path, or nil.
+func analysisPackage(path string, pdoc *doc.Package) *PackageAnalysis {
+	a, _ := analysisResult.get()
+	if a == nil || pdoc == nil {
+		return nil
+	}
+	if p := a.(*Analysis).pkgs[path]; p != nil && p.Name == pdoc.Name {
+		return p
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Loading
+
+// An analysisPkg is a package loaded and type-checked by the analyzer.
+type analysisPkg struct {
+	path  string // import path
+	dir   string // directory in the godoc file system
+	files []*ast.File
+	pkg   *types.Package // nil if the package could not be loaded
+	info  types.Info     // only Objects is collected
+}
+
+// An analyzer type-checks packages from source, loading
+// imported packages on demand from the godoc file system.
+type analyzer struct {
+	fset  *token.FileSet
+	ctxt  build.Context
+	conf  types.Config
+	pkgs  map[string]*analysisPkg // by import path
+	order []*analysisPkg          // successfully loaded packages, in load order
+}
+
+func newAnalyzer() *analyzer {
+	a := &analyzer{
+		fset: token.NewFileSet(),
+		ctxt: build.Default,
+		pkgs: make(map[string]*analysisPkg),
+	}
+	a.ctxt.IsAbsPath = pathpkg.IsAbs
+	a.ctxt.ReadDir = fsReadDir
+	a.ctxt.OpenFile = fsOpenFile
+	a.ctxt.CgoEnabled = false     // cgo files cannot be type-checked
+	a.conf.Error = func(error) {} // use partial information; don't stop at errors
+	a.conf.Import = func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		return a.importPkg(imports, path)
+	}
+	return a
+}
+
+// importPkg implements types.Importer.
+func (a *analyzer) importPkg(imports map[string]*types.Package, path string) (*types.Package, error) {
+	p := a.load(path)
+	if p.pkg == nil {
+		return nil, fmt.Errorf("cannot load package %q", path)
+	}
+	imports[path] = p.pkg
+	return p.pkg, nil
+}
+
+// load loads and type-checks the package with the given import path,
+// if it has not been loaded before.
+func (a *analyzer) load(path string) *analysisPkg {
+	if p := a.pkgs[path]; p != nil {
+		return p // loaded, or being loaded (an import cycle)
+	}
+	p := &analysisPkg{path: path, dir: pathpkg.Join("/src/pkg", path)}
+	a.pkgs[path] = p
+
+	bp, err := a.ctxt.ImportDir(p.dir, 0)
+	if err != nil {
+		return p
+	}
+	for _, name := range bp.GoFiles {
+		file, err := parseFile(a.fset, pathpkg.Join(p.dir, name), parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		p.files = append(p.files, file)
+	}
+	if len(p.files) == 0 {
+		return p
+	}
+
+	p.info.Objects = make(map[*ast.Ident]types.Object)
+	pkg, _ := a.conf.Check(path, a.fset, p.files, &p.info)
+	if pkg == nil {
+		return p
+	}
+	p.pkg = pkg
+	a.order = append(a.order, p)
+	return p
+}
+
+// ----------------------------------------------------------------------------
+// Analysis
+
+// analyze computes the Analysis of the loaded packages.
+func (a *analyzer) analyze() *Analysis {
+	res := &Analysis{
+		files: make(map[string]*fileAnalysis),
+		pkgs:  make(map[string]*PackageAnalysis),
+	}
+	for _, p := range a.order {
+		res.pkgs[p.dir] = &PackageAnalysis{
+			Name:  p.pkg.Name(),
+			types: make(map[string]*TypeAnalysis),
+			funcs: make(map[string]*FuncAnalysis),
+		}
+		for _, file := range p.files {
+			a.addLinks(res, p, file)
+		}
+	}
+	a.addTypes(res)
+	a.addCalls(res)
+	return res
+}
+
+// objLink returns the URL of the declaration of obj. Imported packages
+// link to their documentation, predeclared objects to package builtin.
+func (a *analyzer) objLink(obj types.Object) string {
+	if pkg, ok := obj.(*types.PkgName); ok {
+		return "/pkg/" + pkg.Imported().Path() + "/"
+	}
+	if !obj.Pos().IsValid() {
+		switch {
+		case obj.Parent() == types.Universe:
+			return "/pkg/builtin/#" + obj.Name()
+		case obj.Pkg() != nil:
+			return "/pkg/" + obj.Pkg().Path() + "/#" + obj.Name() // package unsafe
+		}
+		return "/pkg/builtin/"
+	}
+	pos := a.fset.Position(obj.Pos())
+	return posLink(pos.Filename, pos.Line, pos.Offset, pos.Offset+len(obj.Name()))
+}
+
+// addLinks computes the source links for file.
+func (a *analyzer) addLinks(res *Analysis, p *analysisPkg, file *ast.File) {
+	tfile := a.fset.File(file.Pos())
+	if tfile == nil {
+		return
+	}
+	var links []Link
+	ast.Inspect(file, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := p.info.Objects[id]
+		if obj == nil {
+			return true
+		}
+		if obj.Pos() == id.Pos() {
+			return true // the declaration itself
+		}
+		l := Link{
+			Start: tfile.Offset(id.Pos()),
+			End:   tfile.Offset(id.Pos()) + len(id.Name),
+			Href:  a.objLink(obj),
+			Title: types.ObjectString(p.pkg, obj),
+		}
+		links = append(links, l)
+		return true
+	})
+	sort.Sort(linkList(links))
+	res.files[tfile.Name()] = &fileAnalysis{size: tfile.Size(), links: links}
+}
+
+// typeInfo describes a named type considered by addTypes.
+type typeInfo struct {
+	p       *analysisPkg
+	obj     *types.TypeName
+	named   *types.Named
+	iface   *types.Interface // nil if not an interface
+	methods map[string]bool  // names in the method set of *T (or T for interfaces)
+	res     *TypeAnalysis
+}
+
+// typeRef returns a reference to the type, or to a pointer to it if ptr is set.
+func (a *analyzer) typeRef(t *typeInfo, ptr bool) AnalysisRef {
+	name := t.p.path + "." + t.obj.Name()
+	if ptr {
+		name = "*" + name
+	}
+	return AnalysisRef{Name: name, Href: a.objLink(t.obj)}
+}
+
+// addTypes computes the implements relation and the method sets
+// of all package-level named types.
+func (a *analyzer) addTypes(res *Analysis) {
+	var all, ifaces []*typeInfo
+	for _, p := range a.order {
+		scope := p.pkg.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			t := &typeInfo{p: p, obj: obj, named: named, methods: make(map[string]bool), res: new(TypeAnalysis)}
+			var mset *types.MethodSet
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				t.iface = iface
+				mset = types.NewMethodSet(named)
+				if iface.NumMethods() > 0 {
+					ifaces = append(ifaces, t)
+				}
+			} else {
+				mset = types.NewMethodSet(types.NewPointer(named))
+			}
+			for i := 0; i < mset.Len(); i++ {
+				m := mset.At(i).Obj()
+				t.methods[m.Name()] = true
+				t.res.Methods = append(t.res.Methods, types.ObjectString(p.pkg, m))
+			}
+			all = append(all, t)
+			res.pkgs[p.dir].types[name] = t.res
+		}
+	}
+
+	for _, t := range all {
+		for _, u := range ifaces {
+			if t == u || !t.methods[u.iface.Method(0).Name()] {
+				continue // quick rejection
+			}
+			var ptr bool
+			switch {
+			case types.Implements(t.named, u.iface):
+				// T implements U
+			case t.iface == nil && types.Implements(types.NewPointer(t.named), u.iface):
+				ptr = true
+			default:
+				continue
+			}
+			t.res.Implements = append(t.res.Implements, a.typeRef(u, false))
+			u.res.ImplementedBy = append(u.res.ImplementedBy, a.typeRef(t, ptr))
+		}
+	}
+	for _, t := range all {
+		sort.Sort(refList(t.res.Implements))
+		sort.Sort(refList(t.res.ImplementedBy))
+	}
+}
+
+// funcKey returns the name under which the function or method
+// fn is listed on its package page: "F" or "T.M".
+func funcKey(fn *types.Func) string {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return fn.Name()
+	}
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	if named, ok := recv.(*types.Named); ok {
+		return named.Obj().Name() + "." + fn.Name()
+	}
+	return fn.Name() // interface method
+}
+
+// funcAnalysis returns the FuncAnalysis for fn, creating it if needed.
+// It returns nil if fn does not belong to an analyzed package.
+func (a *analyzer) funcAnalysis(res *Analysis, fn *types.Func) *FuncAnalysis {
+	if fn.Pkg() == nil {
+		return nil
+	}
+	p := a.pkgs[fn.Pkg().Path()]
+	if p == nil || p.pkg != fn.Pkg() {
+		return nil
+	}
+	pa := res.pkgs[p.dir]
+	key := funcKey(fn)
+	f := pa.funcs[key]
+	if f == nil {
+		f = new(FuncAnalysis)
+		pa.funcs[key] = f
+	}
+	return f
+}
+
+// addCalls computes the static call graph.
+func (a *analyzer) addCalls(res *Analysis) {
+	type edge struct{ caller, callee *types.Func }
+	seen := make(map[edge]bool)
+	for _, p := range a.order {
+		for _, file := range p.files {
+			for _, decl := range file.Decls {
+				fdecl, ok := decl.(*ast.FuncDecl)
+				if !ok || fdecl.Body == nil {
+					continue
+				}
+				caller, ok := p.info.Objects[fdecl.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				ast.Inspect(fdecl.Body, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					var id *ast.Ident
+					switch fun := call.Fun.(type) {
+					case *ast.Ident:
+						id = fun
+					case *ast.SelectorExpr:
+						id = fun.Sel
+					default:
+						return true
+					}
+					callee, ok := p.info.Objects[id].(*types.Func)
+					if !ok {
+						return true // not a function (e.g. a conversion or builtin)
+					}
+					e := edge{caller, callee}
+					if seen[e] {
+						return true
+					}
+					seen[e] = true
+					if f := a.funcAnalysis(res, caller); f != nil {
+						f.Callees = append(f.Callees, AnalysisRef{callee.FullName(), a.objLink(callee)})
+					}
+					if f := a.funcAnalysis(res, callee); f != nil {
+						pos := a.fset.Position(call.Pos())
+						end := a.fset.Position(call.End())
+						f.Callers = append(f.Callers, AnalysisRef{caller.FullName(), posLink(pos.Filename, pos.Line, pos.Offset, end.Offset)})
+					}
+					return true
+				})
+			}
+		}
+	}
+	for _, pa := range res.pkgs {
+		for _, f := range pa.funcs {
+			sort.Sort(refList(f.Callers))
+			sort.Sort(refList(f.Callees))
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Analyzer
+
+// analysisUpToDate reports whether the analysis is not older
+// than any of the file systems under godoc's observation.
+func analysisUpToDate() bool {
+	_, fsTime := fsModified.get()
+	_, aTime := analysisResult.get()
+	return !fsTime.After(aTime)
+}
+
+func updateAnalysis() {
+	if *verbose {
+		log.Printf("updating analysis...")
+	}
+	start := time.Now()
+	a := newAnalyzer()
+	for dir := range fsDirnames() {
+		if strings.HasPrefix(dir, "/src/pkg/") {
+			a.load(dir[len("/src/pkg/"):])
+		}
+	}
+	res := a.analyze()
+	analysisResult.set(res)
+	if *verbose {
+		log.Printf("analysis updated (%gs, %d packages, %d files)",
+			time.Since(start).Seconds(), len(res.pkgs), len(res.files))
+	}
+}
+
+// runAnalyzer runs the static analysis and repeats it
+// whenever the file systems change.
+func runAnalyzer() {
+	for {
+		if !analysisUpToDate() {
+			updateAnalysis()
+		}
+		delay := 60 * time.Second // by default, try every 60s
+		if *testDir != "" {
+			// in test mode, try once a second for fast startup
+			delay = 1 * time.Second
+		}
+		time.Sleep(delay)
+	}
+}
@@ -50,6 +50,9 @@ The flags are:
 		width of tabs in units of spaces
 	-timestamps=true
 		show timestamps with directory listings
+	-analysis
+		enable static analysis: type-checked source links, implementers
+		of interfaces, method sets and callers of functions
 	-index
 		enable identifier and full text search index
 		(no search box is shown if -index is not set)
@@ -111,6 +114,13 @@ via regular expressions). The maximum number of full text search results shown
 can be set with the -maxresults flag; if set to 0, no full text results are
 shown, and only an identifier index but no full text search index is created.
 
+When godoc runs as a web server and -analysis is set, the packages of the
+source tree are type-checked in the background. Once the analysis is complete,
+identifiers in source views link to their declarations, package pages list the
+implementations of interfaces and the method sets of types, and functions list
+their static callers and callees. Type errors do not stop the analysis, but
+the information for erroneous code may be incomplete.
+
 The presentation mode of web pages served by godoc can be controlled with the
 "m" URL parameter; it accepts a comma-separated list of flag names as value:
 
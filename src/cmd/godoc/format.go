@@ -4,8 +4,8 @@
 
 // This file implements FormatSelections and FormatText.
 // FormatText is used to HTML-format Go and non-Go source
-// text with line numbers and highlighted sections. It is
-// built on top of FormatSelections, a generic formatter
+// text with line numbers, highlighted sections, and links.
+// It is built on top of FormatSelections, a generic formatter
 // for "selected" text.
 
 package main
@@ -307,6 +307,63 @@ var startTags = [][]byte{
 
 var endTag = []byte(`</span>`)
 
+// linkBit is the selection bit used by FormatText for links;
+// it must not conflict with the bits used by startTags.
+const linkBit = 1 << 3
+
+// A Link describes a hyperlink for the text segment [Start, End)
+// of the text formatted by FormatText.
+type Link struct {
+	Start, End int    // text segment
+	Href       string // link target; not escaped
+	Title      string // link title, shown as a tooltip; may be empty
+}
+
+// linkSelection returns the text segments of links as a Selection.
+// The links must be sorted by Start and must not overlap.
+func linkSelection(links []Link) Selection {
+	i := 0
+	return func() (seg []int) {
+		if i < len(links) {
+			seg = []int{links[i].Start, links[i].End}
+			i++
+		}
+		return
+	}
+}
+
+// linkTag returns a SegmentWriter that wraps the text segments covered
+// by links in HTML anchors and formats them otherwise like selectionTag.
+// The returned SegmentWriter must be called for consecutive segments of
+// the text, starting at offset 0.
+func linkTag(links []Link) SegmentWriter {
+	offs := 0 // offset of the current text segment
+	i := 0    // index of the first link that may cover the segment
+	return func(w io.Writer, text []byte, selections int) {
+		if selections&linkBit != 0 {
+			for i < len(links) && links[i].End <= offs {
+				i++
+			}
+			if i < len(links) {
+				l := &links[i]
+				io.WriteString(w, `<a href="`)
+				template.HTMLEscape(w, []byte(l.Href))
+				if l.Title != "" {
+					io.WriteString(w, `" title="`)
+					template.HTMLEscape(w, []byte(l.Title))
+				}
+				io.WriteString(w, `">`)
+				selectionTag(w, text, selections&^linkBit)
+				io.WriteString(w, `</a>`)
+				offs += len(text)
+				return
+			}
+		}
+		selectionTag(w, text, selections&^linkBit)
+		offs += len(text)
+	}
+}
+
 func selectionTag(w io.Writer, text []byte, selections int) {
 	if selections < len(startTags) {
 		if tag := startTags[selections]; len(tag) > 0 {
@@ -329,11 +386,13 @@ func selectionTag(w io.Writer, text []byte, selections int) {
 //	- each occurrence of the regular expression pattern gets the "highlight"
 //	  span class
 //	- text segments covered by selection get the "selection" span class
+//	- text segments described by links are wrapped in HTML anchors; the
+//	  links must be sorted by Start and must not overlap
 //
 // Comments, highlights, and selections may overlap arbitrarily; the respective
 // HTML span classes are specified in the startTags variable.
 //
-func FormatText(w io.Writer, text []byte, line int, goSource bool, pattern string, selection Selection) {
+func FormatText(w io.Writer, text []byte, line int, goSource bool, pattern string, selection Selection, links []Link) {
 	var comments, highlights Selection
 	if goSource {
 		comments = commentSelection(text)
@@ -341,7 +400,7 @@ func FormatText(w io.Writer, text []byte, line int, goSource bool, pattern strin
 	if pattern != "" {
 		highlights = regexpSelection(text, pattern)
 	}
-	if line >= 0 || comments != nil || highlights != nil || selection != nil {
+	if line >= 0 || comments != nil || highlights != nil || selection != nil || links != nil {
 		var lineTag LinkWriter
 		if line >= 0 {
 			lineTag = func(w io.Writer, _ int, start bool) {
@@ -351,7 +410,11 @@ func FormatText(w io.Writer, text []byte, line int, goSource bool, pattern strin
 				}
 			}
 		}
-		FormatSelections(w, text, lineTag, lineSelection(text), selectionTag, comments, highlights, selection)
+		if links != nil {
+			FormatSelections(w, text, lineTag, lineSelection(text), linkTag(links), comments, highlights, selection, linkSelection(links))
+		} else {
+			FormatSelections(w, text, lineTag, lineSelection(text), selectionTag, comments, highlights, selection)
+		}
 	} else {
 		template.HTMLEscape(w, text)
 	}
@@ -71,6 +71,9 @@ var (
 	maxResults    = flag.Int("maxresults", 10000, "maximum number of full text search results shown")
 	indexThrottle = flag.Float64("index_throttle", 0.75, "index throttle value; 0.0 = no time allocated, 1.0 = full throttle")
 
+	// static analysis
+	analysisEnabled = flag.Bool("analysis", false, "enable static analysis: source links, implementers and callers")
+
 	// file system information
 	fsTree      RWValue // *Directory tree of packages, updated with each sync (but sync code is removed now)
 	fsModified  RWValue // timestamp of last call to invalidateIndex
@@ -286,7 +289,7 @@ func node_htmlFunc(node interface{}, fset *token.FileSet) string {
 	var buf1 bytes.Buffer
 	writeNode(&buf1, fset, node)
 	var buf2 bytes.Buffer
-	FormatText(&buf2, buf1.Bytes(), -1, true, "", nil)
+	FormatText(&buf2, buf1.Bytes(), -1, true, "", nil, nil)
 	return buf2.String()
 }
 
@@ -430,7 +433,16 @@ func posLink_urlFunc(node ast.Node, fset *token.FileSet) string {
 	}
 
 	var buf bytes.Buffer
-	template.HTMLEscape(&buf, []byte(relpath))
+	template.HTMLEscape(&buf, []byte(posLink(relpath, line, low, high)))
+	return buf.String()
+}
+
+// posLink returns the (unescaped) URL of the source file relpath,
+// selecting the text range [low, high) if low < high, and positioned
+// at the given line if line > 0.
+func posLink(relpath string, line, low, high int) string {
+	var buf bytes.Buffer
+	buf.WriteString(relpath)
 	// selection ranges are of form "s=low:high"
 	if low < high {
 		fmt.Fprintf(&buf, "?s=%d:%d", low, high) // no need for URL escaping
@@ -660,7 +672,7 @@ func serveTextFile(w http.ResponseWriter, r *http.Request, abspath, relpath, tit
 
 	var buf bytes.Buffer
 	buf.WriteString("<pre>")
-	FormatText(&buf, src, 1, pathpkg.Ext(abspath) == ".go", r.FormValue("h"), rangeSelection(r.FormValue("s")))
+	FormatText(&buf, src, 1, pathpkg.Ext(abspath) == ".go", r.FormValue("h"), rangeSelection(r.FormValue("s")), analysisLinks(abspath, len(src)))
 	buf.WriteString("</pre>")
 
 	servePage(w, relpath, title+" "+relpath, "", "", buf.Bytes())
@@ -814,17 +826,18 @@ func remoteSearchURL(query string, html bool) string {
 }
 
 type PageInfo struct {
-	Dirname  string         // directory containing the package
-	PList    []string       // list of package names found
-	FSet     *token.FileSet // corresponding file set
-	PAst     *ast.File      // nil if no single AST with package exports
-	PDoc     *doc.Package   // nil if no single package documentation
-	Examples []*doc.Example // nil if no example code
-	Dirs     *DirList       // nil if no directory information
-	DirTime  time.Time      // directory time stamp
-	DirFlat  bool           // if set, show directory in a flat (non-indented) manner
-	IsPkg    bool           // false if this is not documenting a real package
-	Err      error          // I/O error or nil
+	Dirname  string           // directory containing the package
+	PList    []string         // list of package names found
+	FSet     *token.FileSet   // corresponding file set
+	PAst     *ast.File        // nil if no single AST with package exports
+	PDoc     *doc.Package     // nil if no single package documentation
+	Examples []*doc.Example   // nil if no example code
+	Dirs     *DirList         // nil if no directory information
+	DirTime  time.Time        // directory time stamp
+	DirFlat  bool             // if set, show directory in a flat (non-indented) manner
+	IsPkg    bool             // false if this is not documenting a real package
+	Analysis *PackageAnalysis // nil if no static analysis information
+	Err      error            // I/O error or nil
 }
 
 func (info *PageInfo) IsEmpty() bool {
@@ -1042,6 +1055,7 @@ func (h *docServer) getPageInfo(abspath, relpath, pkgname string, mode PageInfoM
 		DirTime:  timestamp,
 		DirFlat:  mode&flatDir != 0,
 		IsPkg:    h.isPkg,
+		Analysis: analysisPackage(abspath, pdoc),
 		Err:      nil,
 	}
 }
@@ -271,6 +271,9 @@ func main() {
 			default:
 				log.Print("identifier search index enabled")
 			}
+			if *analysisEnabled {
+				log.Print("static analysis enabled")
+			}
 			fs.Fprint(os.Stderr)
 			handler = loggingHandler(handler)
 		}
@@ -295,6 +298,11 @@ func main() {
 			go indexer()
 		}
 
+		// Start static analysis.
+		if *analysisEnabled {
+			go runAnalyzer()
+		}
+
 		// Start http server.
 		if err := http.ListenAndServe(*httpAddr, handler); err != nil {
 			log.Fatalf("ListenAndServe %s: %v", *httpAddr, err)
@@ -430,7 +438,7 @@ func main() {
 				if *html {
 					var buf bytes.Buffer
 					writeNode(&buf, info.FSet, d)
-					FormatText(os.Stdout, buf.Bytes(), -1, true, "", nil)
+					FormatText(os.Stdout, buf.Bytes(), -1, true, "", nil, nil)
 				} else {
 					writeNode(os.Stdout, info.FSet, d)
 				}
@@ -28,7 +28,7 @@ func newSnippet(fset *token.FileSet, decl ast.Decl, id *ast.Ident) *Snippet {
 	// wrap text with <pre> tag
 	var buf2 bytes.Buffer
 	buf2.WriteString("<pre>")
-	FormatText(&buf2, buf1.Bytes(), -1, true, id.Name, nil)
+	FormatText(&buf2, buf1.Bytes(), -1, true, id.Name, nil, nil)
 	buf2.WriteString("</pre>")
 	return &Snippet{fset.Position(id.Pos()).Line, buf2.String()}
 }
@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/printer"
 	"go/scanner"
@@ -114,7 +115,7 @@ func processFile(filename string, in io.Reader, out io.Writer, stdin bool) error
 	ast.SortImports(fileSet, file)
 
 	if *simplifyAST {
-		simplify(file)
+		format.Simplify(file)
 	}
 
 	var buf bytes.Buffer
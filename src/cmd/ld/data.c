@@ -172,6 +172,12 @@ relocsym(Sym *s)
 		if(r->sym != S && !r->sym->reachable)
 			diag("unreachable sym in relocation: %s %s", s->name, r->sym->name);
 
+		if(r->sym != S && r->sym->type == SHOSTOBJ) {
+			// the host linker fills these in.
+			addhostrel(s, r);
+			continue;
+		}
+
 		switch(r->type) {
 		default:
 			o = 0;
@@ -192,6 +198,12 @@ relocsym(Sym *s)
 			o = r->sym->size + r->add;
 			break;
 		}
+		if(buildmode == BuildmodeCArchive && r->type != D_SIZE && (siz == 4 || siz == 8)) {
+			// the host linker places the Go object of a C archive
+			// itself, so it has to redo the relocations; the
+			// object file writer picks out those it needs.
+			addhostrel(s, r);
+		}
 //print("relocate %s %p %s => %p %p %p %p [%p]\n", s->name, s->value+off, r->sym ? r->sym->name : "<nil>", (void*)symaddr(r->sym), (void*)s->value, (void*)r->off, (void*)r->siz, (void*)o);
 		switch(siz) {
 		default:
@@ -606,6 +618,36 @@ addstrdata(char *name, char *value)
 	adduint32(s, strlen(value));
 	if(PtrSize == 8)
 		adduint32(s, 0);  // round struct to pointer width
+
+	// Keep the variable even if the program never refers to it,
+	// so that tools can read it back from the binary.
+	s->reachable = 1;
+}
+
+// addstrdata1 handles a -X importpath.name=value argument.
+// Unlike the symbol name in -X symbol value, the import path
+// is written as in an import statement; it is escaped here
+// the way the symbol table spells it.
+void
+addstrdata1(char *arg)
+{
+	char *p, *dot, *name;
+
+	p = strchr(arg, '=');
+	dot = nil;
+	if(p != nil) {
+		*p++ = '\0';
+		dot = strrchr(arg, '.');
+	}
+	if(p == nil || dot == nil || dot == arg || dot[1] == '\0') {
+		diag("-X flag requires argument of the form importpath.name=value");
+		errorexit();
+	}
+	*dot = '\0';
+	name = smprint("%s.%s", pathtoprefix(arg), dot+1);
+	*dot = '.';
+	addstrdata(name, p);
+	free(name);
 }
 
 vlong
@@ -856,7 +898,7 @@ dodata(void)
 	sect->vaddr = 0;
 	datsize = 0;
 	s = datap;
-	for(; s != nil && s->type < SSYMTAB; s = s->next) {
+	for(; s != nil && s->type < STYPELINK; s = s->next) {
 		if(s->align != 0)
 			datsize = rnd(datsize, s->align);
 		s->type = SRODATA;
@@ -865,6 +907,17 @@ dodata(void)
 	}
 	sect->len = datsize - sect->vaddr;
 
+	/* typelink */
+	sect = addsection(&segtext, ".typelink", 04);
+	sect->vaddr = datsize;
+	for(; s != nil && s->type < SSYMTAB; s = s->next) {
+		s->type = SRODATA;
+		s->value = datsize;
+		datsize += s->size;
+	}
+	sect->len = datsize - sect->vaddr;
+	datsize = rnd(datsize, PtrSize);
+
 	/* gosymtab */
 	sect = addsection(&segtext, ".gosymtab", 04);
 	sect->vaddr = datsize;
@@ -1035,7 +1088,7 @@ textaddress(void)
 void
 address(void)
 {
-	Section *s, *text, *data, *rodata, *symtab, *pclntab, *noptr, *bss, *noptrbss;
+	Section *s, *text, *data, *rodata, *typelink, *symtab, *pclntab, *noptr, *bss, *noptrbss;
 	Sym *sym, *sub;
 	uvlong va;
 
@@ -1082,7 +1135,8 @@ address(void)
 
 	text = segtext.sect;
 	rodata = text->next;
-	symtab = rodata->next;
+	typelink = rodata->next;
+	symtab = typelink->next;
 	pclntab = symtab->next;
 
 	for(sym = datap; sym != nil; sym = sym->next) {
@@ -1099,6 +1153,8 @@ address(void)
 	xdefine("etext", STEXT, text->vaddr + text->len);
 	xdefine("rodata", SRODATA, rodata->vaddr);
 	xdefine("erodata", SRODATA, rodata->vaddr + rodata->len);
+	xdefine("typelink", SRODATA, typelink->vaddr);
+	xdefine("etypelink", SRODATA, typelink->vaddr + typelink->len);
 	xdefine("symtab", SRODATA, symtab->vaddr);
 	xdefine("esymtab", SRODATA, symtab->vaddr + symtab->len);
 	xdefine("pclntab", SRODATA, pclntab->vaddr);
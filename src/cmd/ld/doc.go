@@ -50,11 +50,49 @@ Options new in this version:
 		The default is the single location $GOROOT/pkg/$GOOS_$GOARCH.
 	-r dir1:dir2:...
 		Set the dynamic linker search path when using ELF.
+	-buildmode mode   (only in 6l)
+		Set the build mode: exe (the default) or c-archive.
+		In c-archive mode, supported only for Linux, the linker links
+		externally but runs ar instead of the host linker, writing an
+		archive that a C program can link against.  The Go runtime
+		starts from the archive's .init_array when the program loads,
+		and C code calls the functions the main package marks with
+		//export once the package initializers have run.  The
+		archive is not position-independent, so the C program must
+		be linked with -no-pie if its compiler defaults to -pie.
+		The c-shared mode is recognized but rejected: 6l does not
+		generate position-independent code.
+	-linkmode mode   (only in 6l)
+		Set the link mode: internal, external or auto (the default).
+		In external mode, supported only for Linux, the linker lays out
+		the Go code as usual but writes it as an ELF object, go.o, and
+		runs the host linker on it and on the C objects of any cgo
+		packages, with the flags their #cgo LDFLAGS directives record.
+		The auto mode links externally only if a cgo package other than
+		net, os/user or runtime/cgo is part of the program.
+	-extld ld   (only in 6l)
+		The host linker to run in external mode; the default is gcc.
+		If it accepts -no-pie, the linker passes it, since the Go code
+		is laid out at fixed addresses.
+	-extldflags flags   (only in 6l)
+		Additional space-separated flags for the host linker.
+	-tmpdir dir   (only in 6l)
+		Write go.o and the C objects to dir, and leave them there,
+		instead of using a new temporary directory.
+	-Z   (only in 6l/8l)
+		Compress the DWARF sections of ELF binaries with zlib, writing
+		them as .zdebug_ sections, which gdb and debug/elf read.
 	-V
 		Print the linker version.
+	-X importpath.name=value
+		Set the value of the string variable name in the package
+		with the given import path, which should be otherwise
+		uninitialized.  The variable is kept in the binary even
+		if the program does not refer to it.  For example,
+			go build -ldflags "-X main.version=$(git describe)"
 	-X symbol value
-		Set the value of an otherwise uninitialized string variable.
-		The symbol name should be of the form importpath.name,
-		as displayed in the symbol table printed by "go tool nm".
+		The older form of -X.  The symbol name should be of the form
+		importpath.name, as displayed in the symbol table printed by
+		"go tool nm".
 */
 package documentation
@@ -116,13 +116,12 @@ sleb128put(vlong v)
  * only a handful of them.  The DWARF spec places no restriction on
  * the ordering of atributes in the Abbrevs and DIEs, and we will
  * always write them out in the order of declaration in the abbrev.
- * This implementation relies on tag, attr < 127, so they serialize as
- * a char.  Higher numbered user-defined tags or attributes can be used
- * for storing internal data but won't be serialized.
+ * Attributes that no abbrev lists, like the internal ones below
+ * defgotype, can be used for storing data but won't be serialized.
  */
 typedef struct DWAttrForm DWAttrForm;
 struct DWAttrForm {
-	uint8 attr;
+	uint16 attr;
 	uint8 form;
 };
 
@@ -234,7 +233,7 @@ static struct DWAbbrev {
 		DW_TAG_subrange_type, DW_CHILDREN_no,
 		// No name!
 		DW_AT_type,	 DW_FORM_ref_addr,
-		DW_AT_upper_bound, DW_FORM_data1,
+		DW_AT_count,	 DW_FORM_udata,
 		0, 0
 	},
 
@@ -251,15 +250,17 @@ static struct DWAbbrev {
 		DW_AT_name,	 DW_FORM_string,
 		DW_AT_encoding,	 DW_FORM_data1,
 		DW_AT_byte_size, DW_FORM_data1,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 	/* ARRAYTYPE */
-	// child is subrange with upper bound
+	// child is subrange with the element count
 	{
 		DW_TAG_array_type, DW_CHILDREN_yes,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_type,	DW_FORM_ref_addr,
 		DW_AT_byte_size, DW_FORM_udata,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -268,6 +269,7 @@ static struct DWAbbrev {
 		DW_TAG_typedef, DW_CHILDREN_no,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_type,	DW_FORM_ref_addr,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -276,6 +278,7 @@ static struct DWAbbrev {
 		DW_TAG_subroutine_type, DW_CHILDREN_yes,
 		DW_AT_name,	DW_FORM_string,
 //		DW_AT_type,	DW_FORM_ref_addr,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -284,6 +287,7 @@ static struct DWAbbrev {
 		DW_TAG_typedef, DW_CHILDREN_yes,
 		DW_AT_name,	 DW_FORM_string,
 		DW_AT_type,	DW_FORM_ref_addr,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -292,6 +296,7 @@ static struct DWAbbrev {
 		DW_TAG_typedef, DW_CHILDREN_no,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_type,	DW_FORM_ref_addr,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -300,6 +305,7 @@ static struct DWAbbrev {
 		DW_TAG_pointer_type, DW_CHILDREN_no,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_type,	DW_FORM_ref_addr,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -308,6 +314,7 @@ static struct DWAbbrev {
 		DW_TAG_structure_type, DW_CHILDREN_yes,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_byte_size, DW_FORM_udata,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -316,6 +323,7 @@ static struct DWAbbrev {
 		DW_TAG_structure_type, DW_CHILDREN_yes,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_byte_size, DW_FORM_udata,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -324,6 +332,7 @@ static struct DWAbbrev {
 		DW_TAG_structure_type, DW_CHILDREN_yes,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_byte_size, DW_FORM_udata,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 
@@ -332,6 +341,7 @@ static struct DWAbbrev {
 		DW_TAG_typedef, DW_CHILDREN_no,
 		DW_AT_name,	DW_FORM_string,
 		DW_AT_type,	DW_FORM_ref_addr,
+		DW_AT_go_kind,	DW_FORM_data1,
 		0, 0
 	},
 };
@@ -339,7 +349,8 @@ static struct DWAbbrev {
 static void
 writeabbrev(void)
 {
-	int i, n;
+	int i;
+	DWAttrForm *af;
 
 	abbrevo = cpos();
 	for (i = 1; i < DW_NABRV; i++) {
@@ -348,10 +359,13 @@ writeabbrev(void)
 		uleb128put(abbrevs[i].tag);
 		cput(abbrevs[i].children);
 		// 0 is not a valid attr or form, and DWAbbrev.attr is
-		// 0-terminated, so we can treat it as a string
-		n = strlen((char*)abbrevs[i].attr) / 2;
-		strnput((char*)abbrevs[i].attr,
-			(n+1) * sizeof(DWAttrForm));
+		// 0-terminated.
+		for(af = abbrevs[i].attr; af->attr; af++) {
+			uleb128put(af->attr);
+			uleb128put(af->form);
+		}
+		cput(0);
+		cput(0);
 	}
 	cput(0);
 	abbrevsize = cpos() - abbrevo;
@@ -385,7 +399,7 @@ hashstr(char* s)
 typedef struct DWAttr DWAttr;
 struct DWAttr {
 	DWAttr *link;
-	uint8 atr;  // DW_AT_
+	uint16 atr;  // DW_AT_
 	uint8 cls;  // DW_CLS_
 	vlong value;
 	char *data;
@@ -413,7 +427,7 @@ static DWDie dwtypes;
 static DWDie dwglobals;
 
 static DWAttr*
-newattr(DWDie *die, uint8 attr, int cls, vlong value, char *data)
+newattr(DWDie *die, uint16 attr, int cls, vlong value, char *data)
 {
 	DWAttr *a;
 
@@ -431,7 +445,7 @@ newattr(DWDie *die, uint8 attr, int cls, vlong value, char *data)
 // name. getattr moves the desired one to the front so
 // frequently searched ones are found faster.
 static DWAttr*
-getattr(DWDie *die, uint8 attr)
+getattr(DWDie *die, uint16 attr)
 {
 	DWAttr *a, *b;
 
@@ -537,7 +551,7 @@ find_or_diag(DWDie *die, char* name)
 }
 
 static DWAttr*
-newrefattr(DWDie *die, uint8 attr, DWDie* ref)
+newrefattr(DWDie *die, uint16 attr, DWDie* ref)
 {
 	if (ref == nil)
 		return nil;
@@ -643,22 +657,18 @@ putattr(int form, int cls, vlong value, char *data)
 static void
 putattrs(int abbrev, DWAttr* attr)
 {
-	DWAttr *attrs[DW_AT_recursive + 1];
+	DWAttr *a;
 	DWAttrForm* af;
 
-	memset(attrs, 0, sizeof attrs);
-	for( ; attr; attr = attr->link)
-		if (attr->atr < nelem(attrs))
-			attrs[attr->atr] = attr;
-
-	for(af = abbrevs[abbrev].attr; af->attr; af++)
-		if (attrs[af->attr])
-			putattr(af->form,
-				attrs[af->attr]->cls,
-				attrs[af->attr]->value,
-				attrs[af->attr]->data);
+	for(af = abbrevs[abbrev].attr; af->attr; af++) {
+		for(a = attr; a != nil; a = a->link)
+			if (a->atr == af->attr)
+				break;
+		if (a != nil)
+			putattr(af->form, a->cls, a->value, a->data);
 		else
 			putattr(af->form, 0, 0, 0);
+	}
 }
 
 static void putdie(DWDie* die);
@@ -715,12 +725,11 @@ newmemberoffsetattr(DWDie *die, int32 offs)
 	char block[10];
 	int i;
 
+	// Member offsets are never negative, and a plus_uconst,
+	// even of 0, is the form other compilers use and readers expect.
 	i = 0;
-	if (offs != 0) {
-		block[i++] = DW_OP_consts;
-		i += sleb128enc(offs, block+i);
-		block[i++] = DW_OP_plus;
-	}
+	block[i++] = DW_OP_plus_uconst;
+	i += uleb128enc(offs, block+i);
 	newattr(die, DW_AT_data_member_location, DW_CLS_BLOCK, i, mal(i));
 	memmove(die->attr->data, block, i);
 }
@@ -856,10 +865,11 @@ decodetype_arrayelem(Sym *s)
 	return decode_reloc_sym(s, CommonSize);	// 0x1c / 0x30
 }
 
+// Type.ArrayType.len, after elem and slice
 static vlong
 decodetype_arraylen(Sym *s)
 {
-	return decode_inuxi(s->p + CommonSize+PtrSize, PtrSize);
+	return decode_inuxi(s->p + CommonSize+2*PtrSize, PtrSize);
 }
 
 // Type.PtrType.elem
@@ -1078,7 +1088,7 @@ defgotype(Sym *gotype)
 		s = decodetype_arrayelem(gotype);
 		newrefattr(die, DW_AT_type, defgotype(s));
 		fld = newdie(die, DW_ABRV_ARRAYRANGE, "range");
-		newattr(fld, DW_AT_upper_bound, DW_CLS_CONSTANT, decodetype_arraylen(gotype), 0);
+		newattr(fld, DW_AT_count, DW_CLS_CONSTANT, decodetype_arraylen(gotype), 0);
 		newrefattr(fld, DW_AT_type, find_or_diag(&dwtypes, "uintptr"));
 		break;
 
@@ -1171,6 +1181,8 @@ defgotype(Sym *gotype)
 		newrefattr(die, DW_AT_type, find_or_diag(&dwtypes, "<unspecified>"));
 	 }
 
+	newattr(die, DW_AT_go_kind, DW_CLS_CONSTANT, kind, 0);
+
 	return die;
 }
 
@@ -2281,6 +2293,61 @@ align(vlong size)
 		strnput("", rnd(size, PEFILEALIGN) - size);
 }
 
+/*
+ * With -Z, replace the ELF debug sections just written by their
+ * compressed forms, the .zdebug sections that gdb and debug/elf
+ * understand: "ZLIB", the uncompressed size as a big-endian 8-byte
+ * number, and the zlib stream.  The sections are read back from the
+ * output file and packed down over the originals.
+ */
+static void
+compresssections(void)
+{
+	static vlong *off[] = {
+		&abbrevo, &lineo, &frameo, &infoo, &pubnameso,
+		&pubtypeso, &arangeso, &gdbscripto,
+	};
+	static vlong *size[] = {
+		&abbrevsize, &linesize, &framesize, &infosize, &pubnamessize,
+		&pubtypessize, &arangessize, &gdbscriptsize,
+	};
+	uchar *data, *raw, *z, hdr[12];
+	vlong start, n;
+	int32 nz;
+	int i, j;
+
+	start = abbrevo;
+	n = cpos() - start;
+	data = malloc(n);
+	if(data == nil) {
+		diag("out of memory");
+		errorexit();
+	}
+	cread(start, data, n);
+
+	cseek(start);
+	for(i=0; i<nelem(off); i++) {
+		raw = data + (*off[i] - start);
+		n = *size[i];
+		*off[i] = cpos();
+		// gdb looks for .debug_gdb_scripts by name.
+		if(n == 0 || off[i] == &gdbscripto) {
+			cwrite(raw, n);
+			continue;
+		}
+		z = zlibcompress(raw, n, &nz);
+		memmove(hdr, "ZLIB", 4);
+		for(j=0; j<8; j++)
+			hdr[4+j] = n >> (56 - 8*j);
+		cwrite(hdr, sizeof hdr);
+		cwrite(z, nz);
+		*size[i] = sizeof hdr + nz;
+		free(z);
+	}
+	ctruncate();
+	free(data);
+}
+
 /*
  * This is the main entry point for generating dwarf.  After emitting
  * the mandatory debug_abbrev section, it calls writelines() to set up
@@ -2309,11 +2376,13 @@ dwarfemitdebugsections(void)
 	// Some types that must exist to define other ones.
 	newdie(&dwtypes, DW_ABRV_NULLTYPE, "<unspecified>");
 	newdie(&dwtypes, DW_ABRV_NULLTYPE, "void");
-	newrefattr(newdie(&dwtypes, DW_ABRV_PTRTYPE, "unsafe.Pointer"),
-		DW_AT_type, find(&dwtypes, "void"));
+	die = newdie(&dwtypes, DW_ABRV_PTRTYPE, "unsafe.Pointer");
+	newrefattr(die, DW_AT_type, find(&dwtypes, "void"));
+	newattr(die, DW_AT_go_kind, DW_CLS_CONSTANT, KindUnsafePointer, 0);
 	die = newdie(&dwtypes, DW_ABRV_BASETYPE, "uintptr");  // needed for array size
 	newattr(die, DW_AT_encoding,  DW_CLS_CONSTANT, DW_ATE_unsigned, 0);
 	newattr(die, DW_AT_byte_size, DW_CLS_CONSTANT, PtrSize, 0);
+	newattr(die, DW_AT_go_kind, DW_CLS_CONSTANT, KindUintptr, 0);
 
 	// Needed by the prettyprinter code for interface inspection.
 	defgotype(lookup_or_diag("type.runtime.commonType"));
@@ -2381,6 +2450,9 @@ dwarfemitdebugsections(void)
 	gdbscripto = writegdbscript();
 	gdbscriptsize = cpos() - gdbscripto;
 	align(gdbscriptsize);
+
+	if(debug['Z'] && iself)
+		compresssections();
 }
 
 /*
@@ -2408,21 +2480,23 @@ vlong elfstrdbg[NElfStrDbg];
 void
 dwarfaddshstrings(Sym *shstrtab)
 {
+	static char *names[NElfStrDbg] = {
+		"abbrev", "aranges", "frame", "info", "line", "loc", "macinfo",
+		"pubnames", "pubtypes", "ranges", "str", "gdb_scripts",
+	};
+	int i;
+
 	if(debug['w'])  // disable dwarf
 		return;
 
-	elfstrdbg[ElfStrDebugAbbrev]   = addstring(shstrtab, ".debug_abbrev");
-	elfstrdbg[ElfStrDebugAranges]  = addstring(shstrtab, ".debug_aranges");
-	elfstrdbg[ElfStrDebugFrame]    = addstring(shstrtab, ".debug_frame");
-	elfstrdbg[ElfStrDebugInfo]     = addstring(shstrtab, ".debug_info");
-	elfstrdbg[ElfStrDebugLine]     = addstring(shstrtab, ".debug_line");
-	elfstrdbg[ElfStrDebugLoc]      = addstring(shstrtab, ".debug_loc");
-	elfstrdbg[ElfStrDebugMacinfo]  = addstring(shstrtab, ".debug_macinfo");
-	elfstrdbg[ElfStrDebugPubNames] = addstring(shstrtab, ".debug_pubnames");
-	elfstrdbg[ElfStrDebugPubTypes] = addstring(shstrtab, ".debug_pubtypes");
-	elfstrdbg[ElfStrDebugRanges]   = addstring(shstrtab, ".debug_ranges");
-	elfstrdbg[ElfStrDebugStr]      = addstring(shstrtab, ".debug_str");
-	elfstrdbg[ElfStrGDBScripts]    = addstring(shstrtab, ".debug_gdb_scripts");
+	// With -Z, the sections but the gdb scripts are compressed
+	// (see compresssections) and named .zdebug_*.
+	for(i=0; i<NElfStrDbg; i++) {
+		if(debug['Z'] && i != ElfStrGDBScripts)
+			elfstrdbg[i] = addstring(shstrtab, smprint(".zdebug_%s", names[i]));
+		else
+			elfstrdbg[i] = addstring(shstrtab, smprint(".debug_%s", names[i]));
+	}
 }
 
 void
@@ -190,6 +190,8 @@ enum
 	DW_AT_lo_user = 0x2000,	// ---
 	DW_AT_hi_user = 0x3fff,	// ---
 
+	// Go-specific type attributes.
+	DW_AT_go_kind = 0x2900,	// constant: the reflect.Kind of the type
 };
 
 // Table 21
@@ -977,6 +977,8 @@ ElfPhdr* elfphload(Segment*);
 ElfShdr* elfshbits(Section*);
 void	elfsetstring(char*, int);
 void	elfaddverneed(Sym*);
+int	putelfstr(char*);
+void	putelfsyment(int, vlong, vlong, int, int);
 
 EXTERN	int	elfstrsize;
 EXTERN	char*	elfstrdat;
@@ -442,6 +442,12 @@ loaddynimport(char *file, char *pkg, char *p, int n)
 		else
 			*next++ = '\0';
 		p0 = p;
+		if(strncmp(p, "cgo_ldflag ", 11) == 0) {
+			// a flag for the host linker, used only
+			// when linking externally.
+			addldflag(p+11);
+			continue;
+		}
 		if(strncmp(p, "dynimport ", 10) != 0)
 			goto err;
 		p += 10;
@@ -658,6 +664,8 @@ deadcode(void)
 		Bprint(&bso, "%5.2f deadcode\n", cputime());
 
 	mark(lookup(INITENTRY, 0));
+	if(linkmode == LinkExternal && buildmode != BuildmodeCArchive)
+		mark(lookup("main", 0));	// called by the C library's start-up code
 	for(i=0; i<nelem(morename); i++)
 		mark(lookup(morename[i], 0));
 
@@ -689,6 +697,12 @@ deadcode(void)
 	else
 		last->next = nil;
 	
+	// link typelinks for the types that are still reachable,
+	// so that reflect can find them.
+	for(s = allsym; s != S; s = s->allsym)
+		if(strncmp(s->name, "go.typelink.", 12) == 0)
+			s->reachable = s->nr > 0 && s->r[0].sym->reachable;
+
 	for(s = allsym; s != S; s = s->allsym)
 		if(strncmp(s->name, "weak.", 5) == 0) {
 			s->special = 1;  // do not lay out in data segment
@@ -49,6 +49,11 @@ char*	goarch;
 char*	goos;
 char*	theline;
 
+static int	rmtmpdir;	// tmpdir was created by us; remove it on exit
+static int	externalobj;	// a host object needs the host linker
+
+static void	hostlinksetup(void);
+
 void
 Lflag(char *arg)
 {
@@ -88,15 +93,18 @@ libinit(void)
 #ifndef _WIN32
 	remove(outfile);
 #endif
-	cout = create(outfile, 1, 0775);
+	cout = create(outfile, ORDWR, 0775);	// read back by cread
 	if(cout < 0) {
 		diag("cannot create %s", outfile);
 		errorexit();
 	}
 
 	if(INITENTRY == nil) {
-		INITENTRY = mal(strlen(goarch)+strlen(goos)+10);
-		sprint(INITENTRY, "_rt0_%s_%s", goarch, goos);
+		INITENTRY = mal(strlen(goarch)+strlen(goos)+20);
+		if(buildmode == BuildmodeCArchive)
+			sprint(INITENTRY, "_rt0_%s_%s_lib", goarch, goos);
+		else
+			sprint(INITENTRY, "_rt0_%s_%s", goarch, goos);
 	}
 	lookup(INITENTRY, 0)->type = SXREF;
 }
@@ -104,6 +112,8 @@ libinit(void)
 void
 errorexit(void)
 {
+	if(rmtmpdir)
+		removeall(tmpdir);
 	if(nerrors) {
 		if(cout >= 0)
 			remove(outfile);
@@ -281,6 +291,12 @@ loadlib(void)
 	loadinternal("runtime");
 	if(thechar == '5')
 		loadinternal("math");
+	if(linkmode == LinkExternal) {
+		// The runtime leaves the thread-local storage set up
+		// by the C library alone only when runtime/cgo is
+		// linked in, so make sure it is.
+		loadinternal("runtime/cgo");
+	}
 
 	for(i=0; i<libraryp; i++) {
 		if(debug['v'])
@@ -288,7 +304,20 @@ loadlib(void)
 		iscgo |= strcmp(library[i].pkg, "runtime/cgo") == 0;
 		objfile(library[i].file, library[i].pkg);
 	}
-	
+
+	if(linkmode == LinkAuto) {
+		// External linking is implemented only by 6l for Linux.
+		if(iscgo && externalobj && thechar == '6' && HEADTYPE == Hlinux)
+			linkmode = LinkExternal;
+		else
+			linkmode = LinkInternal;
+	}
+
+	if(linkmode == LinkInternal)
+		hostobjs();
+	else
+		hostlinksetup();
+
 	// We've loaded all the code now.
 	// If there are no dynamic libraries needed, gcc disables dynamic linking.
 	// Because of this, glibc's dynamic ELF loader occasionally (like in version 2.13)
@@ -298,12 +327,438 @@ loadlib(void)
 	//
 	// Exception: on OS X, programs such as Shark only work with dynamic
 	// binaries, so leave it enabled on OS X (Mach-O) binaries.
-	if(!havedynamic && HEADTYPE != Hdarwin)
+	if(!havedynamic && HEADTYPE != Hdarwin || linkmode == LinkExternal)
 		debug['d'] = 1;
 	
 	importcycles();
 }
 
+/*
+ * Host objects are the ELF object files that gcc compiled for
+ * cgo packages.  They are recorded while the Go objects load and,
+ * once the link mode is known, either loaded into the link
+ * (internal linking) or passed to the host linker along with
+ * the Go object we write (external linking).
+ */
+typedef struct Hostobj Hostobj;
+struct Hostobj
+{
+	void	(*ld)(Biobuf*, char*, int64, char*);
+	char*	pkg;
+	char*	pn;
+	char*	file;
+	int64	off;
+	int64	len;
+};
+
+static Hostobj*	hostobj;
+static int	nhostobj;
+static int	mhostobj;
+
+// The host objects of these packages are known to link correctly
+// with the internal linker.  Any other host object means the
+// program needs the host linker when the link mode is auto.
+static char*	internalpkg[] = {
+	"net",
+	"os/user",
+	"runtime/cgo",
+};
+
+static char**	ldflag;
+static int	nldflag;
+static int	mldflag;
+
+void
+ldhostobj(void (*ld)(Biobuf*, char*, int64, char*), Biobuf *f, char *pkg, int64 len, char *pn, char *file)
+{
+	int i, isinternal;
+	Hostobj *h;
+
+	isinternal = 0;
+	for(i=0; i<nelem(internalpkg); i++) {
+		if(strcmp(pkg, internalpkg[i]) == 0) {
+			isinternal = 1;
+			break;
+		}
+	}
+	if(!isinternal)
+		externalobj = 1;
+
+	if(nhostobj >= mhostobj) {
+		if(mhostobj == 0)
+			mhostobj = 16;
+		else
+			mhostobj *= 2;
+		hostobj = realloc(hostobj, mhostobj*sizeof hostobj[0]);
+		if(hostobj == nil) {
+			diag("out of memory");
+			errorexit();
+		}
+	}
+	h = &hostobj[nhostobj++];
+	h->ld = ld;
+	h->pkg = strdup(pkg);
+	h->pn = strdup(pn);
+	h->file = strdup(file);
+	h->off = Boffset(f);
+	h->len = len;
+}
+
+/*
+ * load the recorded host objects into the link.
+ */
+void
+hostobjs(void)
+{
+	int i;
+	Biobuf *f;
+	Hostobj *h;
+
+	for(i=0; i<nhostobj; i++) {
+		h = &hostobj[i];
+		f = Bopen(h->file, OREAD);
+		if(f == nil) {
+			diag("cannot reopen %s: %r", h->pn);
+			errorexit();
+		}
+		Bseek(f, h->off, 0);
+		h->ld(f, h->pkg, h->len, h->pn);
+		Bterm(f);
+	}
+}
+
+/*
+ * record a flag for the host linker,
+ * from a #pragma cgo_ldflag in a cgo package.
+ */
+void
+addldflag(char *arg)
+{
+	if(nldflag >= mldflag) {
+		if(mldflag == 0)
+			mldflag = 16;
+		else
+			mldflag *= 2;
+		ldflag = realloc(ldflag, mldflag*sizeof ldflag[0]);
+		if(ldflag == nil) {
+			diag("out of memory");
+			errorexit();
+		}
+	}
+	ldflag[nldflag++] = strdup(arg);
+}
+
+/*
+ * record a relocation against a host symbol;
+ * the external linker will apply it.
+ */
+void
+addhostrel(Sym *s, Reloc *r)
+{
+	Hostrel *h;
+
+	if(nhostrel%64 == 0) {
+		hostrel = realloc(hostrel, (nhostrel+64)*sizeof hostrel[0]);
+		if(hostrel == nil) {
+			diag("out of memory");
+			errorexit();
+		}
+	}
+	h = &hostrel[nhostrel++];
+	h->addr = s->value + r->off;
+	h->r = *r;
+}
+
+/*
+ * prepare for external linking: everything the Go objects
+ * refer to but do not define is left to the host linker,
+ * and our own output becomes go.o in a temporary directory.
+ */
+static void
+hostlinksetup(void)
+{
+	Sym *s;
+	char *p;
+
+	for(s = allsym; s != S; s = s->allsym) {
+		if(s->type != SXREF && s->type != SDYNIMPORT)
+			continue;
+		if(strncmp(s->name, "weak.", 5) == 0)
+			continue;
+		s->type = SHOSTOBJ;
+		s->dynimplib = nil;
+		s->dynimpname = nil;
+		s->dynimpvers = nil;
+	}
+
+	if(tmpdir == nil) {
+		tmpdir = mktempdir();
+		if(tmpdir == nil) {
+			diag("cannot create temporary directory: %r");
+			errorexit();
+		}
+		rmtmpdir = 1;
+	}
+
+	// the output file will be written by the host linker;
+	// until then write the Go code to go.o.
+	close(cout);
+	remove(outfile);
+	p = smprint("%s/go.o", tmpdir);
+	cout = create(p, 1, 0775);
+	if(cout < 0) {
+		diag("cannot create %s: %r", p);
+		errorexit();
+	}
+	free(p);
+}
+
+/*
+ * report whether the host linker accepts -no-pie.
+ * compilers configured to build position-independent
+ * executables by default need it, because the Go code
+ * is laid out at fixed addresses; older ones reject it.
+ */
+static int
+hostnopie(void)
+{
+	char *p, *argv[6];
+	int fd, n;
+	static char prog[] = "int main(void) { return 0; }\n";
+
+	p = smprint("%s/nopie.c", tmpdir);
+	fd = create(p, 1, 0664);
+	if(fd < 0) {
+		free(p);
+		return 0;
+	}
+	n = write(fd, prog, sizeof prog - 1);
+	close(fd);
+	free(p);
+	if(n != sizeof prog - 1)
+		return 0;
+
+	// run it through the shell to discard the diagnostics
+	// of a linker that does not know the flag.
+	argv[0] = "sh";
+	argv[1] = "-c";
+	argv[2] = "exec \"$0\" -no-pie -o \"$1/nopie\" \"$1/nopie.c\" >/dev/null 2>&1";
+	argv[3] = extld;
+	argv[4] = tmpdir;
+	argv[5] = nil;
+	return runcmd(argv) == 0;
+}
+
+/*
+ * run the host linker on go.o, which must already have been
+ * written, and the host objects.
+ */
+void
+hostlink(void)
+{
+	char *p, *q, **argv;
+	int i, w, n, argc;
+	int64 len;
+	Hostobj *h;
+	Biobuf *f;
+	static char buf[64<<10];
+
+	if(linkmode != LinkExternal || nerrors > 0)
+		return;
+
+	cursym = nil;	// errors from here on are not about a symbol
+	if(extld == nil)
+		extld = "gcc";
+
+	n = 0;
+	if(extldflags != nil)
+		n = strlen(extldflags)/2 + 1;
+	argv = malloc((11+nhostobj+nldflag+n)*sizeof argv[0]);
+	argc = 0;
+	if(buildmode == BuildmodeCArchive) {
+		// the host linker runs later, when a C program
+		// links against the archive, and go.o is fully
+		// relocatable, so the archive is all we need.
+		argv[argc++] = "ar";
+		argv[argc++] = "rcs";
+		argv[argc++] = outfile;
+	} else {
+		argv[argc++] = extld;
+		if(thechar == '6')
+			argv[argc++] = "-m64";
+		if(debug['s'])
+			argv[argc++] = "-s";
+		if(hostnopie())
+			argv[argc++] = "-no-pie";
+		argv[argc++] = "-o";
+		argv[argc++] = outfile;
+		if(rpath)
+			argv[argc++] = smprint("-Wl,-rpath,%s", rpath);
+
+		// place the Go text and data where we laid them out.
+		argv[argc++] = smprint("-Wl,--section-start=.go.text=%#llux,--section-start=.go.data=%#llux,--section-start=.go.bss=%#llux",
+			(uvlong)segtext.sect->vaddr, (uvlong)segdata.vaddr, (uvlong)(segdata.vaddr+segdata.filelen));
+	}
+
+	// copy the host objects out of their archives.
+	for(i=0; i<nhostobj; i++) {
+		h = &hostobj[i];
+		f = Bopen(h->file, OREAD);
+		if(f == nil) {
+			diag("cannot reopen %s: %r", h->pn);
+			errorexit();
+		}
+		Bseek(f, h->off, 0);
+		p = smprint("%s/%06d.o", tmpdir, i);
+		argv[argc++] = p;
+		w = create(p, 1, 0775);
+		if(w < 0) {
+			diag("cannot create %s: %r", p);
+			errorexit();
+		}
+		len = h->len;
+		while(len > 0 && (n = Bread(f, buf, sizeof buf)) > 0) {
+			if(n > len)
+				n = len;
+			if(write(w, buf, n) != n) {
+				diag("cannot write %s: %r", p);
+				errorexit();
+			}
+			len -= n;
+		}
+		close(w);
+		Bterm(f);
+	}
+
+	// go.o goes after the host objects so that its
+	// thread-local storage ends up closest to the thread pointer,
+	// where the runtime expects it (see tlsoffset).
+	argv[argc++] = smprint("%s/go.o", tmpdir);
+
+	// host linker flags, like the -lpthread that runtime/cgo
+	// needs, are left to the link of the C program.
+	if(buildmode != BuildmodeCArchive) {
+		for(i=0; i<nldflag; i++)
+			argv[argc++] = ldflag[i];
+
+		if(extldflags != nil) {
+			p = strdup(extldflags);
+			for(;;) {
+				while(*p == ' ' || *p == '\t')
+					p++;
+				if(*p == '\0')
+					break;
+				argv[argc++] = p;
+				q = p;
+				while(*q != '\0' && *q != ' ' && *q != '\t')
+					q++;
+				if(*q == '\0')
+					break;
+				*q = '\0';
+				p = q+1;
+			}
+		}
+	}
+	argv[argc] = nil;
+
+	if(debug['v']) {
+		Bprint(&bso, "%5.2f host link:", cputime());
+		for(i=0; i<argc; i++)
+			Bprint(&bso, " %s", argv[i]);
+		Bprint(&bso, "\n");
+		Bflush(&bso);
+	}
+
+	if(runcmd(argv) < 0) {
+		diag("running %s failed: %r", argv[0]);
+		errorexit();
+	}
+}
+
+/*
+ * the flags that ARGBEGIN cannot parse because their names are
+ * longer than one letter.  each may be given as -name value or
+ * -name=value.  they are removed from argv and the remaining
+ * argument count is returned.
+ */
+int
+linkflags(int argc, char **argv)
+{
+	int i, j, n;
+	char *name, *val, *p;
+
+	j = 1;
+	for(i=1; i<argc; i++) {
+		p = argv[i];
+		if(strcmp(p, "--") == 0) {
+			while(i < argc)
+				argv[j++] = argv[i++];
+			break;
+		}
+		if(p[0] != '-') {
+			argv[j++] = p;
+			continue;
+		}
+		name = p+1;
+		if(name[0] == '-')
+			name++;
+		val = strchr(name, '=');
+		n = val ? val-name : strlen(name);
+		if((n != 8 || strncmp(name, "linkmode", 8) != 0) &&
+		   (n != 9 || strncmp(name, "buildmode", 9) != 0) &&
+		   (n != 5 || strncmp(name, "extld", 5) != 0) &&
+		   (n != 10 || strncmp(name, "extldflags", 10) != 0) &&
+		   (n != 6 || strncmp(name, "tmpdir", 6) != 0)) {
+			argv[j++] = p;
+			continue;
+		}
+		if(val != nil)
+			val++;
+		else {
+			if(i+1 >= argc)
+				usage();
+			val = argv[++i];
+		}
+		switch(n) {
+		case 8:
+			if(strcmp(val, "auto") == 0)
+				linkmode = LinkAuto;
+			else if(strcmp(val, "internal") == 0)
+				linkmode = LinkInternal;
+			else if(strcmp(val, "external") == 0)
+				linkmode = LinkExternal;
+			else {
+				print("unknown link mode -linkmode %s\n", val);
+				usage();
+			}
+			break;
+		case 9:
+			if(strcmp(val, "exe") == 0)
+				buildmode = BuildmodeExe;
+			else if(strcmp(val, "c-archive") == 0)
+				buildmode = BuildmodeCArchive;
+			else if(strcmp(val, "c-shared") == 0)
+				buildmode = BuildmodeCShared;
+			else {
+				print("unknown build mode -buildmode %s\n", val);
+				usage();
+			}
+			break;
+		case 5:
+			extld = val;
+			break;
+		case 10:
+			extldflags = val;
+			break;
+		case 6:
+			tmpdir = val;
+			break;
+		}
+	}
+	argv[j] = nil;
+	return j;
+}
+
 /*
  * look for the next file in an archive.
  * adapted from libmach.
@@ -360,7 +815,7 @@ objfile(char *file, char *pkg)
 		/* load it as a regular file */
 		l = Bseek(f, 0L, 2);
 		Bseek(f, 0L, 0);
-		ldobj(f, pkg, l, file, FileObj);
+		ldobj(f, pkg, l, file, file, FileObj);
 		Bterm(f);
 		free(pkg);
 		return;
@@ -419,7 +874,7 @@ objfile(char *file, char *pkg)
 			l--;
 		snprint(pname, sizeof pname, "%s(%.*s)", file, utfnlen(arhdr.name, l), arhdr.name);
 		l = atolwhex(arhdr.size);
-		ldobj(f, pkg, l, pname, ArchiveObj);
+		ldobj(f, pkg, l, pname, file, ArchiveObj);
 	}
 
 out:
@@ -428,7 +883,7 @@ out:
 }
 
 void
-ldobj(Biobuf *f, char *pkg, int64 len, char *pn, int whence)
+ldobj(Biobuf *f, char *pkg, int64 len, char *pn, char *file, int whence)
 {
 	char *line;
 	int n, c1, c2, c3, c4;
@@ -451,7 +906,7 @@ ldobj(Biobuf *f, char *pkg, int64 len, char *pn, int whence)
 
 	magic = c1<<24 | c2<<16 | c3<<8 | c4;
 	if(magic == 0x7f454c46) {	// \x7F E L F
-		ldelf(f, pkg, len, pn);
+		ldhostobj(ldelf, f, pkg, len, pn, file);
 		free(pn);
 		return;
 	}
@@ -928,7 +1383,7 @@ unmal(void *v, uint32 n)
  *
  * Must be same as ../gc/subr.c:/^pathtoprefix.
  */
-static char*
+char*
 pathtoprefix(char *s)
 {
 	static char hex[] = "0123456789abcdef";
@@ -1478,3 +1933,32 @@ cwrite(void *buf, int n)
 	}
 	coutpos += n;
 }
+
+// cread reads n bytes at offset off back from the output file.
+void
+cread(vlong off, void *buf, int n)
+{
+	cflush();
+	seek(cout, off, 0);
+	if(readn(cout, buf, n) != n) {
+		diag("read error: %r");
+		errorexit();
+	}
+	seek(cout, coutpos, 0);
+}
+
+// ctruncate cuts the output file off at the current position.
+void
+ctruncate(void)
+{
+	vlong p;
+
+	p = cpos();
+	cflush();
+	if(ftruncate(cout, p) < 0) {
+		diag("truncate error: %r");
+		errorexit();
+	}
+	seek(cout, p, 0);
+	coutpos = p;
+}
@@ -39,6 +39,7 @@ enum
 	SSTRING,
 	SGOSTRING,
 	SRODATA,
+	STYPELINK,
 	SSYMTAB,
 	SPCLNTAB,
 	SELFROSECT,
@@ -59,6 +60,7 @@ enum
 	SFILE,
 	SCONST,
 	SDYNIMPORT,
+	SHOSTOBJ,	/* defined by a host object; resolved by the external linker */
 
 	SSUB = 1<<8,	/* sub-symbol, linked from parent via ->sub list */
 	
@@ -128,6 +130,11 @@ EXTERN	char*	thestring;
 EXTERN	int	ndynexp;
 EXTERN	int	havedynamic;
 EXTERN	int	iscgo;
+EXTERN	int	linkmode;
+EXTERN	int	buildmode;
+EXTERN	char*	extld;
+EXTERN	char*	extldflags;
+EXTERN	char*	tmpdir;
 
 EXTERN	Segment	segtext;
 EXTERN	Segment	segdata;
@@ -164,7 +171,13 @@ void	Lflag(char *arg);
 void	usage(void);
 void	adddynrel(Sym*, Reloc*);
 void	ldobj1(Biobuf *f, char*, int64 len, char *pn);
-void	ldobj(Biobuf*, char*, int64, char*, int);
+void	ldobj(Biobuf*, char*, int64, char*, char*, int);
+void	ldhostobj(void (*ld)(Biobuf*, char*, int64, char*), Biobuf*, char*, int64, char*, char*);
+void	hostobjs(void);
+void	hostlink(void);
+void	addhostrel(Sym*, Reloc*);
+void	addldflag(char*);
+int	linkflags(int, char**);
 void	ldelf(Biobuf*, char*, int64, char*);
 void	ldmacho(Biobuf*, char*, int64, char*);
 void	ldpe(Biobuf*, char*, int64, char*);
@@ -182,6 +195,8 @@ void	relocsym(Sym*);
 void	savedata(Sym*, Prog*, char*);
 void	symgrow(Sym*, int32);
 void	addstrdata(char*, char*);
+void	addstrdata1(char*);
+char*	pathtoprefix(char*);
 vlong	addstring(Sym*, char*);
 vlong	adduint32(Sym*, uint32);
 vlong	adduint64(Sym*, uint64);
@@ -251,6 +266,34 @@ enum {
 	Pkgdef
 };
 
+/* link modes */
+enum {
+	LinkAuto = 0,	// external if a cgo package needs it, else internal
+	LinkInternal,	// link everything, host objects included, ourselves
+	LinkExternal,	// write a host object and let the host linker finish
+};
+
+/* build modes */
+enum {
+	BuildmodeExe = 0,	// a program
+	BuildmodeCArchive,	// a C archive (.a) for C programs to link against
+	BuildmodeCShared,	// a C shared library; not supported
+};
+
+/*
+ * a reference from Go code to a symbol defined by
+ * a host object, recorded during external linking.
+ */
+typedef struct Hostrel Hostrel;
+struct Hostrel
+{
+	vlong	addr;	// address of the relocated bytes
+	Reloc	r;
+};
+
+EXTERN	Hostrel*	hostrel;
+EXTERN	int	nhostrel;
+
 /* executable header types */
 enum {
 	Hgarbunix = 0,	// garbage unix
@@ -310,5 +353,8 @@ void	cflush(void);
 vlong	cpos(void);
 void	cseek(vlong);
 void	cwrite(void*, int);
+void	cread(vlong, void*, int);
+void	ctruncate(void);
 void	importcycles(void);
 int	Zconv(Fmt*);
+uchar*	zlibcompress(uchar*, int32, int32*);
@@ -0,0 +1,229 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Zlib (RFC 1950) compression, for the .zdebug sections.
+// The deflate stream (RFC 1951) is a single block of LZ77
+// matches found by hash chains, coded with the fixed Huffman
+// codes, which saves writing code tables and does well enough
+// on the very repetitive debug sections.
+
+#include	"l.h"
+#include	"lib.h"
+
+enum
+{
+	WindowSize = 1<<15,
+	HashBits = 15,
+	HashSize = 1<<HashBits,
+	MinMatch = 3,
+	MaxMatch = 258,
+	MaxChain = 64,
+};
+
+typedef struct Zout Zout;
+struct Zout
+{
+	uchar*	p;
+	int32	n;
+	int32	cap;
+	uint32	bits;	// pending bits, low bit first
+	int	nbits;
+};
+
+static void
+zbyte(Zout *z, int c)
+{
+	if(z->n >= z->cap) {
+		z->cap = z->cap*2 + 1024;
+		z->p = realloc(z->p, z->cap);
+		if(z->p == nil) {
+			diag("out of memory");
+			errorexit();
+		}
+	}
+	z->p[z->n++] = c;
+}
+
+// zbits writes the n low bits of v, low bit first.
+static void
+zbits(Zout *z, uint32 v, int n)
+{
+	z->bits |= v << z->nbits;
+	z->nbits += n;
+	while(z->nbits >= 8) {
+		zbyte(z, z->bits);
+		z->bits >>= 8;
+		z->nbits -= 8;
+	}
+}
+
+// zcode writes the n-bit Huffman code c, which deflate
+// stores high bit first.
+static void
+zcode(Zout *z, uint32 c, int n)
+{
+	uint32 r;
+	int i;
+
+	r = 0;
+	for(i=0; i<n; i++) {
+		r = (r<<1) | (c&1);
+		c >>= 1;
+	}
+	zbits(z, r, n);
+}
+
+// zlit writes literal or length symbol c with the fixed codes.
+static void
+zlit(Zout *z, int c)
+{
+	if(c < 144)
+		zcode(z, 0x30+c, 8);
+	else if(c < 256)
+		zcode(z, 0x190+c-144, 9);
+	else if(c < 280)
+		zcode(z, c-256, 7);
+	else
+		zcode(z, 0xc0+c-280, 8);
+}
+
+static int lenbase[] = {
+	3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31,
+	35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258,
+};
+static int lenextra[] = {
+	0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2,
+	3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0,
+};
+static int distbase[] = {
+	1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193,
+	257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145,
+	8193, 12289, 16385, 24577,
+};
+static int distextra[] = {
+	0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6,
+	7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13,
+};
+
+static void
+zmatch(Zout *z, int len, int dist)
+{
+	int i;
+
+	for(i=nelem(lenbase)-1; lenbase[i] > len; i--)
+		;
+	zlit(z, 257+i);
+	zbits(z, len-lenbase[i], lenextra[i]);
+	for(i=nelem(distbase)-1; distbase[i] > dist; i--)
+		;
+	zcode(z, i, 5);
+	zbits(z, dist-distbase[i], distextra[i]);
+}
+
+static uint32
+zhash(uchar *p)
+{
+	return ((p[0]<<10) ^ (p[1]<<5) ^ p[2]) & (HashSize-1);
+}
+
+static uint32
+adler32(uchar *p, int32 n)
+{
+	uint32 a, b;
+	int32 i, k;
+
+	a = 1;
+	b = 0;
+	while(n > 0) {
+		// 5552 is the most bytes that cannot overflow b.
+		k = n < 5552 ? n : 5552;
+		for(i=0; i<k; i++) {
+			a += p[i];
+			b += a;
+		}
+		a %= 65521;
+		b %= 65521;
+		p += k;
+		n -= k;
+	}
+	return (b<<16) | a;
+}
+
+/*
+ * zlibcompress returns the zlib stream for the n bytes at p,
+ * in memory obtained from malloc, and sets *np to its length.
+ */
+uchar*
+zlibcompress(uchar *p, int32 n, int32 *np)
+{
+	Zout z;
+	int32 *head, *prev, i, j, k, best, bestdist, chain, lim;
+	uint32 h, sum;
+
+	memset(&z, 0, sizeof z);
+	head = malloc(HashSize*sizeof head[0]);
+	prev = malloc(WindowSize*sizeof prev[0]);
+	if(head == nil || prev == nil) {
+		diag("out of memory");
+		errorexit();
+	}
+	for(i=0; i<HashSize; i++)
+		head[i] = -1;
+
+	zbyte(&z, 0x78);	// deflate, 32 kB window
+	zbyte(&z, 0x01);	// fastest; check bits make 0x7801 a multiple of 31
+	zbits(&z, 1, 1);	// last block
+	zbits(&z, 1, 2);	// fixed Huffman codes
+
+	for(i=0; i<n; ) {
+		best = 0;
+		bestdist = 0;
+		if(i+MinMatch <= n) {
+			lim = n-i;
+			if(lim > MaxMatch)
+				lim = MaxMatch;
+			h = zhash(p+i);
+			chain = 0;
+			for(j=head[h]; j >= 0 && i-j <= WindowSize-1 && chain < MaxChain; j=prev[j%WindowSize]) {
+				chain++;
+				if(p[j+best] != p[i+best])
+					continue;
+				for(k=0; k<lim && p[j+k] == p[i+k]; k++)
+					;
+				if(k > best) {
+					best = k;
+					bestdist = i-j;
+					if(k == lim)
+						break;
+				}
+			}
+		}
+		if(best < MinMatch) {
+			zlit(&z, p[i]);
+			best = 1;
+		} else
+			zmatch(&z, best, bestdist);
+		for(k=0; k<best; k++, i++) {
+			if(i+MinMatch > n)
+				continue;
+			h = zhash(p+i);
+			prev[i%WindowSize] = head[h];
+			head[h] = i;
+		}
+	}
+	zlit(&z, 256);	// end of block
+	if(z.nbits > 0)
+		zbits(&z, 0, 8-z.nbits);
+
+	sum = adler32(p, n);
+	zbyte(&z, sum>>24);
+	zbyte(&z, sum>>16);
+	zbyte(&z, sum>>8);
+	zbyte(&z, sum);
+
+	free(head);
+	free(prev);
+	*np = z.n;
+	return z.p;
+}
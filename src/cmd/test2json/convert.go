@@ -0,0 +1,272 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An event is a single line of the JSON output.  See doc.go.
+type event struct {
+	Time    *time.Time `json:",omitempty"`
+	Action  string
+	Package string   `json:",omitempty"`
+	Test    string   `json:",omitempty"`
+	Elapsed *float64 `json:",omitempty"`
+	Output  string   `json:",omitempty"`
+}
+
+// A converter turns the text printed by a test binary, and by
+// go test about it, into events.  It is fed one line at a time.
+type converter struct {
+	enc   *json.Encoder
+	pkg   string    // package to report in every event
+	times bool      // whether to add time stamps
+	start time.Time // when the test binary started, if known
+
+	test     string // test now running, to which plain output belongs
+	result   *event // result of the last test, held until its log output ends
+	failed   bool   // a FAIL line was seen
+	panicked bool   // the test binary panicked
+	pkgDone  bool   // the package result has been reported
+}
+
+func newConverter(w io.Writer, pkg string, times bool) *converter {
+	return &converter{enc: json.NewEncoder(w), pkg: pkg, times: times}
+}
+
+// Markers at the start of the lines printed by the testing package.
+var (
+	runMarkers    = []string{"=== RUN: ", "=== RUN "}
+	resultMarkers = map[string]string{
+		"--- PASS: ":  "pass",
+		"--- FAIL: ":  "fail",
+		"--- SKIP: ":  "skip",
+		"--- BENCH: ": "bench",
+	}
+)
+
+// line processes one line of output, which includes its final
+// newline unless it ends the input.
+func (c *converter) line(line string) {
+	text := strings.TrimRight(line, "\r\n")
+
+	if strings.HasPrefix(text, "panic: ") {
+		// The testing package prints the result of a test
+		// that panics, a pass unless it had failed already,
+		// and then lets the panic kill the test binary.
+		c.panicked = true
+		c.failed = true
+	}
+	if strings.HasPrefix(text, "FAIL\t") {
+		// go test's line for a failed package.  A test still
+		// running or just reported is what killed the binary.
+		c.died()
+	}
+
+	if c.result != nil {
+		// Log output follows the result line of a test;
+		// it ends at the next line the testing package
+		// or go test prints on its own account.
+		if !isMarker(text) {
+			c.output(c.result.Test, line)
+			return
+		}
+		c.flush()
+	}
+
+	for _, m := range runMarkers {
+		if strings.HasPrefix(text, m) {
+			name := strings.TrimSpace(text[len(m):])
+			c.send(&event{Action: "run", Test: name})
+			c.output(name, line)
+			c.test = name
+			return
+		}
+	}
+	if strings.HasPrefix(text, "=== PAUSE ") {
+		name := strings.TrimSpace(text[len("=== PAUSE "):])
+		c.output(name, line)
+		c.send(&event{Action: "pause", Test: name})
+		if c.test == name {
+			c.test = ""
+		}
+		return
+	}
+	if strings.HasPrefix(text, "=== CONT ") {
+		name := strings.TrimSpace(text[len("=== CONT "):])
+		c.send(&event{Action: "cont", Test: name})
+		c.output(name, line)
+		c.test = name
+		return
+	}
+	if strings.HasPrefix(text, "Benchmark") {
+		// A benchmark result, or, if the benchmark failed,
+		// the benchmark name followed by its FAIL line.
+		name, rest := text, ""
+		if i := strings.Index(text, "\t"); i >= 0 {
+			name, rest = text[:i], strings.TrimSpace(text[i+1:])
+		}
+		c.output(name, line)
+		if strings.HasPrefix(rest, "--- FAIL: ") {
+			c.result = &event{Action: "fail", Test: name}
+		}
+		return
+	}
+	for m, action := range resultMarkers {
+		if strings.HasPrefix(text, m) {
+			name, elapsed := parseResult(text[len(m):])
+			c.output(name, line)
+			c.result = &event{Action: action, Test: name, Elapsed: elapsed}
+			if action == "fail" {
+				c.failed = true
+			}
+			if c.test == name {
+				c.test = ""
+			}
+			return
+		}
+	}
+
+	switch {
+	case text == "PASS":
+		c.output("", line)
+	case text == "FAIL":
+		c.failed = true
+		c.output("", line)
+	case strings.HasPrefix(text, "ok  \t"):
+		c.output("", line)
+		c.finish("pass", parseSummary(text))
+	case strings.HasPrefix(text, "FAIL\t"):
+		c.output("", line)
+		c.finish("fail", parseSummary(text))
+	case strings.HasPrefix(text, "?   \t"):
+		c.output("", line)
+		c.finish("skip", nil)
+	default:
+		c.output(c.test, line)
+	}
+}
+
+// isMarker reports whether text is a line that the testing
+// package or go test prints, as opposed to test log output.
+func isMarker(text string) bool {
+	if text == "PASS" || text == "FAIL" || strings.HasPrefix(text, "Benchmark") {
+		return true
+	}
+	for _, prefix := range []string{"=== ", "--- ", "ok  \t", "FAIL\t", "?   \t"} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseResult parses the rest of a test result line,
+// "TestName (0.01 seconds)", returning the name and elapsed time.
+func parseResult(s string) (name string, elapsed *float64) {
+	s = strings.TrimSpace(s)
+	name = s
+	if i := strings.Index(s, " ("); i >= 0 {
+		name = s[:i]
+		t := trimSuffix(trimSuffix(s[i+2:], ")"), " seconds")
+		t = trimSuffix(t, "s")
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			elapsed = &f
+		}
+	}
+	return name, elapsed
+}
+
+func trimSuffix(s, suffix string) string {
+	if strings.HasSuffix(s, suffix) {
+		return s[:len(s)-len(suffix)]
+	}
+	return s
+}
+
+// parseSummary returns the elapsed time in the package summary line
+// printed by go test, "ok  \tpkg\t0.011s".
+func parseSummary(text string) *float64 {
+	f := strings.Split(text, "\t")
+	if len(f) < 3 || !strings.HasSuffix(f[2], "s") {
+		return nil
+	}
+	t, err := strconv.ParseFloat(f[2][:len(f[2])-1], 64)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// output sends an output event for line, attributed to test.
+func (c *converter) output(test, line string) {
+	c.send(&event{Action: "output", Test: test, Output: line})
+}
+
+// died reports the tests that were cut short by the test binary dying:
+// a pending pass result becomes a failure, and a test still running
+// fails.
+func (c *converter) died() {
+	if c.result != nil && c.result.Action == "pass" {
+		c.result.Action = "fail"
+	}
+	c.flush()
+	if c.test != "" {
+		c.send(&event{Action: "fail", Test: c.test})
+		c.test = ""
+	}
+	c.failed = true
+}
+
+// flush sends the pending test result, if any.
+func (c *converter) flush() {
+	if c.result != nil {
+		c.send(c.result)
+		c.result = nil
+	}
+}
+
+// finish sends the result of the package.
+func (c *converter) finish(action string, elapsed *float64) {
+	c.flush()
+	if c.pkgDone {
+		return
+	}
+	c.pkgDone = true
+	c.send(&event{Action: action, Elapsed: elapsed})
+}
+
+// exit is called at the end of the input.  If the package result
+// was not reported already, it sends one: pass if the test binary
+// succeeded and printed no FAIL line, fail otherwise.
+func (c *converter) exit(ok bool) {
+	if !ok || c.panicked {
+		c.died()
+	}
+	var elapsed *float64
+	if !c.start.IsZero() {
+		t := time.Now().Sub(c.start).Seconds()
+		elapsed = &t
+	}
+	action := "pass"
+	if !ok || c.failed {
+		action = "fail"
+	}
+	c.finish(action, elapsed)
+}
+
+func (c *converter) send(e *event) {
+	e.Package = c.pkg
+	if c.times {
+		now := time.Now()
+		e.Time = &now
+	}
+	c.enc.Encode(e)
+}
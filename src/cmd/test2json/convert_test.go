@@ -0,0 +1,180 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var convertTests = []struct {
+	in   string
+	ok   bool
+	want []string // action test output
+}{
+	{
+		"=== RUN TestA\n" +
+			"--- PASS: TestA (0.01 seconds)\n" +
+			"\ta_test.go:10: log\n" +
+			"=== RUN TestB\n" +
+			"hello\n" +
+			"--- FAIL: TestB (0.20 seconds)\n" +
+			"FAIL\n" +
+			"FAIL\tpkg\t0.25s\n",
+		true,
+		[]string{
+			`run TestA ""`,
+			`output TestA "=== RUN TestA\n"`,
+			`output TestA "--- PASS: TestA (0.01 seconds)\n"`,
+			`output TestA "\ta_test.go:10: log\n"`,
+			`pass TestA "" 0.01`,
+			`run TestB ""`,
+			`output TestB "=== RUN TestB\n"`,
+			`output TestB "hello\n"`,
+			`output TestB "--- FAIL: TestB (0.20 seconds)\n"`,
+			`fail TestB "" 0.2`,
+			`output  "FAIL\n"`,
+			`output  "FAIL\tpkg\t0.25s\n"`,
+			`fail  "" 0.25`,
+		},
+	},
+	{
+		"=== RUN TestP\n" +
+			"=== PAUSE TestP\n" +
+			"=== CONT TestP\n" +
+			"--- PASS: TestP (0.00 seconds)\n" +
+			"=== RUN: ExampleX\n" +
+			"--- FAIL: ExampleX (0.00 seconds)\n" +
+			"got:\n" +
+			"x\n" +
+			"FAIL\n",
+		false,
+		[]string{
+			`run TestP ""`,
+			`output TestP "=== RUN TestP\n"`,
+			`output TestP "=== PAUSE TestP\n"`,
+			`pause TestP ""`,
+			`cont TestP ""`,
+			`output TestP "=== CONT TestP\n"`,
+			`output TestP "--- PASS: TestP (0.00 seconds)\n"`,
+			`pass TestP "" 0`,
+			`run ExampleX ""`,
+			`output ExampleX "=== RUN: ExampleX\n"`,
+			`output ExampleX "--- FAIL: ExampleX (0.00 seconds)\n"`,
+			`output ExampleX "got:\n"`,
+			`output ExampleX "x\n"`,
+			`fail ExampleX "" 0`,
+			`output  "FAIL\n"`,
+			`fail  ""`,
+		},
+	},
+	{
+		"PASS\n" +
+			"BenchmarkX\t 1000\t 1234 ns/op\n" +
+			"BenchmarkY\t--- FAIL: BenchmarkY\n" +
+			"\ty_test.go:5: bad\n" +
+			"ok  \tpkg\t(cached)\n",
+		true,
+		[]string{
+			`output  "PASS\n"`,
+			`output BenchmarkX "BenchmarkX\t 1000\t 1234 ns/op\n"`,
+			`output BenchmarkY "BenchmarkY\t--- FAIL: BenchmarkY\n"`,
+			`output BenchmarkY "\ty_test.go:5: bad\n"`,
+			`fail BenchmarkY ""`,
+			`output  "ok  \tpkg\t(cached)\n"`,
+			`pass  ""`,
+		},
+	},
+	{
+		// A panicking test is reported as passed before the
+		// panic kills the binary.
+		"=== RUN TestA\n" +
+			"--- PASS: TestA (0.00 seconds)\n" +
+			"=== RUN TestB\n" +
+			"--- PASS: TestB (0.00 seconds)\n" +
+			"panic: boom [recovered]\n" +
+			"\tpanic: boom\n" +
+			"exit status 2\n" +
+			"FAIL\tpkg\t0.01s\n",
+		true,
+		[]string{
+			`run TestA ""`,
+			`output TestA "=== RUN TestA\n"`,
+			`output TestA "--- PASS: TestA (0.00 seconds)\n"`,
+			`pass TestA "" 0`,
+			`run TestB ""`,
+			`output TestB "=== RUN TestB\n"`,
+			`output TestB "--- PASS: TestB (0.00 seconds)\n"`,
+			`output TestB "panic: boom [recovered]\n"`,
+			`output TestB "\tpanic: boom\n"`,
+			`output TestB "exit status 2\n"`,
+			`fail TestB "" 0`,
+			`output  "FAIL\tpkg\t0.01s\n"`,
+			`fail  "" 0.01`,
+		},
+	},
+	{
+		// The same, read straight from the test binary.
+		"=== RUN TestB\n" +
+			"--- PASS: TestB (0.00 seconds)\n" +
+			"panic: boom [recovered]\n",
+		true,
+		[]string{
+			`run TestB ""`,
+			`output TestB "=== RUN TestB\n"`,
+			`output TestB "--- PASS: TestB (0.00 seconds)\n"`,
+			`output TestB "panic: boom [recovered]\n"`,
+			`fail TestB "" 0`,
+			`fail  ""`,
+		},
+	},
+	{
+		// A binary that dies while a test runs.
+		"=== RUN TestC\n" +
+			"unexpected fault address 0x0\n",
+		false,
+		[]string{
+			`run TestC ""`,
+			`output TestC "=== RUN TestC\n"`,
+			`output TestC "unexpected fault address 0x0\n"`,
+			`fail TestC ""`,
+			`fail  ""`,
+		},
+	},
+}
+
+func TestConvert(t *testing.T) {
+	for i, tt := range convertTests {
+		var buf bytes.Buffer
+		c := newConverter(&buf, "pkg", false)
+		if err := convert(c, strings.NewReader(tt.in)); err != nil {
+			t.Fatal(err)
+		}
+		c.exit(tt.ok)
+
+		var got []string
+		dec := json.NewDecoder(&buf)
+		for {
+			var e event
+			if err := dec.Decode(&e); err != nil {
+				break
+			}
+			if e.Package != "pkg" {
+				t.Errorf("#%d: event has package %q, want pkg", i, e.Package)
+			}
+			s := fmt.Sprintf("%s %s %q", e.Action, e.Test, e.Output)
+			if e.Elapsed != nil {
+				s += fmt.Sprintf(" %v", *e.Elapsed)
+			}
+			got = append(got, s)
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("#%d: events:\n%s\nwant:\n%s", i, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+
+Test2json converts the output of go test or of a test binary into a
+machine-readable stream of JSON events.
+
+Usage:
+
+	go tool test2json [-p pkg] [-t] [./pkg.test -test.v]
+
+With no arguments, test2json reads test output from standard input.
+Otherwise it runs the named test binary with the given arguments and
+converts the binary's standard output and standard error, which it
+combines.  The test binary should be run with -test.v, so that it
+announces each test as it starts; 'go test -json' does this itself.
+
+The flags are:
+	-p pkg
+		Report pkg as the package of every event.
+	-t
+		Add a time stamp to every event.
+
+The output is a sequence of JSON objects, one per line, each encoding
+a value of this type:
+
+	type TestEvent struct {
+		Time    time.Time // encodes as an RFC3339-format string
+		Action  string
+		Package string
+		Test    string
+		Elapsed float64 // seconds
+		Output  string
+	}
+
+Fields that do not apply to an event are omitted.  The Action field
+is one of:
+
+	run    - the test has started running
+	pause  - the test has been paused for running in parallel
+	cont   - the test has continued running
+	pass   - the test passed
+	bench  - the benchmark printed log output but did not fail
+	fail   - the test or benchmark failed
+	skip   - the test was skipped or the package contained no tests
+	output - the test printed output
+
+The Test field names the test, example or benchmark the event is
+about.  Events without a Test field are about the package as a whole:
+a final pass, fail or skip event gives the result of the package,
+and output events carry the lines printed outside of any test.
+Elapsed, set on pass and fail events, is the running time of the
+test or package.
+
+Output events carry one line of output each, including the newline.
+All of a test's output is reported before the event that ends it.
+Lines printed while a test runs are attributed to that test; when
+several tests run in parallel the attribution is only as good as the
+test binary's own reporting.
+
+*/
+package main
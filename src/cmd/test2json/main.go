@@ -0,0 +1,82 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+var (
+	flagP = flag.String("p", "", "report the named package as the package being tested")
+	flagT = flag.Bool("t", false, "add time stamps to events")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go tool test2json [-p pkg] [-t] [./pkg.test -test.v]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	c := newConverter(os.Stdout, *flagP, *flagT)
+	if flag.NArg() == 0 {
+		err := convert(c, os.Stdin)
+		c.exit(err == nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "test2json: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	args := flag.Args()
+	c.start = time.Now()
+	cmd := exec.Command(args[0], args[1:]...)
+	r, w := io.Pipe()
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "test2json: %v\n", err)
+		os.Exit(1)
+	}
+	done := make(chan error)
+	go func() {
+		done <- convert(c, r)
+	}()
+	err := cmd.Wait()
+	w.Close()
+	<-done
+	c.exit(err == nil)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// convert passes the lines read from r to c.
+func convert(c *converter, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			c.line(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,135 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code to check that locks are not passed by value.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// checkCopyLocksAssign checks whether an assignment
+// copies a lock.
+func (f *File) checkCopyLocksAssign(as *ast.AssignStmt) {
+	if !vet("copylocks") || len(as.Rhs) > len(as.Lhs) {
+		return
+	}
+	for i, x := range as.Rhs {
+		if id, ok := as.Lhs[i].(*ast.Ident); ok && id.Name == "_" {
+			continue // nothing is copied
+		}
+		if path := f.lockPathRhs(x); path != nil {
+			f.Badf(x.Pos(), "assignment copies lock value to %v: %v", f.gofmt(as.Lhs[i]), path)
+		}
+	}
+}
+
+// checkCopyLocksFunc checks whether a function might
+// inadvertently copy a lock, by checking whether
+// its receiver or parameters contain a lock.
+// A nil recv or typ is ignored.
+func (f *File) checkCopyLocksFunc(name string, recv *ast.FieldList, typ *ast.FuncType) {
+	if !vet("copylocks") {
+		return
+	}
+	if recv != nil && len(recv.List) > 0 {
+		expr := recv.List[0].Type
+		if path := lockPath(f.pkg.pkg, f.typeOf(expr)); path != nil {
+			f.Badf(expr.Pos(), "%s passes Lock by value: %v", name, path)
+		}
+	}
+	if typ != nil && typ.Params != nil {
+		for _, field := range typ.Params.List {
+			expr := field.Type
+			if path := lockPath(f.pkg.pkg, f.typeOf(expr)); path != nil {
+				f.Badf(expr.Pos(), "%s passes Lock by value: %v", name, path)
+			}
+		}
+	}
+}
+
+// checkCopyLocksRange checks whether a range statement
+// might inadvertently copy a lock by checking whether
+// any of the range variables are locks.
+func (f *File) checkCopyLocksRange(r *ast.RangeStmt) {
+	if !vet("copylocks") {
+		return
+	}
+	f.checkCopyLocksRangeVar(r.Key)
+	f.checkCopyLocksRangeVar(r.Value)
+}
+
+func (f *File) checkCopyLocksRangeVar(e ast.Expr) {
+	if e == nil {
+		return
+	}
+	if id, ok := e.(*ast.Ident); ok && id.Name == "_" {
+		return
+	}
+	if path := lockPath(f.pkg.pkg, f.typeOf(e)); path != nil {
+		f.Badf(e.Pos(), "range var %s copies Lock: %v", f.gofmt(e), path)
+	}
+}
+
+// typePath is the chain of types from a lock-holding type
+// down to the lock itself, outermost first.
+type typePath []types.Type
+
+// String pretty-prints a typePath.
+func (path typePath) String() string {
+	var buf bytes.Buffer
+	for i := range path {
+		if i > 0 {
+			fmt.Fprint(&buf, " contains ")
+		}
+		fmt.Fprint(&buf, path[i].String())
+	}
+	return buf.String()
+}
+
+// lockPathRhs returns the lock path of the right-hand side x of an
+// assignment, or nil. Composite literals and function results are
+// fresh values, so assigning them does not copy an existing lock.
+func (f *File) lockPathRhs(x ast.Expr) typePath {
+	switch x.(type) {
+	case *ast.CompositeLit, *ast.CallExpr:
+		return nil
+	}
+	return lockPath(f.pkg.pkg, f.typeOf(x))
+}
+
+// lockPath returns a typePath describing the location of a lock value
+// contained in typ. If there is no contained lock, it returns nil.
+func lockPath(tpkg *types.Package, typ types.Type) typePath {
+	if typ == nil {
+		return nil
+	}
+
+	// We're only interested in the case in which the underlying
+	// type is a struct. (Interfaces and pointers are safe to copy.)
+	styp, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	// We're looking for cases in which a reference to this type
+	// can be locked, but a value cannot. This differentiates
+	// embedded interfaces from embedded values.
+	if plock := types.NewMethodSet(types.NewPointer(typ)).Lookup(tpkg, "Lock"); plock != nil {
+		if lock := types.NewMethodSet(typ).Lookup(tpkg, "Lock"); lock == nil {
+			return typePath{typ}
+		}
+	}
+
+	for i := 0; i < styp.NumFields(); i++ {
+		if subpath := lockPath(tpkg, styp.Field(i).Type()); subpath != nil {
+			return append(typePath{typ}, subpath...)
+		}
+	}
+	return nil
+}
@@ -9,9 +9,17 @@ calls whose arguments do not align with the format string. Vet uses heuristics
 that do not guarantee all reports are genuine problems, but it can find errors
 not caught by the compilers.
 
+By default all checks are performed, except the experimental -shadow check,
+but if explicit flags are provided, only those identified by the flags are
+performed.
+
+Checks that need type information use the go/types package to type-check the
+package first. If type-checking fails, those checks work with the partial
+information available and may miss problems; the -v flag reports the error.
+
 Available checks:
 
-1. Printf family
+1. Printf family, flag -printf
 
 Suspicious calls to functions in the Printf family, including any functions
 with these names:
@@ -28,7 +36,7 @@ complains about arguments that look like format descriptor strings.
 It also checks for errors such as using a Writer as the first argument of
 Printf.
 
-2. Methods
+2. Methods, flag -methods
 
 Non-standard signatures for methods with familiar names, including:
 	Format GobEncode GobDecode MarshalJSON MarshalXML
@@ -36,16 +44,61 @@ Non-standard signatures for methods with familiar names, including:
 	UnmarshalJSON UnreadByte UnreadRune WriteByte
 	WriteTo
 
-3. Struct tags
+3. Struct tags, flag -structtags
 
 Struct tags that do not follow the format understood by reflect.StructTag.Get.
 
+4. Untagged composite literals, flag -composites
+
+Composite struct literals that do not use the field-keyed syntax for struct
+types declared in other packages, including literals whose type is elided
+inside a slice, array or map literal.
+
+5. Unreachable code, flag -unreachable
+
+Statements that follow a return, goto, break, continue or panic, or an
+if, for, switch or select statement that never completes normally. A final
+return or panic that the compilers require is not reported.
+
+6. Copying locks, flag -copylocks
+
+Values of types that hold a sync.Mutex or sync.RWMutex (types whose pointer
+has a Lock method but whose value does not) that are passed by value as
+receivers or parameters, copied by assignment, or copied into range variables.
+
+7. Misuse of sync/atomic, flag -atomic
+
+Assignments of the result of an atomic.Add function to the variable being
+updated, as in x = atomic.AddUint64(&x, 1), which defeats the atomicity.
+
+8. Shadowed err variables, flag -shadow
+
+Declarations of an err variable that shadow an err of the same type from an
+enclosing scope of the same function, where the outer err is read after the
+inner scope ends without having been assigned in between.  This check is
+experimental and is not run by default; request it with -shadow.
+
+9. Self-assignment, flag -assign
+
+Assignments of the form x = x, which are useless and usually a mistake.
+
+10. Shifts, flag -shift
+
+Shifts of fixed-size integers by constant amounts that equal or exceed the
+width of the shifted type.
+
+11. Range loop variables, flag -rangeloops
+
+Loop variables that are used in a function literal that is run by a go or
+defer statement at the end of the loop body. The function probably runs
+after the loop variable has been updated by the next iteration.
+
 Usage:
 
 	go tool vet [flag] [file.go ...]
 	go tool vet [flag] [directory ...] # Scan all .go files under directory, recursively
 
-The flags are:
+Other flags:
 	-v
 		Verbose mode
 	-printfuncs
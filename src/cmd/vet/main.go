@@ -11,9 +11,11 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
+	"go/printer"
 	"go/token"
-	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -23,6 +25,34 @@ import (
 var verbose = flag.Bool("v", false, "verbose")
 var exitCode = 0
 
+// Flags to control which checks to perform. "all" is set to true here, and
+// disabled later if any other flag is set explicitly.
+var report = map[string]*bool{
+	"all":         flag.Bool("all", true, "check everything but -shadow; disabled if any explicit check is requested"),
+	"assign":      flag.Bool("assign", false, "check for useless assignments"),
+	"atomic":      flag.Bool("atomic", false, "check for common mistaken usages of the sync/atomic package"),
+	"composites":  flag.Bool("composites", false, "check that composite literals used type-tagged elements"),
+	"copylocks":   flag.Bool("copylocks", false, "check that locks are not passed by value"),
+	"methods":     flag.Bool("methods", false, "check that canonically named methods are canonically defined"),
+	"printf":      flag.Bool("printf", false, "check printf-like invocations"),
+	"rangeloops":  flag.Bool("rangeloops", false, "check that range loop variables are used correctly"),
+	"shadow":      flag.Bool("shadow", false, "check for shadowed err variables (experimental; not part of -all)"),
+	"shift":       flag.Bool("shift", false, "check for useless shifts"),
+	"structtags":  flag.Bool("structtags", false, "check that struct field tags have canonical format"),
+	"unreachable": flag.Bool("unreachable", false, "check for unreachable code"),
+}
+
+// experimental records the checks that -all does not include;
+// they run only when requested by their own flag.
+var experimental = map[string]bool{
+	"shadow": true,
+}
+
+// vet tells whether to report errors for the named check, a flag name.
+func vet(name string) bool {
+	return *report["all"] && !experimental[name] || *report[name]
+}
+
 // setExit sets the value for os.Exit when it is called, later.  It
 // remembers the highest value.
 func setExit(err int) {
@@ -41,7 +71,9 @@ func Usage() {
 // File is a wrapper for the state of a file used in the parser.
 // The parse tree walkers are all methods of this type.
 type File struct {
+	pkg  *Package
 	fset *token.FileSet
+	name string
 	file *ast.File
 	b    bytes.Buffer // for use by methods
 }
@@ -50,6 +82,15 @@ func main() {
 	flag.Usage = Usage
 	flag.Parse()
 
+	// If any flag is set, we run only those checks requested.
+	// If no flags are set true, set all the checks.
+	for name, setting := range report {
+		if *setting == true && name != "all" {
+			*report["all"] = false
+			break
+		}
+	}
+
 	if *printfuncs != "" {
 		for _, name := range strings.Split(*printfuncs, ",") {
 			if len(name) == 0 {
@@ -74,49 +115,157 @@ func main() {
 	}
 
 	if flag.NArg() == 0 {
-		doFile("stdin", os.Stdin)
-	} else {
+		Usage()
+	}
+	dirs := false
+	files := false
+	for _, name := range flag.Args() {
+		// Is it a directory?
+		fi, err := os.Stat(name)
+		if err != nil {
+			warnf("error walking tree: %s", err)
+			continue
+		}
+		if fi.IsDir() {
+			dirs = true
+		} else {
+			files = true
+		}
+	}
+	if dirs && files {
+		Usage()
+	}
+	if dirs {
 		for _, name := range flag.Args() {
-			// Is it a directory?
-			if fi, err := os.Stat(name); err == nil && fi.IsDir() {
-				walkDir(name)
-			} else {
-				doFile(name, nil)
-			}
+			walkDir(name)
 		}
+		os.Exit(exitCode)
 	}
+	doPackage(flag.Args())
 	os.Exit(exitCode)
 }
 
-// doFile analyzes one file.  If the reader is nil, the source code is read from the
-// named file.
-func doFile(name string, reader io.Reader) {
-	fs := token.NewFileSet()
-	parsedFile, err := parser.ParseFile(fs, name, reader, 0)
+// doPackageDir analyzes the single package found in the directory, if there is one.
+func doPackageDir(directory string) {
+	pkg, err := build.Default.ImportDir(directory, 0)
 	if err != nil {
-		errorf("%s: %s", name, err)
+		// If it's just that there are no go source files, that's fine.
+		if _, nogo := err.(*build.NoGoError); nogo {
+			return
+		}
+		// Non-fatal: we are doing a recursive walk and there may be other directories.
+		warnf("cannot process directory %s: %s", directory, err)
 		return
 	}
-	file := &File{fset: fs, file: parsedFile}
-	file.walkFile(name, parsedFile)
+	var names []string
+	names = append(names, pkg.GoFiles...)
+	names = append(names, pkg.CgoFiles...)
+	names = append(names, pkg.TestGoFiles...) // These are also in the "foo" package.
+	prefixDirectory(directory, names)
+	doPackage(names)
+	// Is there also a "foo_test" package? If so, do that one as well.
+	if len(pkg.XTestGoFiles) > 0 {
+		names = pkg.XTestGoFiles
+		prefixDirectory(directory, names)
+		doPackage(names)
+	}
+}
+
+// doPackage analyzes the single package constructed from the named files.
+// The files may belong to several packages (such as a package and its
+// external tests); each package is analyzed separately, in order of
+// first appearance.
+func doPackage(names []string) {
+	var order []string
+	byPkg := make(map[string][]*File)
+	fs := token.NewFileSet()
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			// Warn but continue to next package.
+			warnf("%s: %s", name, err)
+			return
+		}
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			warnf("%s: %s", name, err)
+			return
+		}
+		parsedFile, err := parser.ParseFile(fs, name, data, 0)
+		if err != nil {
+			warnf("%s: %s", name, err)
+			return
+		}
+		pkgName := parsedFile.Name.Name
+		if byPkg[pkgName] == nil {
+			order = append(order, pkgName)
+		}
+		byPkg[pkgName] = append(byPkg[pkgName], &File{fset: fs, name: name, file: parsedFile})
+	}
+	for _, pkgName := range order {
+		files := byPkg[pkgName]
+		pkg := &Package{files: files}
+		for _, file := range files {
+			file.pkg = pkg
+		}
+		// Type check the package.
+		err := pkg.check(fs, importPath(files[0].name, pkgName))
+		if err != nil && *verbose {
+			// Note that we only report this error when *verbose.
+			Println(err)
+		}
+		for _, file := range files {
+			file.walkFile(file.name, file.file)
+		}
+	}
 }
 
 func visit(path string, f os.FileInfo, err error) error {
 	if err != nil {
-		errorf("walk error: %s", err)
-		return nil
+		warnf("walk error: %s", err)
+		return err
 	}
-	if !f.IsDir() && strings.HasSuffix(path, ".go") {
-		doFile(path, nil)
+	// One package per directory. Ignore the files themselves.
+	if !f.IsDir() {
+		return nil
 	}
+	doPackageDir(path)
 	return nil
 }
 
-// walkDir recursively walks the tree looking for .go files.
+// walkDir recursively walks the tree looking for Go packages.
 func walkDir(root string) {
 	filepath.Walk(root, visit)
 }
 
+// prefixDirectory places the directory name on the beginning of each name in the list.
+func prefixDirectory(directory string, names []string) {
+	if directory != "." {
+		for i, name := range names {
+			names[i] = filepath.Join(directory, name)
+		}
+	}
+}
+
+// importPath returns the import path of the package with the given name
+// that holds the named file, as far as it can be determined; otherwise
+// it returns the package name.
+func importPath(filename, pkgName string) string {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return pkgName
+	}
+	bp, err := build.ImportDir(dir, build.FindOnly)
+	if err != nil || bp.ImportPath == "" || bp.ImportPath == "." {
+		return pkgName
+	}
+	if strings.HasSuffix(pkgName, "_test") {
+		return bp.ImportPath + "_test"
+	}
+	return bp.ImportPath
+}
+
 // error formats the error to standard error, adding program
 // identification and a newline
 func errorf(format string, args ...interface{}) {
@@ -124,6 +273,13 @@ func errorf(format string, args ...interface{}) {
 	setExit(2)
 }
 
+// warnf formats the error to standard error, adding program
+// identification and a newline, but does not exit.
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "vet: "+format+"\n", args...)
+	setExit(1)
+}
+
 // Println is fmt.Println guarded by -v.
 func Println(args ...interface{}) {
 	if !*verbose {
@@ -164,6 +320,13 @@ func (f *File) Warnf(pos token.Pos, format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, loc+format+"\n", args...)
 }
 
+// gofmt returns a string representation of the expression.
+func (f *File) gofmt(x ast.Expr) string {
+	f.b.Reset()
+	printer.Fprint(&f.b, f.fset, x)
+	return f.b.String()
+}
+
 // walkFile walks the file's tree.
 func (f *File) walkFile(name string, file *ast.File) {
 	Println("Checking file", name)
@@ -173,30 +336,85 @@ func (f *File) walkFile(name string, file *ast.File) {
 // Visit implements the ast.Visitor interface.
 func (f *File) Visit(node ast.Node) ast.Visitor {
 	switch n := node.(type) {
+	case *ast.AssignStmt:
+		f.walkAssignStmt(n)
+	case *ast.BinaryExpr:
+		f.walkBinaryExpr(n)
 	case *ast.CallExpr:
 		f.walkCallExpr(n)
 	case *ast.CompositeLit:
 		f.walkCompositeLit(n)
 	case *ast.Field:
 		f.walkFieldTag(n)
+	case *ast.ForStmt:
+		f.walkForStmt(n)
 	case *ast.FuncDecl:
-		f.walkMethodDecl(n)
+		f.walkFuncDecl(n)
+	case *ast.FuncLit:
+		f.walkFuncLit(n)
+	case *ast.GenDecl:
+		f.walkGenDecl(n)
 	case *ast.InterfaceType:
 		f.walkInterfaceType(n)
+	case *ast.RangeStmt:
+		f.walkRangeStmt(n)
 	}
 	return f
 }
 
+// walkAssignStmt walks an assignment statement.
+func (f *File) walkAssignStmt(stmt *ast.AssignStmt) {
+	f.checkAssignStmt(stmt)
+	f.checkAtomicAssignment(stmt)
+	f.checkCopyLocksAssign(stmt)
+	f.checkShadowAssignment(stmt)
+	f.checkShiftAssign(stmt)
+}
+
+// walkBinaryExpr walks a binary expression.
+func (f *File) walkBinaryExpr(expr *ast.BinaryExpr) {
+	f.checkShift(expr)
+}
+
 // walkCall walks a call expression.
 func (f *File) walkCall(call *ast.CallExpr, name string) {
 	f.checkFmtPrintfCall(call, name)
 }
 
+// walkForStmt walks a for statement.
+func (f *File) walkForStmt(stmt *ast.ForStmt) {
+	f.checkForLoop(stmt)
+}
+
+// walkFuncDecl walks a function declaration.
+func (f *File) walkFuncDecl(d *ast.FuncDecl) {
+	f.walkMethodDecl(d)
+	f.checkCopyLocksFunc(d.Name.Name, d.Recv, d.Type)
+	f.checkUnreachable(d.Body)
+}
+
+// walkFuncLit walks a function literal.
+func (f *File) walkFuncLit(x *ast.FuncLit) {
+	f.checkCopyLocksFunc("func", nil, x.Type)
+	f.checkUnreachable(x.Body)
+}
+
+// walkGenDecl walks a general declaration.
+func (f *File) walkGenDecl(d *ast.GenDecl) {
+	f.checkShadowDecl(d)
+}
+
 // walkCompositeLit walks a composite literal.
 func (f *File) walkCompositeLit(c *ast.CompositeLit) {
 	f.checkUntaggedLiteral(c)
 }
 
+// walkRangeStmt walks a range statement.
+func (f *File) walkRangeStmt(stmt *ast.RangeStmt) {
+	f.checkRangeLoop(stmt)
+	f.checkCopyLocksRange(stmt)
+}
+
 // walkFieldTag walks a struct field tag.
 func (f *File) walkFieldTag(field *ast.Field) {
 	if field.Tag == nil {
@@ -55,6 +55,9 @@ var canonicalMethods = map[string]MethodSig{
 }
 
 func (f *File) checkCanonicalMethod(id *ast.Ident, t *ast.FuncType) {
+	if !vet("methods") {
+		return
+	}
 	// Expected input/output.
 	expect, ok := canonicalMethods[id.Name]
 	if !ok {
@@ -0,0 +1,109 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code to check range loop variables bound inside
+// function literals that are deferred or launched in new goroutines. We only
+// check instances where the defer or go statement is the last statement in
+// the loop body, as otherwise we would need whole program analysis.
+//
+// For example:
+//
+//	for i, v := range s {
+//		go func() {
+//			println(i, v) // not what you might expect
+//		}()
+//	}
+//
+// The same applies to the variables of a three-clause for loop.
+// See: http://golang.org/doc/go_faq.html#closures_and_goroutines
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// checkRangeLoop walks the body of the provided range statement, checking if
+// its index or value variables are used unsafely inside goroutines or deferred
+// function literals.
+func (f *File) checkRangeLoop(n *ast.RangeStmt) {
+	if !vet("rangeloops") || n.Tok != token.DEFINE {
+		return
+	}
+	var vars []*ast.Ident
+	if key, ok := n.Key.(*ast.Ident); ok && key.Name != "_" {
+		vars = append(vars, key)
+	}
+	if val, ok := n.Value.(*ast.Ident); ok && val.Name != "_" {
+		vars = append(vars, val)
+	}
+	f.checkLoopCapture(n.Body, vars, "range variable")
+}
+
+// checkForLoop does the same for the variables declared
+// in the init statement of a three-clause for loop.
+func (f *File) checkForLoop(n *ast.ForStmt) {
+	if !vet("rangeloops") {
+		return
+	}
+	init, ok := n.Init.(*ast.AssignStmt)
+	if !ok || init.Tok != token.DEFINE {
+		return
+	}
+	var vars []*ast.Ident
+	for _, x := range init.Lhs {
+		if id, ok := x.(*ast.Ident); ok && id.Name != "_" {
+			vars = append(vars, id)
+		}
+	}
+	f.checkLoopCapture(n.Body, vars, "loop variable")
+}
+
+// checkLoopCapture reports uses of the loop variables vars inside a
+// function literal that is deferred or run as a goroutine by the last
+// statement of the loop body.
+func (f *File) checkLoopCapture(body *ast.BlockStmt, vars []*ast.Ident, what string) {
+	if len(vars) == 0 || body == nil || len(body.List) == 0 {
+		return
+	}
+	var last *ast.CallExpr
+	switch s := body.List[len(body.List)-1].(type) {
+	case *ast.GoStmt:
+		last = s.Call
+	case *ast.DeferStmt:
+		last = s.Call
+	default:
+		return
+	}
+	lit, ok := last.Fun.(*ast.FuncLit)
+	if !ok {
+		return
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for _, v := range vars {
+			if f.sameVar(id, v) {
+				f.Badf(id.Pos(), "%s %s enclosed by function", what, id.Name)
+			}
+		}
+		return true
+	})
+}
+
+// sameVar reports whether the identifier use refers to the variable
+// declared by the identifier def. Without type information it relies
+// on the parser's object resolution.
+func (f *File) sameVar(use, def *ast.Ident) bool {
+	if use.Name != def.Name {
+		return false
+	}
+	if obj := f.objectOf(def); obj != nil {
+		return f.objectOf(use) == obj
+	}
+	return use.Obj != nil && use.Obj == def.Obj
+}
@@ -0,0 +1,92 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code to check for shadowed err variables.
+//
+// The check looks for a declaration of err in an inner scope that hides
+// an err of the same type declared earlier in an enclosing scope, where
+// the outer err is read after the inner scope ends, before it is assigned
+// again. That pattern usually means an error assigned in the inner scope
+// was meant for the outer variable and is lost:
+//
+//	var err error
+//	if cond {
+//		x, err := f() // the outer err is not set
+//		...
+//	}
+//	return err
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// checkShadowAssignment checks for shadowing in a short variable declaration.
+func (f *File) checkShadowAssignment(a *ast.AssignStmt) {
+	if !vet("shadow") || a.Tok != token.DEFINE {
+		return
+	}
+	for _, expr := range a.Lhs {
+		if ident, ok := expr.(*ast.Ident); ok {
+			f.checkShadowing(ident)
+		}
+	}
+}
+
+// checkShadowDecl checks for shadowing in a general variable declaration.
+func (f *File) checkShadowDecl(d *ast.GenDecl) {
+	if !vet("shadow") || d.Tok != token.VAR {
+		return
+	}
+	for _, spec := range d.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			f.Badf(spec.Pos(), "invalid AST: var GenDecl not ValueSpec")
+			return
+		}
+		for _, ident := range valueSpec.Names {
+			f.checkShadowing(ident)
+		}
+	}
+}
+
+// checkShadowing checks whether the identifier declares an err
+// variable that shadows another one.
+func (f *File) checkShadowing(ident *ast.Ident) {
+	if ident.Name != "err" || f.pkg.pkg == nil {
+		return
+	}
+	obj, ok := f.objectOf(ident).(*types.Var)
+	if !ok || obj.Pos() != ident.Pos() || obj.Parent() == nil {
+		// Not a new variable declared by this identifier
+		// (e.g. redeclared by :=), or no type information.
+		return
+	}
+	scope := obj.Parent()
+	if scope.Parent() == nil {
+		return
+	}
+	_, outer := scope.Parent().LookupParent(ident.Name)
+	shadowed, ok := outer.(*types.Var)
+	if !ok || shadowed.Pos() >= ident.Pos() {
+		return
+	}
+	if outerScope := shadowed.Parent(); outerScope == nil || outerScope == f.pkg.pkg.Scope() || outerScope == types.Universe {
+		// Package-level variables are often shadowed on purpose.
+		return
+	}
+	if !types.Identical(obj.Type(), shadowed.Type()) {
+		return
+	}
+	// The shadowing only matters if the outer variable is
+	// consulted after the inner scope has ended.
+	node := f.pkg.scopeNode(scope)
+	if node == nil || !f.pkg.readAfter(shadowed, node.End()) {
+		return
+	}
+	f.Badf(ident.Pos(), "declaration of %s shadows declaration at %s", ident.Name, f.fset.Position(shadowed.Pos()))
+}
@@ -0,0 +1,136 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code to check for suspicious shifts.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"math/big"
+)
+
+// checkShift checks a shift expression for shift amounts that are
+// at least the width of the shifted operand's type.
+func (f *File) checkShift(x *ast.BinaryExpr) {
+	if x.Op == token.SHL || x.Op == token.SHR {
+		f.checkLongShift(x, x.X, x.Y)
+	}
+}
+
+// checkShiftAssign checks a shift assignment such as x <<= 10.
+func (f *File) checkShiftAssign(stmt *ast.AssignStmt) {
+	if stmt.Tok != token.SHL_ASSIGN && stmt.Tok != token.SHR_ASSIGN {
+		return
+	}
+	if len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+		// Not valid Go; the type checker will complain.
+		return
+	}
+	f.checkLongShift(stmt, stmt.Lhs[0], stmt.Rhs[0])
+}
+
+// checkLongShift checks if shift or shift-assign operations shift by more
+// than the length of the underlying variable.
+func (f *File) checkLongShift(node ast.Node, x, y ast.Expr) {
+	if !vet("shift") {
+		return
+	}
+	if f.value(x) != nil {
+		// Ignore shifts of constants; the compilers check
+		// those and they may be intentionally large.
+		return
+	}
+	amt, ok := f.value(y).(*big.Int)
+	if !ok {
+		return
+	}
+	if f.archSized(y, make(map[types.Object]bool)) {
+		// The amount may be right for this architecture and
+		// wrong for another, like math/big's word size _W.
+		return
+	}
+	t := f.typeOf(x)
+	if t == nil {
+		return
+	}
+	typ, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return
+	}
+	var size int64
+	switch typ.Kind() {
+	case types.Int8, types.Uint8:
+		size = 8
+	case types.Int16, types.Uint16:
+		size = 16
+	case types.Int32, types.Uint32:
+		size = 32
+	case types.Int64, types.Uint64:
+		size = 64
+	default:
+		// The size of int, uint and uintptr depends on the
+		// architecture; don't guess.
+		return
+	}
+	if amt.Cmp(big.NewInt(size)) >= 0 {
+		f.Badf(node.Pos(), "%s too small for shift of %d", f.gofmt(x), amt)
+	}
+}
+
+// archSized reports whether the value of the constant expression e
+// depends on the size of int, uint or uintptr: whether it uses
+// unsafe.Sizeof and friends or complements a word-sized value, as in
+// ^uint(0), directly or through the constants it mentions.
+// Constants declared in other packages are assumed to, since their
+// definitions are not visible.  Seen records the constants visited.
+func (f *File) archSized(e ast.Expr, seen map[types.Object]bool) bool {
+	sized := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.XOR && isWordSized(f.typeOf(n)) {
+				sized = true
+			}
+		case *ast.CallExpr:
+			for _, name := range []string{"Alignof", "Offsetof", "Sizeof"} {
+				if f.isPkgObject(n.Fun, "unsafe", name) {
+					sized = true
+				}
+			}
+		case *ast.Ident:
+			c, ok := f.objectOf(n).(*types.Const)
+			if !ok || c.Pkg() == nil || seen[c] {
+				break
+			}
+			seen[c] = true
+			if c.Pkg() != f.pkg.pkg {
+				sized = true
+			} else if d := f.pkg.constDecl(c); d != nil && f.archSized(d, seen) {
+				sized = true
+			}
+		}
+		return !sized
+	})
+	return sized
+}
+
+// isWordSized reports whether t is int, uint or uintptr
+// or a type defined from one of them.
+func isWordSized(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	typ, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch typ.Kind() {
+	case types.Int, types.Uint, types.Uintptr:
+		return true
+	}
+	return false
+}
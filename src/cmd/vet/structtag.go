@@ -14,7 +14,7 @@ import (
 
 // checkField checks a struct field tag.
 func (f *File) checkCanonicalFieldTag(field *ast.Field) {
-	if field.Tag == nil {
+	if !vet("structtags") || field.Tag == nil {
 		return
 	}
 
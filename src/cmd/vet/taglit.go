@@ -8,12 +8,17 @@ package main
 
 import (
 	"go/ast"
+	"go/types"
 	"strings"
 )
 
 // checkUntaggedLiteral checks if a composite literal is an struct literal with
 // untagged fields.
 func (f *File) checkUntaggedLiteral(c *ast.CompositeLit) {
+	if !vet("composites") {
+		return
+	}
+
 	// Check if the CompositeLit contains an untagged field.
 	allKeyValue := true
 	for _, e := range c.Elts {
@@ -26,6 +31,29 @@ func (f *File) checkUntaggedLiteral(c *ast.CompositeLit) {
 		return
 	}
 
+	// If the type checker knows the literal's type, use it: only struct
+	// types declared in other packages are of interest. This also covers
+	// literals whose type is elided, as in []pkg.T{{1, 2}}.
+	if typ := f.typeOf(c); typ != nil {
+		named, ok := typ.(*types.Named)
+		if !ok {
+			return
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			return
+		}
+		pkg := named.Obj().Pkg()
+		if pkg == nil || pkg == f.pkg.pkg {
+			return
+		}
+		typeName := pkg.Path() + "." + named.Obj().Name()
+		if untaggedLiteralWhitelist[typeName] {
+			return
+		}
+		f.Warnf(c.Pos(), "%s struct literal uses untagged fields", typeName)
+		return
+	}
+
 	// Check that the CompositeLit's type has the form pkg.Typ.
 	s, ok := c.Type.(*ast.SelectorExpr)
 	if !ok {
@@ -0,0 +1,39 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the untagged struct literal checker.
+
+package testdata
+
+import (
+	"go/scanner"
+	"go/token"
+	"image"
+	"unicode"
+)
+
+type localT struct {
+	a, b int
+}
+
+var localValue = localT{1, 2} // ok; local type
+
+var goodScannerErrorList = scanner.ErrorList{ // ok; a slice type
+	&scanner.Error{Msg: "foobar"},
+}
+
+var badScannerErrorList = scanner.ErrorList{
+	&scanner.Error{token.Position{}, "foobar"}, // ERROR "struct literal uses untagged fields"
+}
+
+// Elided types of nested literals are checked too.
+var unicodeRange = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0041, 0x005a, 1}, // ERROR "unicode.Range16 struct literal uses untagged fields"
+	},
+}
+
+var points = []image.Point{
+	{1, 2}, // ok; whitelisted
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the copylock checker.
+
+package testdata
+
+import "sync"
+
+type LocalMutex struct {
+	mu sync.Mutex
+}
+
+func (t LocalMutex) Value() int { // ERROR "Value passes Lock by value: testdata.LocalMutex contains sync.Mutex"
+	return 0
+}
+
+func (t *LocalMutex) Ok() int {
+	return 0
+}
+
+func OkFunc(*sync.Mutex) {}
+
+func BadFunc(sync.Mutex) {} // ERROR "BadFunc passes Lock by value: sync.Mutex"
+
+func BadRWFunc(x int, m sync.RWMutex) {} // ERROR "BadRWFunc passes Lock by value: sync.RWMutex"
+
+type EmbeddedRWMutex struct {
+	sync.RWMutex
+}
+
+func (*EmbeddedRWMutex) OkMeth() {}
+
+func (EmbeddedRWMutex) BadMeth() {} // ERROR "BadMeth passes Lock by value: testdata.EmbeddedRWMutex"
+
+// A struct embedding a Locker interface can be copied.
+type EmbeddedLocker struct {
+	sync.Locker
+}
+
+func (EmbeddedLocker) OkMeth() {}
+
+func CopyLocks() {
+	var x sync.Mutex
+	y := x // ERROR "assignment copies lock value to y: sync.Mutex"
+	z := LocalMutex{}
+	var w LocalMutex
+	w = z                      // ERROR "assignment copies lock value to w: testdata.LocalMutex contains sync.Mutex"
+	p := &x                    // ok
+	v := *p                    // ERROR "assignment copies lock value to v: sync.Mutex"
+	f := func(m sync.Mutex) {} // ERROR "func passes Lock by value: sync.Mutex"
+	f(y)
+	_, _ = w, v
+
+	var s []LocalMutex
+	for _, m := range s { // ERROR "range var m copies Lock: testdata.LocalMutex contains sync.Mutex"
+		_ = &m
+	}
+	for i := range s { // ok
+		_ = i
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the dead code checker.
+
+package testdata
+
+func _() int {
+	print(1)
+	return 2
+	println() // ERROR "unreachable code"
+	return 3
+}
+
+func _() int {
+L:
+	print(1)
+	goto L
+	println() // ERROR "unreachable code"
+	return 2
+}
+
+func _() int {
+	print(1)
+	panic(2)
+	println() // ERROR "unreachable code"
+	return 3
+}
+
+func _() int {
+	{
+		print(1)
+		return 2
+		println() // ERROR "unreachable code"
+	}
+	println()
+	return 3
+}
+
+func _() int {
+	if true {
+		return 2
+	} else {
+		return 3
+	}
+	println() // ERROR "unreachable code"
+	return 4
+}
+
+// The compilers require a final return or panic after a statement
+// that never completes; it must not be reported.
+func _() int {
+	for {
+		print(1)
+	}
+	return 2
+}
+
+func _() int {
+	for {
+		print(1)
+	}
+	panic("unreachable")
+}
+
+func _() int {
+	if true {
+		return 1
+	} else {
+		return 2
+	}
+	panic("unreachable")
+}
+
+func _() int {
+	for {
+		if true {
+			break
+		}
+	}
+	println() // ok; the loop can end
+	return 1
+}
+
+func _() int {
+L:
+	for {
+		switch {
+		case true:
+			break L
+		}
+	}
+	println() // ok; the loop can end
+	return 1
+}
+
+func _(x int) int {
+	switch x {
+	case 1:
+		return 1
+	default:
+		return 2
+	}
+	println() // ERROR "unreachable code"
+	return 3
+}
+
+func _(x int) int {
+	switch x {
+	case 1:
+		return 1
+	}
+	println() // ok; no default case
+	return 3
+}
+
+func _(c chan int) int {
+	select {
+	case <-c:
+		return 1
+	}
+	println() // ERROR "unreachable code"
+	return 2
+}
+
+func _(s []int) {
+	for range_ := range s {
+		print(range_)
+		continue
+		println() // ERROR "unreachable code"
+	}
+}
+
+func _() {
+	f := func() int {
+		return 1
+		println() // ERROR "unreachable code"
+		return 2
+	}
+	f()
+}
+
+func _() int {
+	goto L
+	println() // ERROR "unreachable code"
+L:
+	return 1
+}
@@ -0,0 +1,64 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the shadowed variable checker.
+
+package testdata
+
+import (
+	"os"
+)
+
+func ShadowRead(f *os.File, buf []byte) error {
+	var err error
+	if f != nil {
+		_, err := f.Read(buf) // ERROR "declaration of err shadows declaration at testdata/shadow.go:14"
+		if err != nil {
+			return err
+		}
+		i := 3 // ok; not err
+		_ = i
+	}
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		var _, err = f.Read(buf) // ERROR "declaration of err shadows declaration at testdata/shadow.go:14"
+		_ = err
+	}
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 10; i++ {
+		x := i // ok; not err
+		_ = x
+		if err := f.Close(); err != nil { // ok; the outer err is assigned before it is read
+			return err
+		}
+	}
+	a, err := f.Read(buf) // ok; redeclaration, not shadowing
+	_ = a
+	return err
+}
+
+func ShadowUnused(f *os.File) error {
+	err := f.Sync()
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		err := f.Close() // ok; the outer err is not used later
+		return err
+	}
+	return nil
+}
+
+func ShadowDifferentType(f *os.File) error {
+	var err error
+	if f != nil {
+		err := "invalid" // ok; different type
+		_ = err
+	}
+	return err
+}
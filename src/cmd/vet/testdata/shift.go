@@ -0,0 +1,68 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the suspicious shift checker.
+
+package testdata
+
+import "unsafe"
+
+func ShiftTest() {
+	var i8 int8
+	_ = i8 << 7
+	_ = (i8 + 1) << 8 // ERROR "\(i8 \+ 1\) too small for shift of 8"
+	_ = i8 << (7 + 1) // ERROR "i8 too small for shift of 8"
+	_ = i8 >> 8       // ERROR "i8 too small for shift of 8"
+	i8 <<= 8          // ERROR "i8 too small for shift of 8"
+	i8 >>= 8          // ERROR "i8 too small for shift of 8"
+	var i16 int16
+	_ = i16 << 15
+	_ = i16 << 16 // ERROR "i16 too small for shift of 16"
+	var i32 int32
+	_ = i32 << 31
+	_ = i32 << 32 // ERROR "i32 too small for shift of 32"
+	var i64 int64
+	_ = i64 << 63
+	_ = i64 << 64 // ERROR "i64 too small for shift of 64"
+	var u8 uint8
+	_ = u8 << 7
+	_ = u8 << 8 // ERROR "u8 too small for shift of 8"
+	var u64 uint64
+	_ = u64 << 63
+	_ = u64 << 64 // ERROR "u64 too small for shift of 64"
+
+	// The size of these types depends on the architecture.
+	var i int
+	_ = i << 32
+	_ = i << 64
+	var u uint
+	_ = u << 64
+	var p uintptr
+	_ = p << 64
+
+	// Shifts of constants are left to the compilers.
+	const c = 1
+	_ = c << 100
+	_ = int64(c) << 40
+
+	// Shift amounts that are not constant are not checked.
+	var n uint
+	_ = i8 << n
+
+	// Nor are amounts that depend on the word size.
+	_ = u64 >> wordBits
+	_ = u64 >> (wordBits - 1)
+	_ = u64 << (8 * unsafe.Sizeof(p))
+	_ = u64 << halfBits
+	_ = u64 << plainBits // ERROR "u64 too small for shift of 64"
+}
+
+type word uintptr
+
+const (
+	logSize   = ^word(0)>>8&1 + ^word(0)>>16&1 + ^word(0)>>32&1
+	wordBits  = 8 << logSize
+	halfBits  = wordBits * 2
+	plainBits = 64
+)
@@ -0,0 +1,11 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the struct tag checker.
+
+package testdata
+
+type StructTagTest struct {
+	X int "hello" // ERROR "struct field tag"
+}
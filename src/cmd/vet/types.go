@@ -0,0 +1,172 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the pieces of the tool that require type information.
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// Package holds the type information for a package, as far as
+// the type checker could determine it. Checks that need type
+// information must cope with missing entries.
+type Package struct {
+	path   string
+	pkg    *types.Package
+	info   types.Info
+	files  []*File
+	scopes map[*types.Scope]ast.Node // inverse of info.Scopes; built lazily
+	writes map[*ast.Ident]bool       // identifiers assigned to by = or :=; built lazily
+	consts map[types.Object]ast.Expr // constant declarations; built lazily
+}
+
+// check type-checks the package and records the results in pkg.
+// It returns the first type-checking error, if any; checking
+// continues after errors so that partial information is available.
+func (pkg *Package) check(fs *token.FileSet, path string) error {
+	pkg.path = path
+	pkg.info = types.Info{
+		Types:      make(map[ast.Expr]types.Type),
+		Values:     make(map[ast.Expr]interface{}),
+		Objects:    make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	var astFiles []*ast.File
+	for _, f := range pkg.files {
+		astFiles = append(astFiles, f.file)
+	}
+	var firstErr error
+	conf := types.Config{
+		Error: func(err error) {
+			if firstErr == nil {
+				firstErr = err
+			}
+		},
+	}
+	pkg.pkg, _ = conf.Check(path, fs, astFiles, &pkg.info)
+	return firstErr
+}
+
+// typeOf returns the type of expression e, or nil if it is unknown.
+func (f *File) typeOf(e ast.Expr) types.Type {
+	return f.pkg.info.TypeOf(e)
+}
+
+// objectOf returns the object denoted by id, or nil if it is unknown.
+func (f *File) objectOf(id *ast.Ident) types.Object {
+	return f.pkg.info.ObjectOf(id)
+}
+
+// value returns the constant value of expression e, or nil if e
+// is not a constant or its value is unknown.
+func (f *File) value(e ast.Expr) interface{} {
+	return f.pkg.info.Values[e]
+}
+
+// isPkgObject reports whether the expression e denotes the
+// package-level object name in the package with the given path.
+// Without type information it falls back to matching the
+// selector expression pkgname.name syntactically.
+func (f *File) isPkgObject(e ast.Expr, path, name string) bool {
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	if obj := f.objectOf(sel.Sel); obj != nil {
+		return obj.Pkg() != nil && obj.Pkg().Path() == path
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && pkgPath(f, x.Name) == path
+}
+
+// isBuiltin reports whether the identifier id denotes
+// the predeclared function with the given name.
+func (f *File) isBuiltin(id *ast.Ident, name string) bool {
+	if id.Name != name {
+		return false
+	}
+	if obj := f.objectOf(id); obj != nil {
+		_, ok := obj.(*types.Builtin)
+		return ok
+	}
+	return true // no type information; assume the name is not redeclared
+}
+
+// scopeNode returns the syntax node that defines the scope s,
+// or nil if it is unknown.
+func (pkg *Package) scopeNode(s *types.Scope) ast.Node {
+	if pkg.scopes == nil {
+		pkg.scopes = make(map[*types.Scope]ast.Node)
+		for node, scope := range pkg.info.Scopes {
+			pkg.scopes[scope] = node
+		}
+	}
+	return pkg.scopes[s]
+}
+
+// readAfter reports whether the first use of the object obj after
+// position pos reads its value, rather than assigning a new one.
+func (pkg *Package) readAfter(obj types.Object, pos token.Pos) bool {
+	var first *ast.Ident
+	for id, o := range pkg.info.Objects {
+		if o == obj && id.Pos() > pos && (first == nil || id.Pos() < first.Pos()) {
+			first = id
+		}
+	}
+	if first == nil {
+		return false
+	}
+	if pkg.writes == nil {
+		pkg.writes = make(map[*ast.Ident]bool)
+		for _, f := range pkg.files {
+			ast.Inspect(f.file, func(n ast.Node) bool {
+				if a, ok := n.(*ast.AssignStmt); ok && (a.Tok == token.ASSIGN || a.Tok == token.DEFINE) {
+					for _, x := range a.Lhs {
+						if id, ok := x.(*ast.Ident); ok {
+							pkg.writes[id] = true
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+	return !pkg.writes[first]
+}
+
+// constDecl returns the expression that defines the package-level or
+// local constant c, repeating the previous expression of its group
+// where the declaration omits it, or nil if it is unknown.
+func (pkg *Package) constDecl(c types.Object) ast.Expr {
+	if pkg.consts == nil {
+		pkg.consts = make(map[types.Object]ast.Expr)
+		for _, f := range pkg.files {
+			ast.Inspect(f.file, func(n ast.Node) bool {
+				d, ok := n.(*ast.GenDecl)
+				if !ok || d.Tok != token.CONST {
+					return true
+				}
+				var values []ast.Expr
+				for _, spec := range d.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if len(vs.Values) > 0 {
+						values = vs.Values
+					}
+					for i, id := range vs.Names {
+						if obj := pkg.info.Objects[id]; obj != nil && i < len(values) {
+							pkg.consts[obj] = values[i]
+						}
+					}
+				}
+				return false
+			})
+		}
+	}
+	return pkg.consts[c]
+}
@@ -0,0 +1,43 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !windows
+
+#include <u.h>
+#include <errno.h>
+#include <sys/wait.h>
+#define NOPLAN9DEFINES
+#include <libc.h>
+
+/*
+ * runcmd runs argv[0] with arguments argv, waits for it
+ * to finish, and returns 0 if it exited successfully.
+ * Otherwise it returns -1 with the reason in errstr.
+ */
+int
+runcmd(char **argv)
+{
+	int pid, status;
+
+	switch(pid = fork()) {
+	case -1:
+		return -1;
+	case 0:
+		execvp(argv[0], argv);
+		fprint(2, "exec %s: %r\n", argv[0]);
+		_exits("exec");
+	}
+
+	while(waitpid(pid, &status, 0) < 0) {
+		if(errno != EINTR) {
+			werrstr("waitpid: %r");
+			return -1;
+		}
+	}
+	if(!WIFEXITED(status) || WEXITSTATUS(status) != 0) {
+		werrstr("unsuccessful exit status: %#x", status);
+		return -1;
+	}
+	return 0;
+}
@@ -0,0 +1,64 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !windows
+
+#include <u.h>
+#include <dirent.h>
+#include <sys/stat.h>
+#define NOPLAN9DEFINES
+#include <libc.h>
+
+/*
+ * mktempdir creates a new, empty directory under $TMPDIR
+ * (or /tmp) and returns its name, or nil on failure.
+ */
+char*
+mktempdir(void)
+{
+	char *tmp, *p;
+	
+	tmp = getenv("TMPDIR");
+	if(tmp == nil || tmp[0] == '\0')
+		tmp = "/tmp";
+	p = smprint("%s/go-link-XXXXXX", tmp);
+	if(p == nil)
+		return nil;
+	if(mkdtemp(p) == nil) {
+		free(p);
+		return nil;
+	}
+	return p;
+}
+
+/*
+ * removeall removes p and, if it is a directory,
+ * everything it contains.  Errors are ignored.
+ */
+void
+removeall(char *p)
+{
+	DIR *d;
+	struct dirent *dp;
+	char *q;
+	struct stat st;
+
+	if(stat(p, &st) < 0)
+		return;
+	if(!S_ISDIR(st.st_mode)) {
+		unlink(p);
+		return;
+	}
+
+	d = opendir(p);
+	while((dp = readdir(d)) != nil) {
+		if(strcmp(dp->d_name, ".") == 0 || strcmp(dp->d_name, "..") == 0)
+			continue;
+		q = smprint("%s/%s", p, dp->d_name);
+		removeall(q);
+		free(q);
+	}
+	closedir(d);
+	rmdir(p);
+}
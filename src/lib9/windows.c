@@ -24,3 +24,21 @@ int p9waitpid()
 {
 	return -1;
 }
+
+int runcmd(char **argv)
+{
+	USED(argv);
+	werrstr("runcmd not implemented on windows");
+	return -1;
+}
+
+char *mktempdir(void)
+{
+	werrstr("mktempdir not implemented on windows");
+	return nil;
+}
+
+void removeall(char *p)
+{
+	USED(p);
+}
@@ -19,11 +19,20 @@ type buf struct {
 	off      Offset
 	data     []byte
 	addrsize int
+	vers     int // DWARF version of the unit, if reading one
 	err      error
 }
 
 func makeBuf(d *Data, name string, off Offset, data []byte, addrsize int) buf {
-	return buf{d, d.order, name, off, data, addrsize, nil}
+	return buf{d, d.order, name, off, data, addrsize, 0, nil}
+}
+
+// makeUnitBuf returns a buf reading the entries of unit u
+// from offset off on.
+func makeUnitBuf(d *Data, u *unit, off Offset) buf {
+	b := makeBuf(d, "info", off, u.data[off-u.off:], u.addrsize)
+	b.vers = u.vers
+	return b
 }
 
 func (b *buf) uint8() uint8 {
@@ -168,6 +168,10 @@ func (b *buf) entry(atab abbrevTable, ubase Offset) *Entry {
 		case formDwarfBlock:
 			val = b.bytes(int(b.uint()))
 
+		// exprloc: a location expression, new in DWARF 4
+		case formExprloc:
+			val = b.bytes(int(b.uint()))
+
 		// constant
 		case formData1:
 			val = int64(b.uint8())
@@ -185,10 +189,22 @@ func (b *buf) entry(atab abbrevTable, ubase Offset) *Entry {
 		// flag
 		case formFlag:
 			val = b.uint8() == 1
+		case formFlagPresent:
+			val = true
+
+		// offset into another section, such as the
+		// lineptr or loclistptr classes, new in DWARF 4
+		case formSecOffset:
+			val = int64(b.uint32())
 
 		// reference to other entry
 		case formRefAddr:
-			val = Offset(b.addr())
+			// An address in DWARF 2, an offset from DWARF 3 on.
+			if b.vers == 2 {
+				val = Offset(b.addr())
+			} else {
+				val = Offset(b.uint32())
+			}
 		case formRef1:
 			val = Offset(b.uint8()) + ubase
 		case formRef2:
@@ -200,6 +216,10 @@ func (b *buf) entry(atab abbrevTable, ubase Offset) *Entry {
 		case formRefUdata:
 			val = Offset(b.uint()) + ubase
 
+		// type signature, new in DWARF 4
+		case formRefSig8:
+			val = b.uint64()
+
 		// string
 		case formString:
 			val = b.string()
@@ -258,22 +278,17 @@ func (r *Reader) Seek(off Offset) {
 		}
 		u := &d.unit[0]
 		r.unit = 0
-		r.b = makeBuf(r.d, "info", u.off, u.data, u.addrsize)
+		r.b = makeUnitBuf(r.d, u, u.off)
 		return
 	}
 
-	// TODO(rsc): binary search (maybe a new package)
-	var i int
-	var u *unit
-	for i = range d.unit {
-		u = &d.unit[i]
-		if u.off <= off && off < u.off+Offset(len(u.data)) {
-			r.unit = i
-			r.b = makeBuf(r.d, "info", off, u.data[off-u.off:], u.addrsize)
-			return
-		}
+	i := d.offsetToUnit(off)
+	if i < 0 {
+		r.err = errors.New("offset out of range")
+		return
 	}
-	r.err = errors.New("offset out of range")
+	r.unit = i
+	r.b = makeUnitBuf(r.d, &d.unit[i], off)
 }
 
 // maybeNextUnit advances to the next unit if this one is finished.
@@ -281,7 +296,7 @@ func (r *Reader) maybeNextUnit() {
 	for len(r.b.data) == 0 && r.unit+1 < len(r.d.unit) {
 		r.unit++
 		u := &r.d.unit[r.unit]
-		r.b = makeBuf(r.d, "info", u.off, u.data, u.addrsize)
+		r.b = makeUnitBuf(r.d, u, u.off)
 	}
 }
 
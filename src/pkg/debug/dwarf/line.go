@@ -0,0 +1,308 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// DWARF line number information, from the ``line'' section.
+// The section holds, for each compilation unit, a program for
+// a state machine whose successive states form a table mapping
+// instruction addresses to source positions.
+
+package dwarf
+
+import (
+	"io"
+	"path"
+)
+
+// A LineReader reads the table of LineEntry structures for a single
+// compilation unit from a DWARF ``line'' section.  Entries come in
+// order of increasing address within each sequence of contiguous
+// instructions; the last entry of a sequence has EndSequence set.
+type LineReader struct {
+	buf       buf
+	start     Offset // offset of the line program
+	end       Offset // offset of the end of the line program
+	compDir   string
+	fileIndex int // number of files named in the header
+
+	// header
+	vers          int
+	minInstLength int
+	defaultIsStmt bool
+	lineBase      int
+	lineRange     int
+	opcodeBase    int
+	opcodeLengths []int // indexed by opcode; [0] unused
+	dirs          []string
+	files         []*LineFile // indexed by file number; [0] unused
+
+	state LineEntry // the state machine registers
+}
+
+// A LineEntry is a row of the line table: the source position
+// of the instruction at Address.
+type LineEntry struct {
+	Address       uint64
+	File          *LineFile
+	Line          int // 1-based; 0 if the instruction has no source line
+	Column        int // 1-based; 0 if unknown
+	IsStmt        bool
+	BasicBlock    bool
+	PrologueEnd   bool
+	EpilogueBegin bool
+	ISA           int
+	Discriminator int
+
+	// EndSequence marks the first address past the end of a
+	// sequence of instructions.  The other fields are not
+	// meaningful for such an entry.
+	EndSequence bool
+}
+
+// A LineFile is a source file named by a line table.
+type LineFile struct {
+	Name   string // as given, joined to its directory if it is relative
+	Mtime  uint64 // modification time; 0 if unknown
+	Length int    // size in bytes; 0 if unknown
+}
+
+// Standard and extended line number program opcodes.
+const (
+	lnsCopy           = 1
+	lnsAdvancePC      = 2
+	lnsAdvanceLine    = 3
+	lnsSetFile        = 4
+	lnsSetColumn      = 5
+	lnsNegateStmt     = 6
+	lnsSetBasicBlock  = 7
+	lnsConstAddPC     = 8
+	lnsFixedAdvancePC = 9
+	lnsPrologueEnd    = 10 // DWARF 3
+	lnsEpilogueBegin  = 11 // DWARF 3
+	lnsSetISA         = 12 // DWARF 3
+
+	lneEndSequence      = 1
+	lneSetAddress       = 2
+	lneDefineFile       = 3
+	lneSetDiscriminator = 4 // DWARF 4
+)
+
+// LineReader returns a new reader for the line table of the
+// compilation unit cu, an Entry with tag TagCompileUnit.
+// It returns nil, nil if cu has no line table.
+func (d *Data) LineReader(cu *Entry) (*LineReader, error) {
+	off, ok := cu.Val(AttrStmtList).(int64)
+	if !ok {
+		return nil, nil
+	}
+	if off < 0 || off >= int64(len(d.line)) {
+		return nil, DecodeError{"line", Offset(off), "line table offset out of range"}
+	}
+	r := &LineReader{}
+	r.compDir, _ = cu.Val(AttrCompDir).(string)
+	if err := r.readHeader(d, Offset(off)); err != nil {
+		return nil, err
+	}
+	r.Reset()
+	return r, nil
+}
+
+// readHeader reads the header of the line program at offset off.
+func (r *LineReader) readHeader(d *Data, off Offset) error {
+	b := makeBuf(d, "line", off, d.line[off:], 0)
+	n := b.uint32()
+	if n == 0xffffffff {
+		return DecodeError{"line", off, "64-bit DWARF is not supported"}
+	}
+	if int(n) > len(b.data) {
+		return DecodeError{"line", off, "line table too short"}
+	}
+	r.end = b.off + Offset(n)
+	r.vers = int(b.uint16())
+	if r.vers < 2 || r.vers > 4 {
+		return DecodeError{"line", off, "unsupported line table version"}
+	}
+	hlen := b.uint32()
+	r.start = b.off + Offset(hlen)
+	r.minInstLength = int(b.uint8())
+	if r.vers >= 4 {
+		b.uint8() // maximum operations per instruction, for VLIW
+	}
+	r.defaultIsStmt = b.uint8() != 0
+	r.lineBase = int(int8(b.uint8()))
+	r.lineRange = int(b.uint8())
+	r.opcodeBase = int(b.uint8())
+	if r.lineRange == 0 {
+		return DecodeError{"line", off, "zero line range"}
+	}
+	r.opcodeLengths = make([]int, r.opcodeBase)
+	for i := 1; i < r.opcodeBase; i++ {
+		r.opcodeLengths[i] = int(b.uint8())
+	}
+	for {
+		dir := b.string()
+		if dir == "" || b.err != nil {
+			break
+		}
+		r.dirs = append(r.dirs, dir)
+	}
+	r.files = []*LineFile{nil}
+	for b.err == nil && len(b.data) > 0 && b.data[0] != 0 {
+		r.readFile(&b)
+	}
+	r.fileIndex = len(r.files)
+	if b.err != nil {
+		return b.err
+	}
+	if r.start > r.end {
+		return DecodeError{"line", off, "bad line table header length"}
+	}
+	r.buf = makeBuf(d, "line", r.start, d.line[r.start:r.end], 0)
+	return nil
+}
+
+// readFile reads a file entry, from the header or from a
+// DW_LNE_define_file instruction, and adds it to the files.
+func (r *LineReader) readFile(b *buf) {
+	name := b.string()
+	dir := int(b.uint())
+	mtime := b.uint()
+	length := int(b.uint())
+	if !path.IsAbs(name) {
+		switch {
+		case dir > 0 && dir <= len(r.dirs):
+			name = path.Join(r.dirs[dir-1], name)
+		case dir == 0 && r.compDir != "":
+			name = path.Join(r.compDir, name)
+		}
+	}
+	r.files = append(r.files, &LineFile{Name: name, Mtime: mtime, Length: length})
+}
+
+// Reset repositions the reader at the start of the line table.
+func (r *LineReader) Reset() {
+	r.buf.data = r.buf.dwarf.line[r.start:r.end]
+	r.buf.off = r.start
+	r.buf.err = nil
+	r.files = r.files[:r.fileIndex]
+	r.resetState()
+}
+
+// resetState sets the state machine registers to their
+// values at the start of a sequence.
+func (r *LineReader) resetState() {
+	r.state = LineEntry{Line: 1, IsStmt: r.defaultIsStmt}
+	r.setFile(1)
+}
+
+func (r *LineReader) setFile(i int) {
+	r.state.File = nil
+	if i > 0 && i < len(r.files) {
+		r.state.File = r.files[i]
+	}
+}
+
+// Files returns the files named by the line table, so far:
+// the ones defined in the header, and then those defined by the
+// entries read.  File numbers, counted from 1, index this list
+// after the first element, which is nil.
+func (r *LineReader) Files() []*LineFile {
+	return r.files
+}
+
+// Next sets *entry to the next entry of the line table.
+// At the end of the table it returns io.EOF.
+func (r *LineReader) Next(entry *LineEntry) error {
+	b := &r.buf
+	for len(b.data) > 0 && b.err == nil {
+		if r.step(b) {
+			*entry = r.state
+			if r.state.EndSequence {
+				r.resetState()
+			} else {
+				r.state.BasicBlock = false
+				r.state.PrologueEnd = false
+				r.state.EpilogueBegin = false
+				r.state.Discriminator = 0
+			}
+			return nil
+		}
+	}
+	if b.err != nil {
+		return b.err
+	}
+	return io.EOF
+}
+
+// step runs one instruction of the line program and reports
+// whether it appended a row to the table.
+func (r *LineReader) step(b *buf) bool {
+	op := int(b.uint8())
+	if op >= r.opcodeBase {
+		// Special opcode: advance both address and line, append a row.
+		adj := op - r.opcodeBase
+		r.state.Address += uint64(adj / r.lineRange * r.minInstLength)
+		r.state.Line += r.lineBase + adj%r.lineRange
+		return true
+	}
+
+	switch op {
+	case 0:
+		// Extended opcode, with its length.
+		n := int(b.uint())
+		if n == 0 || n > len(b.data) {
+			b.error("bad extended opcode length")
+			return false
+		}
+		start := len(b.data)
+		switch b.uint8() {
+		case lneEndSequence:
+			r.state.EndSequence = true
+			return true
+		case lneSetAddress:
+			b.addrsize = n - 1
+			r.state.Address = b.addr()
+		case lneDefineFile:
+			r.readFile(b)
+		case lneSetDiscriminator:
+			r.state.Discriminator = int(b.uint())
+		}
+		// Skip what is left, including unknown opcodes.
+		if used := start - len(b.data); used < n {
+			b.skip(n - used)
+		}
+
+	case lnsCopy:
+		return true
+	case lnsAdvancePC:
+		r.state.Address += b.uint() * uint64(r.minInstLength)
+	case lnsAdvanceLine:
+		r.state.Line += int(b.int())
+	case lnsSetFile:
+		r.setFile(int(b.uint()))
+	case lnsSetColumn:
+		r.state.Column = int(b.uint())
+	case lnsNegateStmt:
+		r.state.IsStmt = !r.state.IsStmt
+	case lnsSetBasicBlock:
+		r.state.BasicBlock = true
+	case lnsConstAddPC:
+		adj := 255 - r.opcodeBase
+		r.state.Address += uint64(adj / r.lineRange * r.minInstLength)
+	case lnsFixedAdvancePC:
+		r.state.Address += uint64(b.uint16())
+	case lnsPrologueEnd:
+		r.state.PrologueEnd = true
+	case lnsEpilogueBegin:
+		r.state.EpilogueBegin = true
+	case lnsSetISA:
+		r.state.ISA = int(b.uint())
+	default:
+		// An opcode this reader does not know:
+		// skip the unsigned LEB128 arguments the header says it has.
+		for i := 0; i < r.opcodeLengths[op]; i++ {
+			b.uint()
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwarf_test
+
+import (
+	. "debug/dwarf"
+	"io"
+	"path"
+	"testing"
+)
+
+// The line table should place each function's entry
+// at its opening brace, on the line after its name.
+var lineTests = map[string]int{
+	"sum":   15,
+	"scale": 26,
+	"main":  39,
+}
+
+func TestLineELF(t *testing.T) {
+	d := elfData(t, "testdata/line.elf")
+	r := d.Reader()
+	cu, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cu == nil || cu.Tag != TagCompileUnit {
+		t.Fatalf("first entry is %v, want compile unit", cu)
+	}
+	lr, err := d.LineReader(cu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lr == nil {
+		t.Fatal("no line table")
+	}
+
+	lines := make(map[uint64]LineEntry)
+	var ent LineEntry
+	for {
+		err := lr.Next(&ent)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ent.EndSequence {
+			continue
+		}
+		if _, ok := lines[ent.Address]; !ok {
+			lines[ent.Address] = ent
+		}
+	}
+
+	seen := 0
+	for {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != TagSubprogram {
+			continue
+		}
+		name, _ := e.Val(AttrName).(string)
+		want, ok := lineTests[name]
+		if !ok {
+			continue
+		}
+		seen++
+		pc, _ := e.Val(AttrLowpc).(uint64)
+		ent, ok := lines[pc]
+		switch {
+		case !ok:
+			t.Errorf("%s: no line entry at %#x", name, pc)
+		case ent.Line != want:
+			t.Errorf("%s: line at %#x = %d, want %d", name, pc, ent.Line, want)
+		case ent.File == nil || path.Base(ent.File.Name) != "line.c":
+			t.Errorf("%s: file at %#x = %v, want line.c", name, pc, ent.File)
+		}
+	}
+	if seen != len(lineTests) {
+		t.Errorf("found %d of %d functions", seen, len(lineTests))
+	}
+
+	// Reading the table again should give the same entries.
+	lr.Reset()
+	n := 0
+	for lr.Next(&ent) == nil {
+		if !ent.EndSequence && lines[ent.Address] == ent {
+			n++
+		}
+	}
+	if n < len(lines) {
+		t.Errorf("after Reset, found %d of %d entries", n, len(lines))
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// DWARF location lists, from the ``loc'' section.
+// A variable whose location changes as the program runs,
+// as it moves between registers and memory, has a location
+// list in place of a single location expression.
+
+package dwarf
+
+// A LocationEntry is an entry of a location list: for addresses
+// from Low up to but not including High, the location expression
+// Instr gives the variable's location.
+type LocationEntry struct {
+	Low   uint64
+	High  uint64
+	Instr []byte
+}
+
+// AddSection adds the contents of a DWARF section the New function
+// does not take, identified by its ELF name: only ".debug_loc",
+// for LocationList, is used.  Other sections are ignored.
+func (d *Data) AddSection(name string, contents []byte) error {
+	switch name {
+	case ".debug_loc":
+		d.loc = contents
+	}
+	return nil
+}
+
+// LocationList returns the location list at offset off in the
+// ``loc'' section, for an entry of the compilation unit cu.
+// The offset is the value of an attribute, typically AttrLocation,
+// of class loclistptr, which the Entry holds as an int64.
+func (d *Data) LocationList(cu *Entry, off int64) ([]LocationEntry, error) {
+	i := d.offsetToUnit(cu.Offset)
+	if i < 0 {
+		return nil, DecodeError{"info", cu.Offset, "entry is not in any compilation unit"}
+	}
+	if off < 0 || off >= int64(len(d.loc)) {
+		return nil, DecodeError{"loc", Offset(off), "location list offset out of range"}
+	}
+	addrsize := d.unit[i].addrsize
+	b := makeBuf(d, "loc", Offset(off), d.loc[off:], addrsize)
+
+	// Addresses are relative to the base address, the unit's
+	// low pc until an entry selects a new one.
+	base, _ := cu.Val(AttrLowpc).(uint64)
+	maxaddr := ^uint64(0) >> uint(64-8*addrsize)
+	var list []LocationEntry
+	for {
+		low := b.addr()
+		high := b.addr()
+		if b.err != nil {
+			return nil, b.err
+		}
+		switch {
+		case low == 0 && high == 0:
+			return list, nil
+		case low == maxaddr:
+			base = high
+			continue
+		}
+		instr := b.bytes(int(b.uint16()))
+		if b.err != nil {
+			return nil, b.err
+		}
+		list = append(list, LocationEntry{low + base, high + base, instr})
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,92 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwarf_test
+
+import (
+	"bytes"
+	. "debug/dwarf"
+	"testing"
+)
+
+// The optimized sum keeps its parameter p in register 5 (rdi),
+// DW_OP_reg5, except while the loop has it in a scratch register.
+var sumPLocation = []LocationEntry{
+	{Low: 0x1150, High: 0x115d, Instr: []byte{0x55}},
+	{Low: 0x116c, High: 0x1173, Instr: []byte{0x55}},
+}
+
+func TestLocationListELF(t *testing.T) {
+	d := elfData(t, "testdata/line.elf")
+	r := d.Reader()
+	cu, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every list should describe addresses in its function.
+	var fn *Entry
+	nlist := 0
+	sawP := false
+	for {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag == TagSubprogram {
+			fn = e
+			continue
+		}
+		if e.Tag != TagVariable && e.Tag != TagFormalParameter {
+			continue
+		}
+		off, ok := e.Val(AttrLocation).(int64)
+		if !ok {
+			continue
+		}
+		list, err := d.LocationList(cu, off)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) == 0 {
+			t.Errorf("empty location list at %#x", off)
+			continue
+		}
+		nlist++
+		low, _ := fn.Val(AttrLowpc).(uint64)
+		high, _ := fn.Val(AttrHighpc).(uint64)
+		for _, l := range list {
+			if l.Low < low || l.High > high || l.Low >= l.High || len(l.Instr) == 0 {
+				t.Errorf("location [%#x, %#x) %x not in function [%#x, %#x)", l.Low, l.High, l.Instr, low, high)
+			}
+		}
+		if fn.Val(AttrName) == "sum" && e.Val(AttrName) == "p" {
+			sawP = true
+			if !equalLocations(list, sumPLocation) {
+				t.Errorf("sum: location of p = %v, want %v", list, sumPLocation)
+			}
+		}
+	}
+	if !sawP {
+		t.Error("no location list for sum's p")
+	}
+	if nlist == 0 {
+		t.Fatal("no location lists")
+	}
+}
+
+func equalLocations(x, y []LocationEntry) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].Low != y[i].Low || x[i].High != y[i].High || !bytes.Equal(x[i].Instr, y[i].Instr) {
+			return false
+		}
+	}
+	return true
+}
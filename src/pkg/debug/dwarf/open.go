@@ -21,6 +21,7 @@ type Data struct {
 	pubnames []byte
 	ranges   []byte
 	str      []byte
+	loc      []byte // see AddSection
 
 	// parsed data
 	abbrevCache map[uint32]abbrevTable
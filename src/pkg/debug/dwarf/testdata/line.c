@@ -0,0 +1,41 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Linux ELF:
+gcc -O2 -g -gdwarf-3 -gstrict-dwarf -fno-inline -o line.elf line.c
+
+The optimizer moves the variables between registers,
+which gives them location lists.
+*/
+
+int
+sum(int *p, int n)
+{
+	int i, s;
+
+	s = 0;
+	for(i = 0; i < n; i++)
+		s += p[i];
+	return s;
+}
+
+int
+scale(int x, int k)
+{
+	int y;
+
+	y = x * k;
+	if(y > 100)
+		y = sum(&x, 1) + k;
+	return y;
+}
+
+int a[] = {1, 2, 3, 4, 5};
+
+int
+main(int argc, char **argv)
+{
+	return scale(sum(a, argc), 3) & 1;
+}
@@ -88,6 +88,11 @@ type AddrType struct {
 	BasicType
 }
 
+// An UnspecifiedType represents an implicit, unknown, ambiguous or nonexistent type.
+type UnspecifiedType struct {
+	BasicType
+}
+
 // qualifiers
 
 // A QualType represents a type that has the C/C++ "const", "restrict", or "volatile" qualifier.
@@ -332,6 +337,9 @@ func (d *Data) Type(off Offset) (Type, error) {
 			switch kid.Tag {
 			case TagSubrangeType:
 				max, ok := kid.Val(AttrUpperBound).(int64)
+				if count, haveCount := kid.Val(AttrCount).(int64); haveCount {
+					max, ok = count-1, true
+				}
 				if !ok {
 					max = -2 // Count == -1, as in x[].
 				}
@@ -580,6 +588,18 @@ func (d *Data) Type(off Offset) (Type, error) {
 		d.typeCache[off] = t
 		t.Name, _ = e.Val(AttrName).(string)
 		t.Type = typeOf(e)
+
+	case TagUnspecifiedType:
+		// Unspecified type (DWARF v3 §5.2)
+		// Attributes:
+		//	AttrName: name
+		t := new(UnspecifiedType)
+		typ = t
+		d.typeCache[off] = t
+		t.Name, _ = e.Val(AttrName).(string)
+
+	default:
+		err = DecodeError{"info", off, "no type at offset"}
 	}
 
 	if err != nil {
@@ -15,6 +15,7 @@ type unit struct {
 	data     []byte
 	atable   abbrevTable
 	addrsize int
+	vers     int // DWARF version, 2 to 4
 }
 
 func (d *Data) parseUnits() ([]unit, error) {
@@ -36,8 +37,9 @@ func (d *Data) parseUnits() ([]unit, error) {
 		u := &units[i]
 		u.base = b.off
 		n := b.uint32()
-		if vers := b.uint16(); vers != 2 {
-			b.error("unsupported DWARF version " + strconv.Itoa(int(vers)))
+		u.vers = int(b.uint16())
+		if u.vers < 2 || u.vers > 4 {
+			b.error("unsupported DWARF version " + strconv.Itoa(u.vers))
 			break
 		}
 		atable, err := d.parseAbbrev(b.uint32())
@@ -57,3 +59,16 @@ func (d *Data) parseUnits() ([]unit, error) {
 	}
 	return units, nil
 }
+
+// offsetToUnit returns the index of the unit containing offset off,
+// or -1 if there is none.
+func (d *Data) offsetToUnit(off Offset) int {
+	// TODO(rsc): binary search (maybe a new package)
+	for i := range d.unit {
+		u := &d.unit[i]
+		if u.off <= off && off < u.off+Offset(len(u.data)) {
+			return i
+		}
+	}
+	return -1
+}
@@ -7,6 +7,7 @@ package elf
 
 import (
 	"bytes"
+	"compress/zlib"
 	"debug/dwarf"
 	"encoding/binary"
 	"errors"
@@ -559,20 +560,51 @@ func (f *File) applyRelocationsAMD64(dst []byte, rels []byte) error {
 	return nil
 }
 
+// dwarfSection returns the contents of the DWARF section .debug_name,
+// or of its compressed form .zdebug_name, or nil if f has neither.
+func (f *File) dwarfSection(name string) ([]byte, error) {
+	if s := f.Section(".debug_" + name); s != nil {
+		b, err := s.Data()
+		if err != nil && uint64(len(b)) < s.Size {
+			return nil, err
+		}
+		return b, nil
+	}
+	s := f.Section(".zdebug_" + name)
+	if s == nil {
+		return nil, nil
+	}
+	b, err := s.Data()
+	if err != nil && uint64(len(b)) < s.Size {
+		return nil, err
+	}
+	// The compressed section is "ZLIB", the uncompressed size
+	// as a big-endian uint64, and the zlib stream.
+	if len(b) < 12 || string(b[:4]) != "ZLIB" {
+		return nil, &FormatError{int64(s.Offset), "invalid compressed DWARF section " + s.Name, nil}
+	}
+	size := binary.BigEndian.Uint64(b[4:12])
+	r, err := zlib.NewReader(bytes.NewBuffer(b[12:]))
+	if err != nil {
+		return nil, &FormatError{int64(s.Offset), "invalid compressed DWARF section " + s.Name, err}
+	}
+	defer r.Close()
+	dat := make([]byte, size)
+	if _, err := io.ReadFull(r, dat); err != nil {
+		return nil, &FormatError{int64(s.Offset), "invalid compressed DWARF section " + s.Name, err}
+	}
+	return dat, nil
+}
+
 func (f *File) DWARF() (*dwarf.Data, error) {
 	// There are many other DWARF sections, but these
-	// are the required ones, and the debug/dwarf package
-	// does not use the others, so don't bother loading them.
-	var names = [...]string{"abbrev", "info", "str"}
+	// are the ones the debug/dwarf package uses,
+	// so don't bother loading the others.
+	var names = [...]string{"abbrev", "info", "line", "str", "loc"}
 	var dat [len(names)][]byte
 	for i, name := range names {
-		name = ".debug_" + name
-		s := f.Section(name)
-		if s == nil {
-			continue
-		}
-		b, err := s.Data()
-		if err != nil && uint64(len(b)) < s.Size {
+		b, err := f.dwarfSection(name)
+		if err != nil {
 			return nil, err
 		}
 		dat[i] = b
@@ -592,8 +624,15 @@ func (f *File) DWARF() (*dwarf.Data, error) {
 		}
 	}
 
-	abbrev, info, str := dat[0], dat[1], dat[2]
-	return dwarf.New(abbrev, nil, nil, info, nil, nil, nil, str)
+	abbrev, info, line, str, loc := dat[0], dat[1], dat[2], dat[3], dat[4]
+	d, err := dwarf.New(abbrev, nil, nil, info, line, nil, nil, str)
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		d.AddSection(".debug_loc", loc)
+	}
+	return d, nil
 }
 
 // Symbols returns the symbol table for f.
@@ -214,6 +214,50 @@ func TestDWARFRelocations(t *testing.T) {
 	}
 }
 
+func readEntries(t *testing.T, file string) []*dwarf.Entry {
+	f, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := f.DWARF()
+	if err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	var all []*dwarf.Entry
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if e == nil {
+			return all
+		}
+		all = append(all, e)
+	}
+	panic("unreachable")
+}
+
+func TestCompressedDWARF(t *testing.T) {
+	// The zdebug file is the gcc one with its debug sections
+	// compressed by objcopy --compress-debug-sections=zlib-gnu.
+	want := readEntries(t, "testdata/gcc-amd64-linux-exec")
+	got := readEntries(t, "testdata/zdebug-amd64-linux-exec")
+	if len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("compressed DWARF has %d entries, want the same %d as uncompressed", len(got), len(want))
+	}
+}
+
+func TestDWARFSelf(t *testing.T) {
+	// Ensure the DWARF 6l writes can be read back.
+	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
+		return // not ELF
+	}
+	if len(readEntries(t, os.Args[0])) == 0 {
+		t.Error("no DWARF entries in test binary")
+	}
+}
+
 func TestNoSectionOverlaps(t *testing.T) {
 	// Ensure 6l outputs sections without overlaps.
 	if runtime.GOOS != "linux" && runtime.GOOS != "freebsd" {
@@ -262,6 +262,10 @@ const (
 	// If AllowBinary is set, Import can be satisfied by a compiled
 	// package object without corresponding sources.
 	AllowBinary
+
+	// If IgnoreVendor is set, Import does not look for the package
+	// in vendor directories.  See the Import documentation.
+	IgnoreVendor
 )
 
 // A Package describes the Go package found in a directory.
@@ -278,12 +282,13 @@ type Package struct {
 	PkgObj     string // installed .a file
 
 	// Source files
-	GoFiles   []string // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
-	CgoFiles  []string // .go source files that import "C"
-	CFiles    []string // .c source files
-	HFiles    []string // .h source files
-	SFiles    []string // .s source files
-	SysoFiles []string // .syso system object files to add to archive
+	GoFiles        []string // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
+	CgoFiles       []string // .go source files that import "C"
+	IgnoredGoFiles []string // .go source files ignored for this build
+	CFiles         []string // .c source files
+	HFiles         []string // .h source files
+	SFiles         []string // .s source files
+	SysoFiles      []string // .syso system object files to add to archive
 
 	// Cgo directives
 	CgoPkgConfig []string // Cgo pkg-config directives
@@ -339,6 +344,15 @@ func (e *NoGoError) Error() string {
 //	- files starting with _ or . (likely editor temporary files)
 //	- files with build constraints not satisfied by the context
 //
+// A non-local import path is first looked up in the vendor directories
+// that apply to srcDir: if srcDir is inside a Go tree, Import tries
+// srcDir/vendor/path, then the vendor directory of each parent of
+// srcDir, up to and including the top-level vendor directory of the
+// tree.  The first of these holding Go source files is used, and
+// the returned package's ImportPath is the full path of the vendored
+// copy, such as "proj/vendor/example.com/dep".  Setting IgnoreVendor
+// in mode disables the search.
+//
 // If an error occurs, Import returns a non-nil error also returns a non-nil
 // *Package containing partial information.
 //
@@ -349,16 +363,19 @@ func (ctxt *Context) Import(path string, srcDir string, mode ImportMode) (*Packa
 
 	var pkga string
 	var pkgerr error
-	switch ctxt.Compiler {
-	case "gccgo":
-		dir, elem := pathpkg.Split(p.ImportPath)
-		pkga = "pkg/gccgo/" + dir + "lib" + elem + ".a"
-	case "gc":
-		pkga = "pkg/" + ctxt.GOOS + "_" + ctxt.GOARCH + "/" + p.ImportPath + ".a"
-	default:
-		// Save error for end of function.
-		pkgerr = fmt.Errorf("import %q: unknown compiler %q", path, ctxt.Compiler)
+	setPkga := func() {
+		switch ctxt.Compiler {
+		case "gccgo":
+			dir, elem := pathpkg.Split(p.ImportPath)
+			pkga = "pkg/gccgo/" + dir + "lib" + elem + ".a"
+		case "gc":
+			pkga = "pkg/" + ctxt.GOOS + "_" + ctxt.GOARCH + "/" + p.ImportPath + ".a"
+		default:
+			// Save error for end of function.
+			pkgerr = fmt.Errorf("import %q: unknown compiler %q", path, ctxt.Compiler)
+		}
 	}
+	setPkga()
 
 	binaryOnly := false
 	if IsLocalImport(path) {
@@ -410,6 +427,21 @@ func (ctxt *Context) Import(path string, srcDir string, mode ImportMode) (*Packa
 		if strings.HasPrefix(path, "/") {
 			return p, fmt.Errorf("import %q: cannot import absolute path", path)
 		}
+		// Vendor directories get the first chance to satisfy the import.
+		if mode&IgnoreVendor == 0 && srcDir != "" {
+			if root, sub, goroot, ok := ctxt.findVendor(path, srcDir); ok {
+				p.ImportPath = sub
+				p.Root = root
+				p.Goroot = goroot
+				if goroot {
+					p.Dir = ctxt.joinPath(root, "src", "pkg", sub)
+				} else {
+					p.Dir = ctxt.joinPath(root, "src", sub)
+				}
+				setPkga() // p.ImportPath changed
+				goto Found
+			}
+		}
 		// Determine directory from import path.
 		if ctxt.GOROOT != "" {
 			dir := ctxt.joinPath(ctxt.GOROOT, "src", "pkg", path)
@@ -477,6 +509,9 @@ Found:
 			continue
 		}
 		if !ctxt.UseAllFiles && !ctxt.goodOSArchFile(name) {
+			if strings.HasSuffix(name, ".go") {
+				p.IgnoredGoFiles = append(p.IgnoredGoFiles, name)
+			}
 			continue
 		}
 
@@ -512,6 +547,9 @@ Found:
 
 		// Look for +build comments to accept or reject the file.
 		if !ctxt.UseAllFiles && !ctxt.shouldBuild(data) {
+			if ext == ".go" {
+				p.IgnoredGoFiles = append(p.IgnoredGoFiles, name)
+			}
 			continue
 		}
 
@@ -630,6 +668,57 @@ Found:
 	return p, pkgerr
 }
 
+// findVendor looks for the package with the given import path in
+// the vendor directories that apply to srcDir.  If it finds one,
+// it returns the root of the Go tree containing it, the package's
+// import path within that tree, and whether the tree is GOROOT.
+func (ctxt *Context) findVendor(path, srcDir string) (root, sub string, goroot, ok bool) {
+	search := func(root, src string) (string, bool) {
+		dir, ok := ctxt.hasSubdir(src, srcDir)
+		if !ok {
+			return "", false
+		}
+		for {
+			sub := pathpkg.Join(dir, "vendor", path)
+			if ctxt.hasGoFiles(ctxt.joinPath(src, sub)) {
+				return sub, true
+			}
+			if dir == "" {
+				return "", false
+			}
+			if dir = pathpkg.Dir(dir); dir == "." {
+				dir = ""
+			}
+		}
+		panic("unreachable")
+	}
+	if ctxt.GOROOT != "" {
+		if sub, ok := search(ctxt.GOROOT, ctxt.joinPath(ctxt.GOROOT, "src", "pkg")); ok {
+			return ctxt.GOROOT, sub, true, true
+		}
+	}
+	for _, root := range ctxt.gopath() {
+		if sub, ok := search(root, ctxt.joinPath(root, "src")); ok {
+			return root, sub, false, true
+		}
+	}
+	return "", "", false, false
+}
+
+// hasGoFiles reports whether dir is a directory containing .go files.
+func (ctxt *Context) hasGoFiles(dir string) bool {
+	if !ctxt.isDir(dir) {
+		return false
+	}
+	ents, _ := ctxt.readDir(dir)
+	for _, ent := range ents {
+		if !ent.IsDir() && strings.HasSuffix(ent.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
+
 func cleanImports(m map[string][]token.Position) ([]string, map[string][]token.Position) {
 	all := make([]string, 0, len(m))
 	for path := range m {
@@ -876,6 +965,8 @@ func splitQuoted(s string) (r []string, err error) {
 //	!tag (if tag is not listed in ctxt.BuildTags)
 //	a comma-separated list of any of these
 //
+// The tag "ignore" never matches, even if listed in ctxt.BuildTags.
+//
 func (ctxt *Context) match(name string) bool {
 	if name == "" {
 		return false
@@ -900,6 +991,9 @@ func (ctxt *Context) match(name string) bool {
 	}
 
 	// special tags
+	if name == "ignore" {
+		return false
+	}
 	if ctxt.CgoEnabled && name == "cgo" {
 		return true
 	}
@@ -7,6 +7,7 @@ package build
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 )
@@ -37,6 +38,32 @@ func TestMatch(t *testing.T) {
 	match(runtime.GOOS + "," + runtime.GOARCH + ",!bar")
 	nomatch(runtime.GOOS + "," + runtime.GOARCH + ",bar")
 	nomatch("!")
+
+	what = "ignore"
+	ctxt.BuildTags = []string{"foo", "ignore"}
+	nomatch("ignore")
+	nomatch(runtime.GOOS + ",ignore")
+	match("!ignore")
+}
+
+func TestIgnoredGoFiles(t *testing.T) {
+	ctxt := Default
+	for _, tags := range [][]string{nil, {"debug"}, {"debug", "ignore"}} {
+		ctxt.BuildTags = tags
+		p, err := ctxt.ImportDir("testdata/ignored", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"a.go", "debug.go"}
+		wantIgnored := []string{"gen.go"}
+		if len(tags) == 0 {
+			want = []string{"a.go"}
+			wantIgnored = []string{"debug.go", "gen.go"}
+		}
+		if !reflect.DeepEqual(p.GoFiles, want) || !reflect.DeepEqual(p.IgnoredGoFiles, wantIgnored) {
+			t.Errorf("tags %v: GoFiles=%v IgnoredGoFiles=%v, want %v and %v", tags, p.GoFiles, p.IgnoredGoFiles, want, wantIgnored)
+		}
+	}
 }
 
 func TestDotSlashImport(t *testing.T) {
@@ -75,3 +102,43 @@ func TestLocalDirectory(t *testing.T) {
 		t.Fatalf("ImportPath=%q, want %q", p.ImportPath, "go/build")
 	}
 }
+
+func TestVendor(t *testing.T) {
+	gopath, err := filepath.Abs("testdata/vendorpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctxt := Default
+	ctxt.GOROOT = "" // testdata is inside GOROOT; keep it from matching first
+	ctxt.GOPATH = gopath
+	src := filepath.Join(gopath, "src")
+
+	tests := []struct {
+		path   string
+		srcDir string
+		mode   ImportMode
+		want   string
+	}{
+		{"dep", "proj/sub", 0, "proj/vendor/dep"},
+		{"dep", "proj", 0, "proj/vendor/dep"},
+		{"dep", "other", 0, "dep"},
+		{"dep", "proj/sub", IgnoreVendor, "dep"},
+		{"nogo", "proj/sub", 0, "nogo"}, // vendor/nogo has no Go files
+	}
+	for _, tt := range tests {
+		srcDir := filepath.Join(src, filepath.FromSlash(tt.srcDir))
+		p, err := ctxt.Import(tt.path, srcDir, tt.mode)
+		if err != nil {
+			t.Errorf("Import(%q, %q): %v", tt.path, tt.srcDir, err)
+			continue
+		}
+		dir := filepath.Join(src, filepath.FromSlash(tt.want))
+		if p.ImportPath != tt.want || p.Dir != dir || p.Goroot {
+			t.Errorf("Import(%q, %q) = %q in %s, want %q in %s", tt.path, tt.srcDir, p.ImportPath, p.Dir, tt.want, dir)
+		}
+		obj := filepath.Join(gopath, "pkg", ctxt.GOOS+"_"+ctxt.GOARCH, filepath.FromSlash(tt.want)+".a")
+		if ctxt.Compiler == "gc" && p.PkgObj != obj {
+			t.Errorf("Import(%q, %q).PkgObj = %s, want %s", tt.path, tt.srcDir, p.PkgObj, obj)
+		}
+	}
+}
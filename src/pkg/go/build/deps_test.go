@@ -143,12 +143,17 @@ var pkgDeps = map[string][]string{
 	"regexp":         {"L2", "regexp/syntax"},
 	"regexp/syntax":  {"L2"},
 	"runtime/debug":  {"L2", "fmt", "io/ioutil", "os"},
-	"runtime/pprof":  {"L2", "fmt", "text/tabwriter"},
 	"text/tabwriter": {"L2"},
 
-	"testing":        {"L2", "flag", "fmt", "os", "runtime/pprof", "time"},
-	"testing/iotest": {"L2", "log"},
-	"testing/quick":  {"L2", "flag", "fmt", "reflect"},
+	// runtime/pprof is only used by the generated test main package,
+	// through testing/internal/testdeps, so it may import packages
+	// whose own tests import testing.
+	"runtime/pprof": {"L2", "compress/gzip", "fmt", "os", "text/tabwriter", "time"},
+
+	"testing":                   {"L2", "flag", "fmt", "os", "time"},
+	"testing/internal/testdeps": {"L2", "regexp", "runtime/pprof"},
+	"testing/iotest":            {"L2", "log"},
+	"testing/quick":             {"L2", "flag", "fmt", "reflect"},
 
 	// L4 is defined as L3+fmt+log+time, because in general once
 	// you're using L3 packages, use of fmt, log, or time is not a big deal.
@@ -162,14 +167,17 @@ var pkgDeps = map[string][]string{
 	// Go parser.
 	"go/ast":     {"L4", "OS", "go/scanner", "go/token"},
 	"go/doc":     {"L4", "go/ast", "go/token", "regexp", "text/template"},
+	"go/format":  {"L4", "go/ast", "go/parser", "go/printer", "go/token"},
 	"go/parser":  {"L4", "OS", "go/ast", "go/scanner", "go/token"},
 	"go/printer": {"L4", "OS", "go/ast", "go/scanner", "go/token", "text/tabwriter"},
 	"go/scanner": {"L4", "OS", "go/token"},
 	"go/token":   {"L4"},
+	"go/types":   {"L4", "OS", "go/ast", "go/build", "go/token", "math/big", "text/scanner"},
 
 	"GOPARSER": {
 		"go/ast",
 		"go/doc",
+		"go/format",
 		"go/parser",
 		"go/printer",
 		"go/scanner",
@@ -187,7 +195,7 @@ var pkgDeps = map[string][]string{
 	"database/sql":        {"L4", "database/sql/driver"},
 	"database/sql/driver": {"L4", "time"},
 	"debug/dwarf":         {"L4"},
-	"debug/elf":           {"L4", "OS", "debug/dwarf"},
+	"debug/elf":           {"L4", "OS", "compress/zlib", "debug/dwarf"},
 	"debug/gosym":         {"L4"},
 	"debug/macho":         {"L4", "OS", "debug/dwarf"},
 	"debug/pe":            {"L4", "OS", "debug/dwarf"},
@@ -55,6 +55,24 @@
 //	            foo/
 //	                bar.a          (installed package object)
 //
+// Vendor Directories
+//
+// Code below a directory named "vendor" is visible only to the code
+// in the tree rooted at the parent of "vendor", and it is imported
+// using the path below "vendor".  Continuing the example above,
+// if the file /home/user/gocode/src/foo/quux/y.go imports
+// "example.com/dep", Import looks for the package in
+//
+//	/home/user/gocode/src/foo/quux/vendor/example.com/dep
+//	/home/user/gocode/src/foo/vendor/example.com/dep
+//	/home/user/gocode/src/vendor/example.com/dep
+//
+// before looking in the Go root and the Go path, and uses the first
+// of these that holds Go source files.  A vendored package found this
+// way has the full import path of its directory, here for example
+// "foo/vendor/example.com/dep", so two vendored copies of a package
+// are distinct packages.
+//
 // Build Constraints
 //
 // A build constraint is a line comment beginning with the directive +build
@@ -86,11 +104,15 @@
 // system and architecture values, then the file is considered to have an implicit
 // build constraint requiring those terms.
 //
-// To keep a file from being considered for the build:
+// To keep a file from being considered for any build:
 //
 //	// +build ignore
 //
-// (any other unsatisfied word will work as well, but ``ignore'' is conventional.)
+// The word ``ignore'' is never satisfied, even if it is listed in
+// ctxt.BuildTags, so such a file is excluded from every build.
+// This is useful for programs kept alongside a package, such as
+// generators run with 'go run'.  Go files excluded by their build
+// constraints are listed in the package's IgnoredGoFiles.
 //
 // To build a file only when using cgo, and only on Linux and OS X:
 //
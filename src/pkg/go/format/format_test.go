@@ -0,0 +1,165 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package format
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const testfile = "format_test.go"
+
+func diff(t *testing.T, dst, src []byte) {
+	line := 1
+	offs := 0 // line offset
+	for i := 0; i < len(dst) && i < len(src); i++ {
+		d := dst[i]
+		s := src[i]
+		if d != s {
+			t.Errorf("dst:%d: %s\n", line, dst[offs:i+1])
+			t.Errorf("src:%d: %s\n", line, src[offs:i+1])
+			return
+		}
+		if s == '\n' {
+			line++
+			offs = i + 1
+		}
+	}
+	if len(dst) != len(src) {
+		t.Errorf("len(dst) = %d, len(src) = %d\nsrc = %q", len(dst), len(src), src)
+	}
+}
+
+func TestNode(t *testing.T) {
+	src, err := ioutil.ReadFile(testfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, testfile, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err = Node(&buf, fset, file); err != nil {
+		t.Fatal("Node failed:", err)
+	}
+
+	diff(t, buf.Bytes(), src)
+}
+
+func TestSource(t *testing.T) {
+	src, err := ioutil.ReadFile(testfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Source(src)
+	if err != nil {
+		t.Fatal("Source failed:", err)
+	}
+
+	diff(t, res, src)
+}
+
+// Test cases that are expected to succeed: formatting them
+// must not change them.
+var tests = []string{
+	// declaration lists
+	`import "go/format"`,
+	"var x int",
+	"var x int\n\ntype T struct{}",
+
+	// statement lists
+	"x := 0",
+	"f(a, b, c)\nvar x int = f(1, 2, 3)",
+
+	// indentation, leading and trailing space
+	"\tx := 0\n\tgo f()",
+	"\tx := 0\n\tgo f()\n\n\n",
+	"\n\t\t\n\n\tx := 0\n\tgo f()\n\n\n",
+	"\n\t\t\n\n\t\t\tx := 0\n\t\t\tgo f()\n\n\n",
+}
+
+func TestPartial(t *testing.T) {
+	for _, src := range tests {
+		res, err := Source([]byte(src))
+		if err != nil {
+			t.Errorf("Source(%q) failed: %v", src, err)
+			continue
+		}
+		if string(res) != src {
+			t.Errorf("Source(%q) = %q", src, res)
+		}
+	}
+}
+
+func TestSortImports(t *testing.T) {
+	src := "package p\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n"
+	want := "package p\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n"
+	res, err := Source([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res) != want {
+		t.Errorf("Source = %q, want %q", res, want)
+	}
+
+	// Node sorts too, but leaves its argument alone.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Node(&buf, fset, file); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("Node = %q, want %q", buf.String(), want)
+	}
+	buf.Reset()
+	if err := config.Fprint(&buf, fset, file); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != src {
+		t.Errorf("Node modified its argument: now prints as %q", buf.String())
+	}
+}
+
+var simplifyTests = []struct {
+	in, out string
+}{
+	{"var _ = []T{T{1, 2}, T{3, 4}}", "var _ = []T{{1, 2}, {3, 4}}"},
+	{"var _ = map[string]*T{\"a\": &T{1}}", "var _ = map[string]*T{\"a\": {1}}"},
+	{"var _ = []T{U{1}}", "var _ = []T{U{1}}"},
+	{"func f() {\n\tfor i, _ = range v {\n\t}\n}", "func f() {\n\tfor i = range v {\n\t}\n}"},
+}
+
+func TestSimplify(t *testing.T) {
+	for _, tt := range simplifyTests {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", "package p\n\n"+tt.in+"\n", parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Simplify(file)
+		var buf bytes.Buffer
+		if err := Node(&buf, fset, file); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.TrimSpace(buf.String()[len("package p\n"):])
+		if got != tt.out {
+			t.Errorf("Simplify(%q) = %q, want %q", tt.in, got, tt.out)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package format
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+)
+
+// Simplify applies the simplifications of gofmt -s to the AST rooted
+// at node, modifying it in place:
+//
+//	- In array, slice and map composite literals, the element type is
+//	  omitted from elements that are composite literals of exactly that
+//	  type, and &T is omitted from elements of pointer type *T.
+//	- A range clause of the form "for x, _ = range v" becomes
+//	  "for x = range v".
+//
+// The simplified code means the same as the original.
+func Simplify(node ast.Node) {
+	simplify(node)
+}
+
+type simplifier struct{}
+
+func (s *simplifier) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.CompositeLit:
+		// array, slice, and map composite literals may be simplified
+		outer := n
+		var eltType ast.Expr
+		switch typ := outer.Type.(type) {
+		case *ast.ArrayType:
+			eltType = typ.Elt
+		case *ast.MapType:
+			eltType = typ.Value
+		}
+
+		if eltType != nil {
+			typ := reflect.ValueOf(eltType)
+			for i, x := range outer.Elts {
+				px := &outer.Elts[i]
+				// look at value of indexed/named elements
+				if t, ok := x.(*ast.KeyValueExpr); ok {
+					x = t.Value
+					px = &t.Value
+				}
+				simplify(x)
+				// if the element is a composite literal and its literal type
+				// matches the outer literal's element type exactly, the inner
+				// literal type may be omitted
+				if inner, ok := x.(*ast.CompositeLit); ok {
+					if match(typ, reflect.ValueOf(inner.Type)) {
+						inner.Type = nil
+					}
+				}
+				// if the outer literal's element type is a pointer type *T
+				// and the element is & of a composite literal of type T,
+				// the inner &T may be omitted.
+				if ptr, ok := eltType.(*ast.StarExpr); ok {
+					if addr, ok := x.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+						if inner, ok := addr.X.(*ast.CompositeLit); ok {
+							if match(reflect.ValueOf(ptr.X), reflect.ValueOf(inner.Type)) {
+								inner.Type = nil // drop T
+								*px = inner      // drop &
+							}
+						}
+					}
+				}
+			}
+
+			// node was simplified - stop walk (there are no subnodes to simplify)
+			return nil
+		}
+
+	case *ast.RangeStmt:
+		// range of the form: for x, _ = range v {...}
+		// can be simplified to: for x = range v {...}
+		if n.Value != nil {
+			if ident, ok := n.Value.(*ast.Ident); ok && ident.Name == "_" {
+				n.Value = nil
+			}
+		}
+	}
+
+	return s
+}
+
+func simplify(node ast.Node) {
+	var s simplifier
+	ast.Walk(&s, node)
+}
+
+var (
+	identType     = reflect.TypeOf((*ast.Ident)(nil))
+	objectPtrType = reflect.TypeOf((*ast.Object)(nil))
+	positionType  = reflect.TypeOf(token.NoPos)
+)
+
+// match reports whether the ASTs x and y are the same,
+// ignoring positions and object information.
+func match(x, y reflect.Value) bool {
+	if !x.IsValid() || !y.IsValid() {
+		return !x.IsValid() && !y.IsValid()
+	}
+	if x.Type() != y.Type() {
+		return false
+	}
+
+	// Special cases.
+	switch x.Type() {
+	case identType:
+		// For identifiers, only the names need to match.
+		p := x.Interface().(*ast.Ident)
+		v := y.Interface().(*ast.Ident)
+		return p == nil && v == nil || p != nil && v != nil && p.Name == v.Name
+	case objectPtrType, positionType:
+		// object pointers and token positions don't need to match
+		return true
+	}
+
+	p := reflect.Indirect(x)
+	v := reflect.Indirect(y)
+	if !p.IsValid() || !v.IsValid() {
+		return !p.IsValid() && !v.IsValid()
+	}
+
+	switch p.Kind() {
+	case reflect.Slice:
+		if p.Len() != v.Len() {
+			return false
+		}
+		for i := 0; i < p.Len(); i++ {
+			if !match(p.Index(i), v.Index(i)) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			if !match(p.Field(i), v.Field(i)) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Interface:
+		return match(p.Elem(), v.Elem())
+	}
+
+	// Handle token integers, etc.
+	return p.Interface() == v.Interface()
+}
@@ -0,0 +1,628 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements operations on constant values.
+
+package types
+
+import (
+	"fmt"
+	"go/token"
+	"math/big"
+	"strconv"
+)
+
+// Constant values are represented by the following Go types:
+//
+//	boolean constants   bool
+//	integer constants   *big.Int
+//	float constants     *big.Rat
+//	complex constants   Complex
+//	string constants    string
+//
+// Values are never modified in place; operations always
+// allocate a new value for their result.
+
+// A Complex represents a complex constant value.
+type Complex struct {
+	Re, Im *big.Rat
+}
+
+func (c Complex) String() string {
+	return fmt.Sprintf("(%s + %si)", ratString(c.Re), ratString(c.Im))
+}
+
+// Frequently used values.
+var (
+	zeroConst     = big.NewInt(0)
+	oneConst      = big.NewInt(1)
+	minusOneConst = big.NewInt(-1)
+)
+
+// int64 bounds of integer constants that fit into an int64
+var (
+	minInt64 = big.NewInt(-1 << 63)
+	maxInt64 = big.NewInt(1<<63 - 1)
+)
+
+// makeIntConst returns the integer constant value for x.
+func makeIntConst(x int64) *big.Int { return big.NewInt(x) }
+
+// makeRuneConst returns the integer constant value for the rune
+// literal lit; ok is false if lit is not a valid literal.
+func makeRuneConst(lit string) (x *big.Int, ok bool) {
+	if n := len(lit); n >= 2 {
+		if code, _, _, err := strconv.UnquoteChar(lit[1:n-1], '\''); err == nil {
+			return big.NewInt(int64(code)), true
+		}
+	}
+	return nil, false
+}
+
+// makeIntLitConst returns the integer constant value for the
+// integer literal lit; ok is false if lit is not a valid literal.
+func makeIntLitConst(lit string) (x *big.Int, ok bool) {
+	return new(big.Int).SetString(lit, 0)
+}
+
+// makeFloatLitConst returns the float constant value for the
+// floating-point literal lit; ok is false if lit is not a valid literal.
+func makeFloatLitConst(lit string) (x *big.Rat, ok bool) {
+	// big.Rat.SetString does not accept a leading '.'.
+	if len(lit) > 0 && lit[0] == '.' {
+		lit = "0" + lit
+	}
+	return new(big.Rat).SetString(lit)
+}
+
+// makeConst returns the constant value for the basic literal lit
+// of the given kind, or nil if lit is not a valid literal.
+func makeConst(kind token.Token, lit string) interface{} {
+	switch kind {
+	case token.INT:
+		if x, ok := makeIntLitConst(lit); ok {
+			return x
+		}
+	case token.FLOAT:
+		if x, ok := makeFloatLitConst(lit); ok {
+			return x
+		}
+	case token.IMAG:
+		if n := len(lit); n > 0 && lit[n-1] == 'i' {
+			if im, ok := makeFloatLitConst(lit[:n-1]); ok {
+				return Complex{big.NewRat(0, 1), im}
+			}
+		}
+	case token.CHAR:
+		if x, ok := makeRuneConst(lit); ok {
+			return x
+		}
+	case token.STRING:
+		if s, err := strconv.Unquote(lit); err == nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// isZeroConst reports whether the value of constant x is 0.
+func isZeroConst(x interface{}) bool {
+	switch x := x.(type) {
+	case *big.Int:
+		return x.Sign() == 0
+	case *big.Rat:
+		return x.Sign() == 0
+	case Complex:
+		return x.Re.Sign() == 0 && x.Im.Sign() == 0
+	}
+	return false
+}
+
+// isNegConst reports whether the value of constant x is < 0.
+// x must be a non-complex numeric value.
+func isNegConst(x interface{}) bool {
+	switch x := x.(type) {
+	case *big.Int:
+		return x.Sign() < 0
+	case *big.Rat:
+		return x.Sign() < 0
+	}
+	return false
+}
+
+// int64Const returns the value of the integer constant x as an int64;
+// ok is false if x is not an integer constant representable as int64.
+func int64Const(x interface{}) (v int64, ok bool) {
+	switch x := x.(type) {
+	case *big.Int:
+		if x.Cmp(minInt64) >= 0 && x.Cmp(maxInt64) <= 0 {
+			return x.Int64(), true
+		}
+	case *big.Rat:
+		if x.IsInt() {
+			return int64Const(x.Num())
+		}
+	case Complex:
+		if x.Im.Sign() == 0 {
+			return int64Const(x.Re)
+		}
+	}
+	return 0, false
+}
+
+// toIntConst returns the integer constant corresponding to x,
+// or nil if x does not represent an integer value.
+func toIntConst(x interface{}) *big.Int {
+	switch x := x.(type) {
+	case *big.Int:
+		return x
+	case *big.Rat:
+		if x.IsInt() {
+			return new(big.Int).Set(x.Num())
+		}
+	case Complex:
+		if x.Im.Sign() == 0 {
+			return toIntConst(x.Re)
+		}
+	}
+	return nil
+}
+
+// toRatConst returns the float constant corresponding to x,
+// or nil if x does not represent a real value.
+func toRatConst(x interface{}) *big.Rat {
+	switch x := x.(type) {
+	case *big.Int:
+		return new(big.Rat).SetInt(x)
+	case *big.Rat:
+		return x
+	case Complex:
+		if x.Im.Sign() == 0 {
+			return x.Re
+		}
+	}
+	return nil
+}
+
+// toComplexConst returns the complex constant corresponding to x,
+// or the zero Complex if x is not numeric.
+func toComplexConst(x interface{}) Complex {
+	switch x := x.(type) {
+	case *big.Int:
+		return Complex{new(big.Rat).SetInt(x), big.NewRat(0, 1)}
+	case *big.Rat:
+		return Complex{x, big.NewRat(0, 1)}
+	case Complex:
+		return x
+	}
+	return Complex{}
+}
+
+// ratString returns a short decimal representation of x.
+func ratString(x *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().String()
+	}
+	s := x.FloatString(6)
+	// remove trailing zeros, but keep at least one digit after the '.'
+	i := len(s)
+	for s[i-1] == '0' && s[i-2] != '.' {
+		i--
+	}
+	return s[:i]
+}
+
+// constString returns a string representation of constant x.
+func constString(x interface{}) string {
+	switch x := x.(type) {
+	case bool:
+		return strconv.FormatBool(x)
+	case *big.Int:
+		return x.String()
+	case *big.Rat:
+		return ratString(x)
+	case Complex:
+		return x.String()
+	case string:
+		const max = 72
+		s := strconv.Quote(x)
+		if len(s) > max {
+			s = s[:max-3] + "..."
+		}
+		return s
+	}
+	return fmt.Sprintf("%v", x)
+}
+
+// Float limits: the smallest magnitudes that round to infinity
+// for float32 and float64, respectively. They lie half an ulp
+// above the largest finite values (because the mantissa of the
+// largest finite values is odd, ties round up to infinity).
+var (
+	maxFloat32 = new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1<<25-1), 103))
+	maxFloat64 = new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1<<54-1), 970))
+)
+
+// sizeofBasic returns the size in bytes of the integer type
+// of the given kind, or 0 if kind is not an integer kind.
+func (conf *Config) sizeofBasic(kind BasicKind) int64 {
+	switch kind {
+	case Int8, Uint8:
+		return 1
+	case Int16, Uint16:
+		return 2
+	case Int32, Uint32:
+		return 4
+	case Int64, Uint64:
+		return 8
+	case Int, Uint:
+		return conf.intSize()
+	case Uintptr:
+		return conf.ptrSize()
+	}
+	return 0
+}
+
+// representableConst reports whether the value x can be represented
+// as a value of the basic type with the given kind. If x is numeric
+// and rounded is not nil, *rounded is set to the value of x in the
+// representation used for kind.
+func (conf *Config) representableConst(x interface{}, kind BasicKind, rounded *interface{}) bool {
+	if x == nil {
+		return false // errors have been reported before
+	}
+	info := Typ[kind].info
+	switch {
+	case info&IsBoolean != 0:
+		_, ok := x.(bool)
+		return ok
+
+	case info&IsString != 0:
+		_, ok := x.(string)
+		return ok
+
+	case info&IsInteger != 0:
+		v := toIntConst(x)
+		if v == nil {
+			return false
+		}
+		if rounded != nil {
+			*rounded = v
+		}
+		if info&IsUntyped != 0 {
+			return true
+		}
+		size := conf.sizeofBasic(kind)
+		if info&IsUnsigned != 0 {
+			return v.Sign() >= 0 && v.BitLen() <= int(8*size)
+		}
+		// signed integer: -1<<(bits-1) <= v < 1<<(bits-1)
+		if v.Sign() >= 0 {
+			return v.BitLen() < int(8*size)
+		}
+		w := new(big.Int).Neg(v)
+		w.Sub(w, oneConst)
+		return w.BitLen() < int(8*size)
+
+	case info&IsFloat != 0:
+		v := toRatConst(x)
+		if v == nil {
+			return false
+		}
+		if rounded != nil {
+			*rounded = v
+		}
+		return fitsFloat(v, kind)
+
+	case info&IsComplex != 0:
+		if _, ok := x.(bool); ok {
+			return false
+		}
+		if _, ok := x.(string); ok {
+			return false
+		}
+		v := toComplexConst(x)
+		if rounded != nil {
+			*rounded = v
+		}
+		partKind := Float64
+		if kind == Complex64 {
+			partKind = Float32
+		}
+		return fitsFloat(v.Re, partKind) && fitsFloat(v.Im, partKind)
+	}
+	return false
+}
+
+// fitsFloat reports whether v rounds to a finite value
+// of the float type of the given kind.
+func fitsFloat(v *big.Rat, kind BasicKind) bool {
+	switch kind {
+	case Float32:
+		return new(big.Rat).Abs(v).Cmp(maxFloat32) < 0
+	case Float64:
+		return new(big.Rat).Abs(v).Cmp(maxFloat64) < 0
+	}
+	return true // untyped float
+}
+
+// matchConst returns the matching representation (same type) with the
+// smallest complexity for two constant values x and y. They must be
+// of the same "kind" (boolean, numeric, string, or nilType).
+func matchConst(x, y interface{}) (_, _ interface{}) {
+	ord := func(x interface{}) int {
+		switch x.(type) {
+		case *big.Int:
+			return 1
+		case *big.Rat:
+			return 2
+		case Complex:
+			return 3
+		}
+		return 0
+	}
+	ox, oy := ord(x), ord(y)
+	if ox == 0 || oy == 0 || ox == oy {
+		return x, y
+	}
+	if ox > oy {
+		y, x = matchConst(y, x)
+		return x, y
+	}
+	// ox < oy
+	switch oy {
+	case 2:
+		return toRatConst(x), y
+	case 3:
+		return toComplexConst(x), y
+	}
+	panic("unreachable")
+}
+
+// compareConst returns the result of the constant comparison x op y.
+func compareConst(x, y interface{}, op token.Token) bool {
+	x, y = matchConst(x, y)
+
+	switch x := x.(type) {
+	case bool:
+		y := y.(bool)
+		switch op {
+		case token.EQL:
+			return x == y
+		case token.NEQ:
+			return x != y
+		}
+
+	case *big.Int:
+		s := x.Cmp(y.(*big.Int))
+		return compareSign(s, op)
+
+	case *big.Rat:
+		s := x.Cmp(y.(*big.Rat))
+		return compareSign(s, op)
+
+	case Complex:
+		y := y.(Complex)
+		eq := x.Re.Cmp(y.Re) == 0 && x.Im.Cmp(y.Im) == 0
+		switch op {
+		case token.EQL:
+			return eq
+		case token.NEQ:
+			return !eq
+		}
+
+	case string:
+		y := y.(string)
+		switch op {
+		case token.EQL:
+			return x == y
+		case token.NEQ:
+			return x != y
+		case token.LSS:
+			return x < y
+		case token.LEQ:
+			return x <= y
+		case token.GTR:
+			return x > y
+		case token.GEQ:
+			return x >= y
+		}
+	}
+
+	panic("unreachable")
+}
+
+func compareSign(s int, op token.Token) bool {
+	switch op {
+	case token.EQL:
+		return s == 0
+	case token.NEQ:
+		return s != 0
+	case token.LSS:
+		return s < 0
+	case token.LEQ:
+		return s <= 0
+	case token.GTR:
+		return s > 0
+	case token.GEQ:
+		return s >= 0
+	}
+	panic("unreachable")
+}
+
+// unaryOpConst returns the result of the constant evaluation op x where x is of the given type.
+func unaryOpConst(conf *Config, x interface{}, op token.Token, typ *Basic) interface{} {
+	switch op {
+	case token.ADD:
+		return x // nothing to do
+	case token.SUB:
+		switch x := x.(type) {
+		case *big.Int:
+			return new(big.Int).Neg(x)
+		case *big.Rat:
+			return new(big.Rat).Neg(x)
+		case Complex:
+			return Complex{new(big.Rat).Neg(x.Re), new(big.Rat).Neg(x.Im)}
+		}
+	case token.XOR:
+		var z big.Int
+		switch x := x.(type) {
+		case *big.Int:
+			z.Not(x)
+		default:
+			unreachable()
+		}
+		// For unsigned types, the result will be negative and
+		// thus "too large": We must limit the result size to
+		// the type's size.
+		if typ.info&IsUnsigned != 0 {
+			s := uint(conf.sizeofBasic(typ.kind)) * 8
+			z.AndNot(&z, new(big.Int).Lsh(big.NewInt(-1), s)) // z &^= (-1)<<s
+		}
+		return &z
+	case token.NOT:
+		return !x.(bool)
+	}
+	unreachable()
+	return nil
+}
+
+// binaryOpConst returns the result of the constant evaluation x op y;
+// both operands must be of the same constant "kind" (boolean, numeric, or string).
+// If intDiv is true, division (op == token.QUO) is using integer division
+// (and the result is guaranteed to be integer) rather than floating-point
+// division. Division by zero leads to a run-time panic.
+//
+func binaryOpConst(x, y interface{}, op token.Token, intDiv bool) interface{} {
+	x, y = matchConst(x, y)
+
+	switch x := x.(type) {
+	case bool:
+		y := y.(bool)
+		switch op {
+		case token.LAND:
+			return x && y
+		case token.LOR:
+			return x || y
+		}
+
+	case *big.Int:
+		y := y.(*big.Int)
+		var z big.Int
+		switch op {
+		case token.ADD:
+			z.Add(x, y)
+		case token.SUB:
+			z.Sub(x, y)
+		case token.MUL:
+			z.Mul(x, y)
+		case token.QUO:
+			if intDiv {
+				z.Quo(x, y)
+			} else {
+				return new(big.Rat).SetFrac(x, y)
+			}
+		case token.REM:
+			z.Rem(x, y)
+		case token.AND:
+			z.And(x, y)
+		case token.OR:
+			z.Or(x, y)
+		case token.XOR:
+			z.Xor(x, y)
+		case token.AND_NOT:
+			z.AndNot(x, y)
+		default:
+			unreachable()
+		}
+		return &z
+
+	case *big.Rat:
+		y := y.(*big.Rat)
+		var z big.Rat
+		switch op {
+		case token.ADD:
+			z.Add(x, y)
+		case token.SUB:
+			z.Sub(x, y)
+		case token.MUL:
+			z.Mul(x, y)
+		case token.QUO:
+			z.Quo(x, y)
+		default:
+			unreachable()
+		}
+		return &z
+
+	case Complex:
+		y := y.(Complex)
+		a, b := x.Re, x.Im
+		c, d := y.Re, y.Im
+		var re, im big.Rat
+		switch op {
+		case token.ADD:
+			// (a+c) + i(b+d)
+			re.Add(a, c)
+			im.Add(b, d)
+		case token.SUB:
+			// (a-c) + i(b-d)
+			re.Sub(a, c)
+			im.Sub(b, d)
+		case token.MUL:
+			// (ac-bd) + i(bc+ad)
+			var ac, bd, bc, ad big.Rat
+			ac.Mul(a, c)
+			bd.Mul(b, d)
+			bc.Mul(b, c)
+			ad.Mul(a, d)
+			re.Sub(&ac, &bd)
+			im.Add(&bc, &ad)
+		case token.QUO:
+			// (ac+bd)/s + i(bc-ad)/s, with s = cc + dd
+			var ac, bd, bc, ad, cc, dd, s big.Rat
+			ac.Mul(a, c)
+			bd.Mul(b, d)
+			bc.Mul(b, c)
+			ad.Mul(a, d)
+			cc.Mul(c, c)
+			dd.Mul(d, d)
+			s.Add(&cc, &dd)
+			re.Add(&ac, &bd)
+			re.Quo(&re, &s)
+			im.Sub(&bc, &ad)
+			im.Quo(&im, &s)
+		default:
+			unreachable()
+		}
+		return Complex{&re, &im}
+
+	case string:
+		if op == token.ADD {
+			return x + y.(string)
+		}
+	}
+
+	unreachable()
+	return nil
+}
+
+// shiftConst returns the result of the constant evaluation x op s
+// where op is token.SHL or token.SHR (<< or >>). x must be an
+// integer constant.
+//
+func shiftConst(x interface{}, s uint, op token.Token) interface{} {
+	switch x := x.(type) {
+	case *big.Int:
+		var z big.Int
+		switch op {
+		case token.SHL:
+			return z.Lsh(x, s)
+		case token.SHR:
+			return z.Rsh(x, s)
+		}
+	}
+
+	unreachable()
+	return nil
+}
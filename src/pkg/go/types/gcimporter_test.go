@@ -0,0 +1,120 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var imports = make(map[string]*Package)
+
+func testPath(t *testing.T, path string) bool {
+	t0 := time.Now()
+	_, err := GcImport(imports, path)
+	if err != nil {
+		t.Errorf("testPath(%s): %s", path, err)
+		return false
+	}
+	t.Logf("testPath(%s): %v", path, time.Since(t0))
+	return true
+}
+
+const maxTime = 30 * time.Second
+
+func testDir(t *testing.T, dir string, endTime time.Time) (nimports int) {
+	dirname := filepath.Join(runtime.GOROOT(), "pkg", runtime.GOOS+"_"+runtime.GOARCH, dir)
+	list, err := ioutil.ReadDir(dirname)
+	if err != nil {
+		t.Fatalf("testDir(%s): %s", dirname, err)
+	}
+	for _, f := range list {
+		if time.Now().After(endTime) {
+			t.Log("testing time used up")
+			return
+		}
+		switch m := f.Name(); {
+		case strings.HasSuffix(m, ".a"):
+			if testPath(t, filepath.Join(dir, m[:len(m)-len(".a")])) {
+				nimports++
+			}
+		case f.IsDir():
+			nimports += testDir(t, filepath.Join(dir, m), endTime)
+		}
+	}
+	return
+}
+
+func TestImport(t *testing.T) {
+	// cmd/go installs the standard packages below $GOROOT/pkg
+	if _, err := build.Import("fmt", "", build.FindOnly|build.AllowBinary); err != nil {
+		t.Logf("cannot locate standard packages: %s", err)
+		return
+	}
+	nimports := testDir(t, "", time.Now().Add(maxTime)) // installed packages
+	t.Logf("tested %d imports", nimports)
+}
+
+var importedObjectTests = []struct {
+	name string
+	kind string
+	typ  string
+}{
+	{"unsafe.Pointer", "type", "unsafe.Pointer"},
+	{"math.Pi", "const", "untyped float"},
+	{"io.Reader", "type", "interface{Read(p []byte) (n int, err error)}"},
+	{"io.ReadWriter", "type", "interface{Read(p []byte) (n int, err error); Write(p []byte) (n int, err error)}"},
+	{"math.Sin", "func", "func(x float64) float64"},
+	{"strconv.ErrRange", "var", "interface{Error() string}"},
+}
+
+func TestImportedTypes(t *testing.T) {
+	for _, test := range importedObjectTests {
+		s := strings.Split(test.name, ".")
+		if len(s) != 2 {
+			t.Fatal("inconsistent test data")
+		}
+		importPath := s[0]
+		objName := s[1]
+
+		pkg, err := GcImport(imports, importPath)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		obj := pkg.scope.Lookup(objName)
+		if obj == nil {
+			t.Errorf("%s: object not found", test.name)
+			continue
+		}
+
+		if got := objectKind(obj); got != test.kind {
+			t.Errorf("%s: got kind %s; want %s", test.name, got, test.kind)
+		}
+		if got := TypeString(pkg, obj.Type().Underlying()); got != test.typ {
+			t.Errorf("%s: got %q; want %q", test.name, got, test.typ)
+		}
+	}
+}
+
+func objectKind(obj Object) string {
+	switch obj.(type) {
+	case *Const:
+		return "const"
+	case *TypeName:
+		return "type"
+	case *Var:
+		return "var"
+	case *Func:
+		return "func"
+	}
+	return "other"
+}
@@ -0,0 +1,158 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the computation of sizes, alignments,
+// and field offsets as needed by the unsafe package functions.
+
+package types
+
+func (conf *Config) intSize() int64 {
+	if conf != nil && conf.IntSize > 0 {
+		return conf.IntSize
+	}
+	return 4
+}
+
+func (conf *Config) ptrSize() int64 {
+	if conf != nil && conf.PtrSize > 0 {
+		return conf.PtrSize
+	}
+	return 8
+}
+
+// maxAlign is the largest alignment of any type: the alignment
+// of a pointer, or of a 64-bit value, whichever is larger.
+func (conf *Config) maxAlign() int64 {
+	if a := conf.ptrSize(); a > 8 {
+		return a
+	}
+	return 8
+}
+
+// alignof returns the alignment of a variable of type T in bytes.
+func (conf *Config) alignof(T Type) int64 {
+	// Spec: "For a variable x of any type: unsafe.Alignof(x)
+	// is at least 1."
+	switch t := T.Underlying().(type) {
+	case *Array:
+		// spec: "For a variable x of array type: unsafe.Alignof(x)
+		// is the same as unsafe.Alignof(x[0]), but at least 1."
+		return conf.alignof(t.elem)
+	case *Struct:
+		// spec: "For a variable x of struct type: unsafe.Alignof(x)
+		// is the largest of the values unsafe.Alignof(x.f) for each
+		// field f of x, but at least 1."
+		max := int64(1)
+		for _, f := range t.fields {
+			if a := conf.alignof(f.typ); a > max {
+				max = a
+			}
+		}
+		return max
+	case *Basic:
+		if t.info&IsString != 0 {
+			return conf.ptrSize()
+		}
+		if t.info&IsComplex != 0 {
+			// complex values are aligned like their parts
+			return conf.sizeof(T) / 2
+		}
+	case *Slice, *Interface:
+		return conf.ptrSize()
+	}
+	a := conf.sizeof(T) // may be 0
+	// spec: "For a variable x of any type: unsafe.Alignof(x) is at least 1."
+	if a < 1 {
+		return 1
+	}
+	if max := conf.maxAlign(); a > max {
+		return max
+	}
+	return a
+}
+
+// offsetsof returns the offsets of the given struct fields, in bytes.
+func (conf *Config) offsetsof(fields []*Var) []int64 {
+	offsets := make([]int64, len(fields))
+	var o int64
+	for i, f := range fields {
+		a := conf.alignof(f.typ)
+		o = align(o, a)
+		offsets[i] = o
+		o += conf.sizeof(f.typ)
+	}
+	return offsets
+}
+
+// offsetof returns the offset of the field specified via
+// the index sequence relative to typ. All embedded fields
+// must be structs (rather than pointer to structs).
+func (conf *Config) offsetof(typ Type, index []int) int64 {
+	var o int64
+	for _, i := range index {
+		s := derefStructPtr(typ).Underlying().(*Struct)
+		if s.offsets == nil {
+			// compute offsets on demand
+			s.offsets = conf.offsetsof(s.fields)
+		}
+		o += s.offsets[i]
+		typ = s.fields[i].typ
+	}
+	return o
+}
+
+// sizeof returns the size of a variable of type T in bytes.
+func (conf *Config) sizeof(T Type) int64 {
+	switch t := T.Underlying().(type) {
+	case *Basic:
+		if z := conf.sizeofBasic(t.kind); z > 0 {
+			return z
+		}
+		switch t.kind {
+		case Bool:
+			return 1
+		case Float32:
+			return 4
+		case Float64, Complex64:
+			return 8
+		case Complex128:
+			return 16
+		case String:
+			// pointer and int length
+			return align(conf.ptrSize()+conf.intSize(), conf.ptrSize())
+		}
+	case *Array:
+		n := t.len
+		if n == 0 {
+			return 0
+		}
+		a := conf.alignof(t.elem)
+		z := conf.sizeof(t.elem)
+		return align(z, a)*(n-1) + z
+	case *Slice:
+		// pointer and int length and capacity
+		return align(conf.ptrSize()+2*conf.intSize(), conf.ptrSize())
+	case *Struct:
+		n := t.NumFields()
+		if n == 0 {
+			return 0
+		}
+		offsets := t.offsets
+		if t.offsets == nil {
+			// compute offsets on demand
+			offsets = conf.offsetsof(t.fields)
+			t.offsets = offsets
+		}
+		return align(offsets[n-1]+conf.sizeof(t.fields[n-1].typ), conf.alignof(t))
+	case *Interface:
+		return conf.ptrSize() * 2
+	}
+	return conf.ptrSize() // catch-all
+}
+
+// align returns the smallest y >= x such that y % a == 0.
+func align(x, a int64) int64 {
+	y := x + a - 1
+	return y - y%a
+}
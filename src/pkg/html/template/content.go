@@ -53,6 +53,12 @@ type (
 	// `javascript:` URLs are filtered out since they are a frequently
 	// exploited injection vector.
 	URL string
+
+	// Srcset encapsulates a known safe srcset attribute value, a comma
+	// separated list of image URLs each followed by optional metadata
+	// such as a width or pixel density, for example, `/img.png 2x`.
+	// See http://www.w3.org/TR/html-srcset/
+	Srcset string
 )
 
 type contentType uint8
@@ -65,6 +71,7 @@ const (
 	contentTypeJS
 	contentTypeJSStr
 	contentTypeURL
+	contentTypeSrcset
 	// contentTypeUnsafe is used in attr.go for values that affect how
 	// embedded content and network messages are formed, vetted,
 	// or interpreted; or which credentials network messages carry.
@@ -120,6 +127,8 @@ func stringify(args ...interface{}) (string, contentType) {
 			return string(s), contentTypeJSStr
 		case URL:
 			return string(s), contentTypeURL
+		case Srcset:
+			return string(s), contentTypeSrcset
 		}
 	}
 	for i, arg := range args {
@@ -102,6 +102,9 @@ const (
 	stateAttr
 	// stateURL occurs inside an HTML attribute whose content is a URL.
 	stateURL
+	// stateSrcset occurs inside an HTML srcset attribute, whose content
+	// is a comma separated list of URLs each followed by optional metadata.
+	stateSrcset
 	// stateJS occurs inside an event handler or script element.
 	stateJS
 	// stateJSDqStr occurs inside a JavaScript double quoted string.
@@ -130,6 +133,8 @@ const (
 	stateCSSBlockCmt
 	// stateCSSLineCmt occurs inside a CSS // line comment.
 	stateCSSLineCmt
+	// stateDead marks unreachable code after a {{break}} or {{continue}}.
+	stateDead
 	// stateError is an infectious error state outside any valid
 	// HTML/CSS/JS construct.
 	stateError
@@ -145,6 +150,7 @@ var stateNames = [...]string{
 	stateRCDATA:      "stateRCDATA",
 	stateAttr:        "stateAttr",
 	stateURL:         "stateURL",
+	stateSrcset:      "stateSrcset",
 	stateJS:          "stateJS",
 	stateJSDqStr:     "stateJSDqStr",
 	stateJSSqStr:     "stateJSSqStr",
@@ -159,6 +165,7 @@ var stateNames = [...]string{
 	stateCSSURL:      "stateCSSURL",
 	stateCSSBlockCmt: "stateCSSBlockCmt",
 	stateCSSLineCmt:  "stateCSSLineCmt",
+	stateDead:        "stateDead",
 	stateError:       "stateError",
 }
 
@@ -287,6 +294,9 @@ const (
 	elementNone element = iota
 	// elementScript corresponds to the raw text <script> element.
 	elementScript
+	// elementScriptJSON corresponds to a <script> element whose type
+	// attribute names a JSON MIME type, such as "application/ld+json".
+	elementScriptJSON
 	// elementStyle corresponds to the raw text <style> element.
 	elementStyle
 	// elementTextarea corresponds to the RCDATA <textarea> element.
@@ -296,11 +306,12 @@ const (
 )
 
 var elementNames = [...]string{
-	elementNone:     "elementNone",
-	elementScript:   "elementScript",
-	elementStyle:    "elementStyle",
-	elementTextarea: "elementTextarea",
-	elementTitle:    "elementTitle",
+	elementNone:       "elementNone",
+	elementScript:     "elementScript",
+	elementScriptJSON: "elementScriptJSON",
+	elementStyle:      "elementStyle",
+	elementTextarea:   "elementTextarea",
+	elementTitle:      "elementTitle",
 }
 
 func (e element) String() string {
@@ -322,13 +333,19 @@ const (
 	attrStyle
 	// attrURL corresponds to an attribute whose value is a URL.
 	attrURL
+	// attrSrcset corresponds to a srcset attribute.
+	attrSrcset
+	// attrScriptType corresponds to the type attribute of a <script> element.
+	attrScriptType
 )
 
 var attrNames = [...]string{
-	attrNone:   "attrNone",
-	attrScript: "attrScript",
-	attrStyle:  "attrStyle",
-	attrURL:    "attrURL",
+	attrNone:       "attrNone",
+	attrScript:     "attrScript",
+	attrStyle:      "attrStyle",
+	attrURL:        "attrURL",
+	attrSrcset:     "attrSrcset",
+	attrScriptType: "attrScriptType",
 }
 
 func (a attr) String() string {
@@ -132,6 +132,22 @@ then the template output is
 See package json to understand how non-string content is marshalled for
 embedding in JavaScript contexts.
 
+The body of a <script> element whose type attribute is a JSON MIME type, such
+as "application/ld+json", is escaped as JSON, so strings use only the escape
+sequences JSON allows. The body of a <script> whose type browsers do not
+execute, such as "text/template", is escaped as HTML, as is the content of a
+<template> element. Since the type decides which of these applies, it may not
+contain actions.
+
+In a srcset attribute, each image candidate's URL is filtered as for href,
+and its metadata must be a single descriptor such as "2x":
+
+  Context                              {{.}} After
+  <img srcset="{{.}}">                 /a.png 1x, /b.png 2x
+  <img srcset="{{.}}">                 #ZgotmplZ
+
+where {{.}} is `/a.png 1x, /b.png 2x` and `javascript:f() 1x` respectively.
+
 
 Typed Strings
 
@@ -142,7 +158,7 @@ plain text string in the appropriate context.
 When a data value is not plain text, you can make sure it is not over-escaped
 by marking it with its type.
 
-Types HTML, JS, URL, and others from content.go can carry safe content that is
+Types HTML, JS, URL, Srcset, and others from content.go can carry safe content that is
 exempted from escaping.
 
 The template
@@ -40,7 +40,8 @@ const (
 	// OK indicates the lack of an error.
 	OK ErrorCode = iota
 
-	// ErrAmbigContext: "... appears in an ambiguous URL context"
+	// ErrAmbigContext: "... appears in an ambiguous context within a URL",
+	//   "... appears in the type attribute of a <script> element, ..."
 	// Example:
 	//   <a href="
 	//      {{if .C}}
@@ -55,6 +56,12 @@ const (
 	//  it may be either a URL suffix or a query parameter.
 	//   Moving {{.X}} into the condition removes the ambiguity:
 	//   <a href="{{if .C}}/path/{{.X}}{{else}}/search?q={{.X}}">
+	//
+	//   Similarly, in
+	//     <script type="{{.T}}">{{.X}}</script>
+	//   the type decides whether the body of the element is JavaScript,
+	//   JSON, or inert data, so {{.X}} cannot be escaped. Use a constant
+	//   type attribute instead.
 	ErrAmbigContext
 
 	// ErrBadHTML: "expected space, attr name, or end of tag, but got ...",
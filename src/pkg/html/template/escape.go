@@ -42,6 +42,7 @@ func escapeTemplates(tmpl *Template, names ...string) error {
 		tmpl.escaped = true
 	}
 	e.commit()
+	tmpl.nameSpace.escaped = true
 	return nil
 }
 
@@ -53,11 +54,13 @@ var funcMap = template.FuncMap{
 	"html_template_cssvaluefilter":  cssValueFilter,
 	"html_template_htmlnamefilter":  htmlNameFilter,
 	"html_template_htmlescaper":     htmlEscaper,
+	"html_template_jsonstrescaper":  jsonStrEscaper,
 	"html_template_jsregexpescaper": jsRegexpEscaper,
 	"html_template_jsstrescaper":    jsStrEscaper,
 	"html_template_jsvalescaper":    jsValEscaper,
 	"html_template_nospaceescaper":  htmlNospaceEscaper,
 	"html_template_rcdataescaper":   rcdataEscaper,
+	"html_template_srcsetescaper":   srcsetFilterAndEscaper,
 	"html_template_urlescaper":      urlEscaper,
 	"html_template_urlfilter":       urlFilter,
 	"html_template_urlnormalizer":   urlNormalizer,
@@ -91,6 +94,23 @@ type escaper struct {
 	actionNodeEdits   map[*parse.ActionNode][]string
 	templateNodeEdits map[*parse.TemplateNode]string
 	textNodeEdits     map[*parse.TextNode][]byte
+	// rangeContext holds the contexts at the {{break}} and {{continue}}
+	// actions of the innermost {{range}} being escaped.
+	rangeContext *rangeContext
+}
+
+// rangeContext holds the contexts in which the body of a {{range}} loop
+// may be left early.
+type rangeContext struct {
+	outer *rangeContext // enclosing loop
+	exits []rangeExit   // one per break or continue action
+}
+
+// rangeExit is the context at a {{break}} or {{continue}} action.
+type rangeExit struct {
+	c       context
+	line    int
+	keyword string
 }
 
 // newEscaper creates a blank escaper for the given set.
@@ -103,6 +123,7 @@ func newEscaper(t *Template) *escaper {
 		map[*parse.ActionNode][]string{},
 		map[*parse.TemplateNode]string{},
 		map[*parse.TextNode][]byte{},
+		nil,
 	}
 }
 
@@ -118,6 +139,10 @@ func (e *escaper) escape(c context, n parse.Node) context {
 	switch n := n.(type) {
 	case *parse.ActionNode:
 		return e.escapeAction(c, n)
+	case *parse.BreakNode:
+		return e.escapeRangeExit(c, n.Line, "break")
+	case *parse.ContinueNode:
+		return e.escapeRangeExit(c, n.Line, "continue")
 	case *parse.IfNode:
 		return e.escapeBranch(c, &n.BranchNode, "if")
 	case *parse.ListNode:
@@ -162,16 +187,25 @@ func (e *escaper) escapeAction(c context, n *parse.ActionNode) context {
 		case urlPartUnknown:
 			return context{
 				state: stateError,
-				err:   errorf(ErrAmbigContext, n.Line, "%s appears in an ambiguous URL context", n),
+				err:   errorf(ErrAmbigContext, n.Line, "%s appears in an ambiguous context within a URL", n),
 			}
 		default:
 			panic(c.urlPart.String())
 		}
+	case stateSrcset:
+		s = append(s, "html_template_srcsetescaper")
 	case stateJS:
 		s = append(s, "html_template_jsvalescaper")
 		// A slash after a value starts a div operator.
 		c.jsCtx = jsCtxDivOp
-	case stateJSDqStr, stateJSSqStr:
+	case stateJSDqStr:
+		if c.element == elementScriptJSON {
+			// JSON strings do not allow \x escapes.
+			s = append(s, "html_template_jsonstrescaper")
+		} else {
+			s = append(s, "html_template_jsstrescaper")
+		}
+	case stateJSSqStr:
 		s = append(s, "html_template_jsstrescaper")
 	case stateJSRegexp:
 		s = append(s, "html_template_jsregexpescaper")
@@ -182,6 +216,12 @@ func (e *escaper) escapeAction(c context, n *parse.ActionNode) context {
 	case stateRCDATA:
 		s = append(s, "html_template_rcdataescaper")
 	case stateAttr:
+		if c.attr == attrScriptType {
+			return context{
+				state: stateError,
+				err:   errorf(ErrAmbigContext, n.Line, "%s appears in the type attribute of a <script> element, so the content type of the element is ambiguous", n),
+			}
+		}
 		// Handled below in delim check.
 	case stateAttrName, stateTag:
 		c.state = stateAttrName
@@ -275,6 +315,9 @@ var redundantFuncs = map[string]map[string]bool{
 	"html_template_jsstrescaper": {
 		"html_template_attrescaper": true,
 	},
+	"html_template_jsonstrescaper": {
+		"html_template_attrescaper": true,
+	},
 	"html_template_urlescaper": {
 		"html_template_urlnormalizer": true,
 	},
@@ -359,6 +402,12 @@ func join(a, b context, line int, nodeName string) context {
 	if b.state == stateError {
 		return b
 	}
+	if a.state == stateDead {
+		return b
+	}
+	if b.state == stateDead {
+		return a
+	}
 	if a.eq(b) {
 		return a
 	}
@@ -398,13 +447,21 @@ func join(a, b context, line int, nodeName string) context {
 
 // escapeBranch escapes a branch template node: "if", "range" and "with".
 func (e *escaper) escapeBranch(c context, n *parse.BranchNode, nodeName string) context {
+	if nodeName == "range" {
+		e.rangeContext = &rangeContext{outer: e.rangeContext}
+	}
 	c0 := e.escapeList(c, n.List)
-	if nodeName == "range" && c0.state != stateError {
+	if nodeName == "range" {
+		c0 = e.joinRangeExits(c0)
+		if c0.state == stateError {
+			return c0
+		}
 		// The "true" branch of a "range" node can execute multiple times.
 		// We check that executing n.List once results in the same context
 		// as executing n.List twice.
+		e.rangeContext = &rangeContext{outer: e.rangeContext}
 		c1, _ := e.escapeListConditionally(c0, n.List, nil)
-		c0 = join(c0, c1, n.Line, nodeName)
+		c0 = join(c0, e.joinRangeExits(c1), n.Line, nodeName)
 		if c0.state == stateError {
 			// Make clear that this is a problem on loop re-entry
 			// since developers tend to overlook that branch when
@@ -418,6 +475,34 @@ func (e *escaper) escapeBranch(c context, n *parse.BranchNode, nodeName string)
 	return join(c0, c1, n.Line, nodeName)
 }
 
+// escapeRangeExit records the context c at a {{break}} or {{continue}}
+// action and returns the context of the unreachable code after it.
+func (e *escaper) escapeRangeExit(c context, line int, keyword string) context {
+	e.rangeContext.exits = append(e.rangeContext.exits, rangeExit{c, line, keyword})
+	return context{state: stateDead}
+}
+
+// joinRangeExits joins the context c at the end of a {{range}} body with
+// the contexts at the body's {{break}} and {{continue}} actions, and pops
+// the range context. Both kinds of action are treated as going back to the
+// start of the loop, which may then stop.
+func (e *escaper) joinRangeExits(c context) context {
+	rc := e.rangeContext
+	e.rangeContext = rc.outer
+	if c.state == stateError {
+		return c
+	}
+	for _, x := range rc.exits {
+		c = join(c, x.c, x.line, "range")
+		if c.state == stateError {
+			c.err.Line = x.line
+			c.err.Description = "at range loop " + x.keyword + ": " + c.err.Description
+			return c
+		}
+	}
+	return c
+}
+
 // escapeList escapes a list template node.
 func (e *escaper) escapeList(c context, n *parse.ListNode) context {
 	if n == nil {
@@ -425,6 +510,9 @@ func (e *escaper) escapeList(c context, n *parse.ListNode) context {
 	}
 	for _, m := range n.Nodes {
 		c = e.escape(c, m)
+		if c.state == stateDead {
+			break
+		}
 	}
 	return c
 }
@@ -435,6 +523,7 @@ func (e *escaper) escapeList(c context, n *parse.ListNode) context {
 // which is the same as whether e was updated.
 func (e *escaper) escapeListConditionally(c context, n *parse.ListNode, filter func(*escaper, context) bool) (context, bool) {
 	e1 := newEscaper(e.tmpl)
+	e1.rangeContext = e.rangeContext
 	// Make type inferences available to f.
 	for k, v := range e.output {
 		e1.output[k] = v
@@ -689,13 +778,19 @@ func contextAfterText(c context, s []byte) (context, int) {
 		}
 		return c, len(s)
 	}
+	element := c.element
+	if c.attr == attrScriptType {
+		// The type attribute decides whether the <script> body is
+		// JavaScript, JSON, or data that browsers do not execute.
+		element = scriptElementType(html.UnescapeString(string(s[:i])))
+	}
 	if c.delim != delimSpaceOrTagEnd {
 		// Consume any quote.
 		i++
 	}
 	// On exiting an attribute, we discard all state information
 	// except the state and element.
-	return context{state: stateTag, element: c.element}, i
+	return context{state: stateTag, element: element}, i
 }
 
 // editActionNode records a change to an action pipeline for later commit.
@@ -37,6 +37,7 @@ func TestEscape(t *testing.T) {
 		N       int
 		Z       *int
 		W       HTML
+		S       Srcset
 	}{
 		F: false,
 		T: true,
@@ -50,6 +51,7 @@ func TestEscape(t *testing.T) {
 		M: &goodMarshaler{},
 		Z: nil,
 		W: HTML(`&iexcl;<b class="foo">Hello</b>, <textarea>O'World</textarea>!`),
+		S: Srcset(`/a.png 1x, /b.png?x=1&y=2 2x`),
 	}
 	pdata := &data
 
@@ -88,6 +90,11 @@ func TestEscape(t *testing.T) {
 			"{{if $x := .H}}{{$x}}{{end}}",
 			"&lt;Hello&gt;",
 		},
+		{
+			"reassignment",
+			"{{$x := 1}}{{if .H}}{{$x = .C}}{{end}}{{$x}}",
+			"&lt;Cincinatti&gt;",
+		},
 		{
 			"withBody",
 			"{{with .H}}{{.}}{{end}}",
@@ -108,6 +115,16 @@ func TestEscape(t *testing.T) {
 			"{{range .E}}{{.}}{{else}}{{.H}}{{end}}",
 			"&lt;Hello&gt;",
 		},
+		{
+			"rangeBreak",
+			"{{range .A}}<a title='{{.}}'>{{break}}{{end}}",
+			"<a title='&lt;a&gt;'>",
+		},
+		{
+			"rangeContinue",
+			"{{range .A}}{{if true}}{{continue}}{{end}}{{.}}{{end}}",
+			"",
+		},
 		{
 			"nonStringValue",
 			"{{.T}}",
@@ -223,6 +240,46 @@ func TestEscape(t *testing.T) {
 			"<button onclick='alert(&quot;{{.H}}&quot;)'>",
 			`<button onclick='alert(&quot;\x3cHello\x3e&quot;)'>`,
 		},
+		{
+			"jsonStr",
+			`<script type="application/ld+json">{"name": "{{.H}}"}</script>`,
+			`<script type="application/ld+json">{"name": "\u003cHello\u003e"}</script>`,
+		},
+		{
+			"jsonValue",
+			`<script type="application/json; charset=utf-8">{"items": {{.A}}}</script>`,
+			`<script type="application/json; charset=utf-8">{"items": ["\u003ca\u003e","\u003cb\u003e"]}</script>`,
+		},
+		{
+			"jsTypedScript",
+			`<script type="text/javascript">alert("{{.H}}")</script>`,
+			`<script type="text/javascript">alert("\x3cHello\x3e")</script>`,
+		},
+		{
+			"nonJSScript",
+			`<script type="text/template"><p title="{{.H}}">{{.C}}</p></script>`,
+			`<script type="text/template"><p title="&lt;Hello&gt;">&lt;Cincinatti&gt;</p></script>`,
+		},
+		{
+			"templateElement",
+			`<template><a href="{{.H}}">{{.C}}</a><script>alert({{.G}})</script></template>`,
+			`<template><a href="%3cHello%3e">&lt;Cincinatti&gt;</a><script>alert("\u003cGoodbye\u003e")</script></template>`,
+		},
+		{
+			"srcset",
+			`<img srcset="{{"/a.png"}} 1x, {{"javascript:alert(1)"}} 2x">`,
+			`<img srcset="/a.png 1x, #ZgotmplZ 2x">`,
+		},
+		{
+			"srcsetCandidates",
+			`<img srcset={{"/a b.png 1x, /c.png 2x"}}>`,
+			`<img srcset=#ZgotmplZ,&#32;/c.png&#32;2x>`,
+		},
+		{
+			"srcsetTyped",
+			`<img srcset="{{.S}}">`,
+			`<img srcset="/a.png 1x, /b.png?x=1&amp;y=2 2x">`,
+		},
 		{
 			"badMarshaler",
 			`<button onclick='alert(1/{{.B}}in numbers)'>`,
@@ -860,6 +917,14 @@ func TestErrors(t *testing.T) {
 			"<a href='/foo?{{range .Items}}&{{.K}}={{.V}}{{end}}'>",
 			"",
 		},
+		{
+			"{{range .Items}}<a{{if .X}}>{{break}}{{end}}>{{end}}",
+			"",
+		},
+		{
+			"{{range .Items}}<a{{if .X}}{{end}}>{{continue}}<b{{end}}",
+			"",
+		},
 		// Error cases.
 		{
 			"{{if .Cond}}<a{{end}}",
@@ -895,6 +960,14 @@ func TestErrors(t *testing.T) {
 			"\n{{range .Items}} x='<a{{end}}",
 			"z:2: on range loop re-entry: {{range}} branches",
 		},
+		{
+			"{{range .Items}}<a{{if .X}}{{break}}{{end}}>{{end}}",
+			"z:1: at range loop break: {{range}} branches end in different contexts",
+		},
+		{
+			"{{range .Items}}<a{{if .X}}{{continue}}{{end}}>{{end}}",
+			"z:1: at range loop continue: {{range}} branches end in different contexts",
+		},
 		{
 			"<a b=1 c={{.H}}",
 			"z: ends in a non-text context: {stateAttr delimSpaceOrTagEnd",
@@ -905,7 +978,11 @@ func TestErrors(t *testing.T) {
 		},
 		{
 			`<a href="{{if .F}}/foo?a={{else}}/bar/{{end}}{{.H}}">`,
-			"z:1: {{.H}} appears in an ambiguous URL context",
+			"z:1: {{.H}} appears in an ambiguous context within a URL",
+		},
+		{
+			`<script type="{{.T}}">{{.X}}</script>`,
+			"z:1: {{.T}} appears in the type attribute of a <script> element, so the content type of the element is ambiguous",
 		},
 		{
 			`<a onclick="alert('Hello \`,
@@ -1361,6 +1438,42 @@ func TestEscapeText(t *testing.T) {
 			`<script type=text/javascript `,
 			context{state: stateTag, element: elementScript},
 		},
+		{
+			`<script type="`,
+			context{state: stateAttr, delim: delimDoubleQuote, attr: attrScriptType, element: elementScript},
+		},
+		{
+			`<script type="application/ld+json">`,
+			context{state: stateJS, element: elementScriptJSON},
+		},
+		{
+			`<script type='text/template' async>`,
+			context{state: stateText},
+		},
+		{
+			`<script TYPE=module>`,
+			context{state: stateText},
+		},
+		{
+			`<script type="application/ld+json">{"a": "`,
+			context{state: stateJSDqStr, element: elementScriptJSON},
+		},
+		{
+			`<script type="application/ld+json"></script>`,
+			context{state: stateText},
+		},
+		{
+			`<img srcset="`,
+			context{state: stateSrcset, delim: delimDoubleQuote},
+		},
+		{
+			`<img srcset="/a.png 1x, `,
+			context{state: stateSrcset, delim: delimDoubleQuote},
+		},
+		{
+			`<template><a href="`,
+			context{state: stateURL, delim: delimDoubleQuote},
+		},
 		{
 			`<script>foo`,
 			context{state: stateJS, jsCtx: jsCtxDivOp, element: elementScript},
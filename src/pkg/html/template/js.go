@@ -225,6 +225,17 @@ func jsStrEscaper(args ...interface{}) string {
 	return replace(s, jsStrReplacementTable)
 }
 
+// jsonStrEscaper produces a string that can be included between double
+// quotes in JSON embedded in an HTML5 <script> element. It differs from
+// jsStrEscaper in using only the escape sequences that JSON allows.
+func jsonStrEscaper(args ...interface{}) string {
+	s, t := stringify(args...)
+	if t == contentTypeJSStr {
+		return replace(s, jsonStrNormReplacementTable)
+	}
+	return replace(s, jsonStrReplacementTable)
+}
+
 // jsRegexpEscaper behaves like jsStrEscaper but escapes regular expression
 // specials so the result is treated literally when included in a regular
 // expression literal. /foo{{.X}}bar/ matches the string "foo" followed by
@@ -309,6 +320,32 @@ var jsStrNormReplacementTable = []string{
 	'>':  `\x3e`,
 }
 
+// jsonStrReplacementTable is like jsStrReplacementTable but uses \u
+// escapes, and escapes all control characters as JSON requires.
+var jsonStrReplacementTable = makeJSONStrReplacementTable(true)
+
+// jsonStrNormReplacementTable is like jsonStrReplacementTable but does not
+// overencode existing escapes since this table has no entry for `\`.
+var jsonStrNormReplacementTable = makeJSONStrReplacementTable(false)
+
+func makeJSONStrReplacementTable(escapeBackslash bool) []string {
+	t := make([]string, '\\'+1)
+	for c := 0; c < ' '; c++ {
+		t[c] = fmt.Sprintf(`\u%04x`, c)
+	}
+	t['\t'], t['\n'], t['\f'], t['\r'] = `\t`, `\n`, `\f`, `\r`
+	// Encode HTML specials so the output can be embedded in HTML
+	// attributes without further encoding.
+	for _, c := range `"&'+<>` {
+		t[c] = fmt.Sprintf(`\u%04x`, c)
+	}
+	t['/'] = `\/`
+	if escapeBackslash {
+		t['\\'] = `\\`
+	}
+	return t
+}
+
 var jsRegexpReplacementTable = []string{
 	0:    `\0`,
 	'\t': `\t`,
@@ -360,3 +397,39 @@ func isJSIdentPart(r rune) bool {
 	}
 	return false
 }
+
+// scriptElementType returns the element whose body rules apply to a
+// <script> element with the given type attribute. Types that browsers
+// execute as JavaScript yield elementScript and JSON types yield
+// elementScriptJSON. Any other type marks the body as data that is not
+// executed, such as a client-side template, which is escaped as HTML.
+// See http://www.w3.org/TR/html5/scripting-1.html#attr-script-type
+func scriptElementType(mimeType string) element {
+	// Discard parameters such as "; charset=utf-8".
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	switch strings.ToLower(strings.TrimSpace(mimeType)) {
+	case "",
+		"application/ecmascript",
+		"application/javascript",
+		"application/x-ecmascript",
+		"application/x-javascript",
+		"text/ecmascript",
+		"text/javascript",
+		"text/javascript1.0",
+		"text/javascript1.1",
+		"text/javascript1.2",
+		"text/javascript1.3",
+		"text/javascript1.4",
+		"text/javascript1.5",
+		"text/jscript",
+		"text/livescript",
+		"text/x-ecmascript",
+		"text/x-javascript":
+		return elementScript
+	case "application/json", "application/ld+json":
+		return elementScriptJSON
+	}
+	return elementNone
+}
@@ -295,6 +295,21 @@ func TestEscapersOnLower7AndSelectHighCodepoints(t *testing.T) {
 				"pqrstuvwxyz{|}~\x7f" +
 				"\u00A0\u0100\\u2028\\u2029\ufeff\U0001D11E",
 		},
+		{
+			"jsonStrEscaper",
+			jsonStrEscaper,
+			`\u0000\u0001\u0002\u0003\u0004\u0005\u0006\u0007` +
+				`\u0008\t\n\u000b\f\r\u000e\u000f` +
+				`\u0010\u0011\u0012\u0013\u0014\u0015\u0016\u0017` +
+				`\u0018\u0019\u001a\u001b\u001c\u001d\u001e\u001f` +
+				` !\u0022#$%\u0026\u0027()*\u002b,-.\/` +
+				`0123456789:;\u003c=\u003e?` +
+				`@ABCDEFGHIJKLMNO` +
+				`PQRSTUVWXYZ[\\]^_` +
+				"`abcdefghijklmno" +
+				"pqrstuvwxyz{|}~\x7f" +
+				"\u00A0\u0100\\u2028\\u2029\ufeff\U0001D11E",
+		},
 		{
 			"jsRegexpEscaper",
 			jsRegexpEscaper,
@@ -27,8 +27,9 @@ type Template struct {
 
 // nameSpace is the data structure shared by all templates in an association.
 type nameSpace struct {
-	mu  sync.Mutex
-	set map[string]*Template
+	mu      sync.Mutex
+	set     map[string]*Template
+	escaped bool // whether any template in the set has been escaped
 }
 
 // Templates returns a slice of the templates associated with t, including t
@@ -96,13 +97,18 @@ func (t *Template) lookupAndEscapeTemplate(name string) (tmpl *Template, err err
 // Parse parses a string into a template. Nested template definitions
 // will be associated with the top-level template t. Parse may be
 // called multiple times to parse definitions of templates to associate
-// with t. It is an error if a resulting template is non-empty (contains
-// content other than template definitions) and would replace a
-// non-empty template with the same name.  (In multiple calls to Parse
-// with the same receiver template, only one call can contain text
-// other than space, comments, and template definitions.)
+// with t. As in text/template, a non-empty template replaces an
+// earlier one with the same name, which is how the default body of a
+// {{block}} is overridden.
+//
+// Since escaping rewrites the templates of the set, Parse returns an
+// error once any template of the set has executed.
 func (t *Template) Parse(src string) (*Template, error) {
 	t.nameSpace.mu.Lock()
+	if t.nameSpace.escaped {
+		t.nameSpace.mu.Unlock()
+		return nil, fmt.Errorf("html/template: cannot Parse %q after a template of its set has executed", t.Name())
+	}
 	t.escaped = false
 	t.nameSpace.mu.Unlock()
 	ret, err := t.text.Parse(src)
@@ -260,6 +266,16 @@ func (t *Template) Delims(left, right string) *Template {
 	return t
 }
 
+// Option sets options for the template. Options are described by
+// strings of the form "key=value"; see the documentation for
+// text/template's Option method for the known options.
+// Option panics if an option string is unrecognized or otherwise invalid.
+// The return value is the template, so calls can be chained.
+func (t *Template) Option(opt ...string) *Template {
+	t.text.Option(opt...)
+	return t
+}
+
 // Lookup returns the template with the given name that is associated with t,
 // or nil if there is no such template.
 func (t *Template) Lookup(name string) *Template {
@@ -23,6 +23,7 @@ var transitionFunc = [...]func(context, []byte) (context, int){
 	stateRCDATA:      tSpecialTagEnd,
 	stateAttr:        tAttr,
 	stateURL:         tURL,
+	stateSrcset:      tAttr,
 	stateJS:          tJS,
 	stateJSDqStr:     tJSDelimited,
 	stateJSSqStr:     tJSDelimited,
@@ -75,11 +76,12 @@ func tText(c context, s []byte) (context, int) {
 }
 
 var elementContentType = [...]state{
-	elementNone:     stateText,
-	elementScript:   stateJS,
-	elementStyle:    stateCSS,
-	elementTextarea: stateRCDATA,
-	elementTitle:    stateRCDATA,
+	elementNone:       stateText,
+	elementScript:     stateJS,
+	elementScriptJSON: stateJS,
+	elementStyle:      stateCSS,
+	elementTextarea:   stateRCDATA,
+	elementTitle:      stateRCDATA,
 }
 
 // tTag is the context transition function for the tag state.
@@ -106,13 +108,21 @@ func tTag(c context, s []byte) (context, int) {
 			err:   errorf(ErrBadHTML, 0, "expected space, attr name, or end of tag, but got %q", s[i:]),
 		}, len(s)
 	}
-	switch attrType(string(s[i:j])) {
-	case contentTypeURL:
-		attr = attrURL
-	case contentTypeCSS:
-		attr = attrStyle
-	case contentTypeJS:
-		attr = attrScript
+	attrName := string(s[i:j])
+	if c.element == elementScript && strings.ToLower(attrName) == "type" {
+		// The type decides how the body of the <script> is parsed.
+		attr = attrScriptType
+	} else {
+		switch attrType(attrName) {
+		case contentTypeURL:
+			attr = attrURL
+		case contentTypeCSS:
+			attr = attrStyle
+		case contentTypeJS:
+			attr = attrScript
+		case contentTypeSrcset:
+			attr = attrSrcset
+		}
 	}
 	if j == len(s) {
 		state = stateAttrName
@@ -150,10 +160,12 @@ func tAfterName(c context, s []byte) (context, int) {
 }
 
 var attrStartStates = [...]state{
-	attrNone:   stateAttr,
-	attrScript: stateJS,
-	attrStyle:  stateCSS,
-	attrURL:    stateURL,
+	attrNone:       stateAttr,
+	attrScript:     stateJS,
+	attrStyle:      stateCSS,
+	attrURL:        stateURL,
+	attrSrcset:     stateSrcset,
+	attrScriptType: stateAttr,
 }
 
 // tBeforeValue is the context transition function for stateBeforeValue.
@@ -170,7 +182,12 @@ func tBeforeValue(c context, s []byte) (context, int) {
 	case '"':
 		delim, i = delimDoubleQuote, i+1
 	}
-	c.state, c.delim, c.attr = attrStartStates[c.attr], delim, attrNone
+	c.state, c.delim = attrStartStates[c.attr], delim
+	if c.attr != attrScriptType {
+		// Only a <script>'s type is needed once the value starts,
+		// so contextAfterText can pick the body's content type.
+		c.attr = attrNone
+	}
 	return c, i
 }
 
@@ -185,10 +202,11 @@ func tHTMLCmt(c context, s []byte) (context, int) {
 // specialTagEndMarkers maps element types to the character sequence that
 // case-insensitively signals the end of the special tag body.
 var specialTagEndMarkers = [...]string{
-	elementScript:   "</script",
-	elementStyle:    "</style",
-	elementTextarea: "</textarea",
-	elementTitle:    "</title",
+	elementScript:     "</script",
+	elementScriptJSON: "</script",
+	elementStyle:      "</style",
+	elementTextarea:   "</textarea",
+	elementTitle:      "</title",
 }
 
 // tSpecialTagEnd is the context transition function for raw text and RCDATA
@@ -17,13 +17,22 @@ func urlFilter(args ...interface{}) string {
 	if t == contentTypeURL {
 		return s
 	}
+	if !isSafeURL(s) {
+		return "#" + filterFailsafe
+	}
+	return s
+}
+
+// isSafeURL reports whether s is a relative URL or has a protocol that
+// cannot run code: http, https or mailto.
+func isSafeURL(s string) bool {
 	if i := strings.IndexRune(s, ':'); i >= 0 && strings.IndexRune(s[:i], '/') < 0 {
 		protocol := strings.ToLower(s[:i])
 		if protocol != "http" && protocol != "https" && protocol != "mailto" {
-			return "#" + filterFailsafe
+			return false
 		}
 	}
-	return s
+	return true
 }
 
 // urlEscaper produces an output that can be embedded in a URL query.
@@ -103,3 +112,70 @@ func urlProcessor(norm bool, args ...interface{}) string {
 	b.WriteString(s[written:])
 	return b.String()
 }
+
+// srcsetFilterAndEscaper filters and normalizes srcset values, which are
+// comma separated URLs each followed by optional metadata. A URL with an
+// unsafe protocol, or metadata that is not a single descriptor like "2x"
+// or "100w", replaces its whole candidate with a failsafe.
+func srcsetFilterAndEscaper(args ...interface{}) string {
+	s, t := stringify(args...)
+	switch t {
+	case contentTypeSrcset:
+		return s
+	case contentTypeURL:
+		// Normalizing encodes the spaces that would separate the URL
+		// from its metadata, and commas separate one candidate from
+		// the next.
+		return strings.Replace(urlProcessor(true, s), ",", "%2c", -1)
+	}
+	var b bytes.Buffer
+	written := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == ',' {
+			filterSrcsetElement(s[written:i], &b)
+			b.WriteByte(',')
+			written = i + 1
+		}
+	}
+	filterSrcsetElement(s[written:], &b)
+	return b.String()
+}
+
+// filterSrcsetElement writes one srcset image candidate to b.
+func filterSrcsetElement(s string, b *bytes.Buffer) {
+	start := 0
+	for start < len(s) && isHTMLSpace(s[start]) {
+		start++
+	}
+	end := start
+	for end < len(s) && !isHTMLSpace(s[end]) {
+		end++
+	}
+	if url := s[start:end]; isSafeURL(url) {
+		// Any metadata must be a single descriptor such as "2x" or "100w".
+		metadata := strings.Trim(s[end:], htmlSpaceChars)
+		metadataOK := true
+		for i := 0; i < len(metadata); i++ {
+			if c := metadata[i]; !asciiAlphaNum(c) && c != '.' {
+				metadataOK = false
+				break
+			}
+		}
+		if metadataOK {
+			b.WriteString(s[:start])
+			b.WriteString(urlProcessor(true, url))
+			b.WriteString(s[end:])
+			return
+		}
+	}
+	b.WriteString("#")
+	b.WriteString(filterFailsafe)
+}
+
+// htmlSpaceChars are the space characters as defined by HTML5.
+const htmlSpaceChars = " \t\n\f\r"
+
+// isHTMLSpace reports whether c is one of htmlSpaceChars.
+func isHTMLSpace(c byte) bool {
+	return strings.IndexRune(htmlSpaceChars, rune(c)) >= 0
+}
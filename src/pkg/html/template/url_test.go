@@ -87,6 +87,33 @@ func TestURLFilters(t *testing.T) {
 	}
 }
 
+func TestSrcsetFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{"one ok", "http://example.com/img.png", "http://example.com/img.png"},
+		{"one ok with metadata", " /img.png 200w", " /img.png 200w"},
+		{"fractional density", "/img.png 1.5x", "/img.png 1.5x"},
+		{"one bad", "javascript:alert(1) 200w", "#ZgotmplZ"},
+		{"two ok", "foo.png, bar.png", "foo.png, bar.png"},
+		{"left bad", "javascript:alert(1), /foo.png", "#ZgotmplZ, /foo.png"},
+		{"right bad", "/bogus#, javascript:alert(1)", "/bogus#,#ZgotmplZ"},
+		{"bad metadata", "/img.png 1x\" onerror=\"alert(1)", "#ZgotmplZ"},
+		{"normalized", "/img with spaces.png 1x", "#ZgotmplZ"},
+		{"escaped", "/img%20space.png?a=\u00e9 1x", "/img%20space.png?a=%c3%a9 1x"},
+		{"URL", URL("/img, with comma.png"), "/img%2c%20with%20comma.png"},
+		{"Srcset", Srcset("/a.png 1x, /b.png 2x"), "/a.png 1x, /b.png 2x"},
+	}
+
+	for _, test := range tests {
+		if got := srcsetFilterAndEscaper(test.input); got != test.want {
+			t.Errorf("%s: srcsetFilterAndEscaper(%q) want %q != %q", test.name, test.input, test.want, got)
+		}
+	}
+}
+
 func BenchmarkURLEscaper(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		urlEscaper("http://example.com:80/foo?q=bar%20&baz=x+y#frag")
@@ -26,6 +26,14 @@
 //
 //	go tool pprof http://localhost:6060/debug/pprof/
 //
+// Adding the parameter format=proto to a profile request serves the
+// profile in the compressed protocol buffer format instead, which
+// includes the symbol information needed to analyze it on another
+// machine:
+//
+//	http://localhost:6060/debug/pprof/heap?format=proto
+//	http://localhost:6060/debug/pprof/profile?format=proto
+//
 // For a study of the facility in action, visit
 //
 //	http://blog.golang.org/2011/06/profiling-go-programs.html
@@ -65,6 +73,8 @@ func Cmdline(w http.ResponseWriter, r *http.Request) {
 
 // Profile responds with the pprof-formatted cpu profile.
 // The package initialization registers it as /debug/pprof/profile.
+// The profile is in the protocol buffer format if the request
+// has the parameter format=proto.
 func Profile(w http.ResponseWriter, r *http.Request) {
 	sec, _ := strconv.ParseInt(r.FormValue("seconds"), 10, 64)
 	if sec == 0 {
@@ -74,7 +84,13 @@ func Profile(w http.ResponseWriter, r *http.Request) {
 	// Set Content Type assuming StartCPUProfile will work,
 	// because if it does it starts writing.
 	w.Header().Set("Content-Type", "application/octet-stream")
-	if err := pprof.StartCPUProfile(w); err != nil {
+	start := pprof.StartCPUProfile
+	if r.FormValue("format") == "proto" {
+		start = func(w io.Writer) error {
+			return pprof.StartCPUProfileProto(w, pprof.LabelSet{})
+		}
+	}
+	if err := start(w); err != nil {
 		// StartCPUProfile failed, so no writes yet.
 		// Can change header back to text content
 		// and send error code.
@@ -151,6 +167,19 @@ func (name handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Unknown profile: %s\n", name)
 		return
 	}
+	if r.FormValue("format") == "proto" {
+		// Buffer the profile so that an error can still be reported
+		// with a 500 status.
+		var buf bytes.Buffer
+		if err := p.WriteProto(&buf, pprof.LabelSet{}); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "Could not write %s profile: %s\n", name, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(buf.Bytes())
+		return
+	}
 	p.WriteTo(w, debug)
 	return
 }
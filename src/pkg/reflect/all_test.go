@@ -1755,3 +1755,327 @@ func TestAlias(t *testing.T) {
 		t.Errorf("aliasing: old=%q new=%q, want hello, world", oldvalue, newvalue)
 	}
 }
+
+func TestMakeFunc(t *testing.T) {
+	f := makeFuncDummy
+	fv := MakeFunc(TypeOf(f), func(in []Value) []Value { return in })
+	ValueOf(&f).Elem().Set(fv)
+
+	// Call g with small arguments so that there is
+	// something predictable (and different from the
+	// correct results) in those positions on the stack.
+	g := makeFuncDummy
+	g(1, 2, 3, two{4, 5}, 6, 7, 8)
+
+	// Call constructed function f.
+	i, j, k, l, m, n, o := f(10, 20, 30, two{40, 50}, 60, 70, 80)
+	if i != 10 || j != 20 || k != 30 || l != (two{40, 50}) || m != 60 || n != 70 || o != 80 {
+		t.Errorf("Call returned %d, %d, %d, %v, %d, %g, %d; want 10, 20, 30, [40, 50], 60, 70, 80", i, j, k, l, m, n, o)
+	}
+}
+
+func TestMakeFuncSwap(t *testing.T) {
+	swap := func(in []Value) []Value {
+		return []Value{in[1], in[0]}
+	}
+	var intSwap func(int, int) (int, int)
+	ValueOf(&intSwap).Elem().Set(MakeFunc(TypeOf(intSwap), swap))
+	if a, b := intSwap(1, 2); a != 2 || b != 1 {
+		t.Errorf("intSwap(1, 2) = %d, %d, want 2, 1", a, b)
+	}
+	var stringSwap func(string, string) (string, string)
+	ValueOf(&stringSwap).Elem().Set(MakeFunc(TypeOf(stringSwap), swap))
+	if a, b := stringSwap("x", "y"); a != "y" || b != "x" {
+		t.Errorf("stringSwap(x, y) = %s, %s, want y, x", a, b)
+	}
+
+	// Call through reflection as well.
+	out := ValueOf(intSwap).Call([]Value{ValueOf(3), ValueOf(4)})
+	if len(out) != 2 || out[0].Int() != 4 || out[1].Int() != 3 {
+		t.Errorf("Call of MakeFunc result = %v, want [4 3]", out)
+	}
+}
+
+type two [2]uintptr
+
+func makeFuncDummy(b byte, c int, d byte, e two, f byte, g float32, h byte) (i byte, j int, k byte, l two, m byte, n float32, o byte) {
+	return b, c, d, e, f, g, h
+}
+
+func TestSliceOf(t *testing.T) {
+	// check construction and use of type not in binary
+	type T int
+	st := SliceOf(TypeOf(T(1)))
+	v := MakeSlice(st, 10, 10)
+	for i := 0; i < v.Len(); i++ {
+		v.Index(i).Set(ValueOf(T(i)))
+	}
+	s := fmt.Sprint(v.Interface())
+	want := "[0 1 2 3 4 5 6 7 8 9]"
+	if s != want {
+		t.Errorf("constructed slice = %s, want %s", s, want)
+	}
+
+	// check that type already in binary is found
+	type T1 int
+	checkSameType(t, Zero(SliceOf(TypeOf(T1(1)))).Interface(), []T1{})
+	checkSameType(t, Zero(SliceOf(TypeOf(0))).Interface(), []int{})
+}
+
+func TestChanOf(t *testing.T) {
+	// check construction and use of type not in binary
+	type T string
+	ct := ChanOf(BothDir, TypeOf(T("")))
+	v := MakeChan(ct, 2)
+	v.Send(ValueOf(T("hello")))
+	v.Send(ValueOf(T("world")))
+
+	sv1, _ := v.Recv()
+	sv2, _ := v.Recv()
+	s1 := sv1.String()
+	s2 := sv2.String()
+	if s1 != "hello" || s2 != "world" {
+		t.Errorf("constructed chan: have %q, %q, want %q, %q", s1, s2, "hello", "world")
+	}
+
+	// check that type already in binary is found
+	type T1 int
+	checkSameType(t, Zero(ChanOf(BothDir, TypeOf(T1(1)))).Interface(), (chan T1)(nil))
+	checkSameType(t, Zero(ChanOf(RecvDir, TypeOf(0))).Interface(), (<-chan int)(nil))
+	checkSameType(t, Zero(ChanOf(BothDir, TypeOf((<-chan int)(nil)))).Interface(), (chan (<-chan int))(nil))
+}
+
+func TestMapOf(t *testing.T) {
+	// check construction and use of type not in binary
+	type K string
+	type V float64
+
+	v := MakeMap(MapOf(TypeOf(K("")), TypeOf(V(0))))
+	v.SetMapIndex(ValueOf(K("a")), ValueOf(V(1)))
+
+	s := fmt.Sprint(v.Interface())
+	want := "map[a:1]"
+	if s != want {
+		t.Errorf("constructed map = %s, want %s", s, want)
+	}
+
+	// check that type already in binary is found
+	checkSameType(t, Zero(MapOf(TypeOf(V(0)), TypeOf(K("")))).Interface(), map[V]K(nil))
+
+	// check that invalid key type panics
+	shouldPanic(func() { MapOf(TypeOf((func())(nil)), TypeOf(false)) })
+}
+
+func TestStructOf(t *testing.T) {
+	// check construction and use of type not in binary
+	type T string
+	fields := []StructField{
+		{Name: "S", Type: TypeOf(T(""))},
+		{Name: "X", Type: TypeOf(byte(0)), Tag: `json:"x"`},
+		{Name: "Y", Type: TypeOf(uint64(0))},
+		{Name: "P", Type: TypeOf(new(int))},
+	}
+	st := StructOf(fields)
+	want := `struct { S reflect_test.T; X uint8 "json:\"x\""; Y uint64; P *int }`
+	if s := st.String(); s != want {
+		t.Errorf("StructOf: String = %q, want %q", s, want)
+	}
+	var same struct {
+		S T
+		X byte
+		Y uint64
+		P *int
+	}
+	if st.Size() != unsafe.Sizeof(same) {
+		t.Errorf("StructOf: Size = %d, want %d", st.Size(), unsafe.Sizeof(same))
+	}
+	for i, off := range []uintptr{unsafe.Offsetof(same.S), unsafe.Offsetof(same.X), unsafe.Offsetof(same.Y), unsafe.Offsetof(same.P)} {
+		if f := st.Field(i); f.Offset != off || f.Name != fields[i].Name || f.Tag != fields[i].Tag {
+			t.Errorf("StructOf: Field(%d) = %+v, want offset %d", i, f, off)
+		}
+	}
+	v := New(st).Elem()
+	v.Field(0).Set(ValueOf(T("hello")))
+	v.Field(1).SetUint(7)
+	v.Field(3).Set(ValueOf(new(int)))
+	runtime.GC()
+	if s := fmt.Sprint(v.Interface()); s != fmt.Sprint(v.Interface()) || v.Field(0).String() != "hello" || v.Field(1).Uint() != 7 || v.Field(3).IsNil() {
+		t.Errorf("constructed struct = %s", s)
+	}
+
+	// check that the hash and equality functions
+	// compare fields, not memory
+	w := New(st).Elem()
+	w.Set(v)
+	w.Field(0).Set(ValueOf(T(string([]byte("hello")))))
+	if v.Interface() != w.Interface() {
+		t.Errorf("constructed struct values %v and %v are not equal", v.Interface(), w.Interface())
+	}
+	m := MakeMap(MapOf(st, TypeOf(0)))
+	m.SetMapIndex(v, ValueOf(1))
+	if x := m.MapIndex(w); !x.IsValid() || x.Int() != 1 {
+		t.Errorf("constructed struct as map key: MapIndex = %v, want 1", x)
+	}
+	w.Field(2).SetUint(1)
+	if v.Interface() == w.Interface() || m.MapIndex(w).IsValid() {
+		t.Errorf("constructed struct values %v and %v are equal", v.Interface(), w.Interface())
+	}
+
+	// check that a type that is not comparable cannot be a map key
+	shouldPanic(func() { MapOf(StructOf([]StructField{{Name: "B", Type: TypeOf([]byte(nil))}}), TypeOf(0)) })
+
+	// check that the same arguments give the same type
+	if StructOf(fields) != st {
+		t.Errorf("StructOf called twice returned different types")
+	}
+
+	// check that type already in binary is found
+	checkSameType(t, Zero(StructOf([]StructField{{Name: "A", Type: TypeOf(0)}, {Name: "B", Type: TypeOf("")}})).Interface(), struct {
+		A int
+		B string
+	}{})
+	checkSameType(t, Zero(StructOf([]StructField{{Name: "Embedded", Type: TypeOf(Embedded{}), Anonymous: true}})).Interface(), struct{ Embedded }{})
+
+	// check invalid fields
+	shouldPanic(func() { StructOf([]StructField{{Name: "a", Type: TypeOf(0)}}) })
+	shouldPanic(func() { StructOf([]StructField{{Name: "A", Type: TypeOf(0)}, {Name: "A", Type: TypeOf("")}}) })
+	shouldPanic(func() { StructOf([]StructField{{Name: "Buffer", Type: TypeOf(bytes.Buffer{}), Anonymous: true}}) })
+}
+
+type Embedded struct{ X, Y int }
+
+func checkSameType(t *testing.T, x, y interface{}) {
+	if TypeOf(x) != TypeOf(y) {
+		t.Errorf("did not find preexisting type for %s (vs %s)", TypeOf(x), TypeOf(y))
+	}
+}
+
+type MyBytes []byte
+type MyRunes []int32
+type MyString string
+type MyInt int
+
+var convertTests = []struct {
+	in  Value
+	out Value
+}{
+	{V(int8(-1)), V(uint8(255))},
+	{V(int(1) << 10), V(float64(1024))},
+	{V(uint16(65)), V("A")},
+	{V(float64(3.7)), V(int(3))},
+	{V(float32(1.5)), V(float64(1.5))},
+	{V(complex64(1 + 2i)), V(complex128(1 + 2i))},
+	{V("hello"), V([]byte("hello"))},
+	{V([]byte("world")), V("world")},
+	{V("héllo"), V([]rune("héllo"))},
+	{V([]rune{'o', 'k'}), V("ok")},
+	{V(MyString("x")), V("x")},
+	{V("x"), V(MyBytes("x"))},
+	{V(MyRunes{'y'}), V(MyString("y"))},
+	{V(MyInt(5)), V(int(5))},
+	{V(new(int)), V(new(MyInt))},
+	{V(int(7)), V(new(interface{})).Elem()},
+}
+
+func V(x interface{}) Value {
+	return ValueOf(x)
+}
+
+func TestConvert(t *testing.T) {
+	for _, tt := range convertTests {
+		t1 := tt.in.Type()
+		t2 := tt.out.Type()
+		if !t1.ConvertibleTo(t2) {
+			t.Errorf("(%s).ConvertibleTo(%s) = false, want true", t1, t2)
+			continue
+		}
+		v := tt.in.Convert(t2)
+		if v.Type() != t2 {
+			t.Errorf("convert %s to %s: have type %s", t1, t2, v.Type())
+			continue
+		}
+		switch t2.Kind() {
+		case Ptr:
+			if v.Pointer() != tt.in.Pointer() {
+				t.Errorf("convert %s to %s: pointer changed", t1, t2)
+			}
+		case Interface:
+			if v.Elem().Interface() != tt.in.Interface() {
+				t.Errorf("convert %s to %s: have %v", t1, t2, v.Elem())
+			}
+		default:
+			if a, b := fmt.Sprint(v.Interface()), fmt.Sprint(tt.out.Interface()); a != b {
+				t.Errorf("convert %s to %s: have %s, want %s", t1, t2, a, b)
+			}
+		}
+	}
+
+	if TypeOf("").ConvertibleTo(TypeOf(0)) {
+		t.Errorf("string is convertible to int")
+	}
+	shouldPanic(func() { ValueOf(1.5).Convert(TypeOf("")) })
+}
+
+func TestSelect(t *testing.T) {
+	// Nothing ready: default is chosen.
+	c := make(chan int, 1)
+	cases := []SelectCase{
+		{Dir: SelectRecv, Chan: ValueOf(c)},
+		{Dir: SelectDefault},
+	}
+	if i, _, _ := Select(cases); i != 1 {
+		t.Errorf("Select with nothing ready chose %d, want default case 1", i)
+	}
+
+	// Receive is ready.
+	c <- 42
+	i, recv, ok := Select(cases)
+	if i != 0 || !ok || recv.Int() != 42 {
+		t.Errorf("Select = %d, %v, %v, want 0, 42, true", i, recv, ok)
+	}
+
+	// Send is ready; nil channel cases never proceed.
+	var nilc chan int
+	cases = []SelectCase{
+		{Dir: SelectRecv, Chan: ValueOf(nilc)},
+		{Dir: SelectSend, Chan: ValueOf(c), Send: ValueOf(7)},
+		{Dir: SelectRecv},
+	}
+	if i, _, _ := Select(cases); i != 1 {
+		t.Errorf("Select chose %d, want send case 1", i)
+	}
+	if x := <-c; x != 7 {
+		t.Errorf("Select sent %d, want 7", x)
+	}
+
+	// Receive from a closed channel of large elements.
+	type big [4]string
+	bc := make(chan big, 1)
+	bc <- big{"a", "b", "c", "d"}
+	close(bc)
+	cases = []SelectCase{{Dir: SelectRecv, Chan: ValueOf(bc)}}
+	i, recv, ok = Select(cases)
+	if i != 0 || !ok || recv.Interface().(big)[3] != "d" {
+		t.Errorf("Select = %d, %v, %v, want 0, [a b c d], true", i, recv, ok)
+	}
+	i, recv, ok = Select(cases)
+	if i != 0 || ok || recv.Interface().(big) != (big{}) {
+		t.Errorf("Select on closed chan = %d, %v, %v, want 0, zero value, false", i, recv, ok)
+	}
+
+	// Blocking receive woken by another goroutine.
+	uc := make(chan string)
+	go func() { uc <- "hello" }()
+	cases = []SelectCase{
+		{Dir: SelectRecv, Chan: ValueOf(nilc)},
+		{Dir: SelectRecv, Chan: ValueOf(uc)},
+	}
+	i, recv, ok = Select(cases)
+	if i != 1 || !ok || recv.String() != "hello" {
+		t.Errorf("Select = %d, %v, %v, want 1, hello, true", i, recv, ok)
+	}
+
+	shouldPanic(func() { Select([]SelectCase{{Dir: SelectDefault}, {Dir: SelectDefault}}) })
+	shouldPanic(func() { Select([]SelectCase{{Dir: SelectSend, Chan: ValueOf(c)}}) })
+	shouldPanic(func() { Select([]SelectCase{{Dir: SelectRecv, Chan: ValueOf((chan<- int)(c))}}) })
+}
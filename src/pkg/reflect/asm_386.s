@@ -0,0 +1,17 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// makeFuncStub is the code half of the function returned by MakeFunc.
+// See the comment on the declaration of makeFuncStub in makefunc.go
+// for more details.
+// The run time closure built by MakeFunc leaves the *makeFuncImpl
+// at 0(FP) and the caller's PC at 4(FP), so the caller's
+// argument frame begins at 8(FP).
+TEXT ·makeFuncStub(SB),7,$8
+	MOVL	impl+0(FP), AX
+	MOVL	AX, 0(SP)
+	LEAL	argframe+8(FP), CX
+	MOVL	CX, 4(SP)
+	CALL	·callReflect(SB)
+	RET
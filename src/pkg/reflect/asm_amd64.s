@@ -0,0 +1,17 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// makeFuncStub is the code half of the function returned by MakeFunc.
+// See the comment on the declaration of makeFuncStub in makefunc.go
+// for more details.
+// The run time closure built by MakeFunc leaves the *makeFuncImpl
+// at 0(FP) and the caller's PC at 8(FP), so the caller's
+// argument frame begins at 16(FP).
+TEXT ·makeFuncStub(SB),7,$16
+	MOVQ	impl+0(FP), AX
+	MOVQ	AX, 0(SP)
+	LEAQ	argframe+16(FP), CX
+	MOVQ	CX, 8(SP)
+	CALL	·callReflect(SB)
+	RET
@@ -18,6 +18,8 @@ package reflect
 import (
 	"strconv"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -92,6 +94,9 @@ type Type interface {
 	// AssignableTo returns true if a value of the type is assignable to type u.
 	AssignableTo(u Type) bool
 
+	// ConvertibleTo returns true if a value of the type is convertible to type u.
+	ConvertibleTo(u Type) bool
+
 	// Methods applicable only to some types, depending on Kind.
 	// The methods allowed for each kind are:
 	//
@@ -371,7 +376,10 @@ type Method struct {
 
 // High bit says whether type has
 // embedded pointers,to help garbage collector.
-const kindMask = 0x7f
+const (
+	kindMask       = 0x7f
+	kindNoPointers = 0x80
+)
 
 func (k Kind) String() string {
 	if int(k) < len(kindNames) {
@@ -1045,6 +1053,14 @@ func (t *commonType) AssignableTo(u Type) bool {
 	return directlyAssignable(uu, t) || implements(uu, t)
 }
 
+func (t *commonType) ConvertibleTo(u Type) bool {
+	if u == nil {
+		panic("reflect: nil type passed to Type.ConvertibleTo")
+	}
+	uu := u.(*commonType)
+	return convertOp(uu, t) != nil
+}
+
 // implements returns true if the type V implements the interface type T.
 func implements(T, V *commonType) bool {
 	if T.Kind() != Interface {
@@ -1116,22 +1132,41 @@ func directlyAssignable(T, V *commonType) bool {
 		return false
 	}
 
-	// x's type T and V have identical underlying types.
-	// Since at least one is unnamed, only the composite types
-	// need to be considered.
-	switch T.Kind() {
+	// Special case:
+	// x is a bidirectional channel value, T is a channel type,
+	// and x's type V and T have identical element types.
+	if T.Kind() == Chan && V.ChanDir() == BothDir && T.Elem() == V.Elem() {
+		return true
+	}
+
+	// x's type T and V must have identical underlying types.
+	return haveIdenticalUnderlyingType(T, V)
+}
+
+// haveIdenticalUnderlyingType returns true if the types T and V
+// have the same underlying type.
+func haveIdenticalUnderlyingType(T, V *commonType) bool {
+	if T == V {
+		return true
+	}
+
+	kind := T.Kind()
+	if kind != V.Kind() {
+		return false
+	}
+
+	// Non-composite types of equal kind have same underlying type
+	// (the predefined instance of the type).
+	if Bool <= kind && kind <= Complex128 || kind == String || kind == UnsafePointer {
+		return true
+	}
+
+	// Composite types.
+	switch kind {
 	case Array:
 		return T.Elem() == V.Elem() && T.Len() == V.Len()
 
 	case Chan:
-		// Special case:
-		// x is a bidirectional channel value, T is a channel type,
-		// and x's type V and T have identical element types.
-		if V.ChanDir() == BothDir && T.Elem() == V.Elem() {
-			return true
-		}
-
-		// Otherwise continue test for identical underlying type.
 		return V.ChanDir() == T.ChanDir() && T.Elem() == V.Elem()
 
 	case Func:
@@ -1177,8 +1212,8 @@ func directlyAssignable(T, V *commonType) bool {
 		for i := range t.fields {
 			tf := &t.fields[i]
 			vf := &v.fields[i]
-			if tf.name != vf.name || tf.pkgPath != vf.pkgPath ||
-				tf.typ != vf.typ || tf.tag != vf.tag || tf.offset != vf.offset {
+			if !eqStringPtr(tf.name, vf.name) || !eqStringPtr(tf.pkgPath, vf.pkgPath) ||
+				tf.typ != vf.typ || !eqStringPtr(tf.tag, vf.tag) || tf.offset != vf.offset {
 				return false
 			}
 		}
@@ -1187,3 +1222,607 @@ func directlyAssignable(T, V *commonType) bool {
 
 	return false
 }
+
+// eqStringPtr reports whether the optional strings *x and *y are equal.
+// The compilers share the strings of identical names and tags, but the
+// ones StructOf makes are its own.
+func eqStringPtr(x, y *string) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x == y || *x == *y
+}
+
+// isComparable returns true if values of type t can be compared with ==,
+// which is what the runtime requires of map keys.
+func isComparable(t *commonType) bool {
+	switch t.Kind() {
+	case Func, Map, Slice:
+		return false
+	case Array:
+		return isComparable(t.Elem().common())
+	case Struct:
+		st := (*structType)(unsafe.Pointer(t))
+		for i := range st.fields {
+			if !isComparable(toCommonType(st.fields[i].typ)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// typelinks is implemented in package runtime.
+// It returns a slice of all the 'typelink' information in the binary,
+// which is to say a slice of known types, sorted by string.
+// Note that strings are not unique identifiers for types:
+// there can be more than one with a given string.
+// Only types we might want to look up are included:
+// channels, maps, slices, arrays and structs.
+func typelinks() []*runtimeType
+
+// typesByString returns the subslice of typelinks() whose elements have
+// the given string representation.
+// It may be empty (no known types with that string) or may have
+// multiple elements (multiple types with that string).
+func typesByString(s string) []*runtimeType {
+	typ := typelinks()
+
+	// We are looking for the first index i where the string becomes >= s.
+	// This is a copy of sort.Search, with f(h) replaced by (*typ[h].string >= s).
+	i, j := 0, len(typ)
+	for i < j {
+		h := i + (j-i)/2 // avoid overflow when computing h
+		// i ≤ h < j
+		if !(*toCommonType(typ[h]).string >= s) {
+			i = h + 1 // preserves f(i-1) == false
+		} else {
+			j = h // preserves f(j) == true
+		}
+	}
+	// i == j, f(i-1) == false, and f(j) (= f(i)) == true  =>  answer is i.
+
+	// Having found the first, linear scan forward to find the last.
+	// We could do a second binary search, but the caller is going
+	// to do a linear scan anyway.
+	j = i
+	for j < len(typ) && *toCommonType(typ[j]).string == s {
+		j++
+	}
+
+	// This slice will be empty if the string is not found.
+	return typ[i:j]
+}
+
+// The lookupCache caches ChanOf, MapOf, and SliceOf lookups.
+var lookupCache struct {
+	sync.RWMutex
+	m map[cacheKey]*commonType
+}
+
+// A cacheKey is the key for use in the lookupCache.
+// Four values describe any of the types we are looking for:
+// type kind, one or two subtypes, and an extra integer.
+type cacheKey struct {
+	kind  Kind
+	t1    *commonType
+	t2    *commonType
+	extra uintptr
+}
+
+// cacheGet looks for a type under the key k in the lookupCache.
+// If it finds one, it returns that type.
+// If not, it returns nil with the cache locked.
+// The caller is expected to use cachePut to unlock the cache.
+func cacheGet(k cacheKey) Type {
+	lookupCache.RLock()
+	t := lookupCache.m[k]
+	lookupCache.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	lookupCache.Lock()
+	t = lookupCache.m[k]
+	if t != nil {
+		lookupCache.Unlock()
+		return t
+	}
+
+	if lookupCache.m == nil {
+		lookupCache.m = make(map[cacheKey]*commonType)
+	}
+
+	return nil
+}
+
+// cachePut stores the given type in the cache, unlocks the cache,
+// and returns the type. It is expected that the cache is locked
+// because cacheGet returned nil.
+func cachePut(k cacheKey, t *commonType) Type {
+	lookupCache.m[k] = t
+	lookupCache.Unlock()
+	return t
+}
+
+// fnv1 incorporates the list of bytes into the hash x using the FNV-1 hash function.
+func fnv1(x uint32, list ...byte) uint32 {
+	for _, b := range list {
+		x = x*16777619 ^ uint32(b)
+	}
+	return x
+}
+
+// prototype returns the type structure of the interface value i,
+// for use as a template when synthesizing new types of the same kind.
+func prototype(i interface{}) *commonType {
+	return toCommonType((*emptyInterface)(unsafe.Pointer(&i)).typ)
+}
+
+// ChanOf returns the channel type with the given direction and element type.
+// For example, if t represents int, ChanOf(RecvDir, t) represents <-chan int.
+//
+// The gc runtime imposes a limit of 64 kB on channel element types.
+// If t's size is equal to or exceeds this limit, ChanOf panics.
+func ChanOf(dir ChanDir, t Type) Type {
+	typ := t.common()
+
+	// Look in cache.
+	ckey := cacheKey{Chan, typ, nil, uintptr(dir)}
+	if ch := cacheGet(ckey); ch != nil {
+		return ch
+	}
+
+	// This restriction is imposed by the gc compiler and the runtime.
+	if typ.size >= 1<<16 {
+		lookupCache.Unlock()
+		panic("reflect.ChanOf: element size too large")
+	}
+
+	// Look in known types.
+	elem := *typ.string
+	if dir == BothDir && typ.Kind() == Chan && typ.ChanDir() == RecvDir {
+		// chan (<-chan T), not chan <-chan T
+		elem = "(" + elem + ")"
+	}
+	var s string
+	switch dir {
+	default:
+		lookupCache.Unlock()
+		panic("reflect.ChanOf: invalid dir")
+	case SendDir:
+		s = "chan<- " + elem
+	case RecvDir:
+		s = "<-chan " + elem
+	case BothDir:
+		s = "chan " + elem
+	}
+	for _, tt := range typesByString(s) {
+		ch := (*chanType)(unsafe.Pointer(toCommonType(tt)))
+		if toCommonType(ch.elem) == typ && ch.dir == uintptr(dir) {
+			return cachePut(ckey, &ch.commonType)
+		}
+	}
+
+	// Make a channel type, using chan unsafe.Pointer as a prototype.
+	var rt struct {
+		i runtimeType
+		chanType
+	}
+	rt.i = &rt.commonType
+	ch := &rt.chanType
+	*ch = *(*chanType)(unsafe.Pointer(prototype((chan unsafe.Pointer)(nil))))
+	ch.string = &s
+	ch.hash = fnv1(typ.hash, 'c', byte(dir))
+	ch.elem = typ.runtimeType()
+	ch.dir = uintptr(dir)
+	ch.uncommonType = nil
+	ch.ptrToThis = nil
+
+	return cachePut(ckey, &ch.commonType)
+}
+
+// MapOf returns the map type with the given key and element types.
+// For example, if k represents int and e represents string,
+// MapOf(k, e) represents map[int]string.
+//
+// If the key type is not a valid map key type (that is, if it does
+// not implement Go's == operator), MapOf panics.
+func MapOf(key, elem Type) Type {
+	ktyp := key.common()
+	etyp := elem.common()
+
+	if !isComparable(ktyp) {
+		panic("reflect.MapOf: invalid key type " + ktyp.String())
+	}
+
+	// Look in cache.
+	ckey := cacheKey{Map, ktyp, etyp, 0}
+	if mt := cacheGet(ckey); mt != nil {
+		return mt
+	}
+
+	// Look in known types.
+	s := "map[" + *ktyp.string + "]" + *etyp.string
+	for _, tt := range typesByString(s) {
+		mt := (*mapType)(unsafe.Pointer(toCommonType(tt)))
+		if toCommonType(mt.key) == ktyp && toCommonType(mt.elem) == etyp {
+			return cachePut(ckey, &mt.commonType)
+		}
+	}
+
+	// Make a map type, using map[unsafe.Pointer]unsafe.Pointer as a prototype.
+	var rt struct {
+		i runtimeType
+		mapType
+	}
+	rt.i = &rt.commonType
+	mt := &rt.mapType
+	*mt = *(*mapType)(unsafe.Pointer(prototype((map[unsafe.Pointer]unsafe.Pointer)(nil))))
+	mt.string = &s
+	mt.hash = fnv1(etyp.hash, 'm', byte(ktyp.hash>>24), byte(ktyp.hash>>16), byte(ktyp.hash>>8), byte(ktyp.hash))
+	mt.key = ktyp.runtimeType()
+	mt.elem = etyp.runtimeType()
+	mt.uncommonType = nil
+	mt.ptrToThis = nil
+
+	return cachePut(ckey, &mt.commonType)
+}
+
+// SliceOf returns the slice type with element type t.
+// For example, if t represents int, SliceOf(t) represents []int.
+func SliceOf(t Type) Type {
+	typ := t.common()
+
+	// Look in cache.
+	ckey := cacheKey{Slice, typ, nil, 0}
+	if slice := cacheGet(ckey); slice != nil {
+		return slice
+	}
+
+	// Look in known types.
+	s := "[]" + *typ.string
+	for _, tt := range typesByString(s) {
+		slice := (*sliceType)(unsafe.Pointer(toCommonType(tt)))
+		if toCommonType(slice.elem) == typ {
+			return cachePut(ckey, &slice.commonType)
+		}
+	}
+
+	// Make a slice type, using []unsafe.Pointer as a prototype.
+	var rt struct {
+		i runtimeType
+		sliceType
+	}
+	rt.i = &rt.commonType
+	slice := &rt.sliceType
+	*slice = *(*sliceType)(unsafe.Pointer(prototype(([]unsafe.Pointer)(nil))))
+	slice.string = &s
+	slice.hash = fnv1(typ.hash, '[')
+	slice.elem = typ.runtimeType()
+	slice.uncommonType = nil
+	slice.ptrToThis = nil
+
+	return cachePut(ckey, &slice.commonType)
+}
+
+// StructOf returns the struct type containing fields.
+// The Offset and Index fields are ignored and computed as they
+// would be by the compiler.  For example, if fields describe
+// a field A of type int and a field B of type string with tag
+// `json:"b"`, StructOf(fields) represents
+// struct { A int; B string "json:\"b\"" }.
+//
+// StructOf does not support unexported fields, and it does not
+// generate the methods that embedded fields would promote:
+// it panics if a field is unexported, or if an embedded field's
+// type has methods.
+func StructOf(fields []StructField) Type {
+	var (
+		hash   = fnv1(0, []byte("struct {")...)
+		size   uintptr
+		align  uint8 = 1
+		noPtrs       = true
+		fs           = make([]structField, len(fields))
+		str          = make([]byte, 0, 64)
+		seen         = make(map[string]bool)
+	)
+	str = append(str, "struct {"...)
+	for i, field := range fields {
+		f := structFieldOf(field)
+		name := field.Name
+		if seen[name] {
+			panic("reflect.StructOf: duplicate field " + name)
+		}
+		seen[name] = true
+
+		ft := toCommonType(f.typ)
+		if ft.kind&kindNoPointers == 0 {
+			noPtrs = false
+		}
+		if ft.align > align {
+			align = ft.align
+		}
+		a := uintptr(ft.fieldAlign)
+		size = (size + a - 1) &^ (a - 1)
+		f.offset = size
+		size += ft.size
+		fs[i] = f
+
+		if i > 0 {
+			str = append(str, ';')
+		}
+		str = append(str, ' ')
+		if f.name != nil {
+			str = append(str, name...)
+			str = append(str, ' ')
+		}
+		str = append(str, *ft.string...)
+		hash = fnv1(hash, []byte(name)...)
+		hash = fnv1(hash, byte(ft.hash>>24), byte(ft.hash>>16), byte(ft.hash>>8), byte(ft.hash))
+		if f.tag != nil {
+			str = append(str, ' ')
+			str = appendQuotedTag(str, *f.tag)
+			hash = fnv1(hash, []byte(*f.tag)...)
+		}
+	}
+	if len(fields) > 0 {
+		str = append(str, ' ')
+	}
+	str = append(str, '}')
+	size = (size + uintptr(align) - 1) &^ (uintptr(align) - 1)
+	s := string(str)
+
+	// Look in cache.
+	structLookupCache.RLock()
+	for _, st := range structLookupCache.m[hash] {
+		if haveIdenticalStructFields(st.fields, fs) {
+			structLookupCache.RUnlock()
+			return &st.commonType
+		}
+	}
+	structLookupCache.RUnlock()
+
+	structLookupCache.Lock()
+	defer structLookupCache.Unlock()
+	if structLookupCache.m == nil {
+		structLookupCache.m = make(map[uint32][]*structType)
+	}
+	for _, st := range structLookupCache.m[hash] {
+		if haveIdenticalStructFields(st.fields, fs) {
+			return &st.commonType
+		}
+	}
+
+	// Look in known types.
+	for _, tt := range typesByString(s) {
+		st := (*structType)(unsafe.Pointer(toCommonType(tt)))
+		if haveIdenticalStructFields(st.fields, fs) {
+			structLookupCache.m[hash] = append(structLookupCache.m[hash], st)
+			return &st.commonType
+		}
+	}
+
+	// Make a struct type, using struct{} as a prototype.
+	var rt struct {
+		i runtimeType
+		structType
+	}
+	rt.i = &rt.commonType
+	st := &rt.structType
+	*st = *(*structType)(unsafe.Pointer(prototype(struct{}{})))
+	st.size = size
+	st.hash = hash
+	st.align = align
+	st.fieldAlign = align
+	st.kind = uint8(Struct)
+	if noPtrs {
+		st.kind |= kindNoPointers
+	}
+	st.string = &s
+	st.uncommonType = nil
+	st.ptrToThis = nil
+	st.fields = fs
+	st.alg = structAlg(st)
+
+	structLookupCache.m[hash] = append(structLookupCache.m[hash], st)
+	return &st.commonType
+}
+
+// structLookupCache caches StructOf lookups.
+// StructOf does not share the common lookupCache
+// because a struct type is not described by a few values.
+var structLookupCache struct {
+	sync.RWMutex
+	m map[uint32][]*structType // keyed by hash of the StructOf arguments
+}
+
+// structFieldOf converts the StructField argument of StructOf
+// into the runtime form, without the offset.
+func structFieldOf(field StructField) structField {
+	if field.Type == nil {
+		panic("reflect.StructOf: field " + field.Name + " has no type")
+	}
+	if field.Name == "" {
+		panic("reflect.StructOf: field has no name")
+	}
+	if field.PkgPath != "" || !isExported(field.Name) {
+		panic("reflect.StructOf: field " + field.Name + " is unexported")
+	}
+	ft := field.Type.common()
+	f := structField{typ: ft.runtimeType()}
+	if field.Anonymous {
+		// An embedded field is named by its type, or by
+		// the type its pointer type points to.
+		et := ft
+		if ft.Kind() == Ptr {
+			et = ft.Elem().common()
+		}
+		if et.Name() != field.Name {
+			panic("reflect.StructOf: embedded field " + field.Name + " is not named by its type " + et.String())
+		}
+		if ft.NumMethod() > 0 || ft.Kind() != Ptr && ft.Kind() != Interface && ft.ptrTo().NumMethod() > 0 {
+			panic("reflect.StructOf: embedded field " + field.Name + " has methods")
+		}
+	} else {
+		name := field.Name
+		f.name = &name
+	}
+	if field.Tag != "" {
+		tag := string(field.Tag)
+		f.tag = &tag
+	}
+	return f
+}
+
+// haveIdenticalStructFields reports whether the fields x and y
+// describe identical struct types.
+func haveIdenticalStructFields(x, y []structField) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		xf, yf := &x[i], &y[i]
+		if !eqStringPtr(xf.name, yf.name) || !eqStringPtr(xf.pkgPath, yf.pkgPath) ||
+			xf.typ != yf.typ || !eqStringPtr(xf.tag, yf.tag) || xf.offset != yf.offset {
+			return false
+		}
+	}
+	return true
+}
+
+func isExported(name string) bool {
+	c := name[0]
+	if c < utf8.RuneSelf {
+		return 'A' <= c && c <= 'Z'
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// appendQuotedTag appends the tag, quoted the way the compilers
+// quote tags in the string form of a struct type.
+func appendQuotedTag(b []byte, tag string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(tag); {
+		r, n := utf8.DecodeRuneInString(tag[i:])
+		switch {
+		case r == utf8.RuneError && n == 1, r < ' ' && r != '\t' && r != '\n':
+			b = append(b, '\\', 'x', hex[tag[i]>>4], hex[tag[i]&0xf])
+		case r == '\t':
+			b = append(b, '\\', 't')
+		case r == '\n':
+			b = append(b, '\\', 'n')
+		case r == '"' || r == '\\':
+			b = append(b, '\\', byte(r))
+		default:
+			b = append(b, tag[i:i+n]...)
+		}
+		i += n
+	}
+	return append(b, '"')
+}
+
+// Algorithm tables of the kinds StructOf needs that are
+// the same for any size, from types known to use them.
+var (
+	memAlg  = prototype([3]byte{}).alg   // hash and compare memory
+	noeqAlg = prototype([3]func(){}).alg // not comparable
+)
+
+// A typeAlg is the algorithm table of a type (../runtime/runtime.h:/Alg).
+// Its function pointers are unsafe.Pointers, not uintptrs,
+// so that the garbage collector keeps run time closures alive.
+type typeAlg struct {
+	hash  unsafe.Pointer // func(h *uintptr, size uintptr, p unsafe.Pointer)
+	equal unsafe.Pointer // func(eq *bool, size uintptr, x, y unsafe.Pointer)
+	print unsafe.Pointer // func(size uintptr, p unsafe.Pointer)
+	copy  unsafe.Pointer // func(size uintptr, dst, src unsafe.Pointer)
+}
+
+// structAlg returns the algorithm table for the struct type t,
+// chosen the way the compilers choose one.
+func structAlg(t *structType) *uintptr {
+	comparable, mem := true, true
+	for i := range t.fields {
+		ft := toCommonType(t.fields[i].typ)
+		if !isComparable(ft) {
+			comparable = false
+		}
+		if !isMemory(ft) {
+			mem = false
+		}
+	}
+	switch {
+	case !comparable:
+		return noeqAlg
+	case len(t.fields) == 1:
+		// A one-field struct is the same as its field alone.
+		return toCommonType(t.fields[0].typ).alg
+	case mem:
+		return memAlg
+	}
+
+	// Compare and hash field by field, with run time closures
+	// that pass t as an extra leading argument, the way
+	// MakeFunc calls callReflect.
+	mt := (*typeAlg)(unsafe.Pointer(memAlg))
+	hash, equal := structHash, structEqual
+	alg := &typeAlg{
+		hash:  makeclosure(int32(ptrSize), *(*unsafe.Pointer)(unsafe.Pointer(&hash)), unsafe.Pointer(t)),
+		equal: makeclosure(int32(ptrSize), *(*unsafe.Pointer)(unsafe.Pointer(&equal)), unsafe.Pointer(t)),
+		print: mt.print,
+		copy:  mt.copy,
+	}
+	return (*uintptr)(unsafe.Pointer(alg))
+}
+
+// isMemory reports whether values of type t compare equal
+// exactly when their memory does.
+func isMemory(t *commonType) bool {
+	switch t.Kind() {
+	case Bool, Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64,
+		Uintptr, Chan, Ptr, UnsafePointer:
+		return true
+	case Array:
+		return t.Len() == 0 || isMemory(t.Elem().common())
+	case Struct:
+		st := (*structType)(unsafe.Pointer(t))
+		for i := range st.fields {
+			if !isMemory(toCommonType(st.fields[i].typ)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// structHash is the hash function of a struct type made by StructOf
+// whose fields are not all memory.  The run time closure in the
+// algorithm table passes t; callerpc is the closure's return address.
+func structHash(t *structType, callerpc uintptr, h *uintptr, size uintptr, p unsafe.Pointer) {
+	for i := range t.fields {
+		f := &t.fields[i]
+		ft := toCommonType(f.typ)
+		var hash func(*uintptr, uintptr, unsafe.Pointer)
+		*(*unsafe.Pointer)(unsafe.Pointer(&hash)) = (*typeAlg)(unsafe.Pointer(ft.alg)).hash
+		hash(h, ft.size, unsafe.Pointer(uintptr(p)+f.offset))
+	}
+}
+
+// structEqual is the equality function of a struct type made by
+// StructOf whose fields are not all memory.  See structHash.
+func structEqual(t *structType, callerpc uintptr, eq *bool, size uintptr, x, y unsafe.Pointer) {
+	for i := range t.fields {
+		f := &t.fields[i]
+		ft := toCommonType(f.typ)
+		var equal func(*bool, uintptr, unsafe.Pointer, unsafe.Pointer)
+		*(*unsafe.Pointer)(unsafe.Pointer(&equal)) = (*typeAlg)(unsafe.Pointer(ft.alg)).equal
+		equal(eq, ft.size, unsafe.Pointer(uintptr(x)+f.offset), unsafe.Pointer(uintptr(y)+f.offset))
+		if !*eq {
+			return
+		}
+	}
+}
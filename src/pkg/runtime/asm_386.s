@@ -264,6 +264,14 @@ TEXT reflect·call(SB), 7, $0
 	RET
 
 
+// Called from reflection library to build the function
+// value returned by MakeFunc.  The argument frame has the
+// same layout as runtime·closure's with a single captured word.
+//
+// func makeclosure(siz int32, fn unsafe.Pointer, arg unsafe.Pointer) (code unsafe.Pointer)
+TEXT reflect·makeclosure(SB), 7, $0
+	JMP	runtime·closure(SB)
+
 // Return point when leaving stack.
 TEXT runtime·lessstack(SB), 7, $0
 	// Save return value in m->cret
@@ -492,6 +500,15 @@ TEXT runtime·cgocallback(SB),7,$12
 	// Done!
 	RET
 
+// void setmg(M*, G*); set m and g. for use by needm.
+TEXT runtime·setmg(SB), 7, $0
+	MOVL	mm+0(FP), AX
+	get_tls(CX)
+	MOVL	AX, m(CX)
+	MOVL	gg+4(FP), BX
+	MOVL	BX, g(CX)
+	RET
+
 // check that SP is in range [g->stackbase, g->stackguard)
 TEXT runtime·stackcheck(SB), 7, $0
 	get_tls(CX)
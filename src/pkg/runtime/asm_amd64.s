@@ -6,8 +6,8 @@
 
 TEXT _rt0_amd64(SB),7,$-8
 	// copy arguments forward on an even stack
-	MOVQ	0(DI), AX		// argc
-	LEAQ	8(DI), BX		// argv
+	MOVQ	DI, AX		// argc
+	MOVQ	SI, BX		// argv
 	SUBQ	$(4*8+7), SP		// 2args 2auto
 	ANDQ	$~15, SP
 	MOVQ	AX, 16(SP)
@@ -26,6 +26,7 @@ TEXT _rt0_amd64(SB),7,$-8
 	JZ	needtls
 	// g0 already in DI
 	MOVQ	DI, CX	// Win64 uses CX for first parameter
+	MOVQ	$setmg_gcc<>(SB), SI
 	CALL	AX
 	CMPL	runtime·iswindows(SB), $0
 	JEQ ok
@@ -237,6 +238,14 @@ TEXT reflect·call(SB), 7, $0
 	MOVQ	$0, 0x1103	// crash if newstack returns
 	RET
 
+// Called from reflection library to build the function
+// value returned by MakeFunc.  The argument frame has the
+// same layout as runtime·closure's with a single captured word.
+//
+// func makeclosure(siz int32, fn unsafe.Pointer, arg unsafe.Pointer) (code unsafe.Pointer)
+TEXT reflect·makeclosure(SB), 7, $0
+	JMP	runtime·closure(SB)
+
 // Return point when leaving stack.
 TEXT runtime·lessstack(SB), 7, $0
 	// Save return value in m->cret
@@ -468,18 +477,36 @@ TEXT runtime·cgocallback(SB),7,$24
 	MOVQ	frame+8(FP), BX
 	MOVQ	framesize+16(FP), DX
 
-	// Save current m->g0->sched.sp on stack and then set it to SP.
+	// If m is nil, we have been called on a thread that Go did not
+	// create.  In a library that is expected: needm lends us an m
+	// for the duration of the call.  Otherwise we're going to crash
+	// as soon as we try to use m; instead, try to print a nice error.
 	get_tls(CX)
 	MOVQ	m(CX), BP
-	
-	// If m is nil, it is almost certainly because we have been called
-	// on a thread that Go did not create.  We're going to crash as
-	// soon as we try to use m; instead, try to print a nice error and exit.
+	MOVQ	BP, R8	// 0 if we need an m, saved on the stack below
 	CMPQ	BP, $0
-	JNE 2(PC)
+	JNE	havem
+	CMPL	runtime·islibrary(SB), $0
+	JNE	needm
 	CALL	runtime·badcallback(SB)
+	JMP	havem
+needm:
+	// Call through a register, to keep the linker's
+	// stack check from following needm's nosplit calls.
+	MOVQ	$runtime·needm(SB), AX
+	CALL	AX
+	MOVQ	$0, R8
+	get_tls(CX)
+	MOVQ	m(CX), BP
+	MOVQ	fn+0(FP), AX
+	MOVQ	frame+8(FP), BX
+	MOVQ	framesize+16(FP), DX
 
+havem:
+	// Save the old m and current m->g0->sched.sp on stack
+	// and then set the latter to SP.
 	MOVQ	m_g0(BP), SI
+	PUSHQ	R8
 	PUSHQ	(g_sched+gobuf_sp)(SI)
 	MOVQ	SP, (g_sched+gobuf_sp)(SI)
 
@@ -535,9 +562,35 @@ TEXT runtime·cgocallback(SB),7,$24
 	MOVQ	(g_sched+gobuf_sp)(SI), SP
 	POPQ	(g_sched+gobuf_sp)(SI)
 
+	// If we borrowed an m from needm, give it back.
+	POPQ	R8
+	CMPQ	R8, $0
+	JNE	3(PC)
+	MOVQ	$runtime·dropm(SB), AX
+	CALL	AX
+
 	// Done!
 	RET
 
+// void setmg(M*, G*); set m and g. for use by needm.
+TEXT runtime·setmg(SB), 7, $0
+	MOVQ	mm+0(FP), AX
+	get_tls(CX)
+	MOVQ	AX, m(CX)
+	MOVQ	gg+8(FP), BX
+	MOVQ	BX, g(CX)
+	RET
+
+// void setmg_gcc(M*, G*); set m and g called from gcc.
+// The gcc-compiled thread start code calls this instead of
+// storing to the thread-local storage itself, so that only the
+// Go linker needs to know where g and m live.
+TEXT setmg_gcc<>(SB),7,$0
+	get_tls(AX)
+	MOVQ	DI, m(AX)
+	MOVQ	SI, g(AX)
+	RET
+
 // check that SP is in range [g->stackbase, g->stackguard)
 TEXT runtime·stackcheck(SB), 7, $0
 	get_tls(CX)
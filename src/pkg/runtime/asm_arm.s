@@ -209,6 +209,14 @@ TEXT reflect·call(SB), 7, $-4
 	MOVW	(g_sched+gobuf_sp)(g), SP
 	B	runtime·newstack(SB)
 
+// Called from reflection library to build the function
+// value returned by MakeFunc.  The argument frame has the
+// same layout as runtime·closure's with a single captured word.
+//
+// func makeclosure(siz int32, fn unsafe.Pointer, arg unsafe.Pointer) (code unsafe.Pointer)
+TEXT reflect·makeclosure(SB), 7, $-4
+	B	runtime·closure(SB)
+
 // Return point when leaving stack.
 // using frame size $-4 means do not save LR on stack.
 TEXT runtime·lessstack(SB), 7, $-4
@@ -239,6 +247,12 @@ TEXT	runtime·asmcgocall(SB),7,$0
 TEXT	runtime·cgocallback(SB),7,$0
 	B	runtime·cgounimpl(SB)
 
+// void setmg(M*, G*); set m and g. for use by needm.
+TEXT runtime·setmg(SB), 7, $-4
+	MOVW	mm+0(FP), m
+	MOVW	gg+4(FP), g
+	RET
+
 TEXT runtime·memclr(SB),7,$20
 	MOVW	0(FP), R0
 	MOVW	$0, R1		// c = 0
@@ -71,3 +71,20 @@ _cgo_panic(void *a, int32 n)
 {
 	runtime·cgocallback((void(*)(void))_cgo_panic_internal, a, n);
 }
+
+// The runtime calls into the gcc-compiled half of this package
+// through these function pointers.  They are defined here, in the
+// 6c world, so that the gcc-compiled objects only define functions
+// and can be handed unchanged to an external linker.
+
+extern void xinitcgo(G*);
+void (*initcgo)(G*) = xinitcgo;
+
+extern void xlibcgo_thread_start(void*);
+void (*libcgo_thread_start)(void*) = xlibcgo_thread_start;
+
+extern void x_cgo_malloc(void*);
+void (*_cgo_malloc)(void*) = x_cgo_malloc;
+
+extern void x_cgo_free(void*);
+void (*_cgo_free)(void*) = x_cgo_free;
@@ -115,8 +115,6 @@ xinitcgo(G *g)
 	inittls();
 }
 
-void (*initcgo)(G*) = xinitcgo;
-
 void
 libcgo_sys_thread_start(ThreadStart *ts)
 {
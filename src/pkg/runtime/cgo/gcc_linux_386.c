@@ -21,8 +21,6 @@ xinitcgo(G *g)
 	pthread_attr_destroy(&attr);
 }
 
-void (*initcgo)(G*) = xinitcgo;
-
 void
 libcgo_sys_thread_start(ThreadStart *ts)
 {
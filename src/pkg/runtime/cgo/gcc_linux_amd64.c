@@ -8,9 +8,10 @@
 #include "libcgo.h"
 
 static void* threadentry(void*);
+static void (*setmg_gcc)(void*, void*);
 
 void
-xinitcgo(G* g)
+xinitcgo(G* g, void (*setmg)(void*, void*))
 {
 	pthread_attr_t attr;
 	size_t size;
@@ -19,10 +20,9 @@ xinitcgo(G* g)
 	pthread_attr_getstacksize(&attr, &size);
 	g->stackguard = (uintptr)&attr - size + 4096;
 	pthread_attr_destroy(&attr);
+	setmg_gcc = setmg;
 }
 
-void (*initcgo)(G*) = xinitcgo;
-
 void
 libcgo_sys_thread_start(ThreadStart *ts)
 {
@@ -65,15 +65,33 @@ threadentry(void *v)
 	ts.g->stackguard = (uintptr)&ts - ts.g->stackguard + 4096;
 
 	/*
-	 * Set specific keys.  On Linux/ELF, the thread local storage
-	 * is just before %fs:0.  Our dynamic 6.out's reserve 16 bytes
-	 * for the two words g and m at %fs:-16 and %fs:-8.
+	 * Set specific keys.  Only the Go linker knows where in the
+	 * thread-local storage g and m live, so let Go code store them.
 	 */
-	asm volatile (
-		"movq %0, %%fs:-16\n"	// MOVL g, -16(FS)
-		"movq %1, %%fs:-8\n"	// MOVL m, -8(FS)
-		:: "r"(ts.g), "r"(ts.m)
-	);
+	setmg_gcc((void*)ts.m, (void*)ts.g);
+
 	crosscall_amd64(ts.fn);
 	return nil;
 }
+
+void
+xlibcgo_thread_create(void (*fn)(void))
+{
+	sigset_t ign, oset;
+	pthread_t p;
+	int err;
+
+	// The runtime enables the signals on the new thread
+	// once it is ready for them.
+	sigfillset(&ign);
+	sigprocmask(SIG_SETMASK, &ign, &oset);
+
+	err = pthread_create(&p, nil, (void*(*)(void*))fn, nil);
+
+	sigprocmask(SIG_SETMASK, &oset, nil);
+
+	if (err != 0) {
+		fprintf(stderr, "runtime/cgo: pthread_create failed: %s\n", strerror(err));
+		abort();
+	}
+}
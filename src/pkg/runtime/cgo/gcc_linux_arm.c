@@ -10,8 +10,6 @@ xinitcgo(G *g)
 	// unimplemented
 }
 
-void (*initcgo)(G*) = xinitcgo;
-
 void
 libcgo_sys_thread_start(ThreadStart *ts)
 {
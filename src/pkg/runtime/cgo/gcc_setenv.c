@@ -9,10 +9,8 @@
 #include <stdlib.h>
 
 /* Stub for calling setenv */
-static void
+void
 xlibcgo_setenv(char **arg)
 {
 	setenv(arg[0], arg[1], 1);
 }
-
-void (*libcgo_setenv)(char**) = xlibcgo_setenv;
@@ -38,17 +38,24 @@ struct ThreadStart
 };
 
 /*
- * Called by 5c/6c/8c world.
+ * Called by 5c/6c/8c world, through the libcgo_thread_start
+ * pointer defined in callbacks.c.
  * Makes a local copy of the ThreadStart and
  * calls libcgo_sys_thread_start(ts).
  */
-extern void (*libcgo_thread_start)(ThreadStart *ts);
+void xlibcgo_thread_start(ThreadStart *ts);
 
 /*
  * Creates the new operating system thread (OS, arch dependent).
  */
 void libcgo_sys_thread_start(ThreadStart *ts);
 
+/*
+ * Creates a thread running fn, on which the runtime of a
+ * library initializes itself (linux/amd64).
+ */
+void xlibcgo_thread_create(void (*fn)(void));
+
 /*
  * Call fn in the 6c world.
  */
@@ -0,0 +1,8 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// See the comment at the end of callbacks.c.
+
+extern void xlibcgo_thread_create(void(*)(void));
+void (*libcgo_thread_create)(void(*)(void)) = xlibcgo_thread_create;
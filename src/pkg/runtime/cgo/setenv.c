@@ -0,0 +1,10 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build darwin freebsd linux netbsd openbsd
+
+// See the comment at the end of callbacks.c.
+
+extern void xlibcgo_setenv(char**);
+void (*libcgo_setenv)(char**) = xlibcgo_setenv;
@@ -198,6 +198,13 @@ runtime·cgocallbackg(void (*fn)(void), void *arg, uintptr argsize)
 
 	runtime·exitsyscall();	// coming out of cgo call
 
+	// needm took the last extra m; make another for the next
+	// thread that calls in (see needm in proc.c).
+	if(m->needextram) {
+		m->needextram = 0;
+		runtime·newextram();
+	}
+
 	// Add entry to defer stack in case of panic.
 	d.fn = (byte*)unwindm;
 	d.siz = 0;
@@ -4,22 +4,19 @@
 
 #include "runtime.h"
 
-#pragma textflag 7
-// func closure(siz int32,
-//	fn func(arg0, arg1, arg2 *ptr, callerpc uintptr, xxx) yyy,
-//	arg0, arg1, arg2 *ptr) (func(xxx) yyy)
-void
-runtime·closure(int32 siz, byte *fn, byte *arg0)
+// mkclosure lays out the code that calls fn with the siz bytes
+// of arguments at arg0 prepended to its own.  It uses buf if it
+// is not nil, or else allocates the code on the heap.
+static byte*
+mkclosure(byte *buf, int32 bufsiz, int32 siz, byte *fn, byte *arg0)
 {
-	byte *p, *q, **ret;
+	byte *p, *q, *code;
 	int32 i, n;
 	int64 pcrel;
 
 	if(siz < 0 || siz%8 != 0)
 		runtime·throw("bad closure size");
 
-	ret = (byte**)((byte*)&arg0 + siz);
-
 	if(siz > 100) {
 		// TODO(rsc): implement stack growth preamble?
 		runtime·throw("closure too big");
@@ -40,12 +37,17 @@ runtime·closure(int32 siz, byte *fn, byte *arg0)
 	if(n%8)
 		n += 8 - n%8;
 
-	p = runtime·mal(n);
-	*ret = p;
+	if(buf != nil) {
+		if(n > bufsiz)
+			runtime·throw("closure buffer too small");
+		p = buf;
+	} else
+		p = runtime·mal(n);
+	code = p;
 	q = p + n - siz;
 
 	if(siz > 0) {
-		runtime·memmove(q, (byte*)&arg0, siz);
+		runtime·memmove(q, arg0, siz);
 
 		// SUBQ $siz, SP
 		*p++ = 0x48;
@@ -118,6 +120,33 @@ runtime·closure(int32 siz, byte *fn, byte *arg0)
 
 	if(p > q)
 		runtime·throw("bad math in sys.closure");
+	return code;
 }
 
+#pragma textflag 7
+// func closure(siz int32,
+//	fn func(arg0, arg1, arg2 *ptr, callerpc uintptr, xxx) yyy,
+//	arg0, arg1, arg2 *ptr) (func(xxx) yyy)
+void
+runtime·closure(int32 siz, byte *fn, byte *arg0)
+{
+	byte *code;
+
+	code = mkclosure(nil, 0, siz, fn, (byte*)&arg0);
+	*(byte**)((byte*)&arg0 + siz) = code;
+}
 
+#pragma textflag 7
+// func closurebuf(buf *byte, bufsiz int32, siz int32,
+//	fn func(arg0, arg1, arg2 *ptr, callerpc uintptr, xxx) yyy,
+//	arg0, arg1, arg2 *ptr) (func(xxx) yyy)
+// is closure for a func literal that does not escape:
+// buf is a bufsiz-byte array in the caller's frame.
+void
+runtime·closurebuf(byte *buf, int32 bufsiz, int32 siz, byte *fn, byte *arg0)
+{
+	byte *code;
+
+	code = mkclosure(buf, bufsiz, siz, fn, (byte*)&arg0);
+	*(byte**)((byte*)&arg0 + siz) = code;
+}
@@ -45,19 +45,19 @@ vars:		WORD	arg0
 
 extern void runtime·cacheflush(byte* start, byte* end);
 
-#pragma textflag 7
-void
-runtime·closure(int32 siz, byte *fn, byte *arg0)
+// mkclosure lays out the code that calls fn with the siz bytes
+// of arguments at arg0 prepended to its own.  It uses buf if it
+// is not nil, or else allocates the code on the heap.
+static byte*
+mkclosure(byte *buf, int32 bufsiz, int32 siz, byte *fn, byte *arg0)
 {
-	byte *p, *q, **ret;
+	byte *p, *q, *code;
 	uint32 *pc;
 	int32 n;
 
 	if(siz < 0 || siz%4 != 0)
 		runtime·throw("bad closure size");
 
-	ret = (byte**)((byte*)&arg0 + siz);
-
 	if(siz > 100) {
 		// TODO(kaib): implement stack growth preamble?
 		runtime·throw("closure too big");
@@ -73,8 +73,13 @@ runtime·closure(int32 siz, byte *fn, byte *arg0)
 	// store args aligned after code, so gc can find them.
 	n += siz;
 
-	p = runtime·mal(n);
-	*ret = p;
+	if(buf != nil) {
+		if(n > bufsiz)
+			runtime·throw("closure buffer too small");
+		p = buf;
+	} else
+		p = runtime·mal(n);
+	code = p;
 	q = p + n - siz;
 
 	pc = (uint32*)p;
@@ -83,7 +88,7 @@ runtime·closure(int32 siz, byte *fn, byte *arg0)
 	*pc++ = 0xe52de000 | (siz + 4);
 
 	if(siz > 0) {
-		runtime·memmove(q, (byte*)&arg0, siz);
+		runtime·memmove(q, arg0, siz);
 
 		//	MOVW	$vars(PC), R0
 		*pc = 0xe28f0000 | (int32)(q - (byte*)pc - 8);
@@ -124,6 +129,34 @@ runtime·closure(int32 siz, byte *fn, byte *arg0)
 	if(p > q)
 		runtime·throw("bad math in sys.closure");
 
-	runtime·cacheflush(*ret, q+siz);
+	runtime·cacheflush(code, q+siz);
+	return code;
+}
+
+#pragma textflag 7
+// func closure(siz int32,
+//	fn func(arg0, arg1, arg2 *ptr, callerpc uintptr, xxx) yyy,
+//	arg0, arg1, arg2 *ptr) (func(xxx) yyy)
+void
+runtime·closure(int32 siz, byte *fn, byte *arg0)
+{
+	byte *code;
+
+	code = mkclosure(nil, 0, siz, fn, (byte*)&arg0);
+	*(byte**)((byte*)&arg0 + siz) = code;
 }
 
+#pragma textflag 7
+// func closurebuf(buf *byte, bufsiz int32, siz int32,
+//	fn func(arg0, arg1, arg2 *ptr, callerpc uintptr, xxx) yyy,
+//	arg0, arg1, arg2 *ptr) (func(xxx) yyy)
+// is closure for a func literal that does not escape:
+// buf is a bufsiz-byte array in the caller's frame.
+void
+runtime·closurebuf(byte *buf, int32 bufsiz, int32 siz, byte *fn, byte *arg0)
+{
+	byte *code;
+
+	code = mkclosure(buf, bufsiz, siz, fn, (byte*)&arg0);
+	*(byte**)((byte*)&arg0 + siz) = code;
+}
@@ -47,6 +47,13 @@
 // handoff using atomic operations.  The operations are needed, however,
 // in order to let the log closer set the high bit to indicate "EOF" safely
 // in the situation when normally the goroutine "owns" handoff.
+//
+// Each stack trace is also keyed by the label set id of the goroutine
+// that was running (see runtime/pprof's Labels).  The id is opaque to
+// the runtime.  By default the log is written in the plain pprof format
+// and the id is dropped; after runtime/pprof asks for labeled output,
+// every record after the header carries the id between the depth and
+// the stack, and runtime/pprof strips it again as it writes the profile.
 
 #include "runtime.h"
 #include "arch_GOARCH.h"
@@ -67,6 +74,7 @@ typedef struct Entry Entry;
 struct Entry {
 	uintptr count;
 	uintptr depth;
+	uintptr labels;
 	uintptr stack[MaxStack];
 };
 
@@ -76,6 +84,7 @@ struct Bucket {
 
 struct Profile {
 	bool on;		// profiling is on
+	bool labeled;		// log records carry label set ids
 	Note wait;		// goroutine waits here
 	uintptr count;		// tick count
 	uintptr evicts;		// eviction count
@@ -103,9 +112,10 @@ struct Profile {
 
 static Lock lk;
 static Profile *prof;
+static bool wantlabels;
 
-static void tick(uintptr*, int32);
-static void add(Profile*, uintptr*, int32);
+static void tick(uintptr*, int32, uintptr);
+static void add(Profile*, uintptr*, int32, uintptr);
 static bool evict(Profile*, Entry*);
 static bool flushlog(Profile*);
 
@@ -150,6 +160,7 @@ runtime·SetCPUProfileRate(int32 hz)
 		}
 
 		prof->on = true;
+		prof->labeled = wantlabels;
 		p = prof->log[0];
 		// pprof binary header format.
 		// http://code.google.com/p/google-perftools/source/browse/trunk/src/profiledata.cc#117
@@ -188,9 +199,9 @@ runtime·SetCPUProfileRate(int32 hz)
 }
 
 static void
-tick(uintptr *pc, int32 n)
+tick(uintptr *pc, int32 n, uintptr labels)
 {
-	add(prof, pc, n);
+	add(prof, pc, n, labels);
 }
 
 // add adds the stack trace to the profile.
@@ -199,7 +210,7 @@ tick(uintptr *pc, int32 n)
 // held at the time of the signal, nor can it use substantial amounts
 // of stack.  It is allowed to call evict.
 static void
-add(Profile *p, uintptr *pc, int32 n)
+add(Profile *p, uintptr *pc, int32 n, uintptr labels)
 {
 	int32 i, j;
 	uintptr h, x;
@@ -210,7 +221,7 @@ add(Profile *p, uintptr *pc, int32 n)
 		n = MaxStack;
 	
 	// Compute hash.
-	h = 0;
+	h = labels*31;
 	for(i=0; i<n; i++) {
 		h = h<<8 | (h>>(8*(sizeof(h)-1)));
 		x = pc[i];
@@ -222,7 +233,7 @@ add(Profile *p, uintptr *pc, int32 n)
 	b = &p->hash[h%HashSize];
 	for(i=0; i<Assoc; i++) {
 		e = &b->entry[i];
-		if(e->depth != n)	
+		if(e->depth != n || e->labels != labels)
 			continue;
 		for(j=0; j<n; j++)
 			if(e->stack[j] != pc[j])
@@ -249,6 +260,7 @@ add(Profile *p, uintptr *pc, int32 n)
 	
 	// Reuse the newly evicted entry.
 	e->depth = n;
+	e->labels = labels;
 	e->count = 1;
 	for(i=0; i<n; i++)
 		e->stack[i] = pc[i];
@@ -267,7 +279,7 @@ evict(Profile *p, Entry *e)
 	uintptr *log, *q;
 	
 	d = e->depth;
-	nslot = d+2;
+	nslot = d+2+p->labeled;
 	log = p->log[p->toggle];
 	if(p->nlog+nslot > nelem(p->log[0])) {
 		if(!flushlog(p))
@@ -278,6 +290,8 @@ evict(Profile *p, Entry *e)
 	q = log+p->nlog;
 	*q++ = e->count;
 	*q++ = d;
+	if(p->labeled)
+		*q++ = e->labels;
 	for(i=0; i<d; i++)
 		*q++ = e->stack[i];
 	p->nlog = q - log;
@@ -304,6 +318,8 @@ flushlog(Profile *p)
 	if(p->lost > 0) {
 		*q++ = p->lost;
 		*q++ = 1;
+		if(p->labeled)
+			*q++ = 0;
 		*q++ = (uintptr)LostProfileData;
 	}
 	p->nlog = q - log;
@@ -424,3 +440,13 @@ runtime·CPUProfile(Slice ret)
 	ret = getprofile(prof);
 	FLUSH(&ret);
 }
+
+// setCPUProfileLabels asks for label set ids in the log records
+// of profiles started from now on.  It is called by runtime/pprof.
+void
+runtime∕pprof·runtime_setCPUProfileLabels(bool on)
+{
+	runtime·lock(&lk);
+	wantlabels = on;
+	runtime·unlock(&lk);
+}
@@ -0,0 +1,69 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "strings"
+
+// buildInfo is set by the linker when the go command builds a program.
+// It holds one line per item, with tab-separated fields:
+//
+//	go	go version used for the build
+//	path	import path of the main package
+//	main	repository holding the main package	revision
+//	dep	other repository providing packages	revision
+//
+// Tools reading a binary find it by the symbol runtime/debug.buildInfo.
+var buildInfo string
+
+// BuildInfo describes how a program was built by the go command.
+type BuildInfo struct {
+	GoVersion string       // version of Go that built the program
+	Path      string       // import path of the main package
+	Main      Repository   // repository holding the main package, if known
+	Deps      []Repository // other repositories the program was built from
+}
+
+// A Repository identifies a version control repository
+// and the revision of it that was used.
+type Repository struct {
+	Path     string // import path of the repository root
+	Revision string // revision checked out, "" if unknown
+}
+
+// ReadBuildInfo returns the build information embedded in the
+// running program.  It returns ok == false for programs built
+// without it, such as test binaries.
+func ReadBuildInfo() (info *BuildInfo, ok bool) {
+	if buildInfo == "" {
+		return nil, false
+	}
+	return parseBuildInfo(buildInfo), true
+}
+
+func parseBuildInfo(data string) *BuildInfo {
+	info := new(BuildInfo)
+	for _, line := range strings.Split(data, "\n") {
+		f := strings.Split(line, "\t")
+		if len(f) < 2 {
+			continue
+		}
+		var repo Repository
+		repo.Path = f[1]
+		if len(f) > 2 {
+			repo.Revision = f[2]
+		}
+		switch f[0] {
+		case "go":
+			info.GoVersion = f[1]
+		case "path":
+			info.Path = f[1]
+		case "main":
+			info.Main = repo
+		case "dep":
+			info.Deps = append(info.Deps, repo)
+		}
+	}
+	return info
+}
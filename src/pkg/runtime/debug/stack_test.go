@@ -5,6 +5,7 @@
 package debug
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -60,3 +61,19 @@ func check(t *testing.T, line, has string) {
 		t.Errorf("expected %q in %q", has, line)
 	}
 }
+
+func TestParseBuildInfo(t *testing.T) {
+	info := parseBuildInfo("go\tgo1.0.3\npath\tproj/cmd/app\nmain\tproj\tabc123\ndep\texample.com/dep\t4\ndep\tcode.google.com/p/x\n")
+	want := &BuildInfo{
+		GoVersion: "go1.0.3",
+		Path:      "proj/cmd/app",
+		Main:      Repository{"proj", "abc123"},
+		Deps:      []Repository{{"example.com/dep", "4"}, {"code.google.com/p/x", ""}},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("parseBuildInfo = %+v, want %+v", info, want)
+	}
+	if _, ok := ReadBuildInfo(); ok {
+		t.Errorf("ReadBuildInfo in test binary: ok = true, want false")
+	}
+}
@@ -702,3 +702,15 @@ reflect·unsafe_NewArray(Eface typ, uint32 n, void *ret)
 		ret = runtime·mal(size);
 	FLUSH(&ret);
 }
+
+extern Type *typelink[], *etypelink[];
+
+// func typelinks() []*runtimeType
+void
+reflect·typelinks(Slice ret)
+{
+	ret.array = (byte*)typelink;
+	ret.len = etypelink - typelink;
+	ret.cap = ret.len;
+	FLUSH(&ret);
+}
@@ -99,7 +99,8 @@ struct FinBlock
 };
 
 extern byte data[];
-extern byte etext[];
+extern byte edata[];
+extern byte bss[];
 extern byte ebss[];
 
 static G *fing;
@@ -644,8 +645,10 @@ mark(void (*scan)(byte*, int64))
 	G *gp;
 	FinBlock *fb;
 
-	// mark data+bss.
-	scan(data, ebss - data);
+	// mark data and bss separately: when linked into a C program
+	// they need not be next to each other.
+	scan(data, edata - data);
+	scan(bss, ebss - bss);
 
 	// mark stacks
 	for(gp=runtime·allg; gp!=nil; gp=gp->alllink) {
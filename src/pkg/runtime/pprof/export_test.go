@@ -0,0 +1,13 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Export guts for testing.
+
+package pprof
+
+func NumLabelSets() int {
+	labelSets.Lock()
+	defer labelSets.Unlock()
+	return len(labelSets.sets)
+}
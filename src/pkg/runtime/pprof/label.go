@@ -0,0 +1,236 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// A LabelSet is a set of key/value labels.
+// Labels attached to a goroutine are recorded with the CPU profile
+// samples and goroutine profile entries taken from that goroutine,
+// and can be used to select which samples and entries are written.
+// A LabelSet is immutable; the zero LabelSet is empty.
+type LabelSet struct {
+	list []label // sorted by key
+}
+
+type label struct {
+	key   string
+	value string
+}
+
+type byKey []label
+
+func (x byKey) Len() int           { return len(x) }
+func (x byKey) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byKey) Less(i, j int) bool { return x[i].key < x[j].key }
+
+// Labels returns a LabelSet holding the given key/value pairs.
+// The arguments alternate between keys and values; Labels panics
+// if given an odd number of arguments.  If a key appears more than
+// once, the last value wins.
+func Labels(args ...string) LabelSet {
+	if len(args)%2 != 0 {
+		panic("pprof: odd number of arguments to Labels")
+	}
+	var s LabelSet
+	for i := 0; i < len(args); i += 2 {
+		s = s.with(args[i], args[i+1])
+	}
+	return s
+}
+
+// with returns a copy of s with key set to value.
+func (s LabelSet) with(key, value string) LabelSet {
+	list := make([]label, 0, len(s.list)+1)
+	for _, l := range s.list {
+		if l.key != key {
+			list = append(list, l)
+		}
+	}
+	list = append(list, label{key, value})
+	sort.Sort(byKey(list))
+	return LabelSet{list}
+}
+
+// merge returns the labels of s overridden by those of t.
+func (s LabelSet) merge(t LabelSet) LabelSet {
+	for _, l := range t.list {
+		s = s.with(l.key, l.value)
+	}
+	return s
+}
+
+// Len returns the number of labels in s.
+func (s LabelSet) Len() int {
+	return len(s.list)
+}
+
+// Label returns the value of the label with the given key
+// and whether such a label is present.
+func (s LabelSet) Label(key string) (value string, ok bool) {
+	i := sort.Search(len(s.list), func(i int) bool { return s.list[i].key >= key })
+	if i < len(s.list) && s.list[i].key == key {
+		return s.list[i].value, true
+	}
+	return "", false
+}
+
+// Match reports whether s holds every key/value pair in filter.
+// Every LabelSet matches the empty filter.
+func (s LabelSet) Match(filter LabelSet) bool {
+	for _, l := range filter.list {
+		if v, ok := s.Label(l.key); !ok || v != l.value {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the labels in s as {"key":"value", ...}, sorted by key.
+func (s LabelSet) String() string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, l := range s.list {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%q:%q", l.key, l.value)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// The runtime records a goroutine's labels as an integer id, so
+// that the profiling signal handler can copy them without
+// allocating.  Id 0 is the empty set.  Ids are not reused.
+//
+// A label set is dropped from the table once no goroutine holds
+// its id.  Sweeping for such sets needs a pass over all goroutines,
+// so it is done only when the table has doubled since the last
+// sweep.  While a profile is being taken, its samples may hold ids
+// that no goroutine does any more, so the table is pinned and no
+// sets are dropped.
+var labelSets struct {
+	sync.Mutex
+	ids  map[string]uintptr // by LabelSet.String
+	sets map[uintptr]LabelSet
+	last uintptr // last id assigned
+	live int     // number of sets after the last sweep
+	pins int     // number of profiles being taken
+}
+
+// minLabelSweep is the smallest table size that is swept.
+const minLabelSweep = 256
+
+// labelSetIDLocked returns the id of s, assigning one if necessary.
+// labelSets must be locked.
+func labelSetIDLocked(s LabelSet) uintptr {
+	if len(s.list) == 0 {
+		return 0
+	}
+	key := s.String()
+	id, ok := labelSets.ids[key]
+	if !ok {
+		if labelSets.ids == nil {
+			labelSets.ids = make(map[string]uintptr)
+			labelSets.sets = make(map[uintptr]LabelSet)
+		}
+		n := len(labelSets.sets)
+		if labelSets.pins == 0 && n >= minLabelSweep && n >= 2*labelSets.live {
+			sweepLabelSetsLocked()
+		}
+		labelSets.last++
+		id = labelSets.last
+		labelSets.ids[key] = id
+		labelSets.sets[id] = s
+	}
+	return id
+}
+
+// sweepLabelSetsLocked drops the label sets that no goroutine holds.
+// labelSets must be locked, so that no goroutine can take up an id
+// the sweep is about to drop.
+func sweepLabelSetsLocked() {
+	ids := make([]uintptr, runtime.NumGoroutine()+10)
+	for {
+		n := runtime_goroutineLabelIDs(ids)
+		if n <= len(ids) {
+			ids = ids[:n]
+			break
+		}
+		// More goroutines now; try again.
+		ids = make([]uintptr, n+10)
+	}
+	inUse := make(map[uintptr]bool)
+	for _, id := range ids {
+		inUse[id] = true
+	}
+	for key, id := range labelSets.ids {
+		if !inUse[id] {
+			delete(labelSets.ids, key)
+			delete(labelSets.sets, id)
+		}
+	}
+	labelSets.live = len(labelSets.sets)
+}
+
+// labelSetByID returns the LabelSet with the given id.
+func labelSetByID(id uintptr) LabelSet {
+	if id == 0 {
+		return LabelSet{}
+	}
+	labelSets.Lock()
+	defer labelSets.Unlock()
+	return labelSets.sets[id]
+}
+
+// pinLabelSets stops label sets from being dropped until
+// the matching call of unpinLabelSets.
+func pinLabelSets() {
+	labelSets.Lock()
+	labelSets.pins++
+	labelSets.Unlock()
+}
+
+func unpinLabelSets() {
+	labelSets.Lock()
+	labelSets.pins--
+	labelSets.Unlock()
+}
+
+// Implemented in the runtime.
+func runtime_setGoroutineLabels(id uintptr)
+func runtime_getGoroutineLabels() uintptr
+func runtime_goroutineLabelIDs(ids []uintptr) int
+
+// GoroutineLabels returns the labels of the current goroutine.
+func GoroutineLabels() LabelSet {
+	return labelSetByID(runtime_getGoroutineLabels())
+}
+
+// SetGoroutineLabels replaces the labels of the current goroutine.
+// Goroutines started afterward by the current goroutine inherit them.
+// Most code should use Do instead.
+func SetGoroutineLabels(labels LabelSet) {
+	labelSets.Lock()
+	defer labelSets.Unlock()
+	runtime_setGoroutineLabels(labelSetIDLocked(labels))
+}
+
+// Do calls f with the current goroutine's labels extended by labels,
+// restoring the original labels when f returns or panics.
+// Goroutines started by f inherit the extended labels.
+func Do(labels LabelSet, f func()) {
+	old := GoroutineLabels()
+	defer SetGoroutineLabels(old)
+	SetGoroutineLabels(old.merge(labels))
+	f()
+}
@@ -18,6 +18,8 @@ import (
 	"strings"
 	"sync"
 	"text/tabwriter"
+	"time"
+	"unsafe"
 )
 
 // BUG(rsc): A bug in the OS X Snow Leopard 64-bit kernel prevents
@@ -44,12 +46,21 @@ import (
 // the StartCPUProfile and StopCPUProfile functions, because it streams
 // output to a writer during profiling.
 //
+// The goroutine and CPU profiles record the labels (see Do) of the
+// goroutines they sample and can be restricted to a subset of them.
+//
+// Besides the legacy formats, every profile can be written in the
+// compressed protocol buffer format of the pprof tools, which includes
+// the symbol information needed to read it (see WriteProto).
+//
 type Profile struct {
-	name  string
-	mu    sync.Mutex
-	m     map[interface{}][]uintptr
-	count func() int
-	write func(io.Writer, int) error
+	name          string
+	mu            sync.Mutex
+	m             map[interface{}][]uintptr
+	count         func() int
+	write         func(io.Writer, int) error
+	writeMatching func(io.Writer, int, LabelSet) error
+	writeProto    func(io.Writer, LabelSet) error
 }
 
 // profiles records all registered profiles.
@@ -59,21 +70,25 @@ var profiles struct {
 }
 
 var goroutineProfile = &Profile{
-	name:  "goroutine",
-	count: countGoroutine,
-	write: writeGoroutine,
+	name:          "goroutine",
+	count:         countGoroutine,
+	write:         writeGoroutine,
+	writeMatching: writeGoroutineMatching,
+	writeProto:    writeGoroutineProto,
 }
 
 var threadcreateProfile = &Profile{
-	name:  "threadcreate",
-	count: countThreadCreate,
-	write: writeThreadCreate,
+	name:       "threadcreate",
+	count:      countThreadCreate,
+	write:      writeThreadCreate,
+	writeProto: writeThreadCreateProto,
 }
 
 var heapProfile = &Profile{
-	name:  "heap",
-	count: countHeap,
-	write: writeHeap,
+	name:       "heap",
+	count:      countHeap,
+	write:      writeHeap,
+	writeProto: writeHeapProto,
 }
 
 func lockProfiles() {
@@ -172,7 +187,6 @@ func (p *Profile) Count() int {
 //
 // Passing skip=0 begins the stack trace at the call to Add inside rpc.NewClient.
 // Passing skip=1 begins the stack trace at the call to NewClient inside mypkg.Run.
-//
 func (p *Profile) Add(value interface{}, skip int) {
 	if p.name == "" {
 		panic("pprof: use of uninitialized Profile")
@@ -221,6 +235,11 @@ func (p *Profile) WriteTo(w io.Writer, debug int) error {
 		return p.write(w, debug)
 	}
 
+	return printCountProfile(w, debug, p.name, p.snapshot())
+}
+
+// snapshot returns the stacks in a profile created by NewProfile.
+func (p *Profile) snapshot() stackProfile {
 	// Obtain consistent snapshot under lock; then process without lock.
 	var all [][]uintptr
 	p.mu.Lock()
@@ -231,8 +250,72 @@ func (p *Profile) WriteTo(w io.Writer, debug int) error {
 
 	// Map order is non-deterministic; make output deterministic.
 	sort.Sort(stackProfile(all))
+	return stackProfile(all)
+}
 
-	return printCountProfile(w, debug, p.name, stackProfile(all))
+// WriteToMatching is like WriteTo but includes only the stacks
+// sampled from goroutines whose labels match filter (see LabelSet.Match).
+// Only the goroutine profile records labels; for other profiles,
+// WriteToMatching returns an error unless filter is empty.
+func (p *Profile) WriteToMatching(w io.Writer, debug int, filter LabelSet) error {
+	if p.name == "" {
+		panic("pprof: use of zero Profile")
+	}
+	if filter.Len() == 0 {
+		return p.WriteTo(w, debug)
+	}
+	if p.writeMatching == nil {
+		return fmt.Errorf("pprof: %s profile does not record labels", p.name)
+	}
+	return p.writeMatching(w, debug, filter)
+}
+
+// WriteProto writes the profile to w in the gzip-compressed protocol
+// buffer format read by the pprof tools, restricted to the stacks
+// whose goroutine labels match filter.  The profile includes the
+// function names, file names and line numbers of its stacks, so it
+// can be analyzed without the program binary.  As with WriteToMatching,
+// only the goroutine profile can be filtered by a non-empty filter.
+func (p *Profile) WriteProto(w io.Writer, filter LabelSet) error {
+	if p.name == "" {
+		panic("pprof: use of zero Profile")
+	}
+	if filter.Len() > 0 && p.writeMatching == nil {
+		return fmt.Errorf("pprof: %s profile does not record labels", p.name)
+	}
+	if p.writeProto != nil {
+		return p.writeProto(w, filter)
+	}
+	return writeCountProto(w, p.name, p.snapshot())
+}
+
+// writeCountProto writes a countProfile in the protocol buffer format,
+// with one sample per distinct stack and label set.
+func writeCountProto(w io.Writer, name string, p countProfile) error {
+	prof := &protoProfile{
+		sampleTypes: []valueType{{name, "count"}},
+		periodType:  valueType{name, "count"},
+		period:      1,
+		start:       time.Now(),
+	}
+	lp, _ := p.(labeledProfile)
+	index := map[string]int{}
+	var buf bytes.Buffer
+	for i := 0; i < p.Len(); i++ {
+		var labels LabelSet
+		if lp != nil {
+			labels = lp.Labels(i)
+		}
+		buf.Reset()
+		fmt.Fprintf(&buf, "%v %v", p.Stack(i), labels)
+		if j, ok := index[buf.String()]; ok {
+			prof.samples[j].values[0]++
+			continue
+		}
+		index[buf.String()] = len(prof.samples)
+		prof.add(p.Stack(i), labels, 1)
+	}
+	return prof.write(w)
 }
 
 type stackProfile [][]uintptr
@@ -259,6 +342,13 @@ type countProfile interface {
 	Stack(i int) []uintptr
 }
 
+// A labeledProfile is a countProfile whose traces also carry
+// the labels of the goroutine they were taken from.
+type labeledProfile interface {
+	countProfile
+	Labels(i int) LabelSet
+}
+
 // printCountProfile prints a countProfile at the specified debug level.
 func printCountProfile(w io.Writer, debug int, name string, p countProfile) error {
 	b := bufio.NewWriter(w)
@@ -271,30 +361,41 @@ func printCountProfile(w io.Writer, debug int, name string, p countProfile) erro
 
 	fmt.Fprintf(w, "%s profile: total %d\n", name, p.Len())
 
+	// Labels are only printed in the commented form,
+	// so at debug=0 equal stacks are counted together.
+	var lp labeledProfile
+	if debug > 0 {
+		lp, _ = p.(labeledProfile)
+	}
+
 	// Build count of each stack.
 	var buf bytes.Buffer
-	key := func(stk []uintptr) string {
+	key := func(i int) string {
 		buf.Reset()
 		fmt.Fprintf(&buf, "@")
-		for _, pc := range stk {
+		for _, pc := range p.Stack(i) {
 			fmt.Fprintf(&buf, " %#x", pc)
 		}
+		if lp != nil {
+			if l := lp.Labels(i); l.Len() > 0 {
+				fmt.Fprintf(&buf, "\n# labels: %v", l)
+			}
+		}
 		return buf.String()
 	}
 	m := map[string]int{}
 	n := p.Len()
 	for i := 0; i < n; i++ {
-		m[key(p.Stack(i))]++
+		m[key(i)]++
 	}
 
 	// Print stacks, listing count on first occurrence of a unique stack.
 	for i := 0; i < n; i++ {
-		stk := p.Stack(i)
-		s := key(stk)
+		s := key(i)
 		if count := m[s]; count != 0 {
 			fmt.Fprintf(w, "%d %s\n", count, s)
 			if debug > 0 {
-				printStackRecord(w, stk, false)
+				printStackRecord(w, p.Stack(i), false)
 			}
 			delete(m, s)
 		}
@@ -455,6 +556,60 @@ func writeHeap(w io.Writer, debug int) error {
 	return b.Flush()
 }
 
+// writeHeapProto writes the current runtime heap profile to w
+// in the protocol buffer format.  Unlike the legacy format, which
+// leaves that to pprof, it scales the sampled values up to estimates
+// of the actual allocations.
+func writeHeapProto(w io.Writer, filter LabelSet) error {
+	var p []runtime.MemProfileRecord
+	n, ok := runtime.MemProfile(nil, false)
+	for {
+		p = make([]runtime.MemProfileRecord, n+50)
+		n, ok = runtime.MemProfile(p, false)
+		if ok {
+			p = p[0:n]
+			break
+		}
+	}
+	sort.Sort(byInUseBytes(p))
+
+	rate := int64(runtime.MemProfileRate)
+	prof := &protoProfile{
+		sampleTypes: []valueType{
+			{"alloc_objects", "count"},
+			{"alloc_space", "bytes"},
+			{"inuse_objects", "count"},
+			{"inuse_space", "bytes"},
+		},
+		periodType: valueType{"space", "bytes"},
+		period:     rate,
+		start:      time.Now(),
+	}
+	for i := range p {
+		r := &p[i]
+		allocObjects, allocBytes := scaleHeapSample(r.AllocObjects, r.AllocBytes, rate)
+		inuseObjects, inuseBytes := scaleHeapSample(r.InUseObjects(), r.InUseBytes(), rate)
+		prof.add(r.Stack(), LabelSet{}, allocObjects, allocBytes, inuseObjects, inuseBytes)
+	}
+	return prof.write(w)
+}
+
+// scaleHeapSample estimates the allocations that count sampled
+// objects totalling size bytes stand for.  The allocator records an
+// allocation of b < rate bytes with probability about b/rate, and
+// every larger allocation.
+func scaleHeapSample(count, size, rate int64) (int64, int64) {
+	if count == 0 || size == 0 || rate <= 1 {
+		return count, size
+	}
+	avg := size / count
+	if avg >= rate {
+		return count, size
+	}
+	scale := float64(rate) / float64(avg)
+	return int64(float64(count) * scale), int64(float64(size) * scale)
+}
+
 // countThreadCreate returns the size of the current ThreadCreateProfile.
 func countThreadCreate() int {
 	n, _ := runtime.ThreadCreateProfile(nil)
@@ -466,6 +621,12 @@ func writeThreadCreate(w io.Writer, debug int) error {
 	return writeRuntimeProfile(w, debug, "threadcreate", runtime.ThreadCreateProfile)
 }
 
+// writeThreadCreateProto writes the current runtime ThreadCreateProfile to w
+// in the protocol buffer format.
+func writeThreadCreateProto(w io.Writer, filter LabelSet) error {
+	return writeCountProto(w, "threadcreate", runtimeProfile(fetchRuntimeProfile(runtime.ThreadCreateProfile)))
+}
+
 // countGoroutine returns the number of goroutines.
 func countGoroutine() int {
 	return runtime.NumGoroutine()
@@ -473,10 +634,63 @@ func countGoroutine() int {
 
 // writeGoroutine writes the current runtime GoroutineProfile to w.
 func writeGoroutine(w io.Writer, debug int) error {
+	return writeGoroutineMatching(w, debug, LabelSet{})
+}
+
+// Implemented in the runtime.
+func runtime_goroutineProfileWithLabels(p []runtime.StackRecord, labels []uintptr) (n int, ok bool)
+
+// writeGoroutineMatching writes the stacks of the goroutines
+// whose labels match filter to w.
+func writeGoroutineMatching(w io.Writer, debug int, filter LabelSet) error {
 	if debug >= 2 {
+		if filter.Len() > 0 {
+			return fmt.Errorf("pprof: cannot select goroutine stacks by label at debug=%d", debug)
+		}
 		return writeGoroutineStacks(w)
 	}
-	return writeRuntimeProfile(w, debug, "goroutine", runtime.GoroutineProfile)
+	return printCountProfile(w, debug, "goroutine", fetchGoroutines(filter))
+}
+
+// writeGoroutineProto writes the stacks of the goroutines whose
+// labels match filter to w in the protocol buffer format.
+func writeGoroutineProto(w io.Writer, filter LabelSet) error {
+	return writeCountProto(w, "goroutine", fetchGoroutines(filter))
+}
+
+// fetchGoroutines returns the stacks and labels of the goroutines
+// whose labels match filter.
+func fetchGoroutines(filter LabelSet) labeledRuntimeProfile {
+	// The ids are looked up after the runtime has let go of
+	// the goroutines, which may have dropped them by then.
+	pinLabelSets()
+	defer unpinLabelSets()
+
+	// Same dance as in fetchRuntimeProfile.
+	var p []runtime.StackRecord
+	var ids []uintptr
+	n, ok := runtime_goroutineProfileWithLabels(nil, nil)
+	for {
+		p = make([]runtime.StackRecord, n+10)
+		ids = make([]uintptr, len(p))
+		n, ok = runtime_goroutineProfileWithLabels(p, ids)
+		if ok {
+			p = p[0:n]
+			ids = ids[0:n]
+			break
+		}
+		// Profile grew; try again.
+	}
+
+	var prof labeledRuntimeProfile
+	for i := range p {
+		l := labelSetByID(ids[i])
+		if l.Match(filter) {
+			prof.p = append(prof.p, p[i])
+			prof.labels = append(prof.labels, l)
+		}
+	}
+	return prof
 }
 
 func writeGoroutineStacks(w io.Writer) error {
@@ -501,6 +715,10 @@ func writeGoroutineStacks(w io.Writer) error {
 }
 
 func writeRuntimeProfile(w io.Writer, debug int, name string, fetch func([]runtime.StackRecord) (int, bool)) error {
+	return printCountProfile(w, debug, name, runtimeProfile(fetchRuntimeProfile(fetch)))
+}
+
+func fetchRuntimeProfile(fetch func([]runtime.StackRecord) (int, bool)) []runtime.StackRecord {
 	// Find out how many records there are (fetch(nil)),
 	// allocate that many records, and get the data.
 	// There's a race—more records might be added between
@@ -522,7 +740,7 @@ func writeRuntimeProfile(w io.Writer, debug int, name string, fetch func([]runti
 		// Profile grew; try again.
 	}
 
-	return printCountProfile(w, debug, name, runtimeProfile(p))
+	return p
 }
 
 type runtimeProfile []runtime.StackRecord
@@ -530,6 +748,15 @@ type runtimeProfile []runtime.StackRecord
 func (p runtimeProfile) Len() int              { return len(p) }
 func (p runtimeProfile) Stack(i int) []uintptr { return p[i].Stack() }
 
+type labeledRuntimeProfile struct {
+	p      []runtime.StackRecord
+	labels []LabelSet
+}
+
+func (p labeledRuntimeProfile) Len() int              { return len(p.p) }
+func (p labeledRuntimeProfile) Stack(i int) []uintptr { return p.p[i].Stack() }
+func (p labeledRuntimeProfile) Labels(i int) LabelSet { return p.labels[i] }
+
 var cpu struct {
 	sync.Mutex
 	profiling bool
@@ -540,6 +767,25 @@ var cpu struct {
 // While profiling, the profile will be buffered and written to w.
 // StartCPUProfile returns an error if profiling is already enabled.
 func StartCPUProfile(w io.Writer) error {
+	return startCPUProfile(w, LabelSet{}, false)
+}
+
+// StartCPUProfileMatching is like StartCPUProfile but writes only
+// the samples taken from goroutines whose labels match filter
+// (see LabelSet.Match).
+func StartCPUProfileMatching(w io.Writer, filter LabelSet) error {
+	return startCPUProfile(w, filter, false)
+}
+
+// StartCPUProfileProto is like StartCPUProfileMatching but writes the
+// profile in the protocol buffer format described at Profile.WriteProto,
+// with each sample carrying the labels of its goroutine.
+// The profile is kept in memory and written by StopCPUProfile.
+func StartCPUProfileProto(w io.Writer, filter LabelSet) error {
+	return startCPUProfile(w, filter, true)
+}
+
+func startCPUProfile(w io.Writer, filter LabelSet, proto bool) error {
 	// The runtime routines allow a variable profiling rate,
 	// but in practice operating systems cannot trigger signals
 	// at more than about 500 Hz, and our processing of the
@@ -567,22 +813,154 @@ func StartCPUProfile(w io.Writer) error {
 		return fmt.Errorf("cpu profiling already in use")
 	}
 	cpu.profiling = true
+	pinLabelSets() // until profileWriter has read every sample
+	runtime_setCPUProfileLabels(true)
 	runtime.SetCPUProfileRate(hz)
-	go profileWriter(w)
+	runtime_setCPUProfileLabels(false)
+	var p *cpuProto
+	if proto {
+		p = newCPUProto(hz)
+	}
+	go profileWriter(w, filter, p)
 	return nil
 }
 
-func profileWriter(w io.Writer) {
+// Implemented in the runtime.
+func runtime_setCPUProfileLabels(on bool)
+
+// profileWriter writes the CPU profile to w, in the protocol
+// buffer format if p is not nil.
+func profileWriter(w io.Writer, filter LabelSet, p *cpuProto) {
+	m := &cpuMatcher{filter, map[uintptr]bool{0: filter.Len() == 0}}
+	header := true
 	for {
 		data := runtime.CPUProfile()
 		if data == nil {
 			break
 		}
-		w.Write(data)
+		if p != nil {
+			p.addBlock(data, header, m)
+		} else {
+			w.Write(unlabelCPUProfile(data, header, m))
+		}
+		header = false
+	}
+	unpinLabelSets()
+	if p != nil {
+		p.write(w)
 	}
 	cpu.done <- true
 }
 
+// A cpuMatcher matches label set ids against a filter,
+// caching the result for each id.
+type cpuMatcher struct {
+	filter LabelSet
+	cache  map[uintptr]bool
+}
+
+func (m *cpuMatcher) match(id uintptr) bool {
+	ok, found := m.cache[id]
+	if !found {
+		ok = labelSetByID(id).Match(m.filter)
+		m.cache[id] = ok
+	}
+	return ok
+}
+
+const wordSize = int(unsafe.Sizeof(uintptr(0)))
+
+// cpuWords returns a block of CPU profile data from the runtime as
+// a slice of words.  If header is set, the block begins with the
+// profile header, and cpuWords also returns the header's length.
+func cpuWords(data []byte, header bool) (words []uintptr, hdr int) {
+	if len(data) == 0 {
+		return nil, 0
+	}
+	words = (*[1 << 20]uintptr)(unsafe.Pointer(&data[0]))[:len(data)/wordSize]
+	if header {
+		// count, depth, then depth words.
+		hdr = 2 + int(words[1])
+	}
+	return words, hdr
+}
+
+// cpuRecords calls f for each labeled sample record in words.
+func cpuRecords(words []uintptr, f func(count, id uintptr, stk []uintptr)) {
+	for len(words) >= 3 {
+		count, depth, id := words[0], words[1], words[2]
+		stk := words[3 : 3+depth]
+		words = words[3+depth:]
+		f(count, id, stk)
+	}
+}
+
+// unlabelCPUProfile rewrites a block of labeled CPU profile data from
+// the runtime in the plain pprof format, in place, dropping the samples
+// that m does not match.
+func unlabelCPUProfile(data []byte, header bool, m *cpuMatcher) []byte {
+	words, n := cpuWords(data, header)
+	cpuRecords(words[n:], func(count, id uintptr, stk []uintptr) {
+		if !m.match(id) {
+			return
+		}
+		// Records only move toward the start of words,
+		// so this never clobbers a record still to be read.
+		words[n] = count
+		words[n+1] = uintptr(len(stk))
+		n += 2
+		n += copy(words[n:], stk)
+	})
+	return data[:n*wordSize]
+}
+
+// A cpuProto accumulates CPU profile samples for writing
+// in the protocol buffer format.
+type cpuProto struct {
+	prof  protoProfile
+	index map[string]int // sample index by stack and label id
+	buf   bytes.Buffer
+}
+
+func newCPUProto(hz int) *cpuProto {
+	period := int64(1e9 / hz)
+	return &cpuProto{
+		prof: protoProfile{
+			sampleTypes: []valueType{{"samples", "count"}, {"cpu", "nanoseconds"}},
+			periodType:  valueType{"cpu", "nanoseconds"},
+			period:      period,
+			start:       time.Now(),
+		},
+		index: map[string]int{},
+	}
+}
+
+// addBlock adds the samples in a block of CPU profile data that m matches.
+func (p *cpuProto) addBlock(data []byte, header bool, m *cpuMatcher) {
+	words, hdr := cpuWords(data, header)
+	cpuRecords(words[hdr:], func(count, id uintptr, stk []uintptr) {
+		if !m.match(id) {
+			return
+		}
+		// The runtime may log the same stack more than once.
+		p.buf.Reset()
+		fmt.Fprintf(&p.buf, "%d %v", id, stk)
+		if i, ok := p.index[p.buf.String()]; ok {
+			s := &p.prof.samples[i]
+			s.values[0] += int64(count)
+			s.values[1] += int64(count) * p.prof.period
+			return
+		}
+		p.index[p.buf.String()] = len(p.prof.samples)
+		p.prof.add(append([]uintptr(nil), stk...), labelSetByID(id), int64(count), int64(count)*p.prof.period)
+	})
+}
+
+func (p *cpuProto) write(w io.Writer) error {
+	p.prof.duration = time.Now().Sub(p.prof.start)
+	return p.prof.write(w)
+}
+
 // StopCPUProfile stops the current CPU profile, if any.
 // StopCPUProfile only returns after all the writes for the
 // profile have completed.
@@ -6,31 +6,21 @@ package pprof_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"hash/adler32"
 	"hash/crc32"
+	"io/ioutil"
 	"os/exec"
 	"runtime"
 	. "runtime/pprof"
+	"strconv"
 	"strings"
 	"testing"
 	"unsafe"
 )
 
 func TestCPUProfile(t *testing.T) {
-	switch runtime.GOOS {
-	case "darwin":
-		out, err := exec.Command("uname", "-a").CombinedOutput()
-		if err != nil {
-			t.Fatal(err)
-		}
-		vers := string(out)
-		t.Logf("uname -a: %v", vers)
-		// Lion uses "Darwin Kernel Version 11".
-		if strings.Contains(vers, "Darwin Kernel Version 10") && strings.Contains(vers, "RELEASE_X86_64") {
-			t.Logf("skipping test on known-broken kernel (64-bit Leopard / Snow Leopard)")
-			return
-		}
-	case "plan9":
-		// unimplemented
+	if skipCPUProfile(t) {
 		return
 	}
 
@@ -47,8 +37,66 @@ func TestCPUProfile(t *testing.T) {
 	}
 	StopCPUProfile()
 
+	if !profileContains(t, prof.Bytes(), "ChecksumIEEE") {
+		t.Fatal("did not find ChecksumIEEE in the profile")
+	}
+}
+
+func TestCPUProfileMatching(t *testing.T) {
+	if skipCPUProfile(t) {
+		return
+	}
+
+	buf := make([]byte, 100000)
+	var prof bytes.Buffer
+	if err := StartCPUProfileMatching(&prof, Labels("worker", "crc")); err != nil {
+		t.Fatal(err)
+	}
+	Do(Labels("worker", "crc"), func() {
+		for i := 0; i < 1000; i++ {
+			crc32.ChecksumIEEE(buf)
+		}
+	})
+	Do(Labels("worker", "adler"), func() {
+		for i := 0; i < 1000; i++ {
+			adler32.Checksum(buf)
+		}
+	})
+	StopCPUProfile()
+
+	if !profileContains(t, prof.Bytes(), "ChecksumIEEE") {
+		t.Fatal("did not find ChecksumIEEE in the profile")
+	}
+	if profileContains(t, prof.Bytes(), "adler32.Checksum") {
+		t.Fatal("found adler32.Checksum, which ran with other labels, in the profile")
+	}
+}
+
+func skipCPUProfile(t *testing.T) bool {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("uname", "-a").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vers := string(out)
+		t.Logf("uname -a: %v", vers)
+		// Lion uses "Darwin Kernel Version 11".
+		if strings.Contains(vers, "Darwin Kernel Version 10") && strings.Contains(vers, "RELEASE_X86_64") {
+			t.Logf("skipping test on known-broken kernel (64-bit Leopard / Snow Leopard)")
+			return true
+		}
+	case "plan9":
+		// unimplemented
+		return true
+	}
+	return false
+}
+
+// profileContains checks that the CPU profile in bytes is well formed
+// and reports whether it contains a function whose name contains name.
+func profileContains(t *testing.T, bytes []byte, name string) bool {
 	// Convert []byte to []uintptr.
-	bytes := prof.Bytes()
 	val := *(*[]uintptr)(unsafe.Pointer(&bytes))
 	val = val[:len(bytes)/int(unsafe.Sizeof(uintptr(0)))]
 
@@ -59,7 +107,6 @@ func TestCPUProfile(t *testing.T) {
 		t.Fatalf("unexpected header %#x", val[:5])
 	}
 
-	// Check that profile is well formed and contains ChecksumIEEE.
 	found := false
 	val = val[5:]
 	for len(val) > 0 {
@@ -71,14 +118,263 @@ func TestCPUProfile(t *testing.T) {
 			if f == nil {
 				continue
 			}
-			if strings.Contains(f.Name(), "ChecksumIEEE") {
+			if strings.Contains(f.Name(), name) {
 				found = true
 			}
 		}
 		val = val[2+val[1]:]
 	}
+	return found
+}
 
-	if !found {
-		t.Fatal("did not find ChecksumIEEE in the profile")
+func TestLabels(t *testing.T) {
+	if n := GoroutineLabels().Len(); n != 0 {
+		t.Fatalf("goroutine starts with %d labels, want 0", n)
+	}
+	Do(Labels("a", "1", "b", "2"), func() {
+		Do(Labels("b", "3"), func() {
+			l := GoroutineLabels()
+			if s := l.String(); s != `{"a":"1", "b":"3"}` {
+				t.Errorf("nested labels = %s", s)
+			}
+			c := make(chan LabelSet)
+			go func() { c <- GoroutineLabels() }()
+			if s := (<-c).String(); s != l.String() {
+				t.Errorf("new goroutine has labels %s, want %s", s, l)
+			}
+		})
+		if v, ok := GoroutineLabels().Label("b"); !ok || v != "2" {
+			t.Errorf("after inner Do, label b = %q, %v, want \"2\", true", v, ok)
+		}
+	})
+	if n := GoroutineLabels().Len(); n != 0 {
+		t.Fatalf("after Do, goroutine has %d labels, want 0", n)
+	}
+}
+
+func TestLabelSetsDropped(t *testing.T) {
+	stop := make(chan bool)
+	held := make(chan LabelSet)
+	defer close(stop)
+	Do(Labels("role", "holder"), func() {
+		go func() {
+			held <- GoroutineLabels()
+			<-stop
+			held <- GoroutineLabels()
+		}()
+	})
+	want := (<-held).String()
+
+	// A label per request, as a server might use, must not
+	// make the table grow without bound.
+	const n = 10000
+	for i := 0; i < n; i++ {
+		Do(Labels("request", strconv.Itoa(i)), func() {})
+	}
+	if m := NumLabelSets(); m > n/4 {
+		t.Errorf("%d label sets kept after %d calls of Do", m, n)
+	}
+
+	stop <- true
+	if got := (<-held).String(); got != want {
+		t.Errorf("goroutine labels changed from %s to %s", want, got)
+	}
+}
+
+func TestLabelSetMatch(t *testing.T) {
+	s := Labels("k1", "v1", "k2", "v2")
+	for _, tt := range []struct {
+		filter LabelSet
+		want   bool
+	}{
+		{LabelSet{}, true},
+		{Labels("k1", "v1"), true},
+		{Labels("k2", "v2", "k1", "v1"), true},
+		{Labels("k1", "v2"), false},
+		{Labels("k3", "v1"), false},
+	} {
+		if got := s.Match(tt.filter); got != tt.want {
+			t.Errorf("%v.Match(%v) = %v, want %v", s, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestGoroutineProfileMatching(t *testing.T) {
+	stop := make(chan bool)
+	ready := make(chan bool)
+	defer close(stop)
+	Do(Labels("role", "sleeper"), func() {
+		for i := 0; i < 3; i++ {
+			go func() {
+				ready <- true
+				<-stop
+			}()
+			<-ready
+		}
+	})
+
+	var buf bytes.Buffer
+	if err := Lookup("goroutine").WriteToMatching(&buf, 1, Labels("role", "sleeper")); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "goroutine profile: total 3\n") {
+		t.Errorf("unexpected profile:\n%s", out)
+	}
+	if !strings.Contains(out, "\n# labels: {\"role\":\"sleeper\"}\n") {
+		t.Errorf("profile does not show labels:\n%s", out)
+	}
+
+	if err := Lookup("heap").WriteToMatching(&buf, 1, Labels("role", "sleeper")); err == nil {
+		t.Errorf("heap profile WriteToMatching succeeded; want error")
+	}
+}
+
+// A decodedProfile holds the parts of a protocol buffer
+// profile that the tests look at.
+type decodedProfile struct {
+	samples int
+	labels  int
+	strings []string
+}
+
+// decodeProto decodes a gzipped profile.  It walks the top level
+// messages only, except for counting sample labels.
+func decodeProto(t *testing.T, data []byte) *decodedProfile {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := new(decodedProfile)
+	for len(data) > 0 {
+		tag, wire, _, body := protoField(t, &data)
+		switch {
+		case tag == 2 && wire == 2: // sample
+			p.samples++
+			for len(body) > 0 {
+				if tag, _, _, _ := protoField(t, &body); tag == 3 {
+					p.labels++
+				}
+			}
+		case tag == 6 && wire == 2: // string table
+			p.strings = append(p.strings, string(body))
+		}
+	}
+	if len(p.strings) == 0 || p.strings[0] != "" {
+		t.Fatalf("bad string table %q", p.strings)
+	}
+	return p
+}
+
+func protoVarint(t *testing.T, data *[]byte) uint64 {
+	var x uint64
+	for i, c := range *data {
+		x |= uint64(c&0x7f) << uint(7*i)
+		if c < 0x80 {
+			*data = (*data)[i+1:]
+			return x
+		}
+	}
+	t.Fatal("truncated varint")
+	return 0
+}
+
+// protoField reads a varint or length-delimited field from data.
+func protoField(t *testing.T, data *[]byte) (tag, wire int, val uint64, body []byte) {
+	key := protoVarint(t, data)
+	tag, wire = int(key>>3), int(key&7)
+	switch wire {
+	case 0:
+		val = protoVarint(t, data)
+	case 2:
+		n := protoVarint(t, data)
+		if n > uint64(len(*data)) {
+			t.Fatalf("field %d: length %d exceeds data", tag, n)
+		}
+		body, *data = (*data)[:n], (*data)[n:]
+	default:
+		t.Fatalf("field %d: unexpected wire type %d", tag, wire)
+	}
+	return
+}
+
+func (p *decodedProfile) hasString(s string) bool {
+	for _, x := range p.strings {
+		if strings.Contains(x, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGoroutineProfileProto(t *testing.T) {
+	stop := make(chan bool)
+	defer close(stop)
+	Do(Labels("role", "blocked"), func() {
+		go blockedGoroutine(stop)
+	})
+	runtime.Gosched()
+
+	var buf bytes.Buffer
+	if err := Lookup("goroutine").WriteProto(&buf, Labels("role", "blocked")); err != nil {
+		t.Fatal(err)
+	}
+	p := decodeProto(t, buf.Bytes())
+	if p.samples != 1 || p.labels != 1 {
+		t.Errorf("got %d samples with %d labels, want 1 and 1", p.samples, p.labels)
+	}
+	for _, s := range []string{"goroutine", "count", "role", "blocked", "blockedGoroutine", "pprof_test.go"} {
+		if !p.hasString(s) {
+			t.Errorf("profile strings do not include %q: %q", s, p.strings)
+		}
+	}
+}
+
+func blockedGoroutine(stop chan bool) {
+	<-stop
+}
+
+func TestHeapProfileProto(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Lookup("heap").WriteProto(&buf, LabelSet{}); err != nil {
+		t.Fatal(err)
+	}
+	p := decodeProto(t, buf.Bytes())
+	for _, s := range []string{"alloc_space", "inuse_space", "bytes"} {
+		if !p.hasString(s) {
+			t.Errorf("profile strings do not include %q", s)
+		}
+	}
+}
+
+func TestCPUProfileProto(t *testing.T) {
+	if skipCPUProfile(t) {
+		return
+	}
+
+	buf := make([]byte, 100000)
+	var prof bytes.Buffer
+	if err := StartCPUProfileProto(&prof, LabelSet{}); err != nil {
+		t.Fatal(err)
+	}
+	Do(Labels("worker", "crc"), func() {
+		for i := 0; i < 1000; i++ {
+			crc32.ChecksumIEEE(buf)
+		}
+	})
+	StopCPUProfile()
+
+	p := decodeProto(t, prof.Bytes())
+	if p.samples == 0 || p.labels == 0 {
+		t.Errorf("got %d samples with %d labels, want some of each", p.samples, p.labels)
+	}
+	for _, s := range []string{"cpu", "nanoseconds", "ChecksumIEEE", "worker"} {
+		if !p.hasString(s) {
+			t.Errorf("profile strings do not include %q", s)
+		}
 	}
 }
@@ -0,0 +1,300 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// This file writes profiles in the compressed protocol buffer format
+// described by profile.proto in the pprof distribution
+// (https://github.com/google/pprof/blob/master/proto/profile.proto).
+// Unlike the legacy formats, a profile in this format carries the
+// function names, file names and line numbers of every stack it holds,
+// so it can be analyzed without the program binary.
+
+// Field numbers from profile.proto.
+const (
+	tagProfile_SampleType    = 1
+	tagProfile_Sample        = 2
+	tagProfile_Mapping       = 3
+	tagProfile_Location      = 4
+	tagProfile_Function      = 5
+	tagProfile_StringTable   = 6
+	tagProfile_TimeNanos     = 9
+	tagProfile_DurationNanos = 10
+	tagProfile_PeriodType    = 11
+	tagProfile_Period        = 12
+
+	tagValueType_Type = 1
+	tagValueType_Unit = 2
+
+	tagSample_Location = 1
+	tagSample_Value    = 2
+	tagSample_Label    = 3
+
+	tagLabel_Key = 1
+	tagLabel_Str = 2
+
+	tagMapping_ID             = 1
+	tagMapping_Start          = 2
+	tagMapping_Limit          = 3
+	tagMapping_Offset         = 4
+	tagMapping_Filename       = 5
+	tagMapping_HasFunctions   = 7
+	tagMapping_HasFilenames   = 8
+	tagMapping_HasLineNumbers = 9
+
+	tagLocation_ID        = 1
+	tagLocation_MappingID = 2
+	tagLocation_Address   = 3
+	tagLocation_Line      = 4
+
+	tagLine_FunctionID = 1
+	tagLine_Line       = 2
+
+	tagFunction_ID         = 1
+	tagFunction_Name       = 2
+	tagFunction_SystemName = 3
+	tagFunction_Filename   = 4
+	tagFunction_StartLine  = 5
+)
+
+// A valueType names a sample value and its unit, such as "cpu", "nanoseconds".
+type valueType struct {
+	typ, unit string
+}
+
+// A protoSample is a single sample: a stack with one value per sample type.
+type protoSample struct {
+	stk    []uintptr
+	values []int64
+	labels LabelSet
+}
+
+// A protoProfile collects the samples of a profile to be written
+// in the protocol buffer format.
+type protoProfile struct {
+	sampleTypes []valueType
+	periodType  valueType
+	period      int64
+	start       time.Time // zero if unknown
+	duration    time.Duration
+	samples     []protoSample
+}
+
+// add adds a sample to the profile.
+func (p *protoProfile) add(stk []uintptr, labels LabelSet, values ...int64) {
+	p.samples = append(p.samples, protoSample{stk, values, labels})
+}
+
+// A protoBuilder holds the tables referred to by the samples
+// while a protoProfile is encoded.
+type protoBuilder struct {
+	buf      protobuf
+	strings  []string
+	stringID map[string]int64
+	locs     map[locKey]uint64
+	funcs    map[string]uint64
+	mappings []memMap
+}
+
+// A locKey identifies a location: the same pc is symbolized
+// differently as a leaf and as a return address in a caller.
+type locKey struct {
+	pc   uintptr
+	leaf bool
+}
+
+// A memMap is an executable region of the process's address space.
+type memMap struct {
+	start, limit, offset uintptr
+	file                 string
+}
+
+// write writes p to w, symbolized and gzip-compressed.
+func (p *protoProfile) write(w io.Writer) error {
+	b := &protoBuilder{
+		strings:  []string{""},
+		stringID: map[string]int64{"": 0},
+		locs:     map[locKey]uint64{},
+		funcs:    map[string]uint64{},
+		mappings: readMappings(),
+	}
+
+	for _, t := range p.sampleTypes {
+		b.buf.message(tagProfile_SampleType, b.valueType(t))
+	}
+	for _, s := range p.samples {
+		var m protobuf
+		ids := make([]uint64, len(s.stk))
+		for i, pc := range s.stk {
+			ids[i] = b.location(pc, i == 0)
+		}
+		m.uint64s(tagSample_Location, ids)
+		m.int64s(tagSample_Value, s.values)
+		for _, l := range s.labels.list {
+			var lm protobuf
+			lm.int64(tagLabel_Key, b.string(l.key))
+			lm.int64(tagLabel_Str, b.string(l.value))
+			m.message(tagSample_Label, &lm)
+		}
+		b.buf.message(tagProfile_Sample, &m)
+	}
+	for i, mm := range b.mappings {
+		var m protobuf
+		m.uint64(tagMapping_ID, uint64(i+1))
+		m.uint64(tagMapping_Start, uint64(mm.start))
+		m.uint64(tagMapping_Limit, uint64(mm.limit))
+		m.uint64(tagMapping_Offset, uint64(mm.offset))
+		m.int64(tagMapping_Filename, b.string(mm.file))
+		m.bool(tagMapping_HasFunctions, true)
+		m.bool(tagMapping_HasFilenames, true)
+		m.bool(tagMapping_HasLineNumbers, true)
+		b.buf.message(tagProfile_Mapping, &m)
+	}
+	if !p.start.IsZero() {
+		b.buf.int64(tagProfile_TimeNanos, p.start.UnixNano())
+	}
+	b.buf.int64(tagProfile_DurationNanos, int64(p.duration))
+	b.buf.message(tagProfile_PeriodType, b.valueType(p.periodType))
+	b.buf.int64(tagProfile_Period, p.period)
+
+	// The string table must come last: writing the other
+	// fields is what fills it.
+	for _, s := range b.strings {
+		b.buf.string(tagProfile_StringTable, s)
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.buf.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// string returns the index of s in the string table, adding it if necessary.
+func (b *protoBuilder) string(s string) int64 {
+	id, ok := b.stringID[s]
+	if !ok {
+		id = int64(len(b.strings))
+		b.strings = append(b.strings, s)
+		b.stringID[s] = id
+	}
+	return id
+}
+
+func (b *protoBuilder) valueType(t valueType) *protobuf {
+	var m protobuf
+	m.int64(tagValueType_Type, b.string(t.typ))
+	m.int64(tagValueType_Unit, b.string(t.unit))
+	return &m
+}
+
+// location returns the id of the location for pc,
+// writing the location and its function the first time pc is seen.
+// Every pc but the leaf is a return address, which may belong to
+// the line after the call or even to the next function, so those
+// are symbolized using pc-1, the last byte of the call instruction.
+func (b *protoBuilder) location(pc uintptr, leaf bool) uint64 {
+	k := locKey{pc, leaf}
+	if id, ok := b.locs[k]; ok {
+		return id
+	}
+	id := uint64(len(b.locs) + 1)
+	b.locs[k] = id
+
+	var m protobuf
+	m.uint64(tagLocation_ID, id)
+	for i, mm := range b.mappings {
+		if mm.start <= pc && pc < mm.limit {
+			m.uint64(tagLocation_MappingID, uint64(i+1))
+			break
+		}
+	}
+	m.uint64(tagLocation_Address, uint64(pc))
+	spc := pc
+	if !leaf && spc > 0 {
+		spc--
+	}
+	if f := runtime.FuncForPC(spc); f != nil {
+		_, line := f.FileLine(spc)
+		var lm protobuf
+		lm.uint64(tagLine_FunctionID, b.function(f))
+		lm.int64(tagLine_Line, int64(line))
+		m.message(tagLocation_Line, &lm)
+	}
+	b.buf.message(tagProfile_Location, &m)
+	return id
+}
+
+// function returns the id of f, writing f the first time it is seen.
+func (b *protoBuilder) function(f *runtime.Func) uint64 {
+	name := f.Name()
+	if id, ok := b.funcs[name]; ok {
+		return id
+	}
+	id := uint64(len(b.funcs) + 1)
+	b.funcs[name] = id
+
+	file, line := f.FileLine(f.Entry())
+	var m protobuf
+	m.uint64(tagFunction_ID, id)
+	m.int64(tagFunction_Name, b.string(name))
+	m.int64(tagFunction_SystemName, b.string(name))
+	m.int64(tagFunction_Filename, b.string(file))
+	m.int64(tagFunction_StartLine, int64(line))
+	b.buf.message(tagProfile_Function, &m)
+	return id
+}
+
+// readMappings returns the executable mappings of the process,
+// as listed in /proc/self/maps.  It returns nil on systems
+// without /proc; the profile is still fully symbolized.
+func readMappings() []memMap {
+	f, err := os.Open("/proc/self/maps")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	// Each line looks like
+	//	00400000-0052e000 r-xp 00000000 08:01 1234    /path/to/binary
+	var mappings []memMap
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadSlice('\n')
+		if err != nil {
+			break
+		}
+		fields := bytes.Fields(line)
+		if len(fields) < 5 || len(fields[1]) < 3 || fields[1][2] != 'x' {
+			continue
+		}
+		addr := bytes.SplitN(fields[0], []byte("-"), 2)
+		if len(addr) != 2 {
+			continue
+		}
+		start, err1 := strconv.ParseUint(string(addr[0]), 16, 64)
+		limit, err2 := strconv.ParseUint(string(addr[1]), 16, 64)
+		offset, err3 := strconv.ParseUint(string(fields[2]), 16, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		var file string
+		if len(fields) > 5 {
+			file = string(fields[5])
+		}
+		mappings = append(mappings, memMap{uintptr(start), uintptr(limit), uintptr(offset), file})
+	}
+	return mappings
+}
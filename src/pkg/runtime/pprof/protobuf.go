@@ -0,0 +1,85 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+// A protobuf is a simple protocol buffer encoder.
+// It knows only the handful of wire types used by the profile format:
+// varints and length-delimited strings and messages.
+// Nested messages are written by encoding them into their own
+// protobuf and appending the result with message.
+type protobuf struct {
+	data []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 128 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protobuf) key(tag int, wire int) {
+	b.varint(uint64(tag)<<3 | uint64(wire))
+}
+
+func (b *protobuf) uint64(tag int, x uint64) {
+	// Zero is the default value and is never written.
+	if x == 0 {
+		return
+	}
+	b.key(tag, wireVarint)
+	b.varint(x)
+}
+
+func (b *protobuf) int64(tag int, x int64) {
+	b.uint64(tag, uint64(x))
+}
+
+func (b *protobuf) bool(tag int, x bool) {
+	if x {
+		b.uint64(tag, 1)
+	}
+}
+
+func (b *protobuf) string(tag int, x string) {
+	b.key(tag, wireBytes)
+	b.varint(uint64(len(x)))
+	b.data = append(b.data, x...)
+}
+
+func (b *protobuf) message(tag int, m *protobuf) {
+	b.key(tag, wireBytes)
+	b.varint(uint64(len(m.data)))
+	b.data = append(b.data, m.data...)
+}
+
+// uint64s writes x as a packed repeated field.
+func (b *protobuf) uint64s(tag int, x []uint64) {
+	if len(x) == 0 {
+		return
+	}
+	var p protobuf
+	for _, u := range x {
+		p.varint(u)
+	}
+	b.key(tag, wireBytes)
+	b.varint(uint64(len(p.data)))
+	b.data = append(b.data, p.data...)
+}
+
+// int64s writes x as a packed repeated field.
+func (b *protobuf) int64s(tag int, x []int64) {
+	u := make([]uint64, len(x))
+	for i, v := range x {
+		u[i] = uint64(v)
+	}
+	b.uint64s(tag, u)
+}
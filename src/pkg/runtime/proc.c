@@ -10,6 +10,7 @@
 #include "stack.h"
 
 bool	runtime·iscgo;
+int32	runtime·islibrary;
 
 static void unwindstack(G*, byte*);
 static void schedule(G*);
@@ -148,6 +149,8 @@ static void matchmg(void);	// match m's to g's
 static void readylocked(G*);	// ready, but sched is locked
 static void mnextg(M*, G*);
 static void mcommoninit(M*);
+static M* lockextra(bool);
+static void unlockextra(M*);
 
 void
 setmcpumax(uint32 n)
@@ -167,6 +170,10 @@ setmcpumax(uint32 n)
 // Keep trace of scavenger's goroutine for deadlock detection.
 static G *scvg;
 
+// In a library, set once runtime·main has run the package
+// initializers; needm waits for it.
+static uint32 libinitdone;
+
 // The bootstrap sequence is:
 //
 //	call osinit
@@ -236,6 +243,15 @@ runtime·main(void)
 	if(!runtime·sched.lockmain)
 		runtime·UnlockOSThread();
 
+	if(runtime·islibrary) {
+		// There is no main.main to run: the C program calls
+		// the exported functions instead, on threads of its own,
+		// which need m's to run Go code (see needm).
+		runtime·newextram();
+		runtime·atomicstore(&libinitdone, 1);
+		return;
+	}
+
 	// The deadlock detection has false negatives.
 	// Let scvg start up, to eliminate the false negative
 	// for the trivial program func main() { select{} }.
@@ -345,6 +361,8 @@ mcommoninit(M *m)
 	if(m->mcache == nil)
 		m->mcache = runtime·allocmcache();
 
+	runtime·mpreinit(m);
+
 	runtime·callers(1, m->createstack, nelem(m->createstack));
 
 	// Add to runtime·allm so garbage collector doesn't free m
@@ -610,9 +628,12 @@ top:
 	// wrong and should include gwait, but that does not happen in
 	// standard Go programs, which all start the scavenger.
 	//
-	if((scvg == nil && runtime·sched.grunning == 0) ||
+	// A library cannot deadlock: the C program may call into
+	// it at any time.
+	if(!runtime·islibrary &&
+	   ((scvg == nil && runtime·sched.grunning == 0) ||
 	   (scvg != nil && runtime·sched.grunning == 1 && runtime·sched.gwait == 0 &&
-	    (scvg->status == Grunning || scvg->status == Gsyscall))) {
+	    (scvg->status == Grunning || scvg->status == Gsyscall)))) {
 		runtime·throw("all goroutines are asleep - deadlock!");
 	}
 
@@ -767,6 +788,10 @@ runtime·mstart(void)
 // foreign code.
 void (*libcgo_thread_start)(void*);
 
+// In a library, the runtime initializes itself on a thread
+// made by libcgo_thread_create (see rt0_linux_amd64.s).
+void (*libcgo_thread_create)(void(*)(void));
+
 typedef struct CgoThreadStart CgoThreadStart;
 struct CgoThreadStart
 {
@@ -830,6 +855,140 @@ runtime·newm(void)
 	return m;
 }
 
+// Threads created by C code in a library (-buildmode=c-archive)
+// run Go code, when they call an exported function, on one of
+// the "extra" m's kept on this list.  The list is locked by
+// swapping in MLOCKED; see lockextra.
+static M *extram;
+
+#define MLOCKED ((M*)1)
+
+// needm is called by cgocallback when a callback happens on a
+// thread that Go did not create, and so has no m.  It takes an m
+// off the extra list and installs it, along with its g0, whose
+// stack is the thread's own.
+// There is no m or g yet, so needm cannot split the stack.
+#pragma textflag 7
+void
+runtime·needm(byte x)
+{
+	M *mp;
+
+	// The runtime initializes itself on a thread of its own
+	// while the C program starts; wait for it.
+	while(!runtime·atomicload(&libinitdone))
+		runtime·usleep(100);
+
+	// If we are taking the last m, have cgocallbackg make
+	// another one once it is safe to allocate: after exitsyscall,
+	// when no garbage collection can be running.
+	mp = lockextra(false);
+	mp->needextram = mp->schedlink == nil;
+	unlockextra(mp->schedlink);
+
+	// We don't know how big the C stack is, just as we don't
+	// for any scheduling stack, but assume at least 32 kB.
+	runtime·setmg(mp, mp->g0);
+	g->stackbase = &x + 1024;
+	g->stackguard = &x - 32*1024;
+
+	runtime·asminit();
+	runtime·minit();
+}
+
+// newextram makes an m, along with the goroutine that callbacks
+// on it will run on, and puts it on the extra list.
+// It needs a working m of its own, to allocate.
+void
+runtime·newextram(void)
+{
+	M *mp;
+	G *gp;
+
+	mp = runtime·malloc(sizeof(M));
+	mcommoninit(mp);
+	mp->g0 = runtime·malg(-1);	// the stack is set in needm
+
+	// The goroutine is in a system call, like any goroutine
+	// whose m is running C code, and stays locked to the m.
+	// Its pc is never returned to, but runtime·goexit
+	// shows the traceback routines where the stack ends.
+	gp = runtime·malg(StackMin);
+	gp->sched.pc = (byte*)runtime·goexit;
+	gp->sched.sp = gp->stackbase;
+	gp->sched.g = gp;
+	gp->status = Gsyscall;
+	gp->m = mp;
+	gp->lockedm = mp;
+	mp->curg = gp;
+	mp->lockedg = gp;
+
+	schedlock();
+	if(runtime·lastg == nil)
+		runtime·allg = gp;
+	else
+		runtime·lastg->alllink = gp;
+	runtime·lastg = gp;
+	runtime·sched.gcount++;
+	runtime·sched.grunning++;
+	runtime·sched.goidgen++;
+	gp->goid = runtime·sched.goidgen;
+	schedunlock();
+
+	mp->schedlink = lockextra(true);
+	unlockextra(mp);
+}
+
+// dropm is called by cgocallback, on the way back to C, to undo
+// a needm: it puts the m back on the extra list.  The thread
+// might never call into Go again, so the m cannot stay behind.
+void
+runtime·dropm(void)
+{
+	M *mp;
+
+	runtime·unminit();
+	mp = m;
+	runtime·setmg(nil, nil);
+
+	mp->schedlink = lockextra(true);
+	unlockextra(mp);
+}
+
+// lockextra locks the extra list and returns its head.
+// The caller unlocks it by storing a new head with unlockextra.
+// Unless nilokay is set, lockextra waits for the list to be non-empty.
+#pragma textflag 7
+static M*
+lockextra(bool nilokay)
+{
+	M *mp;
+
+	for(;;) {
+		mp = runtime·atomicloadp((void**)&extram);
+		if(mp == MLOCKED) {
+			runtime·osyield();
+			continue;
+		}
+		if(mp == nil && !nilokay) {
+			runtime·usleep(1);
+			continue;
+		}
+		if(!runtime·casp((void**)&extram, mp, MLOCKED)) {
+			runtime·osyield();
+			continue;
+		}
+		return mp;
+	}
+}
+
+#pragma textflag 7
+static void
+unlockextra(M *mp)
+{
+	runtime·atomicstorep((void**)&extram, mp);
+}
+
 // One round of scheduler: find a goroutine and run it.
 // The argument is the goroutine that was running before
 // schedule was called, or nil if this is the first call.
@@ -1284,6 +1443,7 @@ runtime·newproc1(byte *fn, byte *argp, int32 narg, int32 nret, void *callerpc)
 	newg->sched.g = newg;
 	newg->entry = fn;
 	newg->gopc = (uintptr)callerpc;
+	newg->labels = g->labels;
 
 	runtime·sched.gcount++;
 	runtime·sched.goidgen++;
@@ -1703,6 +1863,44 @@ runtime·NumGoroutine(int32 ret)
 	FLUSH(&ret);
 }
 
+// Label set ids for runtime/pprof.
+// Goroutines inherit the id of the goroutine that starts them (see newproc1).
+void
+runtime∕pprof·runtime_setGoroutineLabels(uintptr id)
+{
+	g->labels = id;
+}
+
+void
+runtime∕pprof·runtime_getGoroutineLabels(uintptr ret)
+{
+	ret = g->labels;
+	FLUSH(&ret);
+}
+
+// goroutineLabelIDs fills ids with the label set ids of the
+// goroutines, as far as there is room, and returns their number.
+// runtime/pprof uses it to free the label sets no longer in use.
+void
+runtime∕pprof·runtime_goroutineLabelIDs(Slice ids, int32 n)
+{
+	G *gp;
+	uintptr *p;
+
+	n = 0;
+	p = (uintptr*)ids.array;
+	schedlock();
+	for(gp = runtime·allg; gp != nil; gp = gp->alllink) {
+		if(gp->status == Gdead)
+			continue;
+		if(n < ids.len)
+			p[n] = gp->labels;
+		n++;
+	}
+	schedunlock();
+	FLUSH(&n);
+}
+
 int32
 runtime·gcount(void)
 {
@@ -1729,7 +1927,7 @@ runtime·badmcall2(void)  // called from assembly
 
 static struct {
 	Lock;
-	void (*fn)(uintptr*, int32);
+	void (*fn)(uintptr*, int32, uintptr);
 	int32 hz;
 	uintptr pcbuf[100];
 } prof;
@@ -1750,13 +1948,13 @@ runtime·sigprof(uint8 *pc, uint8 *sp, uint8 *lr, G *gp)
 	}
 	n = runtime·gentraceback(pc, sp, lr, gp, 0, prof.pcbuf, nelem(prof.pcbuf));
 	if(n > 0)
-		prof.fn(prof.pcbuf, n);
+		prof.fn(prof.pcbuf, n, gp != nil ? gp->labels : 0);
 	runtime·unlock(&prof);
 }
 
 // Arrange to call fn with a traceback hz times a second.
 void
-runtime·setcpuprofilerate(void (*fn)(uintptr*, int32), int32 hz)
+runtime·setcpuprofilerate(void (*fn)(uintptr*, int32, uintptr), int32 hz)
 {
 	// Force sane arguments.
 	if(hz < 0)
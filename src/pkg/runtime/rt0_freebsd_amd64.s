@@ -5,5 +5,7 @@
 // Darwin and Linux use the same linkage to main
 
 TEXT _rt0_amd64_freebsd(SB),7,$-8
+	LEAQ	8(DI), SI // argv
+	MOVQ	0(DI), DI // argc
 	MOVQ	$_rt0_amd64(SB), DX
 	JMP	DX
@@ -5,6 +5,55 @@
 // Darwin and Linux use the same linkage to main
 
 TEXT _rt0_amd64_linux(SB),7,$-8
+	LEAQ	8(SP), SI // argv
+	MOVQ	0(SP), DI // argc
 	MOVQ	$_rt0_amd64(SB), AX
-	MOVQ	SP, DI
 	JMP	AX
+
+// When the program is linked by the host linker (6l -linkmode=external),
+// the C library's start-up code calls main(argc, argv) instead,
+// with argc in DI and argv in SI.
+TEXT main(SB),7,$-8
+	MOVQ	$_rt0_amd64(SB), AX
+	JMP	AX
+
+// When the program is a library (6l -buildmode=c-archive), the
+// C library calls this, through .init_array, before the C program's
+// main, with argc in DI and argv in SI.  It starts a thread to
+// initialize the runtime and run the package initializers, and
+// returns to C straight away.  Calls to exported functions wait
+// for the initialization to finish (see needm).
+TEXT _rt0_amd64_linux_lib(SB),7,$56
+	// C's callee-save registers.
+	MOVQ	BX, 0(SP)
+	MOVQ	BP, 8(SP)
+	MOVQ	R12, 16(SP)
+	MOVQ	R13, 24(SP)
+	MOVQ	R14, 32(SP)
+	MOVQ	R15, 40(SP)
+
+	MOVQ	DI, _rt0_amd64_linux_lib_argc<>(SB)
+	MOVQ	SI, _rt0_amd64_linux_lib_argv<>(SB)
+	MOVL	$1, runtime·islibrary(SB)
+
+	MOVQ	$_rt0_amd64_linux_lib_go<>(SB), DI
+	MOVQ	libcgo_thread_create(SB), AX
+	CALL	AX
+
+	MOVQ	0(SP), BX
+	MOVQ	8(SP), BP
+	MOVQ	16(SP), R12
+	MOVQ	24(SP), R13
+	MOVQ	32(SP), R14
+	MOVQ	40(SP), R15
+	RET
+
+// The thread started by _rt0_amd64_linux_lib.
+TEXT _rt0_amd64_linux_lib_go<>(SB),7,$0
+	MOVQ	_rt0_amd64_linux_lib_argc<>(SB), DI
+	MOVQ	_rt0_amd64_linux_lib_argv<>(SB), SI
+	MOVQ	$_rt0_amd64(SB), AX
+	JMP	AX
+
+GLOBL _rt0_amd64_linux_lib_argc<>(SB), $8
+GLOBL _rt0_amd64_linux_lib_argv<>(SB), $8
@@ -3,6 +3,7 @@
 // license that can be found in the LICENSE file.
 
 TEXT _rt0_amd64_netbsd(SB),7,$-8
+	LEAQ	8(SP), SI // argv
+	MOVQ	0(SP), DI // argc
 	MOVQ	$_rt0_amd64(SB), DX
-	MOVQ	SP, DI
 	JMP	DX
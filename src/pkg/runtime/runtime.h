@@ -197,6 +197,7 @@ struct	G
 	uintptr	sigcode1;
 	uintptr	sigpc;
 	uintptr	gopc;	// pc of go statement that created this goroutine
+	uintptr	labels;	// runtime/pprof label set id, inherited by new goroutines
 	uintptr	end[];
 };
 struct	M
@@ -243,6 +244,7 @@ struct	M
 	uintptr	waitsema;	// semaphore for parking on locks
 	uint32	waitsemacount;
 	uint32	waitsemalock;
+	bool	needextram;	// took the last extra m; make more (see needm)
 
 #ifdef GOOS_windows
 	void*	thread;		// thread handle
@@ -463,6 +465,7 @@ extern	int32	runtime·gcwaiting;		// gc is waiting to run
 int8*	runtime·goos;
 int32	runtime·ncpu;
 extern	bool	runtime·iscgo;
+extern	int32	runtime·islibrary;	// built with -buildmode=c-archive
 
 /*
  * common functions and data
@@ -530,7 +533,13 @@ void	runtime·newosproc(M *m, G *g, void *stk, void (*fn)(void));
 void	runtime·signalstack(byte*, int32);
 G*	runtime·malg(int32);
 void	runtime·asminit(void);
+void	runtime·mpreinit(M*);
 void	runtime·minit(void);
+void	runtime·unminit(void);
+void	runtime·setmg(M*, G*);
+void	runtime·needm(byte);
+void	runtime·dropm(void);
+void	runtime·newextram(void);
 Func*	runtime·findfunc(uintptr);
 int32	runtime·funcline(Func*, uintptr);
 void*	runtime·stackalloc(uint32);
@@ -569,7 +578,7 @@ void	runtime·dopanic(int32);
 void	runtime·startpanic(void);
 void	runtime·sigprof(uint8 *pc, uint8 *sp, uint8 *lr, G *gp);
 void	runtime·resetcpuprofiler(int32);
-void	runtime·setcpuprofilerate(void(*)(uintptr*, int32), int32);
+void	runtime·setcpuprofilerate(void(*)(uintptr*, int32, uintptr), int32);
 void	runtime·usleep(uint32);
 int64	runtime·cputicks(void);
 
@@ -21,6 +21,14 @@ runtime·initsig(void)
 		t = &runtime·sigtab[i];
 		if((t->flags == 0) || (t->flags & SigDefault))
 			continue;
+		// A library shares the process with a C program that
+		// has its own ideas about signals.  Take over only those
+		// that turn faults in Go code into panics, and leave the
+		// rest alone unless Go code asks for them (see sigenable).
+		if(runtime·islibrary && !(t->flags & SigPanic)) {
+			t->flags |= SigDefault;
+			continue;
+		}
 		runtime·setsig(i, runtime·sighandler, true);
 	}
 }
@@ -55,6 +55,52 @@ func TestStackSplit(t *testing.T) {
 	}
 }
 
+// atBoundary recurses until the stack is nearly exhausted, so that
+// the next call of a function with a frame of a few hundred bytes
+// needs a new segment, and then calls f n times from there.
+// A loop like that used to allocate and free a segment per call.
+func atBoundary(n int, f func() (uintptr, uintptr)) (sp, guard uintptr) {
+	sp, guard = Stackguard()
+	if sp >= guard+StackLimit {
+		return atBoundary(n, f)
+	}
+	for i := 0; i < n; i++ {
+		sp, guard = f()
+	}
+	return
+}
+
+func TestStackSplitHot(t *testing.T) {
+	for _, f := range []func() (uintptr, uintptr){stack256, stack1024, stack4000} {
+		sp, guard := atBoundary(100, f)
+		bottom := guard - StackGuard
+		if sp < bottom+StackLimit {
+			fun := FuncForPC(*(*uintptr)(unsafe.Pointer(&f)))
+			t.Errorf("after %s at segment boundary: sp=%#x < limit=%#x (guard=%#x, bottom=%#x)",
+				fun.Name(), sp, bottom+StackLimit, guard, bottom)
+		}
+	}
+}
+
+// splitLeaf has a frame big enough to need a new segment when
+// called at a boundary, but does little else.
+func splitLeaf() (uintptr, uintptr) {
+	var buf [256]byte
+	buf[0] = Used
+	Used = buf[0]
+	return 0, 0
+}
+
+func BenchmarkStackSplitHot(b *testing.B) {
+	atBoundary(b.N, splitLeaf)
+}
+
+func BenchmarkStackSplitNone(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		splitLeaf()
+	}
+}
+
 var splitTests = []func() (uintptr, uintptr){
 	// Edit .+1,/^}/-1|seq 4 4 5000 | sed 's/.*/	stack&,/' | fmt
 	stack4, stack8, stack12, stack16, stack20, stack24, stack28,
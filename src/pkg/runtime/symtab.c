@@ -17,7 +17,7 @@
 #include "os_GOOS.h"
 #include "arch_GOARCH.h"
 
-extern byte pclntab[], epclntab[], symtab[], esymtab[];
+extern byte pclntab[], epclntab[], symtab[], esymtab[], etext[];
 
 typedef struct Sym Sym;
 struct Sym
@@ -34,7 +34,9 @@ walksymtab(void (*fn)(Sym*))
 {
 	byte *p, *ep, *q;
 	Sym s;
+	uintptr bias;
 
+	bias = 0;
 	p = symtab;
 	ep = esymtab;
 	while(p < ep) {
@@ -66,6 +68,21 @@ walksymtab(void (*fn)(Sym*))
 			p = q+1;
 		}
 		p += 4;	// go type
+
+		// The table holds the addresses the linker chose, but
+		// the host linker places a library (-buildmode=c-archive)
+		// itself, moving the text as a whole.  etext, which comes
+		// first, tells by how much.
+		switch(s.symtype) {
+		case 't':
+		case 'T':
+		case 'l':
+		case 'L':
+			if(runtime·strcmp(s.name, (byte*)"etext") == 0)
+				bias = (uintptr)etext - s.value;
+			s.value += bias;
+			break;
+		}
 		fn(&s);
 	}
 }
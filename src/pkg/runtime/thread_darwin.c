@@ -100,11 +100,19 @@ runtime·newosproc(M *m, G *g, void *stk, void (*fn)(void))
 }
 
 // Called to initialize a new m (including the bootstrap m).
+// Called on the parent thread (main thread in case of bootstrap), can allocate memory.
+void
+runtime·mpreinit(M *mp)
+{
+	mp->gsignal = runtime·malg(32*1024);	// OS X wants >=8K, Linux >=2K
+}
+
+// Called to initialize a new m (including the bootstrap m).
+// Called on the new thread, can not allocate memory.
 void
 runtime·minit(void)
 {
 	// Initialize signal handling.
-	m->gsignal = runtime·malg(32*1024);	// OS X wants >=8K, Linux >=2K
 	runtime·signalstack(m->gsignal->stackguard - StackGuard, 32*1024);
 
 	if(m->profilehz > 0)
@@ -113,6 +121,13 @@ runtime·minit(void)
 		runtime·sigprocmask(SIG_SETMASK, &sigset_prof, nil);
 }
 
+// Called from dropm to undo the effect of an minit.
+// Only libraries drop m's, and they are built only for Linux.
+void
+runtime·unminit(void)
+{
+}
+
 // Mach IPC, to get at semaphores
 // Definitions are in /usr/include/mach on a Mac.
 
@@ -8,11 +8,28 @@
 
 int8 *goos = "plan9";
 
+// Called to initialize a new m (including the bootstrap m).
+// Called on the parent thread (main thread in case of bootstrap), can allocate memory.
+void
+runtime·mpreinit(M *mp)
+{
+	USED(mp);
+}
+
+// Called to initialize a new m (including the bootstrap m).
+// Called on the new thread, can not allocate memory.
 void
 runtime·minit(void)
 {
 }
 
+// Called from dropm to undo the effect of an minit.
+// Only libraries drop m's, and they are built only for Linux.
+void
+runtime·unminit(void)
+{
+}
+
 static int32
 getproccount(void)
 {
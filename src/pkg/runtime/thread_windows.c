@@ -205,11 +205,27 @@ runtime·newosproc(M *m, G *g, void *stk, void (*fn)(void))
 }
 
 // Called to initialize a new m (including the bootstrap m).
+// Called on the parent thread (main thread in case of bootstrap), can allocate memory.
+void
+runtime·mpreinit(M *mp)
+{
+	USED(mp);
+}
+
+// Called to initialize a new m (including the bootstrap m).
+// Called on the new thread, can not allocate memory.
 void
 runtime·minit(void)
 {
 }
 
+// Called from dropm to undo the effect of an minit.
+// Only libraries drop m's, and they are built only for Linux.
+void
+runtime·unminit(void)
+{
+}
+
 int64
 runtime·nanotime(void)
 {
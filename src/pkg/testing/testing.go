@@ -79,11 +79,12 @@
 package testing
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
-	"runtime/pprof"
 	"strconv"
 	"strings"
 	"time"
@@ -242,8 +243,14 @@ func (c *common) Fatalf(format string, args ...interface{}) {
 // Parallel signals that this test is to be run in parallel with (and only with) 
 // other parallel tests in this CPU group.
 func (t *T) Parallel() {
+	if *chatty {
+		fmt.Printf("=== PAUSE %s\n", t.name)
+	}
 	t.signal <- (*T)(nil) // Release main testing loop
 	<-t.startParallel     // Wait for serial tests to finish
+	if *chatty {
+		fmt.Printf("=== CONT %s\n", t.name)
+	}
 }
 
 // An internal type but exported because it is cross-package; part of the implementation
@@ -264,6 +271,7 @@ func tRunner(t *T, test *InternalTest) {
 		t.duration = time.Now().Sub(t.start)
 		// If the test panicked, print any test output before dying.
 		if err := recover(); err != nil {
+			t.Fail()
 			t.report()
 			panic(err)
 		}
@@ -273,9 +281,48 @@ func tRunner(t *T, test *InternalTest) {
 	test.F(t)
 }
 
+// testDeps is the functionality the generated main package of a test
+// binary passes to MainStart.  Keeping it out of this package means
+// packages such as runtime/pprof need not be imported by testing, so
+// their own dependencies remain free to have tests that import testing.
+type testDeps interface {
+	MatchString(pat, str string) (bool, error)
+	StartCPUProfile(io.Writer) error
+	StopCPUProfile()
+	WriteHeapProfile(io.Writer) error
+}
+
+var deps testDeps
+
+// matchStringOnly is the testDeps used by Main, whose callers supply
+// only a matching function.  Profiles are written by runtime/pprof,
+// which package testing cannot import, so they are only available to
+// test binaries that call MainStart.
+type matchStringOnly func(pat, str string) (bool, error)
+
+var errMain = errors.New("profiles need a test binary that calls testing.MainStart")
+
+func (f matchStringOnly) MatchString(pat, str string) (bool, error) { return f(pat, str) }
+func (f matchStringOnly) StartCPUProfile(w io.Writer) error         { return errMain }
+func (f matchStringOnly) StopCPUProfile()                           {}
+func (f matchStringOnly) WriteHeapProfile(w io.Writer) error        { return errMain }
+
 // An internal function but exported because it is cross-package; part of the implementation
-// of the "go test" command.
+// of the "go test" command.  It is superseded by MainStart:
+// with Main, the -test.cpuprofile and -test.memprofile flags
+// report an error.
 func Main(matchString func(pat, str string) (bool, error), tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) {
+	MainStart(matchStringOnly(matchString), tests, benchmarks, examples)
+}
+
+// MainStart is an internal function but exported because it is cross-package;
+// part of the implementation of the "go test" command.
+func MainStart(d testDeps, tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) {
+	deps = d
+	matchString := func(pat, str string) (bool, error) {
+		return deps.MatchString(pat, str)
+	}
+
 	flag.Parse()
 	parseCpuList()
 
@@ -384,11 +431,11 @@ func before() {
 	if *cpuProfile != "" {
 		f, err := os.Create(*cpuProfile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "testing: %s", err)
+			fmt.Fprintf(os.Stderr, "testing: %s\n", err)
 			return
 		}
-		if err := pprof.StartCPUProfile(f); err != nil {
-			fmt.Fprintf(os.Stderr, "testing: can't start cpu profile: %s", err)
+		if err := deps.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "testing: can't start cpu profile: %s\n", err)
 			f.Close()
 			return
 		}
@@ -400,16 +447,16 @@ func before() {
 // after runs after all testing.
 func after() {
 	if *cpuProfile != "" {
-		pprof.StopCPUProfile() // flushes profile to disk
+		deps.StopCPUProfile() // flushes profile to disk
 	}
 	if *memProfile != "" {
 		f, err := os.Create(*memProfile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "testing: %s", err)
+			fmt.Fprintf(os.Stderr, "testing: %s\n", err)
 			return
 		}
-		if err = pprof.WriteHeapProfile(f); err != nil {
-			fmt.Fprintf(os.Stderr, "testing: can't write %s: %s", *memProfile, err)
+		if err = deps.WriteHeapProfile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "testing: can't write %s: %s\n", *memProfile, err)
 		}
 		f.Close()
 	}
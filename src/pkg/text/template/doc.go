@@ -20,6 +20,25 @@ The input text for a template is UTF-8-encoded text in any format.
 "{{" and "}}"; all text outside actions is copied to the output unchanged.
 Actions may not span newlines, although comments can.
 
+To aid in formatting template source code, if an action's left delimiter
+(by default "{{") is followed immediately by a minus sign and a space, all
+trailing white space is trimmed from the immediately preceding text.
+Similarly, if the right delimiter ("}}") is preceded by a space and minus
+sign, all leading white space is trimmed from the immediately following
+text. In these trim markers, the space must be present: "{{- 3}}" is like
+"{{3}}" but trims the immediately preceding text, while "{{-3}}" parses as
+an action containing the number -3. For instance, when executing the
+template whose source is
+
+	"{{23 -}} < {{- 45}}"
+
+the generated output would be
+
+	"23<45"
+
+For this trimming, the definition of white space characters is the same as
+in Go: space, horizontal tab, carriage return, and newline.
+
 Once constructed, a template may be executed safely in parallel.
 
 Here is a trivial example that prints "17 items are made of wool".
@@ -43,8 +62,10 @@ data, defined in detail below.
 
 */
 //	{{/* a comment */}}
+//	{{- /* a comment with white space trimmed from preceding and following text */ -}}
 //		A comment; discarded. May contain newlines.
-//		Comments do not nest.
+//		Comments do not nest and must start and end at the
+//		delimiters, as shown here.
 /*
 
 	{{pipeline}}
@@ -76,6 +97,14 @@ data, defined in detail below.
 		T0 is executed; otherwise, dot is set to the successive elements
 		of the array, slice, or map and T1 is executed.
 
+	{{break}}
+		The innermost {{range pipeline}} loop is ended early, stopping
+		the current iteration and bypassing all remaining iterations.
+
+	{{continue}}
+		The current iteration of the innermost {{range pipeline}} loop
+		is stopped, and the loop starts the next iteration.
+
 	{{template "name"}}
 		The template with the specified name is executed with nil data.
 
@@ -83,6 +112,14 @@ data, defined in detail below.
 		The template with the specified name is executed with dot set
 		to the value of the pipeline.
 
+	{{block "name" pipeline}} T1 {{end}}
+		A block is shorthand for defining a template
+			{{define "name"}} T1 {{end}}
+		and then executing it in place
+			{{template "name" pipeline}}
+		The typical use is to define a set of root templates that are
+		then customised by redefining the block templates within.
+
 	{{with pipeline}} T1 {{end}}
 		If the value of the pipeline is empty, no output is generated;
 		otherwise, dot is set to the value of the pipeline and T1 is
@@ -194,6 +231,17 @@ The initialization has syntax
 where $variable is the name of the variable. An action that declares a
 variable produces no output.
 
+Variables previously declared can also be assigned, using the syntax
+
+	$variable = pipeline
+
+Assigning to a variable that is not in scope is an error. Because the
+assignment changes the existing variable rather than declaring a new one,
+a value assigned inside an "if", "with", or "range" remains visible after
+its "end":
+
+	{{$last := ""}}{{range .}}{{$last = .}}{{end}}{{$last}}
+
 If a "range" action initializes a variable, the variable is set to the
 successive elements of the iteration.  Also, a "range" may declare two
 variables, separated by a comma:
@@ -296,6 +344,39 @@ Predefined global functions are named as follows.
 The boolean functions take any zero value to be false and a non-zero value to
 be true.
 
+There is also a set of binary comparison operators defined as
+functions:
+
+	eq
+		Returns the boolean truth of arg1 == arg2
+	ne
+		Returns the boolean truth of arg1 != arg2
+	lt
+		Returns the boolean truth of arg1 < arg2
+	le
+		Returns the boolean truth of arg1 <= arg2
+	gt
+		Returns the boolean truth of arg1 > arg2
+	ge
+		Returns the boolean truth of arg1 >= arg2
+
+For simpler multi-way equality tests, eq (only) accepts two or more
+arguments and compares the second and subsequent to the first,
+returning in effect
+
+	arg1==arg2 || arg1==arg3 || arg1==arg4 ...
+
+(Unlike with || in Go, however, eq is a function call and all the
+arguments will be evaluated.)
+
+The comparison functions work on basic types only: booleans, numbers
+and strings. Booleans and complex numbers may only be tested for
+equality. Numbers are compared by value whatever their types, so a
+uint8 field may be compared with the constant 3 or with a float64:
+signed and unsigned integers are compared exactly, and a floating-point
+number is compared with any other number as a float64. Values of
+different kinds, such as a string and a number, cannot be compared.
+
 Associated templates
 
 Each template is named by a string specified when it is created. Also, each
@@ -329,6 +410,10 @@ produce the text
 
 	ONE TWO
 
+A later definition of a template replaces an earlier one with the same
+name, which is how a {{block}} is customised. Defining a template twice
+in the same call to Parse is an error.
+
 By construction, a template may reside in only one association. If it's
 necessary to have a template addressable from multiple associations, the
 template definition must be parsed multiple times to create distinct *Template
@@ -354,5 +439,12 @@ or to invoke a particular template explicitly by name,
 		log.Fatalf("execution failed: %s", err)
 	}
 
+Execution stops with an error if templates invoke one another more than
+100000 levels deep, which guards against unbounded recursion. The limit,
+a limit on the size of the output, and the handling of missing map keys
+can be adjusted with the Option method:
+
+	tmpl.Option("maxdepth=100", "maxoutput=1048576", "missingkey=error")
+
 */
 package template
@@ -5,6 +5,7 @@
 package template
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -18,10 +19,11 @@ import (
 // template so that multiple executions of the same template
 // can execute in parallel.
 type state struct {
-	tmpl *Template
-	wr   io.Writer
-	line int        // line number for errors
-	vars []variable // push-down stack of variable values.
+	tmpl  *Template
+	wr    io.Writer
+	line  int        // line number for errors
+	vars  []variable // push-down stack of variable values.
+	depth int        // the height of the stack of executing templates.
 }
 
 // variable holds the dynamic value of a variable such as $, $x etc.
@@ -45,11 +47,23 @@ func (s *state) pop(mark int) {
 	s.vars = s.vars[0:mark]
 }
 
-// setVar overwrites the top-nth variable on the stack. Used by range iterations.
-func (s *state) setVar(n int, value reflect.Value) {
+// setTopVar overwrites the top-nth variable on the stack. Used by range iterations.
+func (s *state) setTopVar(n int, value reflect.Value) {
 	s.vars[len(s.vars)-n].value = value
 }
 
+// setVar overwrites the innermost variable with the given name.
+// Used by assignments.
+func (s *state) setVar(name string, value reflect.Value) {
+	for i := s.mark() - 1; i >= 0; i-- {
+		if s.vars[i].name == name {
+			s.vars[i].value = value
+			return
+		}
+	}
+	s.errorf("undefined variable: %s", name)
+}
+
 // varValue returns the value of the named variable.
 func (s *state) varValue(name string) reflect.Value {
 	for i := s.mark() - 1; i >= 0; i-- {
@@ -63,6 +77,13 @@ func (s *state) varValue(name string) reflect.Value {
 
 var zero reflect.Value
 
+// walkBreak and walkContinue are panicked by {{break}} and {{continue}}
+// and recovered by the enclosing {{range}}.
+var (
+	walkBreak    = errors.New("break")
+	walkContinue = errors.New("continue")
+)
+
 // errorf formats the error and terminates processing.
 func (s *state) errorf(format string, args ...interface{}) {
 	format = fmt.Sprintf("template: %s:%d: %s", s.tmpl.Name(), s.line, format)
@@ -74,6 +95,28 @@ func (s *state) error(err error) {
 	s.errorf("%s", err)
 }
 
+// limitWriter passes writes through to w until n bytes have been written,
+// after which it reports an error.
+type limitWriter struct {
+	w   io.Writer
+	n   int64 // bytes remaining
+	max int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= l.n {
+		n, err := l.w.Write(p)
+		l.n -= int64(n)
+		return n, err
+	}
+	n, err := l.w.Write(p[:l.n])
+	l.n -= int64(n)
+	if err == nil {
+		err = fmt.Errorf("exceeded maximum output size (%d bytes)", l.max)
+	}
+	return n, err
+}
+
 // errRecover is the handler that turns panics into returns from the top
 // level of Parse.
 func errRecover(errp *error) {
@@ -114,6 +157,9 @@ func (t *Template) Execute(wr io.Writer, data interface{}) (err error) {
 	if t.Tree == nil || t.Root == nil {
 		state.errorf("%q is an incomplete or empty template", t.name)
 	}
+	if max := t.option.maxOutput; max > 0 {
+		state.wr = &limitWriter{w: wr, n: max, max: max}
+	}
 	state.walk(value, t.Root)
 	return
 }
@@ -130,6 +176,10 @@ func (s *state) walk(dot reflect.Value, n parse.Node) {
 		if len(n.Pipe.Decl) == 0 {
 			s.printValue(n, val)
 		}
+	case *parse.BreakNode:
+		panic(walkBreak)
+	case *parse.ContinueNode:
+		panic(walkContinue)
 	case *parse.IfNode:
 		s.line = n.Line
 		s.walkIfOrWith(parse.NodeIf, dot, n.Pipe, n.List, n.ElseList)
@@ -207,20 +257,43 @@ func isTrue(val reflect.Value) (truth, ok bool) {
 
 func (s *state) walkRange(dot reflect.Value, r *parse.RangeNode) {
 	defer s.pop(s.mark())
+	defer func() {
+		// A {{break}} ends the loop.
+		if e := recover(); e != nil && e != walkBreak {
+			panic(e)
+		}
+	}()
 	val, _ := indirect(s.evalPipeline(dot, r.Pipe))
 	// mark top of stack before any variables in the body are pushed.
 	mark := s.mark()
 	oneIteration := func(index, elem reflect.Value) {
-		// Set top var (lexically the second if there are two) to the element.
-		if len(r.Pipe.Decl) > 0 {
-			s.setVar(1, elem)
-		}
-		// Set next var (lexically the first if there are two) to the index.
-		if len(r.Pipe.Decl) > 1 {
-			s.setVar(2, index)
+		defer s.pop(mark)
+		defer func() {
+			// A {{continue}} ends the iteration.
+			if e := recover(); e != nil && e != walkContinue {
+				panic(e)
+			}
+		}()
+		decl := r.Pipe.Decl
+		if r.Pipe.IsAssign {
+			// The variables live outside the loop; assign them by name.
+			if len(decl) > 0 {
+				s.setVar(decl[len(decl)-1].Ident[0], elem)
+			}
+			if len(decl) > 1 {
+				s.setVar(decl[0].Ident[0], index)
+			}
+		} else {
+			// Set top var (lexically the second if there are two) to the element.
+			if len(decl) > 0 {
+				s.setTopVar(1, elem)
+			}
+			// Set next var (lexically the first if there are two) to the index.
+			if len(decl) > 1 {
+				s.setTopVar(2, index)
+			}
 		}
 		s.walk(elem, r.List)
-		s.pop(mark)
 	}
 	switch val.Kind() {
 	case reflect.Array, reflect.Slice:
@@ -272,7 +345,11 @@ func (s *state) walkTemplate(dot reflect.Value, t *parse.TemplateNode) {
 	}
 	// Variables declared by the pipeline persist.
 	dot = s.evalPipeline(dot, t.Pipe)
+	if max := s.tmpl.option.maxDepth; max > 0 && s.depth >= max {
+		s.errorf("exceeded maximum template depth (%d)", max)
+	}
 	newState := *s
+	newState.depth++
 	newState.tmpl = tmpl
 	// No dynamic scoping: template invocations inherit no variables.
 	newState.vars = []variable{{"$", dot}}
@@ -286,7 +363,8 @@ func (s *state) walkTemplate(dot reflect.Value, t *parse.TemplateNode) {
 // evalPipeline returns the value acquired by evaluating a pipeline. If the
 // pipeline has a variable declaration, the variable will be pushed on the
 // stack. Callers should therefore pop the stack after they are finished
-// executing commands depending on the pipeline value.
+// executing commands depending on the pipeline value. If the pipeline
+// assigns to existing variables instead, they are overwritten in place.
 func (s *state) evalPipeline(dot reflect.Value, pipe *parse.PipeNode) (value reflect.Value) {
 	if pipe == nil {
 		return
@@ -299,7 +377,11 @@ func (s *state) evalPipeline(dot reflect.Value, pipe *parse.PipeNode) (value ref
 		}
 	}
 	for _, variable := range pipe.Decl {
-		s.push(variable.Ident[0], value)
+		if pipe.IsAssign {
+			s.setVar(variable.Ident[0], value)
+		} else {
+			s.push(variable.Ident[0], value)
+		}
 	}
 	return value
 }
@@ -436,7 +518,18 @@ func (s *state) evalField(dot reflect.Value, fieldName string, args []parse.Node
 			if hasArgs {
 				s.errorf("%s is not a method but has arguments", fieldName)
 			}
-			return receiver.MapIndex(nameVal)
+			result := receiver.MapIndex(nameVal)
+			if !result.IsValid() {
+				switch s.tmpl.option.missingKey {
+				case mapInvalid:
+					// Just use the invalid value.
+				case mapZeroValue:
+					result = reflect.Zero(receiver.Type().Elem())
+				case mapError:
+					s.errorf("map has no entry for key %q", fieldName)
+				}
+			}
+			return result
 		}
 	}
 	if isNil {
@@ -663,7 +756,9 @@ func (s *state) printValue(n parse.Node, v reflect.Value) {
 		v, _ = indirect(v) // fmt.Fprint handles nil.
 	}
 	if !v.IsValid() {
-		fmt.Fprint(s.wr, "<no value>")
+		if _, err := fmt.Fprint(s.wr, "<no value>"); err != nil {
+			s.error(err)
+		}
 		return
 	}
 
@@ -677,7 +772,9 @@ func (s *state) printValue(n parse.Node, v reflect.Value) {
 			}
 		}
 	}
-	fmt.Fprint(s.wr, v.Interface())
+	if _, err := fmt.Fprint(s.wr, v.Interface()); err != nil {
+		s.error(err)
+	}
 }
 
 // Types to help sort the keys in a map for reproducible output.
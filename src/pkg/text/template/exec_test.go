@@ -9,6 +9,8 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -374,6 +376,10 @@ var execTests = []execTest{
 
 	// Booleans
 	{"not", "{{not true}} {{not false}}", "false true", nil, true},
+	{"eq uint16 ideal", "{{eq .U16 3}}", "false", tVal, true},
+	{"lt int field", "{{lt 3 .I}}", "true", tVal, true},
+	{"ge string", "{{ge .X `x`}}", "true", tVal, true},
+	{"eq struct", "{{eq .U .U}}", "", tVal, false},
 	{"and", "{{and false 0}} {{and 1 0}} {{and 0 true}} {{and 1 1}}", "false 0 0 1", nil, true},
 	{"or", "{{or 0 0}} {{or 1 0}} {{or 0 true}} {{or 1 1}}", "0 1 true 1", nil, true},
 	{"boolean if", "{{if and true 1 `hi`}}TRUE{{else}}FALSE{{end}}", "TRUE", tVal, true},
@@ -435,8 +441,29 @@ var execTests = []execTest{
 	{"range $x $y MSIone", "{{range $x, $y := .MSIone}}<{{$x}}={{$y}}>{{end}}", "<one=1>", tVal, true},
 	{"range $x PSI", "{{range $x := .PSI}}<{{$x}}>{{end}}", "<21><22><23>", tVal, true},
 	{"declare in range", "{{range $x := .PSI}}<{{$foo:=$x}}{{$x}}>{{end}}", "<21><22><23>", tVal, true},
+	{"assign", "{{$x := 1}}{{$x = 2}}{{$x}}", "2", tVal, true},
+	{"assign in if", "{{$x := 0}}{{if true}}{{$x = 1}}{{end}}{{$x}}", "1", tVal, true},
+	{"assign in range", "{{$last := 0}}{{range .SI}}{{$last = .}}{{end}}{{$last}}", "5", tVal, true},
+	{"assign shadowed", "{{$x := 1}}{{with $x := 2}}{{$x = 3}}{{$x}}{{end}}{{$x}}", "31", tVal, true},
+	{"range assign", "{{$i := 0}}{{$v := 0}}{{range $i, $v = .SI}}{{end}}{{$i}}={{$v}}", "2=5", tVal, true},
 	{"range count", `{{range $i, $x := count 5}}[{{$i}}]{{$x}}{{end}}`, "[0]a[1]b[2]c[3]d[4]e", tVal, true},
 	{"range nil count", `{{range $i, $x := count 0}}{{else}}empty{{end}}`, "empty", tVal, true},
+	{"range break", "{{range .SI}}{{if eq . 4}}{{break}}{{end}}-{{.}}-{{end}}", "-3-", tVal, true},
+	{"range continue", "{{range .SI}}{{if eq . 4}}{{continue}}{{end}}-{{.}}-{{end}}", "-3--5-", tVal, true},
+	{"range break in with", "{{range .SI}}{{with .}}{{break}}{{end}}-{{.}}-{{end}}", "", tVal, true},
+	{"range break skips else", "{{range .SI}}{{break}}{{else}}EMPTY{{end}}", "", tVal, true},
+	{"range break inner loop", "{{range .SI}}{{range $.SI}}{{break}}{{end}}-{{.}}-{{end}}", "-3--4--5-", tVal, true},
+	{"range continue map", "{{range $k, $v := .MSI}}{{if eq $k `two`}}{{continue}}{{end}}{{$v}}{{end}}", "13", tVal, true},
+	{"range break chan", `{{range $i, $x := count 5}}{{if eq $i 2}}{{break}}{{end}}{{$x}}{{end}}`, "ab", tVal, true},
+	{"range continue pops variables", "{{range .SI}}{{$x := .}}{{continue}}{{end}}{{range $y := .SI}}{{end}}", "", tVal, true},
+
+	// Block.
+	{"block", `{{block "x" .I}}<{{.}}>{{end}}`, "<17>", tVal, true},
+	{"block reuse", `{{block "x" .I}}<{{.}}>{{end}}{{template "x" .X}}`, "<17><x>", tVal, true},
+
+	// Trim markers.
+	{"trim", "-- {{- .I -}} --", "--17--", tVal, true},
+	{"trim newlines", "{{range .SI -}}\n\t{{.}}\n{{- end}}", "345", tVal, true},
 
 	// Cute examples.
 	{"or as if true", `{{or .SI "slice is empty"}}`, "[3 4 5]", tVal, true},
@@ -720,3 +747,308 @@ func TestTree(t *testing.T) {
 		t.Errorf("expected %q got %q", expect, result)
 	}
 }
+
+type cmpTest struct {
+	expr  string
+	truth string
+	ok    bool
+}
+
+var cmpTests = []cmpTest{
+	{"eq true true", "true", true},
+	{"eq true false", "false", true},
+	{"eq 1+2i 1+2i", "true", true},
+	{"eq 1+2i 1+3i", "false", true},
+	{"eq 1.5 1.5", "true", true},
+	{"eq 1.5 2.5", "false", true},
+	{"eq 1 1", "true", true},
+	{"eq 1 2", "false", true},
+	{"eq `xy` `xy`", "true", true},
+	{"eq `xy` `xyz`", "false", true},
+	{"eq .Uthree .Uthree", "true", true},
+	{"eq .Uthree .Ufour", "false", true},
+	{"eq 3 4 5 6 3", "true", true},
+	{"eq 3 4 5 6 7", "false", true},
+	{"ne true true", "false", true},
+	{"ne true false", "true", true},
+	{"ne 1+2i 1+2i", "false", true},
+	{"ne 1+2i 1+3i", "true", true},
+	{"ne 1.5 1.5", "false", true},
+	{"ne 1.5 2.5", "true", true},
+	{"ne 1 1", "false", true},
+	{"ne 1 2", "true", true},
+	{"ne `xy` `xy`", "false", true},
+	{"ne `xy` `xyz`", "true", true},
+	{"ne .Uthree .Uthree", "false", true},
+	{"ne .Uthree .Ufour", "true", true},
+	{"lt 1.5 1.5", "false", true},
+	{"lt 1.5 2.5", "true", true},
+	{"lt 1 1", "false", true},
+	{"lt 1 2", "true", true},
+	{"lt `xy` `xy`", "false", true},
+	{"lt `xy` `xyz`", "true", true},
+	{"lt .Uthree .Uthree", "false", true},
+	{"lt .Uthree .Ufour", "true", true},
+	{"le 1.5 1.5", "true", true},
+	{"le 1.5 2.5", "true", true},
+	{"le 2.5 1.5", "false", true},
+	{"le 1 1", "true", true},
+	{"le 1 2", "true", true},
+	{"le 2 1", "false", true},
+	{"le `xy` `xy`", "true", true},
+	{"le `xy` `xyz`", "true", true},
+	{"le `xyz` `xy`", "false", true},
+	{"le .Uthree .Uthree", "true", true},
+	{"le .Uthree .Ufour", "true", true},
+	{"le .Ufour .Uthree", "false", true},
+	{"gt 1.5 1.5", "false", true},
+	{"gt 1.5 2.5", "false", true},
+	{"gt 1 1", "false", true},
+	{"gt 2 1", "true", true},
+	{"gt 1 2", "false", true},
+	{"gt `xy` `xy`", "false", true},
+	{"gt `xy` `xyz`", "false", true},
+	{"gt .Uthree .Uthree", "false", true},
+	{"gt .Uthree .Ufour", "false", true},
+	{"gt .Ufour .Uthree", "true", true},
+	{"ge 1.5 1.5", "true", true},
+	{"ge 1.5 2.5", "false", true},
+	{"ge 2.5 1.5", "true", true},
+	{"ge 1 1", "true", true},
+	{"ge 1 2", "false", true},
+	{"ge 2 1", "true", true},
+	{"ge `xy` `xy`", "true", true},
+	{"ge `xy` `xyz`", "false", true},
+	{"ge `xyz` `xy`", "true", true},
+	{"ge .Uthree .Uthree", "true", true},
+	{"ge .Uthree .Ufour", "false", true},
+	{"ge .Ufour .Uthree", "true", true},
+	// Mixing signed and unsigned integers.
+	{"eq .Uthree .Three", "true", true},
+	{"eq .Three .Uthree", "true", true},
+	{"le .Uthree .Three", "true", true},
+	{"le .Three .Uthree", "true", true},
+	{"ge .Uthree .Three", "true", true},
+	{"ge .Three .Uthree", "true", true},
+	{"lt .Uthree .Three", "false", true},
+	{"lt .Three .Uthree", "false", true},
+	{"gt .Uthree .Three", "false", true},
+	{"gt .Three .Uthree", "false", true},
+	{"eq .Ufour .Three", "false", true},
+	{"lt .Ufour .Three", "false", true},
+	{"gt .Ufour .Three", "true", true},
+	{"eq .NegOne .Uthree", "false", true},
+	{"eq .Uthree .NegOne", "false", true},
+	{"ne .NegOne .Uthree", "true", true},
+	{"ne .Uthree .NegOne", "true", true},
+	{"lt .NegOne .Uthree", "true", true},
+	{"lt .Uthree .NegOne", "false", true},
+	{"le .NegOne .Uthree", "true", true},
+	{"le .Uthree .NegOne", "false", true},
+	{"gt .NegOne .Uthree", "false", true},
+	{"gt .Uthree .NegOne", "true", true},
+	{"ge .NegOne .Uthree", "false", true},
+	{"ge .Uthree .NegOne", "true", true},
+	// Mixing integers and floating-point numbers.
+	{"eq .Three 3.0", "true", true},
+	{"eq 3.0 .Uthree", "true", true},
+	{"lt .Three 3.5", "true", true},
+	{"gt .Ufour 3.5", "true", true},
+	{"ge 2.5 .NegOne", "true", true},
+	{"eq .NaN .NaN", "false", true},
+	{"lt .NaN 1", "false", true},
+	{"ge .NaN 1", "false", true},
+	// Errors
+	{"eq `xy` 1", "", false},    // Different types.
+	{"eq true 1", "", false},    // Different types.
+	{"eq 1+0i 1", "", false},    // Complex is not a number here.
+	{"lt true true", "", false}, // Unordered types.
+	{"lt 1+0i 1+0i", "", false}, // Unordered types.
+	{"eq .Three", "", false},    // Missing argument.
+	{"eq .Ptr .Ptr", "", false}, // Pointers are not comparable.
+}
+
+func TestComparison(t *testing.T) {
+	b := new(bytes.Buffer)
+	var cmpStruct = struct {
+		Uthree, Ufour uint
+		NegOne, Three int
+		NaN           float64
+		Ptr           *int
+	}{3, 4, -1, 3, math.NaN(), nil}
+	for _, test := range cmpTests {
+		text := fmt.Sprintf("{{if %s}}true{{else}}false{{end}}", test.expr)
+		tmpl, err := New("empty").Parse(text)
+		if err != nil {
+			t.Fatalf("%q: %s", test.expr, err)
+		}
+		b.Reset()
+		err = tmpl.Execute(b, &cmpStruct)
+		if test.ok && err != nil {
+			t.Errorf("%s errored incorrectly: %s", test.expr, err)
+			continue
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s did not error", test.expr)
+			continue
+		}
+		if b.String() != test.truth {
+			t.Errorf("%s: want %s; got %s", test.expr, test.truth, b.String())
+		}
+	}
+}
+
+func TestBlock(t *testing.T) {
+	const (
+		input   = `a({{block "inner" .}}bar({{.}})baz{{end}})b`
+		want    = `a(bar(hello)baz)b`
+		overlay = `{{define "inner"}}foo({{.}})bar{{end}}`
+		want2   = `a(foo(goodbye)bar)b`
+	)
+	tmpl, err := New("outer").Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl2, err := tmpl.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl2.Parse(overlay); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := tmpl2.Execute(&buf, "goodbye"); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want2 {
+		t.Errorf("got %q, want %q", got, want2)
+	}
+}
+
+func TestMissingMapKey(t *testing.T) {
+	data := map[string]int{
+		"x": 99,
+	}
+	tmpl, err := New("t1").Parse("{{.x}} {{.y}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	// By default, just get "<no value>"
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "99 <no value>"
+	got := b.String()
+	if got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	// Same if we set the option explicitly to the default.
+	tmpl.Option("missingkey=default")
+	b.Reset()
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		t.Fatal("default:", err)
+	}
+	got = b.String()
+	if got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	// Next we ask for a zero value
+	tmpl.Option("missingkey=zero")
+	b.Reset()
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		t.Fatal("zero:", err)
+	}
+	want = "99 0"
+	got = b.String()
+	if got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	// Now we ask for an error.
+	tmpl.Option("missingkey=error")
+	err = tmpl.Execute(&b, data)
+	if err == nil {
+		t.Errorf("expected error; got none")
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	tmpl, err := New("t").Parse(`{{define "r"}}{{if .}}{{template "r" .}}{{end}}{{end}}{{template "r" true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tmpl.Execute(ioutil.Discard, nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeded maximum template depth (100000)") {
+		t.Fatalf("expected depth error; got %v", err)
+	}
+	tmpl.Option("maxdepth=10")
+	err = tmpl.Execute(ioutil.Discard, nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeded maximum template depth (10)") {
+		t.Fatalf("expected depth error; got %v", err)
+	}
+	// Ten levels are allowed.
+	dec := FuncMap{"dec": func(i int) int { return i - 1 }}
+	tmpl, err = New("t").Funcs(dec).Option("maxdepth=10").Parse(`{{define "r"}}{{if .}}{{template "r" dec .}}{{end}}{{end}}{{template "r" .}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tmpl.Execute(ioutil.Discard, 9); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = tmpl.Execute(ioutil.Discard, 10); err == nil {
+		t.Fatalf("expected depth error")
+	}
+}
+
+func TestMaxOutput(t *testing.T) {
+	tmpl, err := New("t").Option("maxoutput=10").Parse(`{{range .}}{{.}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, []string{"abcd", "efgh"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b.Reset()
+	err = tmpl.Execute(&b, []string{"abcd", "efgh", "ijkl"})
+	if err == nil || !strings.Contains(err.Error(), "exceeded maximum output size (10 bytes)") {
+		t.Fatalf("expected output size error; got %v", err)
+	}
+	if got, want := b.String(), "abcdefghij"; got != want {
+		t.Errorf("got %q; expected %q", got, want)
+	}
+	// Text is limited as well as values.
+	tmpl, err = New("t").Option("maxoutput=3").Parse(`hello`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tmpl.Execute(ioutil.Discard, nil); err == nil {
+		t.Fatalf("expected output size error")
+	}
+}
+
+func TestBadOption(t *testing.T) {
+	for _, opt := range []string{"", "missingkey", "missingkey=bogus", "maxdepth=-1", "maxoutput=x", "unknown=1"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Option(%q) did not panic", opt)
+				}
+			}()
+			New("t").Option(opt)
+		}()
+	}
+}
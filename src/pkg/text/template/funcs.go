@@ -6,6 +6,7 @@ package template
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -35,6 +36,14 @@ var builtins = FuncMap{
 	"printf":   fmt.Sprintf,
 	"println":  fmt.Sprintln,
 	"urlquery": URLQueryEscaper,
+
+	// Comparisons
+	"eq": eq, // ==
+	"ge": ge, // >=
+	"gt": gt, // >
+	"le": le, // <=
+	"lt": lt, // <
+	"ne": ne, // !=
 }
 
 var builtinFuncs = createValueFuncs(builtins)
@@ -242,6 +251,174 @@ func not(arg interface{}) (truth bool) {
 	return !truth
 }
 
+// Comparison.
+
+var (
+	errBadComparisonType = errors.New("invalid type for comparison")
+	errBadComparison     = errors.New("incompatible types for comparison")
+	errNoComparison      = errors.New("missing argument for comparison")
+)
+
+type kind int
+
+const (
+	invalidKind kind = iota
+	boolKind
+	complexKind
+	intKind
+	floatKind
+	stringKind
+	uintKind
+)
+
+// basicKind returns the comparison kind of v.
+func basicKind(v reflect.Value) (kind, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return boolKind, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intKind, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintKind, nil
+	case reflect.Float32, reflect.Float64:
+		return floatKind, nil
+	case reflect.Complex64, reflect.Complex128:
+		return complexKind, nil
+	case reflect.String:
+		return stringKind, nil
+	}
+	return invalidKind, errBadComparisonType
+}
+
+// isNumeric reports whether values of kind k are ordered numbers.
+func isNumeric(k kind) bool {
+	return k == intKind || k == uintKind || k == floatKind
+}
+
+// comparableKinds returns the basic kinds of v1 and v2, or an error if
+// they cannot be compared. Values of the same kind can be compared, and
+// so can any two numbers, whatever their types.
+func comparableKinds(v1, v2 reflect.Value) (k1, k2 kind, err error) {
+	if k1, err = basicKind(v1); err != nil {
+		return
+	}
+	if k2, err = basicKind(v2); err != nil {
+		return
+	}
+	if k1 != k2 && !(isNumeric(k1) && isNumeric(k2)) {
+		err = errBadComparison
+	}
+	return
+}
+
+// floatValue returns the numeric value v of kind k as a float64.
+func floatValue(v reflect.Value, k kind) float64 {
+	switch k {
+	case intKind:
+		return float64(v.Int())
+	case uintKind:
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+// equal reports whether v1 == v2. Signed and unsigned integers are
+// compared exactly; a floating-point number and any other number are
+// compared as float64.
+func equal(v1, v2 reflect.Value) (bool, error) {
+	k1, k2, err := comparableKinds(v1, v2)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case k1 == boolKind:
+		return v1.Bool() == v2.Bool(), nil
+	case k1 == complexKind:
+		return v1.Complex() == v2.Complex(), nil
+	case k1 == stringKind:
+		return v1.String() == v2.String(), nil
+	case k1 == intKind && k2 == intKind:
+		return v1.Int() == v2.Int(), nil
+	case k1 == uintKind && k2 == uintKind:
+		return v1.Uint() == v2.Uint(), nil
+	case k1 == intKind && k2 == uintKind:
+		return v1.Int() >= 0 && uint64(v1.Int()) == v2.Uint(), nil
+	case k1 == uintKind && k2 == intKind:
+		return v2.Int() >= 0 && v1.Uint() == uint64(v2.Int()), nil
+	}
+	return floatValue(v1, k1) == floatValue(v2, k2), nil
+}
+
+// less reports whether v1 < v2, with the same coercions as equal.
+// Booleans and complex numbers are not ordered.
+func less(v1, v2 reflect.Value) (bool, error) {
+	k1, k2, err := comparableKinds(v1, v2)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case k1 == boolKind, k1 == complexKind:
+		return false, errBadComparisonType
+	case k1 == stringKind:
+		return v1.String() < v2.String(), nil
+	case k1 == intKind && k2 == intKind:
+		return v1.Int() < v2.Int(), nil
+	case k1 == uintKind && k2 == uintKind:
+		return v1.Uint() < v2.Uint(), nil
+	case k1 == intKind && k2 == uintKind:
+		return v1.Int() < 0 || uint64(v1.Int()) < v2.Uint(), nil
+	case k1 == uintKind && k2 == intKind:
+		return v2.Int() >= 0 && v1.Uint() < uint64(v2.Int()), nil
+	}
+	return floatValue(v1, k1) < floatValue(v2, k2), nil
+}
+
+// eq evaluates the comparison a == b || a == c || ...
+func eq(arg1 interface{}, arg2 ...interface{}) (bool, error) {
+	if len(arg2) == 0 {
+		return false, errNoComparison
+	}
+	v1 := reflect.ValueOf(arg1)
+	for _, arg := range arg2 {
+		truth, err := equal(v1, reflect.ValueOf(arg))
+		if err != nil || truth {
+			return truth, err
+		}
+	}
+	return false, nil
+}
+
+// ne evaluates the comparison a != b.
+func ne(arg1, arg2 interface{}) (bool, error) {
+	// != is the inverse of ==.
+	equal, err := eq(arg1, arg2)
+	return !equal, err
+}
+
+// lt evaluates the comparison a < b.
+func lt(arg1, arg2 interface{}) (bool, error) {
+	return less(reflect.ValueOf(arg1), reflect.ValueOf(arg2))
+}
+
+// le evaluates the comparison a <= b.
+func le(arg1, arg2 interface{}) (bool, error) {
+	lessThan, err := lt(arg1, arg2)
+	if lessThan || err != nil {
+		return lessThan, err
+	}
+	return eq(arg1, arg2)
+}
+
+// gt evaluates the comparison a > b.
+func gt(arg1, arg2 interface{}) (bool, error) {
+	return lt(arg2, arg1)
+}
+
+// ge evaluates the comparison a >= b.
+func ge(arg1, arg2 interface{}) (bool, error) {
+	return le(arg2, arg1)
+}
+
 // HTML escaping.
 
 var (
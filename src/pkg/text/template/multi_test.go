@@ -265,16 +265,24 @@ func TestRedefinition(t *testing.T) {
 	if tmpl, err = New("tmpl1").Parse(`{{define "test"}}foo{{end}}`); err != nil {
 		t.Fatalf("parse 1: %v", err)
 	}
-	if _, err = tmpl.Parse(`{{define "test"}}bar{{end}}`); err == nil {
-		t.Fatal("expected error")
+	if _, err = tmpl.Parse(`{{define "test"}}bar{{end}}`); err != nil {
+		t.Fatalf("got error %v, expected nil", err)
+	}
+	if _, err = tmpl.New("tmpl2").Parse(`{{define "test"}}baz{{end}}`); err != nil {
+		t.Fatalf("got error %v, expected nil", err)
+	}
+	var b bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&b, "test", nil); err != nil {
+		t.Fatal(err)
 	}
-	if !strings.Contains(err.Error(), "redefinition") {
-		t.Fatalf("expected redefinition error; got %v", err)
+	if b.String() != "baz" {
+		t.Errorf("got %q; expected %q", b.String(), "baz")
 	}
-	if _, err = tmpl.New("tmpl2").Parse(`{{define "test"}}bar{{end}}`); err == nil {
+	// Two definitions in one Parse are still an error.
+	if _, err = tmpl.Parse(`{{define "test"}}x{{end}}{{define "test"}}y{{end}}`); err == nil {
 		t.Fatal("expected error")
 	}
-	if !strings.Contains(err.Error(), "redefinition") {
-		t.Fatalf("expected redefinition error; got %v", err)
+	if !strings.Contains(err.Error(), "multiple definition") {
+		t.Fatalf("expected multiple definition error; got %v", err)
 	}
 }
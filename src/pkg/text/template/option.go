@@ -0,0 +1,98 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code to handle template options.
+
+package template
+
+import (
+	"strconv"
+	"strings"
+)
+
+// missingKeyAction defines how to respond to indexing a map with a key that is not present.
+type missingKeyAction int
+
+const (
+	mapInvalid   missingKeyAction = iota // Return an invalid reflect.Value.
+	mapZeroValue                         // Return the zero value for the map element.
+	mapError                             // Error out
+)
+
+// defaultMaxDepth is the default limit on nested {{template}} invocations.
+// It stops runaway recursion long before the stack is exhausted.
+const defaultMaxDepth = 100000
+
+type option struct {
+	missingKey missingKeyAction
+	maxDepth   int   // Limit on nested template invocations; 0 means none.
+	maxOutput  int64 // Limit on bytes written by Execute; 0 means none.
+}
+
+// Option sets options for the template. Options are described by
+// strings of the form "key=value". If the option string is
+// unrecognized or otherwise invalid, Option panics. The options are
+// shared by all templates associated with t and apply to subsequent
+// executions. The return value is the template, so calls can be chained.
+//
+// Known options:
+//
+// missingkey: Control the behavior during execution if a map is
+// indexed with a key that is not present in the map.
+//	"missingkey=default" or "missingkey=invalid"
+//		The default behavior: Do nothing and continue execution.
+//		If printed, the result of the index operation is the string
+//		"<no value>".
+//	"missingkey=zero"
+//		The operation returns the zero value for the map type's element.
+//	"missingkey=error"
+//		Execution stops immediately with an error.
+//
+// maxdepth: "maxdepth=N" limits the nesting of {{template}} invocations
+// to N levels; execution stops with an error if a template recurses any
+// deeper. The default is 100000. N=0 removes the limit.
+//
+// maxoutput: "maxoutput=N" stops execution with an error once it has
+// written N bytes. By default there is no limit.
+func (t *Template) Option(opt ...string) *Template {
+	t.init()
+	for _, s := range opt {
+		t.setOption(s)
+	}
+	return t
+}
+
+func (t *Template) setOption(opt string) {
+	if opt == "" {
+		panic("empty option string")
+	}
+	elems := strings.Split(opt, "=")
+	if len(elems) == 2 {
+		switch elems[0] {
+		case "missingkey":
+			switch elems[1] {
+			case "invalid", "default":
+				t.option.missingKey = mapInvalid
+				return
+			case "zero":
+				t.option.missingKey = mapZeroValue
+				return
+			case "error":
+				t.option.missingKey = mapError
+				return
+			}
+		case "maxdepth":
+			if n, err := strconv.Atoi(elems[1]); err == nil && n >= 0 {
+				t.option.maxDepth = n
+				return
+			}
+		case "maxoutput":
+			if n, err := strconv.ParseInt(elems[1], 10, 64); err == nil && n >= 0 {
+				t.option.maxOutput = n
+				return
+			}
+		}
+	}
+	panic("unrecognized option: " + opt)
+}
@@ -36,6 +36,7 @@ type itemType int
 
 const (
 	itemError        itemType = iota // error occurred; value is text of error
+	itemAssign                       // equals ('=') introducing an assignment
 	itemBool                         // boolean constant
 	itemChar                         // printable ASCII character; grab bag for comma etc.
 	itemCharConstant                 // character constant
@@ -54,6 +55,9 @@ const (
 	itemVariable   // variable starting with '$', such as '$' or  '$1' or '$hello'.
 	// Keywords appear after all the rest.
 	itemKeyword  // used only to delimit the keywords
+	itemBlock    // block keyword
+	itemBreak    // break keyword
+	itemContinue // continue keyword
 	itemDot      // the cursor, spelled '.'.
 	itemDefine   // define keyword
 	itemElse     // else keyword
@@ -67,6 +71,7 @@ const (
 // Make the types prettyprint.
 var itemName = map[itemType]string{
 	itemError:        "error",
+	itemAssign:       "=",
 	itemBool:         "bool",
 	itemChar:         "char",
 	itemCharConstant: "charconst",
@@ -83,6 +88,9 @@ var itemName = map[itemType]string{
 	itemString:       "string",
 	itemVariable:     "variable",
 	// keywords
+	itemBlock:    "block",
+	itemBreak:    "break",
+	itemContinue: "continue",
 	itemDot:      ".",
 	itemDefine:   "define",
 	itemElse:     "else",
@@ -103,6 +111,9 @@ func (i itemType) String() string {
 
 var key = map[string]itemType{
 	".":        itemDot,
+	"block":    itemBlock,
+	"break":    itemBreak,
+	"continue": itemContinue,
 	"define":   itemDefine,
 	"else":     itemElse,
 	"end":      itemEnd,
@@ -232,15 +243,26 @@ const (
 	rightDelim   = "}}"
 	leftComment  = "/*"
 	rightComment = "*/"
+	// A trim marker attached to a delimiter removes the white space
+	// on that side of the action: "{{- " trims the preceding text,
+	// " -}}" the following text.
+	leftTrimMarker  = "- "
+	rightTrimMarker = " -"
 )
 
 // lexText scans until an opening action delimiter, "{{".
 func lexText(l *lexer) stateFn {
 	for {
 		if strings.HasPrefix(l.input[l.pos:], l.leftDelim) {
+			delim := l.pos
+			if strings.HasPrefix(l.input[delim+len(l.leftDelim):], leftTrimMarker) {
+				l.pos = l.start + len(strings.TrimRight(l.input[l.start:delim], spaceChars))
+			}
 			if l.pos > l.start {
 				l.emit(itemText)
 			}
+			l.pos = delim
+			l.ignore()
 			return lexLeftDelim
 		}
 		if l.next() == eof {
@@ -255,40 +277,84 @@ func lexText(l *lexer) stateFn {
 	return nil
 }
 
-// lexLeftDelim scans the left delimiter, which is known to be present.
+// lexLeftDelim scans the left delimiter, which is known to be present,
+// and an optional trim marker after it.
 func lexLeftDelim(l *lexer) stateFn {
-	if strings.HasPrefix(l.input[l.pos:], l.leftDelim+leftComment) {
+	trim := 0
+	if strings.HasPrefix(l.input[l.pos+len(l.leftDelim):], leftTrimMarker) {
+		trim = len(leftTrimMarker)
+	}
+	if strings.HasPrefix(l.input[l.pos+len(l.leftDelim)+trim:], leftComment) {
+		l.pos += len(l.leftDelim) + trim
 		return lexComment
 	}
 	l.pos += len(l.leftDelim)
 	l.emit(itemLeftDelim)
+	l.pos += trim
+	l.ignore()
 	return lexInsideAction
 }
 
 // lexComment scans a comment. The left comment marker is known to be present.
+// The comment must be followed directly by the right delimiter, optionally
+// preceded by a trim marker.
 func lexComment(l *lexer) stateFn {
-	i := strings.Index(l.input[l.pos:], rightComment+l.rightDelim)
+	i := strings.Index(l.input[l.pos:], rightComment)
 	if i < 0 {
 		return l.errorf("unclosed comment")
 	}
-	l.pos += i + len(rightComment) + len(l.rightDelim)
+	l.pos += i + len(rightComment)
+	trim := strings.HasPrefix(l.input[l.pos:], rightTrimMarker+l.rightDelim)
+	if trim {
+		l.pos += len(rightTrimMarker)
+	}
+	if !strings.HasPrefix(l.input[l.pos:], l.rightDelim) {
+		return l.errorf("comment ends before closing delimiter")
+	}
+	l.pos += len(l.rightDelim)
+	if trim {
+		l.trimLeadingSpace()
+	}
 	l.ignore()
 	return lexText
 }
 
-// lexRightDelim scans the right delimiter, which is known to be present.
+// lexRightDelim scans the right delimiter, which is known to be present,
+// possibly preceded by a trim marker.
 func lexRightDelim(l *lexer) stateFn {
+	trim := strings.HasPrefix(l.input[l.pos:], rightTrimMarker)
+	if trim {
+		l.pos += len(rightTrimMarker)
+		l.ignore()
+	}
 	l.pos += len(l.rightDelim)
 	l.emit(itemRightDelim)
+	if trim {
+		l.trimLeadingSpace()
+		l.ignore()
+	}
 	return lexText
 }
 
+// trimLeadingSpace advances over the white space at the current position.
+func (l *lexer) trimLeadingSpace() {
+	rest := l.input[l.pos:]
+	l.pos += len(rest) - len(strings.TrimLeft(rest, spaceChars))
+}
+
+// atRightDelim reports whether the lexer is at a right delimiter,
+// possibly preceded by a trim marker.
+func (l *lexer) atRightDelim() bool {
+	return strings.HasPrefix(l.input[l.pos:], l.rightDelim) ||
+		strings.HasPrefix(l.input[l.pos:], rightTrimMarker+l.rightDelim)
+}
+
 // lexInsideAction scans the elements inside action delimiters.
 func lexInsideAction(l *lexer) stateFn {
 	// Either number, quoted string, or identifier.
 	// Spaces separate and are ignored.
 	// Pipe symbols separate and are emitted.
-	if strings.HasPrefix(l.input[l.pos:], l.rightDelim) {
+	if l.atRightDelim() {
 		return lexRightDelim
 	}
 	switch r := l.next(); {
@@ -296,6 +362,8 @@ func lexInsideAction(l *lexer) stateFn {
 		return l.errorf("unclosed action")
 	case isSpace(r):
 		l.ignore()
+	case r == '=':
+		l.emit(itemAssign)
 	case r == ':':
 		if l.next() != '=' {
 			return l.errorf("expected :=")
@@ -492,6 +560,9 @@ Loop:
 	return lexInsideAction
 }
 
+// spaceChars are the characters removed by trim markers.
+const spaceChars = " \t\r\n"
+
 // isSpace reports whether r is a space character.
 func isSpace(r rune) bool {
 	switch r {
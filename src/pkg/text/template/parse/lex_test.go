@@ -36,6 +36,11 @@ var lexTests = []lexTest{
 		{itemText, "-world"},
 		tEOF,
 	}},
+	{"text with trimmed comment", "hello- {{- /* this is a comment */ -}}  -world", []item{
+		{itemText, "hello-"},
+		{itemText, "-world"},
+		tEOF,
+	}},
 	{"punctuation", "{{,@%}}", []item{
 		tLeft,
 		{itemChar, ","},
@@ -105,6 +110,14 @@ var lexTests = []lexTest{
 		tRight,
 		tEOF,
 	}},
+	{"new keywords", "{{block break continue}}", []item{
+		tLeft,
+		{itemBlock, "block"},
+		{itemBreak, "break"},
+		{itemContinue, "continue"},
+		tRight,
+		tEOF,
+	}},
 	{"variables", "{{$c := printf $ $hello $23 $ $var.Field .Method}}", []item{
 		tLeft,
 		{itemVariable, "$c"},
@@ -153,6 +166,33 @@ var lexTests = []lexTest{
 		tRight,
 		tEOF,
 	}},
+	{"assignment", "{{$v = 3}}", []item{
+		tLeft,
+		{itemVariable, "$v"},
+		{itemAssign, "="},
+		{itemNumber, "3"},
+		tRight,
+		tEOF,
+	}},
+	{"trim markers", "hello \n{{- 3 -}}\t world", []item{
+		{itemText, "hello"},
+		tLeft,
+		{itemNumber, "3"},
+		tRight,
+		{itemText, "world"},
+		tEOF,
+	}},
+	{"trim marker needs space", "x {{-3}} {{.X -}}", []item{
+		{itemText, "x "},
+		tLeft,
+		{itemNumber, "-3"},
+		tRight,
+		{itemText, " "},
+		tLeft,
+		{itemField, ".X"},
+		tRight,
+		tEOF,
+	}},
 	// errors
 	{"badchar", "#{{\x01}}", []item{
 		{itemText, "#"},
@@ -184,6 +224,9 @@ var lexTests = []lexTest{
 		tLeft,
 		{itemError, `bad number syntax: "3k"`},
 	}},
+	{"text after comment", "{{/* comment */ x}}", []item{
+		{itemError, "comment ends before closing delimiter"},
+	}},
 
 	// Fixed bugs
 	// Many elements in an action blew the lookahead until
@@ -51,6 +51,8 @@ const (
 	NodeTemplate                   // A template invocation action.
 	NodeVariable                   // A $ variable.
 	NodeWith                       // A with action.
+	NodeBreak                      // A break action.
+	NodeContinue                   // A continue action.
 )
 
 // Nodes.
@@ -110,12 +112,13 @@ func (t *TextNode) Copy() Node {
 	return &TextNode{NodeType: NodeText, Text: append([]byte{}, t.Text...)}
 }
 
-// PipeNode holds a pipeline with optional declaration
+// PipeNode holds a pipeline with optional declaration or assignment.
 type PipeNode struct {
 	NodeType
-	Line int             // The line number in the input.
-	Decl []*VariableNode // Variable declarations in lexical order.
-	Cmds []*CommandNode  // The commands in lexical order.
+	Line     int             // The line number in the input.
+	IsAssign bool            // The variables are being assigned, not declared.
+	Decl     []*VariableNode // Variables in lexical order.
+	Cmds     []*CommandNode  // The commands in lexical order.
 }
 
 func newPipeline(line int, decl []*VariableNode) *PipeNode {
@@ -135,7 +138,11 @@ func (p *PipeNode) String() string {
 			}
 			s += v.String()
 		}
-		s += " := "
+		if p.IsAssign {
+			s += " = "
+		} else {
+			s += " := "
+		}
 	}
 	for i, c := range p.Cmds {
 		if i > 0 {
@@ -155,6 +162,7 @@ func (p *PipeNode) CopyPipe() *PipeNode {
 		decl = append(decl, d.Copy().(*VariableNode))
 	}
 	n := newPipeline(p.Line, decl)
+	n.IsAssign = p.IsAssign
 	for _, c := range p.Cmds {
 		n.append(c.Copy().(*CommandNode))
 	}
@@ -584,6 +592,42 @@ func (w *WithNode) Copy() Node {
 	return newWith(w.Line, w.Pipe.CopyPipe(), w.List.CopyList(), w.ElseList.CopyList())
 }
 
+// BreakNode represents a {{break}} action.
+type BreakNode struct {
+	NodeType
+	Line int // The line number in the input.
+}
+
+func newBreak(line int) *BreakNode {
+	return &BreakNode{NodeType: NodeBreak, Line: line}
+}
+
+func (b *BreakNode) String() string {
+	return "{{break}}"
+}
+
+func (b *BreakNode) Copy() Node {
+	return newBreak(b.Line)
+}
+
+// ContinueNode represents a {{continue}} action.
+type ContinueNode struct {
+	NodeType
+	Line int // The line number in the input.
+}
+
+func newContinue(line int) *ContinueNode {
+	return &ContinueNode{NodeType: NodeContinue, Line: line}
+}
+
+func (c *ContinueNode) String() string {
+	return "{{continue}}"
+}
+
+func (c *ContinueNode) Copy() Node {
+	return newContinue(c.Line)
+}
+
 // TemplateNode represents a {{template}} action.
 type TemplateNode struct {
 	NodeType
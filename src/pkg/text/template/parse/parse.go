@@ -21,11 +21,13 @@ type Tree struct {
 	Name string    // name of the template represented by the tree.
 	Root *ListNode // top-level root of the tree.
 	// Parsing only; cleared after parse.
-	funcs     []map[string]interface{}
-	lex       *lexer
-	token     [2]item // two-token lookahead for parser.
-	peekCount int
-	vars      []string // variables defined at the moment.
+	funcs      []map[string]interface{}
+	lex        *lexer
+	token      [2]item // two-token lookahead for parser.
+	peekCount  int
+	vars       []string         // variables defined at the moment.
+	treeSet    map[string]*Tree // the trees defined by this parse.
+	rangeDepth int              // nesting depth of {{range}} actions.
 }
 
 // Parse returns a map from template name to parse.Tree, created by parsing the
@@ -130,11 +132,13 @@ func (t *Tree) recover(errp *error) {
 }
 
 // startParse initializes the parser, using the lexer.
-func (t *Tree) startParse(funcs []map[string]interface{}, lex *lexer) {
+func (t *Tree) startParse(funcs []map[string]interface{}, lex *lexer, treeSet map[string]*Tree) {
 	t.Root = nil
 	t.lex = lex
 	t.vars = []string{"$"}
 	t.funcs = funcs
+	t.treeSet = treeSet
+	t.rangeDepth = 0
 }
 
 // stopParse terminates parsing.
@@ -142,6 +146,7 @@ func (t *Tree) stopParse() {
 	t.lex = nil
 	t.vars = nil
 	t.funcs = nil
+	t.treeSet = nil
 }
 
 // atEOF returns true if, possibly after spaces, we're at EOF.
@@ -171,18 +176,18 @@ func (t *Tree) atEOF() bool {
 // the treeSet map.
 func (t *Tree) Parse(s, leftDelim, rightDelim string, treeSet map[string]*Tree, funcs ...map[string]interface{}) (tree *Tree, err error) {
 	defer t.recover(&err)
-	t.startParse(funcs, lex(t.Name, s, leftDelim, rightDelim))
-	t.parse(treeSet)
-	t.add(treeSet)
+	t.startParse(funcs, lex(t.Name, s, leftDelim, rightDelim), treeSet)
+	t.parse()
+	t.add()
 	t.stopParse()
 	return t, nil
 }
 
 // add adds tree to the treeSet.
-func (t *Tree) add(treeSet map[string]*Tree) {
-	tree := treeSet[t.Name]
+func (t *Tree) add() {
+	tree := t.treeSet[t.Name]
 	if tree == nil || IsEmptyTree(tree.Root) {
-		treeSet[t.Name] = t
+		t.treeSet[t.Name] = t
 		return
 	}
 	if !IsEmptyTree(t.Root) {
@@ -196,6 +201,8 @@ func IsEmptyTree(n Node) bool {
 	case nil:
 		return true
 	case *ActionNode:
+	case *BreakNode:
+	case *ContinueNode:
 	case *IfNode:
 	case *ListNode:
 		for _, node := range n.Nodes {
@@ -218,15 +225,15 @@ func IsEmptyTree(n Node) bool {
 // parse is the top-level parser for a template, essentially the same
 // as itemList except it also parses {{define}} actions.
 // It runs to EOF.
-func (t *Tree) parse(treeSet map[string]*Tree) (next Node) {
+func (t *Tree) parse() (next Node) {
 	t.Root = newList()
 	for t.peek().typ != itemEOF {
 		if t.peek().typ == itemLeftDelim {
 			delim := t.next()
 			if t.next().typ == itemDefine {
 				newT := New("definition") // name will be updated once we know it.
-				newT.startParse(t.funcs, t.lex)
-				newT.parseDefinition(treeSet)
+				newT.startParse(t.funcs, t.lex, t.treeSet)
+				newT.parseDefinition()
 				continue
 			}
 			t.backup2(delim)
@@ -243,7 +250,7 @@ func (t *Tree) parse(treeSet map[string]*Tree) (next Node) {
 // parseDefinition parses a {{define}} ...  {{end}} template definition and
 // installs the definition in the treeSet map.  The "define" keyword has already
 // been scanned.
-func (t *Tree) parseDefinition(treeSet map[string]*Tree) {
+func (t *Tree) parseDefinition() {
 	const context = "define clause"
 	name := t.expectOneOf(itemString, itemRawString, context)
 	var err error
@@ -257,8 +264,8 @@ func (t *Tree) parseDefinition(treeSet map[string]*Tree) {
 	if end.Type() != nodeEnd {
 		t.errorf("unexpected %s in %s", end, context)
 	}
+	t.add()
 	t.stopParse()
-	t.add(treeSet)
 }
 
 // itemList:
@@ -299,6 +306,12 @@ func (t *Tree) textOrAction() Node {
 // First word could be a keyword such as range.
 func (t *Tree) action() (n Node) {
 	switch token := t.next(); token.typ {
+	case itemBlock:
+		return t.blockControl()
+	case itemBreak:
+		return t.breakControl()
+	case itemContinue:
+		return t.continueControl()
 	case itemElse:
 		return t.elseControl()
 	case itemEnd:
@@ -318,35 +331,46 @@ func (t *Tree) action() (n Node) {
 }
 
 // Pipeline:
-//	field or command
-//	pipeline "|" pipeline
+//	declarations? command ('|' command)*
+// Declarations:
+//	variable (',' variable)? (':=' | '=')
 func (t *Tree) pipeline(context string) (pipe *PipeNode) {
 	var decl []*VariableNode
-	// Are there declarations?
+	isAssign := false
+	// Are there declarations or assignments?
 	for {
 		if v := t.peek(); v.typ == itemVariable {
 			t.next()
-			if next := t.peek(); next.typ == itemColonEquals || (next.typ == itemChar && next.val == ",") {
+			if next := t.peek(); next.typ == itemColonEquals || next.typ == itemAssign || (next.typ == itemChar && next.val == ",") {
 				t.next()
 				variable := newVariable(v.val)
 				if len(variable.Ident) != 1 {
 					t.errorf("illegal variable in declaration: %s", v.val)
 				}
 				decl = append(decl, variable)
-				t.vars = append(t.vars, v.val)
 				if next.typ == itemChar && next.val == "," {
 					if context == "range" && len(decl) < 2 {
 						continue
 					}
 					t.errorf("too many declarations in %s", context)
 				}
+				isAssign = next.typ == itemAssign
 			} else {
 				t.backup2(v)
 			}
 		}
 		break
 	}
+	for _, v := range decl {
+		if isAssign {
+			// Only existing variables may be assigned.
+			t.useVar(v.Ident[0])
+		} else {
+			t.vars = append(t.vars, v.Ident[0])
+		}
+	}
 	pipe = newPipeline(t.lex.lineNumber(), decl)
+	pipe.IsAssign = isAssign
 	for {
 		switch token := t.next(); token.typ {
 		case itemRightDelim:
@@ -370,7 +394,14 @@ func (t *Tree) parseControl(context string) (lineNum int, pipe *PipeNode, list,
 	defer t.popVars(len(t.vars))
 	pipe = t.pipeline(context)
 	var next Node
+	if context == "range" {
+		// {{break}} and {{continue}} may appear in the loop body.
+		t.rangeDepth++
+	}
 	list, next = t.itemList()
+	if context == "range" {
+		t.rangeDepth--
+	}
 	switch next.Type() {
 	case nodeEnd: //done
 	case nodeElse:
@@ -423,12 +454,68 @@ func (t *Tree) elseControl() Node {
 	return newElse(t.lex.lineNumber())
 }
 
+// Break:
+//	{{break}}
+// Break keyword is past.
+func (t *Tree) breakControl() Node {
+	if t.rangeDepth == 0 {
+		t.errorf("{{break}} outside {{range}}")
+	}
+	t.expect(itemRightDelim, "break")
+	return newBreak(t.lex.lineNumber())
+}
+
+// Continue:
+//	{{continue}}
+// Continue keyword is past.
+func (t *Tree) continueControl() Node {
+	if t.rangeDepth == 0 {
+		t.errorf("{{continue}} outside {{range}}")
+	}
+	t.expect(itemRightDelim, "continue")
+	return newContinue(t.lex.lineNumber())
+}
+
+// Block:
+//	{{block stringValue pipeline}} itemList {{end}}
+// Block keyword is past. A block is shorthand for defining a template
+// and then executing it in place:
+//	{{define stringValue}} itemList {{end}}
+//	{{template stringValue pipeline}}
+// The definition may be replaced by a later one with the same name.
+func (t *Tree) blockControl() Node {
+	const context = "block clause"
+	name := t.templateName(context)
+	pipe := t.pipeline(context)
+	block := New(name)
+	block.startParse(t.funcs, t.lex, t.treeSet)
+	var end Node
+	block.Root, end = block.itemList()
+	if end.Type() != nodeEnd {
+		t.errorf("unexpected %s in %s", end, context)
+	}
+	block.add()
+	block.stopParse()
+	return newTemplate(t.lex.lineNumber(), name, pipe)
+}
+
 // Template:
 //	{{template stringValue pipeline}}
 // Template keyword is past.  The name must be something that can evaluate
 // to a string.
 func (t *Tree) templateControl() Node {
-	var name string
+	name := t.templateName("template invocation")
+	var pipe *PipeNode
+	if t.next().typ != itemRightDelim {
+		t.backup()
+		// Do not pop variables; they persist until "end".
+		pipe = t.pipeline("template")
+	}
+	return newTemplate(t.lex.lineNumber(), name, pipe)
+}
+
+// templateName parses the quoted name of a template.
+func (t *Tree) templateName(context string) (name string) {
 	switch token := t.next(); token.typ {
 	case itemString, itemRawString:
 		s, err := strconv.Unquote(token.val)
@@ -437,15 +524,9 @@ func (t *Tree) templateControl() Node {
 		}
 		name = s
 	default:
-		t.unexpected(token, "template invocation")
+		t.unexpected(token, context)
 	}
-	var pipe *PipeNode
-	if t.next().typ != itemRightDelim {
-		t.backup()
-		// Do not pop variables; they persist until "end".
-		pipe = t.pipeline("template")
-	}
-	return newTemplate(t.lex.lineNumber(), name, pipe)
+	return
 }
 
 // command:
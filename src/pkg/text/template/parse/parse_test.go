@@ -205,6 +205,10 @@ var parseTests = []parseTest{
 		`{{range $x := .SI}}{{.}}{{end}}`},
 	{"range 2 vars", "{{range $x, $y := .SI}}{{.}}{{end}}", noError,
 		`{{range $x, $y := .SI}}{{.}}{{end}}`},
+	{"assign", "{{$x := 0}}{{$x = 1}}{{$x}}", noError,
+		`{{$x := 0}}{{$x = 1}}{{$x}}`},
+	{"range 2 vars assign", "{{$x := 0}}{{$y := 0}}{{range $x, $y = .SI}}{{.}}{{end}}", noError,
+		`{{$x := 0}}{{$y := 0}}{{range $x, $y = .SI}}{{.}}{{end}}`},
 	{"constants", "{{range .SI 1 -3.2i true false 'a'}}{{end}}", noError,
 		`{{range .SI 1 -3.2i true false 'a'}}{{end}}`},
 	{"template", "{{template `x`}}", noError,
@@ -215,6 +219,16 @@ var parseTests = []parseTest{
 		`{{with .X}}"hello"{{end}}`},
 	{"with with else", "{{with .X}}hello{{else}}goodbye{{end}}", noError,
 		`{{with .X}}"hello"{{else}}"goodbye"{{end}}`},
+	{"block definition", `{{block "foo" .}}hello{{end}}`, noError,
+		`{{template "foo" .}}`},
+	{"break", "{{range .SI}}{{if .}}{{break}}{{end}}{{.}}{{end}}", noError,
+		`{{range .SI}}{{if .}}{{break}}{{end}}{{.}}{{end}}`},
+	{"continue", "{{range .SI}}{{if .}}{{continue}}{{end}}{{.}}{{end}}", noError,
+		`{{range .SI}}{{if .}}{{continue}}{{end}}{{.}}{{end}}`},
+	{"trim left and right", "x \r\n\t{{- 3 -}}\n\n\ty", noError,
+		`"x"{{3}}"y"`},
+	{"comment trim", "x \r\n\t{{- /* hi */ -}}\n\n\ty", noError,
+		`"x""y"`},
 	// Errors.
 	{"unclosed action", "hello{{range", hasError, ""},
 	{"unmatched end", "{{end}}", hasError, ""},
@@ -224,12 +238,21 @@ var parseTests = []parseTest{
 	{"undefined variable", "{{$x}}", hasError, ""},
 	{"variable undefined after end", "{{with $x := 4}}{{end}}{{$x}}", hasError, ""},
 	{"variable undefined in template", "{{template $v}}", hasError, ""},
+	{"assign undefined variable", "{{$x = 1}}", hasError, ""},
+	{"assign variable out of scope", "{{with $x := 4}}{{end}}{{$x = 1}}", hasError, ""},
+	{"assign with field", "{{$x := 0}}{{$x.Y = 4}}", hasError, ""},
 	{"declare with field", "{{with $x.Y := 4}}{{end}}", hasError, ""},
 	{"template with field ref", "{{template .X}}", hasError, ""},
 	{"template with var", "{{template $v}}", hasError, ""},
 	{"invalid punctuation", "{{printf 3, 4}}", hasError, ""},
 	{"multidecl outside range", "{{with $v, $u := 3}}{{end}}", hasError, ""},
 	{"too many decls in range", "{{range $u, $v, $w := 3}}{{end}}", hasError, ""},
+	{"break outside range", "{{break}}", hasError, ""},
+	{"continue outside range", "{{if .X}}{{continue}}{{end}}", hasError, ""},
+	{"break in range else", "{{range .X}}{{else}}{{break}}{{end}}", hasError, ""},
+	{"break in block in range", "{{range .X}}{{block `b` .}}{{break}}{{end}}{{end}}", hasError, ""},
+	{"block without pipeline", "{{block `b`}}x{{end}}", hasError, ""},
+	{"block without end", "{{block `b` .}}x", hasError, ""},
 	// Equals (and other chars) do not assignments make (yet).
 	{"bug0a", "{{$x := 0}}{{$x}}", noError, "{{$x := 0}}{{$x}}"},
 	{"bug0b", "{{$x = 1}}{{$x}}", hasError, ""},
@@ -299,6 +322,30 @@ var isEmptyTests = []isEmptyTest{
 	{"definitions and space", "{{define `x`}}something{{end}}\n\n{{define `y`}}something{{end}}\n\n", true},
 	{"definitions and text", "{{define `x`}}something{{end}}\nx\n{{define `y`}}something{{end}}\ny\n}}", false},
 	{"definition and action", "{{define `x`}}something{{end}}{{if 3}}foo{{end}}", false},
+	{"block", "{{block `x` .}}something{{end}}", false},
+}
+
+func TestBlock(t *testing.T) {
+	const (
+		input = `a{{block "inner" .}}bar{{.}}baz{{end}}b`
+		outer = `"a"{{template "inner" .}}"b"`
+		inner = `"bar"{{.}}"baz"`
+	)
+	treeSet := make(map[string]*Tree)
+	tmpl, err := New("outer").Parse(input, "", "", treeSet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := tmpl.Root.String(), outer; g != w {
+		t.Errorf("outer template = %s, want %s", g, w)
+	}
+	inTmpl := treeSet["inner"]
+	if inTmpl == nil {
+		t.Fatal("block did not define template")
+	}
+	if g, w := inTmpl.Root.String(), inner; g != w {
+		t.Errorf("inner template = %s, want %s", g, w)
+	}
 }
 
 func TestIsEmpty(t *testing.T) {
@@ -18,6 +18,7 @@ type common struct {
 	// expose reflection to the client.
 	parseFuncs FuncMap
 	execFuncs  map[string]reflect.Value
+	option     option
 }
 
 // Template is the representation of a parsed template. The *parse.Tree
@@ -62,6 +63,7 @@ func (t *Template) init() {
 		t.tmpl = make(map[string]*Template)
 		t.parseFuncs = make(FuncMap)
 		t.execFuncs = make(map[string]reflect.Value)
+		t.option.maxDepth = defaultMaxDepth
 	}
 }
 
@@ -89,6 +91,7 @@ func (t *Template) Clone() (*Template, error) {
 	for k, v := range t.execFuncs {
 		nt.execFuncs[k] = v
 	}
+	nt.option = t.option
 	return nt, nil
 }
 
@@ -158,11 +161,10 @@ func (t *Template) Lookup(name string) *Template {
 
 // Parse parses a string into a template. Nested template definitions will be
 // associated with the top-level template t. Parse may be called multiple times
-// to parse definitions of templates to associate with t. It is an error if a
-// resulting template is non-empty (contains content other than template
-// definitions) and would replace a non-empty template with the same name.
-// (In multiple calls to Parse with the same receiver template, only one call
-// can contain text other than space, comments, and template definitions.)
+// to parse definitions of templates to associate with t. A non-empty template
+// replaces any earlier template with the same name; templates containing only
+// space, comments, and template definitions do not. Within a single call,
+// defining a template twice is an error.
 func (t *Template) Parse(text string) (*Template, error) {
 	t.init()
 	trees, err := parse.Parse(t.name, text, t.leftDelim, t.rightDelim, t.parseFuncs, builtins)
@@ -172,15 +174,12 @@ func (t *Template) Parse(text string) (*Template, error) {
 	// Add the newly parsed trees, including the one for t, into our common structure.
 	for name, tree := range trees {
 		// If the name we parsed is the name of this template, overwrite this template.
-		// The associate method checks it's not a redefinition.
 		tmpl := t
 		if name != t.name {
 			tmpl = t.New(name)
 		}
 		// Even if t == tmpl, we need to install it in the common.tmpl map.
-		if replace, err := t.associate(tmpl, tree); err != nil {
-			return nil, err
-		} else if replace {
+		if t.associate(tmpl, tree) {
 			tmpl.Tree = tree
 		}
 		tmpl.leftDelim = t.leftDelim
@@ -190,25 +189,19 @@ func (t *Template) Parse(text string) (*Template, error) {
 }
 
 // associate installs the new template into the group of templates associated
-// with t. It is an error to reuse a name except to overwrite an empty
-// template. The two are already known to share the common structure.
-// The boolean return value reports wither to store this tree as t.Tree.
-func (t *Template) associate(new *Template, tree *parse.Tree) (bool, error) {
+// with t. A non-empty definition replaces an earlier one with the same name,
+// which is how the default body of a {{block}} is overridden; an empty one
+// never replaces an existing template. The two are already known to share
+// the common structure. The boolean return value reports whether to store
+// this tree as t.Tree.
+func (t *Template) associate(new *Template, tree *parse.Tree) bool {
 	if new.common != t.common {
 		panic("internal error: associate not common")
 	}
-	name := new.name
-	if old := t.tmpl[name]; old != nil {
-		oldIsEmpty := parse.IsEmptyTree(old.Root)
-		newIsEmpty := parse.IsEmptyTree(tree.Root)
-		if newIsEmpty {
-			// Whether old is empty or not, new is empty; no reason to replace old.
-			return false, nil
-		}
-		if !oldIsEmpty {
-			return false, fmt.Errorf("template: redefinition of template %q", name)
-		}
+	if old := t.tmpl[new.name]; old != nil && parse.IsEmptyTree(tree.Root) && old.Tree != nil {
+		// New is empty; no reason to replace old.
+		return false
 	}
-	t.tmpl[name] = new
-	return true, nil
+	t.tmpl[new.name] = new
+	return true
 }
@@ -0,0 +1,104 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This benchmark tests tight integer loops over slices: indexing,
+// bounds check elimination and register allocation.  Compare
+// 6g with and without -O, the flag enabling the SSA back end,
+// using go test -gcflags -O.
+
+package go1
+
+import "testing"
+
+// sumLoop returns the sum of the elements of a.
+func sumLoop(a []int) int {
+	s := 0
+	for i := 0; i < len(a); i++ {
+		s += a[i]
+	}
+	return s
+}
+
+// mulLoop multiplies the n×n matrices x and y into z.
+func mulLoop(z, x, y []int, n int) {
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			s := 0
+			for k := 0; k < n; k++ {
+				s += x[i*n+k] * y[k*n+j]
+			}
+			z[i*n+j] = s
+		}
+	}
+}
+
+// sortLoop sorts a by insertion.
+func sortLoop(a []int) {
+	for i := 1; i < len(a); i++ {
+		v := a[i]
+		j := i
+		for j > 0 && a[j-1] > v {
+			a[j] = a[j-1]
+			j--
+		}
+		a[j] = v
+	}
+}
+
+// bitLoop returns the number of set bits in the elements of a.
+func bitLoop(a []int) int {
+	n := 0
+	for i := 0; i < len(a); i++ {
+		x := uint32(a[i])
+		x = x - x>>1&0x55555555
+		x = x&0x33333333 + x>>2&0x33333333
+		x = (x + x>>4) & 0x0f0f0f0f
+		x = x + x>>8
+		x = x + x>>16
+		n += int(x & 0x3f)
+	}
+	return n
+}
+
+var intData = makeIntData(1 << 12)
+
+func makeIntData(n int) []int {
+	a := make([]int, n)
+	x := uint32(1)
+	for i := range a {
+		x = x*1103515245 + 12345
+		a[i] = int(x >> 8)
+	}
+	return a
+}
+
+func BenchmarkIntSum(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sumLoop(intData)
+	}
+}
+
+func BenchmarkIntMatMul(b *testing.B) {
+	const n = 32
+	z := make([]int, n*n)
+	x := intData[:n*n]
+	y := intData[n*n : 2*n*n]
+	for i := 0; i < b.N; i++ {
+		mulLoop(z, x, y, n)
+	}
+}
+
+func BenchmarkIntSort(b *testing.B) {
+	a := make([]int, 256)
+	for i := 0; i < b.N; i++ {
+		copy(a, intData)
+		sortLoop(a)
+	}
+}
+
+func BenchmarkIntBits(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		bitLoop(intData)
+	}
+}
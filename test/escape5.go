@@ -0,0 +1,106 @@
+// errchk -0 $G -m -l $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that the escape analysis
+// uses the tags of functions analyzed earlier, follows calls of
+// function literals in place and keeps small makes on the stack.
+// Compiles but does not run.  Inlining is disabled.
+
+package foo
+
+var gp *int
+
+func noleak(p *int) int { // ERROR "noleak p does not escape"
+	return *p
+}
+
+func leak(p *int) { // ERROR "leaking param: p"
+	gp = p
+}
+
+// x is declared inside the loop, but noleak's tag
+// says its argument does not escape.
+func f1(n int) int {
+	s := 0
+	for i := 0; i < n; i++ {
+		x := i
+		s += noleak(&x) // ERROR "f1 &x does not escape"
+	}
+	return s
+}
+
+func f2() {
+	x := 0 // ERROR "moved to heap: x"
+	leak(&x) // ERROR "&x escapes to heap"
+}
+
+// Mutually recursive functions are analyzed together.
+func even(n int, p *int) bool { // ERROR "even p does not escape"
+	if n == 0 {
+		return *p == 0
+	}
+	return odd(n-1, p)
+}
+
+func odd(n int, p *int) bool { // ERROR "odd p does not escape"
+	if n == 0 {
+		return *p != 0
+	}
+	return even(n-1, p)
+}
+
+func f3() bool {
+	x := 1
+	return even(10, &x) // ERROR "f3 &x does not escape"
+}
+
+// Arguments of a func literal called in place flow to its parameters.
+func f4() int {
+	x := 1
+	return func(p *int) int { // ERROR "func literal does not escape" "p does not escape"
+		return *p
+	}(&x) // ERROR "f4 &x does not escape"
+}
+
+func f5() {
+	x := 1 // ERROR "moved to heap: x"
+	func(p *int) { // ERROR "func literal does not escape" "leaking param: p"
+		gp = p
+	}(&x) // ERROR "&x escapes to heap"
+}
+
+func apply(f func(int) int, v int) int { // ERROR "apply f does not escape"
+	return f(v)
+}
+
+func f6(n int) int {
+	t := 0
+	f := func(i int) int { // ERROR "f6 func literal does not escape"
+		t += i
+		return t
+	}
+	return apply(f, n)
+}
+
+var gf func() int
+
+func f7(n int) { // ERROR "moved to heap: n"
+	gf = func() int { // ERROR "func literal escapes to heap"
+		return n // ERROR "&n escapes to heap"
+	}
+}
+
+func f8() int {
+	s := make([]int, 10) // ERROR "f8 make\(\[\]int, 10\) does not escape"
+	for i := range s {
+		s[i] = i
+	}
+	return s[3]
+}
+
+func f9() []int {
+	return make([]int, 10) // ERROR "make\(\[\]int, 10\) escapes to heap"
+}
@@ -0,0 +1,79 @@
+// errchk -0 $G -m $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that inlining is working.
+// Compiles but does not run.
+
+package foo
+
+func add(a, b int) int { // ERROR "can inline add"
+	return a + b
+}
+
+// Calls to inlinable functions don't prevent inlining.
+func add3(a, b, c int) int { // ERROR "can inline add3"
+	return add(add(a, b), c) // ERROR "inlining call to add"
+}
+
+type T struct{ x int }
+
+func (t T) get() int { // ERROR "can inline T.get"
+	return t.x
+}
+
+func (t T) twice() int { // ERROR "can inline T.twice"
+	return t.get() + t.get() // ERROR "inlining call to T.get"
+}
+
+func last(xs ...int) int { // ERROR "can inline last" "xs does not escape"
+	if len(xs) == 0 {
+		return 0
+	}
+	return xs[len(xs)-1]
+}
+
+// Locals are fine.
+func abs(a int) int { // ERROR "can inline abs"
+	b := a
+	if b < 0 {
+		b = -b
+	}
+	return b
+}
+
+// But not a local that shadows a package-level name.
+func shadow() int {
+	x := 1
+	return x
+}
+
+var x int
+
+func f() {
+	x = add3(1, 2, 3)     // ERROR "inlining call to add3" "inlining call to add"
+	x = T{1}.twice()      // ERROR "inlining call to T.twice" "inlining call to T.get"
+	x = T.twice(T{2})     // ERROR "inlining call to T.twice" "inlining call to T.get"
+	x = last()            // ERROR "inlining call to last"
+	x = last(1, 2)        // ERROR "inlining call to last" "\[\]int literal does not escape"
+	x = last([]int{3}...) // ERROR "inlining call to last" "\[\]int literal does not escape"
+	x = abs(-1)           // ERROR "inlining call to abs"
+}
+
+// Calls to functions that can't be inlined make the caller non-inlinable too,
+// however cheap it is: a traceback from g must still show h and F.
+func g() int {
+	for {
+	}
+	return 0
+}
+
+func h() {
+	g()
+}
+
+func F() int {
+	return g() + 1
+}
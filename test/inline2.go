@@ -0,0 +1,43 @@
+// skip
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file is compiled and then imported by inline3.go.
+
+package inl
+
+var n = 100
+
+type pair struct{ a, b int }
+
+func (p pair) sum() int { return p.a + p.b }
+
+func get() int { return n }
+
+// Abs declares a local and is inlined in other packages.
+func Abs(x int) int {
+	y := x
+	if y < 0 {
+		y = -y
+	}
+	return y
+}
+
+// Sum calls a method on a local of an unexported type.
+func Sum(x int) int {
+	p := pair{x, get()}
+	return p.sum()
+}
+
+// Shadow is not inlined: once the block is gone from the export
+// data, its n would capture the final use of the package-level n.
+func Shadow() int {
+	s := n
+	{
+		n := 2
+		s += n
+	}
+	return s + n
+}
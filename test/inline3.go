@@ -0,0 +1,27 @@
+// $G $D/inline2.go && errchk -0 $G -m $D/$F.go && $L $F.$A && ./$A.out
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that functions with locals are inlined across package boundaries
+// and still compute the right results.
+
+package main
+
+import "./inline2"
+
+func main() {
+	if x := inl.Abs(-3); x != 3 { // ERROR "inlining call to inl.Abs"
+		println("inl.Abs", x)
+		panic("fail")
+	}
+	if x := inl.Sum(1); x != 101 { // ERROR "inlining call to inl.Sum" "inlining call to inl.get" "inlining call to .*pair.*sum"
+		println("inl.Sum", x)
+		panic("fail")
+	}
+	if x := inl.Shadow(); x != 202 {
+		println("inl.Shadow", x)
+		panic("fail")
+	}
+}
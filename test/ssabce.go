@@ -0,0 +1,54 @@
+// [ $A != 6 ] || errchk -0 $G -O -m -l $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that the SSA back end
+// removes the bounds checks implied by dominating branches.
+// Compiles but does not run.
+
+package foo
+
+func loop(a []int) int { // ERROR "compiling loop with SSA" "a does not escape"
+	s := 0
+	for i := 0; i < len(a); i++ {
+		s += a[i] // ERROR "removed bounds check"
+	}
+	return s
+}
+
+func guarded(a []int, i int) int { // ERROR "compiling guarded with SSA" "a does not escape"
+	if i >= 0 && i < len(a) {
+		return a[i] // ERROR "removed bounds check"
+	}
+	return 0
+}
+
+func unsigned(a []int, i uint) int { // ERROR "compiling unsigned with SSA" "a does not escape"
+	if i < uint(len(a)) {
+		return a[i] // ERROR "removed bounds check"
+	}
+	return 0
+}
+
+func twice(a []int, i int) int { // ERROR "compiling twice with SSA" "a does not escape"
+	return a[i] + a[i] // ERROR "removed bounds check" "common subexpression PtrIndex" "common subexpression Load"
+}
+
+// The index may be negative.
+func unguarded(a []int, i int) int { // ERROR "compiling unguarded with SSA" "a does not escape"
+	if i < len(a) {
+		return a[i]
+	}
+	return 0
+}
+
+// The loop may go past the end.
+func wrongbound(a []int, n int) int { // ERROR "compiling wrongbound with SSA" "a does not escape"
+	s := 0
+	for i := 0; i < n; i++ {
+		s += a[i]
+	}
+	return s
+}
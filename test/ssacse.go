@@ -0,0 +1,44 @@
+// [ $A != 6 ] || errchk -0 $G -O -m -l $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that the SSA back end
+// eliminates common subexpressions.  Compiles but does not run.
+
+package foo
+
+func square(a, b int) int { // ERROR "compiling square with SSA"
+	return (a + b) * (a + b) // ERROR "common subexpression Add"
+}
+
+func twostmts(a, b int) int { // ERROR "compiling twostmts with SSA"
+	x := a<<3 - b
+	y := a<<3 - b // ERROR "common subexpression Lsh" "common subexpression Sub"
+	return x * y
+}
+
+// A computation in a dominating block is reused.
+func dominated(a, b int) int { // ERROR "compiling dominated with SSA"
+	x := a * b
+	if a > 0 {
+		return a * b // ERROR "common subexpression Mul"
+	}
+	return x
+}
+
+// A computation in a sibling block is not.
+func siblings(a, b int) int { // ERROR "compiling siblings with SSA"
+	if a > 0 {
+		return a * b
+	}
+	return a * b
+}
+
+// Loads are not shared across stores.
+func loads(p *int, a int) int { // ERROR "compiling loads with SSA" "p does not escape"
+	x := *p
+	*p = a        // ERROR "removed nil check"
+	return x + *p // ERROR "removed nil check"
+}
@@ -0,0 +1,47 @@
+// [ $A != 6 ] || errchk -0 $G -O -m -l $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that the SSA back end
+// removes dead code.  Compiles but does not run.
+
+package foo
+
+const debug = false
+
+var trace int
+
+func overwritten(a, b int) int { // ERROR "compiling overwritten with SSA"
+	x := a * b // ERROR "removed dead Mul"
+	x = a
+	return x
+}
+
+func unused(a, b int) int { // ERROR "compiling unused with SSA"
+	c := a ^ b // ERROR "removed dead Xor"
+	_ = c
+	return a
+}
+
+func constbranch(a, b int) int { // ERROR "compiling constbranch with SSA"
+	if debug {
+		trace = a * b // ERROR "removed dead Mul"
+	}
+	return a + b
+}
+
+func live(a, b int) int { // ERROR "compiling live with SSA"
+	x := a * b
+	if a > 0 {
+		x = a
+	}
+	return x
+}
+
+// Stores are never dead.
+func store(a, b int) { // ERROR "compiling store with SSA"
+	trace = a * b
+	trace = a
+}
@@ -0,0 +1,42 @@
+// [ $A != 6 ] || errchk -0 $G -O -m -l $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that the SSA back end
+// removes redundant nil checks.  Compiles but does not run.
+
+package foo
+
+type T struct {
+	x, y int
+}
+
+func guarded(p *int) int { // ERROR "compiling guarded with SSA" "p does not escape"
+	if p != nil {
+		return *p // ERROR "removed nil check"
+	}
+	return 0
+}
+
+func reversed(p *int) int { // ERROR "compiling reversed with SSA" "p does not escape"
+	if nil == p {
+		return 0
+	}
+	return *p // ERROR "removed nil check"
+}
+
+func twice(t *T) int { // ERROR "compiling twice with SSA" "t does not escape"
+	return t.x + t.y // ERROR "removed nil check"
+}
+
+func unchecked(p *int) int { // ERROR "compiling unchecked with SSA" "p does not escape"
+	return *p
+}
+
+var g T
+
+func global() int { // ERROR "compiling global with SSA"
+	return g.x
+}
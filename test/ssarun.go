@@ -0,0 +1,208 @@
+// $G -O -l $D/$F.go && $L $F.$A && ./$A.out
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that functions compiled by the SSA back end compute the
+// right results and keep the nil and bounds checks they need.
+// ssadce.go, ssacse.go, ssabce.go, ssanil.go and ssaspill.go check
+// which functions the back end compiles and what it removes.
+
+package main
+
+import "fmt"
+
+type T struct {
+	x, y int
+}
+
+var trace int
+
+func sum(a []int) int {
+	s := 0
+	for i := 0; i < len(a); i++ {
+		s += a[i]
+	}
+	return s
+}
+
+func sumn(a []int, n int) int {
+	s := 0
+	for i := 0; i < n; i++ {
+		s += a[i]
+	}
+	return s
+}
+
+func index(a []int, i int) int {
+	if i < len(a) {
+		return a[i]
+	}
+	return -1
+}
+
+func uindex(a []int, i uint) int {
+	if i < uint(len(a)) {
+		return a[i]
+	}
+	return -1
+}
+
+// transpose stores through indexes computed into temporaries.
+func transpose(z, x []int, n int) {
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			z[j*n+i] = x[i*n+j]
+		}
+	}
+}
+
+func square(a, b int) int {
+	return (a + b) * (a + b)
+}
+
+func reload(p *int, a int) int {
+	x := *p
+	*p = a
+	return x + *p
+}
+
+func deref(p *int) int {
+	if p != nil {
+		return *p
+	}
+	return 0
+}
+
+func fields(t *T) int {
+	return t.x - t.y
+}
+
+func overwritten(a, b int) int {
+	x := a * b
+	x = a
+	return x
+}
+
+func narrow(a int32, b uint8) int64 {
+	a += a << 30
+	b += b << 7
+	return int64(a) + int64(b)
+}
+
+// wide mixes 32- and 64-bit values, including shifts by 32 or more.
+func wide(a int64, b uint32) int64 {
+	c := int32(a)
+	d := b*3 + 1
+	var r int64
+	if d < b {
+		r = 1
+	}
+	if c < 0 {
+		r += 2
+	}
+	return r + int64(c) + int64(d) + int64(uint32(c)>>3) + int64(c>>33) + int64(b<<35) + int64(-c) + int64(^b)
+}
+
+func index32(a []int, i int32) int {
+	return a[i]
+}
+
+func index64(a []int, i int64) int {
+	return a[i]
+}
+
+func spill(a, b int) int {
+	x0 := a + 1
+	x1 := a + 2
+	x2 := a + 3
+	x3 := a + 4
+	x4 := a + 5
+	x5 := a + 6
+	x6 := a + 7
+	x7 := a + 8
+	x8 := a + 9
+	x9 := a + 10
+	x10 := a + 11
+	x11 := a + 12
+	x12 := a + 13
+	x13 := a + 14
+	x14 := a + 15
+	trace = x14
+	return b*x0 + x1*x2 + x3*x4 + x5*x6 + x7*x8 + x9*x10 + x11*x12 + x13*x14
+}
+
+func spillref(a, b int) int {
+	r := b * (a + 1)
+	for i := 2; i <= 15; i += 2 {
+		r += (a + i) * (a + i + 1)
+	}
+	return r
+}
+
+func check(name string, got, want int64) {
+	if got != want {
+		panic(fmt.Sprintf("%s = %d, want %d", name, got, want))
+	}
+}
+
+func mustPanic(name string, f func()) {
+	defer func() {
+		if recover() == nil {
+			panic(name + " did not panic")
+		}
+	}()
+	f()
+}
+
+func main() {
+	a := []int{1, 2, 3, 4, 5}
+	check("sum", int64(sum(a)), 15)
+	check("sum(nil)", int64(sum(nil)), 0)
+	check("sumn", int64(sumn(a, 3)), 6)
+	check("index", int64(index(a, 4)), 5)
+	check("index past end", int64(index(a, 5)), -1)
+	check("uindex", int64(uindex(a, 2)), 3)
+	check("uindex past end", int64(uindex(a, 1<<31)), -1)
+	z := make([]int, 6)
+	transpose(z, []int{1, 2, 3, 4, 5, 6}[:4], 2)
+	check("transpose", int64(z[0]*1000+z[1]*100+z[2]*10+z[3]), 1324)
+	mustPanic("transpose short", func() { transpose(z, a, 3) })
+	check("square", int64(square(3, 4)), 49)
+	x := 7
+	check("reload", int64(reload(&x, 3)), 10)
+	check("deref", int64(deref(&x)), 3)
+	check("deref(nil)", int64(deref(nil)), 0)
+	check("fields", int64(fields(&T{9, 4})), 5)
+	check("overwritten", int64(overwritten(6, 7)), 6)
+	// main is compiled by the old back end, which computes the reference;
+	// -l keeps the calls from being inlined into it.
+	a32, b8 := int32(3), uint8(3)
+	check("narrow", narrow(a32, b8), int64(a32+a32<<30)+int64(b8+b8<<7))
+	for _, v := range []int64{-1 << 40, -5, 0, 7, 1<<31 + 3, 1<<32 - 1} {
+		c, b := int32(v), uint32(v)
+		d := b*3 + 1
+		want := int64(c) + int64(d) + int64(uint32(c)>>3) + int64(c>>33) + int64(b<<35) + int64(-c) + int64(^b)
+		if d < b {
+			want++
+		}
+		if c < 0 {
+			want += 2
+		}
+		check(fmt.Sprint("wide ", v), wide(v, b), want)
+	}
+	check("index32", int64(index32(a, 4)), 5)
+	check("index64", int64(index64(a, 4)), 5)
+	for _, v := range []int{-20, -1, 0, 1, 1000} {
+		check(fmt.Sprint("spill ", v), int64(spill(v, v+3)), int64(spillref(v, v+3)))
+	}
+
+	// The checks that could not be proved must remain.
+	mustPanic("sumn past end", func() { sumn(a, 6) })
+	mustPanic("index negative", func() { index(a, -1) })
+	mustPanic("fields(nil)", func() { fields(nil) })
+	mustPanic("index32 negative", func() { index32(a, -1) })
+	mustPanic("index64 past 1<<32", func() { index64(a, 1<<32+1) })
+	mustPanic("index64 negative", func() { index64(a, -1<<32) })
+}
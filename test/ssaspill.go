@@ -0,0 +1,41 @@
+// [ $A != 6 ] || errchk -0 $G -O -m -l $D/$F.go
+
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test, using compiler diagnostic flags, that the SSA register
+// allocator spills values when more are live than there are
+// registers, and only then.  Compiles but does not run.
+
+package foo
+
+// 13 registers hold a, b and the first values.  The first product
+// takes over b's register, so only the three values that are live
+// the longest are spilled.
+func spill(a, b int) int { // ERROR "compiling spill with SSA"
+	x0 := a + 1
+	x1 := a + 2
+	x2 := a + 3
+	x3 := a + 4
+	x4 := a + 5
+	x5 := a + 6
+	x6 := a + 7
+	x7 := a + 8
+	x8 := a + 9
+	x9 := a + 10
+	x10 := a + 11
+	x11 := a + 12 // ERROR "spilled Add"
+	x12 := a + 13 // ERROR "spilled Add"
+	x13 := a + 14 // ERROR "spilled Add"
+	x14 := a + 15
+	return b*x0 + x1*x2 + x3*x4 + x5*x6 + x7*x8 + x9*x10 + x11*x12 + x13*x14
+}
+
+func nospill(a, b int) int { // ERROR "compiling nospill with SSA"
+	x0 := a + 1
+	x1 := a + 2
+	x2 := a + 3
+	x3 := a + 4
+	return b*x0 + x1*x2 + x3
+}